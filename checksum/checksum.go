@@ -2,18 +2,123 @@
 package checksum
 
 import (
+	"crypto/md5"  // #nosec
+	"crypto/sha1" // #nosec
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
+	"fmt"
 	"hash"
+	"hash/crc32"
 	"io"
 	"os"
+	"sort"
+
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/goreleaser/goreleaser/internal/artifact"
 )
 
+// algorithms supported, by name
+var algorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+	"sha1":   sha1.New,
+	"md5":    md5.New,
+	"crc32": func() hash.Hash {
+		return crc32.NewIEEE()
+	},
+	"blake2b": func() hash.Hash {
+		// only fails with keys longer than 64 bytes
+		h, _ := blake2b.New512(nil)
+		return h
+	},
+}
+
+// tags of the algorithms in BSD-style checksum lines, as written by the
+// --tag flag of the coreutils tools
+var tags = map[string]string{
+	"sha256":  "SHA256",
+	"sha512":  "SHA512",
+	"sha1":    "SHA1",
+	"md5":     "MD5",
+	"crc32":   "CRC32",
+	"blake2b": "BLAKE2b",
+}
+
+// Line formats the checksum line of the given file, either GNU-style,
+// `sum  name`, or BSD-style, `ALGORITHM (name) = sum`
+func Line(format, algorithm, sum, name string) string {
+	if format == "bsd" {
+		return fmt.Sprintf("%s (%s) = %s\n", tags[algorithm], name, sum)
+	}
+	return fmt.Sprintf("%s  %s\n", sum, name)
+}
+
+// Algorithms returns the names of the supported algorithms
+func Algorithms() []string {
+	var names []string
+	for name := range algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // SHA256 sum of the given file
 func SHA256(path string) (string, error) {
 	return calculate(sha256.New(), path)
 }
 
+// Calculate the sum of the given file with the given algorithm
+func Calculate(algorithm, path string) (string, error) {
+	sums, err := CalculateAll([]string{algorithm}, path)
+	if err != nil {
+		return "", err
+	}
+	return sums[algorithm], nil
+}
+
+// Artifact returns the sum of the artifact with the given algorithm, as
+// recorded by the checksums pipe, calculating it when it wasn't
+func Artifact(algorithm string, a artifact.Artifact) (string, error) {
+	if sum, ok := a.Checksums[algorithm]; ok {
+		return sum, nil
+	}
+	return Calculate(algorithm, a.Path)
+}
+
+// CalculateAll sums the given file with each of the given algorithms,
+// reading it only once, and returns the sums by algorithm
+func CalculateAll(names []string, path string) (map[string]string, error) {
+	var hashes = map[string]hash.Hash{}
+	var writers []io.Writer
+	for _, name := range names {
+		fn, ok := algorithms[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid checksum algorithm: %s", name)
+		}
+		if _, ok := hashes[name]; ok {
+			continue
+		}
+		hashes[name] = fn()
+		writers = append(writers, hashes[name])
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() // nolint: errcheck
+	if _, err := io.Copy(io.MultiWriter(writers...), file); err != nil {
+		return nil, err
+	}
+	var sums = map[string]string{}
+	for name, hash := range hashes {
+		sums[name] = hex.EncodeToString(hash.Sum(nil))
+	}
+	return sums, nil
+}
+
 func calculate(hash hash.Hash, path string) (string, error) {
 	file, err := os.Open(path)
 	if err != nil {
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/goreleaser/goreleaser/internal/artifact"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -20,6 +21,47 @@ func TestChecksums(t *testing.T) {
 	assert.Equal(t, "5e2bf57d3f40c4b6df69daf1936cb766f832374b4fc0259a7cbff06e2f70f269", sum)
 }
 
+func TestCalculate(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var file = filepath.Join(folder, "subject")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("lorem ipsum"), 0644))
+	sum, err := Calculate("sha256", file)
+	assert.NoError(t, err)
+	assert.Equal(t, "5e2bf57d3f40c4b6df69daf1936cb766f832374b4fc0259a7cbff06e2f70f269", sum)
+	sum, err = Calculate("md5", file)
+	assert.NoError(t, err)
+	assert.Equal(t, "80a751fde577028640c419000e33eba6", sum)
+}
+
+func TestCalculateAll(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var file = filepath.Join(folder, "subject")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("lorem ipsum"), 0644))
+	sums, err := CalculateAll([]string{"sha256", "md5", "sha256"}, file)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"sha256": "5e2bf57d3f40c4b6df69daf1936cb766f832374b4fc0259a7cbff06e2f70f269",
+		"md5":    "80a751fde577028640c419000e33eba6",
+	}, sums)
+}
+
+func TestCalculateAllOpenFailure(t *testing.T) {
+	sums, err := CalculateAll([]string{"sha256", "sha512"}, "/tmp/this-file-wont-exist-I-hope")
+	assert.Empty(t, sums)
+	assert.Error(t, err)
+}
+
+func TestCalculateInvalidAlgorithm(t *testing.T) {
+	_, err := Calculate("sha3", "/tmp/this-file-wont-exist-I-hope")
+	assert.EqualError(t, err, "invalid checksum algorithm: sha3")
+}
+
+func TestAlgorithms(t *testing.T) {
+	assert.Equal(t, []string{"blake2b", "crc32", "md5", "sha1", "sha256", "sha512"}, Algorithms())
+}
+
 func TestOpenFailure(t *testing.T) {
 	sum, err := SHA256("/tmp/this-file-wont-exist-I-hope")
 	assert.Empty(t, sum)
@@ -36,3 +78,26 @@ func TestFileDoesntExist(t *testing.T) {
 	_, err = doCalculate(sha256.New(), file)
 	assert.Error(t, err)
 }
+
+func TestLine(t *testing.T) {
+	assert.Equal(t, "abc  foo.tar.gz\n", Line("gnu", "sha256", "abc", "foo.tar.gz"))
+	assert.Equal(t, "SHA256 (foo.tar.gz) = abc\n", Line("bsd", "sha256", "abc", "foo.tar.gz"))
+	assert.Equal(t, "BLAKE2b (foo.tar.gz) = abc\n", Line("bsd", "blake2b", "abc", "foo.tar.gz"))
+}
+
+func TestArtifact(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var file = filepath.Join(folder, "subject")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("lorem ipsum"), 0644))
+	var a = artifact.Artifact{
+		Path:      file,
+		Checksums: map[string]string{"sha256": "recorded"},
+	}
+	sum, err := Artifact("sha256", a)
+	assert.NoError(t, err)
+	assert.Equal(t, "recorded", sum)
+	sum, err = Artifact("md5", a)
+	assert.NoError(t, err)
+	assert.Equal(t, "80a751fde577028640c419000e33eba6", sum)
+}
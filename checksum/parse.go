@@ -0,0 +1,83 @@
+package checksum
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Entry is a line of a checksums file
+type Entry struct {
+	Algorithm string
+	Name      string
+	Sum       string
+}
+
+var (
+	// bsdRe matches the `ALGORITHM (name) = sum` lines
+	bsdRe = regexp.MustCompile(`^([A-Za-z0-9]+) \((.+)\) = ([0-9a-fA-F]+)$`)
+	// gnuRe matches the `sum  name` lines, the name being prefixed with a
+	// `*` instead of a space by the tools in binary mode
+	gnuRe = regexp.MustCompile(`^([0-9a-fA-F]+) [ *](.+)$`)
+)
+
+// lengths of the hex sums of the algorithms, to tell which one a GNU-style
+// line, which doesn't name it, was written with. Sums of 128 characters are
+// taken as sha512, use the algorithm argument of Parse for blake2b.
+var lengths = map[int]string{
+	8:   "crc32",
+	32:  "md5",
+	40:  "sha1",
+	64:  "sha256",
+	128: "sha512",
+}
+
+// Parse reads the lines of a checksums file, in both the formats Line
+// writes, which may be mixed. The algorithm of the GNU-style lines is the
+// given one, when not empty, or the one whose sums have their length.
+// Empty lines are ignored.
+func Parse(r io.Reader, algorithm string) ([]Entry, error) {
+	var byTag = map[string]string{}
+	for name, tag := range tags {
+		byTag[tag] = name
+	}
+	var entries []Entry
+	var scanner = bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		var line = strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if match := bsdRe.FindStringSubmatch(line); match != nil {
+			name, ok := byTag[match[1]]
+			if !ok {
+				return nil, fmt.Errorf("line %d: unknown checksum algorithm %s", n, match[1])
+			}
+			entries = append(entries, Entry{
+				Algorithm: name,
+				Name:      match[2],
+				Sum:       strings.ToLower(match[3]),
+			})
+			continue
+		}
+		if match := gnuRe.FindStringSubmatch(line); match != nil {
+			var name = algorithm
+			if name == "" {
+				name = lengths[len(match[1])]
+			}
+			if name == "" {
+				return nil, fmt.Errorf("line %d: can't tell the checksum algorithm of %s", n, match[2])
+			}
+			entries = append(entries, Entry{
+				Algorithm: name,
+				Name:      match[2],
+				Sum:       strings.ToLower(match[1]),
+			})
+			continue
+		}
+		return nil, fmt.Errorf("line %d: not a checksum line: %q", n, line)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,51 @@
+package checksum
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	var sha256 = "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"
+	var md5 = "d3b07384d113edec49eaa6238ad5ff00"
+	var content = Line("gnu", "sha256", sha256, "a.tar.gz") +
+		"\n" +
+		Line("bsd", "md5", md5, "b (1).tar.gz") +
+		md5 + " *c.zip\r\n"
+	entries, err := Parse(strings.NewReader(content), "")
+	assert.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{Algorithm: "sha256", Name: "a.tar.gz", Sum: sha256},
+		{Algorithm: "md5", Name: "b (1).tar.gz", Sum: md5},
+		{Algorithm: "md5", Name: "c.zip", Sum: md5},
+	}, entries)
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	var sum = strings.Repeat("a", 128)
+	entries, err := Parse(strings.NewReader(Line("gnu", "blake2b", sum, "a.tar.gz")), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "sha512", entries[0].Algorithm)
+	entries, err = Parse(strings.NewReader(Line("gnu", "blake2b", sum, "a.tar.gz")), "blake2b")
+	assert.NoError(t, err)
+	assert.Equal(t, "blake2b", entries[0].Algorithm)
+	entries, err = Parse(strings.NewReader(Line("bsd", "blake2b", sum, "a.tar.gz")), "sha512")
+	assert.NoError(t, err)
+	assert.Equal(t, "blake2b", entries[0].Algorithm)
+}
+
+func TestParseErrors(t *testing.T) {
+	for line, msg := range map[string]string{
+		"SHA3 (a.tar.gz) = abcd":   "line 1: unknown checksum algorithm SHA3",
+		"abcdef  a.tar.gz":         "line 1: can't tell the checksum algorithm of a.tar.gz",
+		"\nnot a checksum":         `line 2: not a checksum line: "not a checksum"`,
+		"a.tar.gz: OK":             `line 1: not a checksum line: "a.tar.gz: OK"`,
+		"zzzz  a.tar.gz\n":         `line 1: not a checksum line: "zzzz  a.tar.gz"`,
+		"d3b07384d113edec49eaa623": "line 1: not a checksum line: \"d3b07384d113edec49eaa623\"",
+	} {
+		_, err := Parse(strings.NewReader(line), "")
+		assert.EqualError(t, err, msg, line)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"time"
 
 	"github.com/apex/log"
 	yaml "gopkg.in/yaml.v2"
@@ -18,10 +19,16 @@ type GitHubURLs struct {
 	Download string `yaml:"download,omitempty"`
 }
 
+// GitLabURLs holds the URLs to be used when using gitlab
+type GitLabURLs struct {
+	API string `yaml:"api,omitempty"`
+}
+
 // Repo represents any kind of repo (github, gitlab, etc)
 type Repo struct {
-	Owner string `yaml:",omitempty"`
-	Name  string `yaml:",omitempty"`
+	Owner  string `yaml:",omitempty"`
+	Name   string `yaml:",omitempty"`
+	Branch string `yaml:",omitempty"`
 }
 
 // String of the repo, e.g. owner/name
@@ -32,30 +39,123 @@ func (r Repo) String() string {
 	return r.Owner + "/" + r.Name
 }
 
+// GitRepo is a git repository that is cloned and pushed to
+type GitRepo struct {
+	URL     string `yaml:"url,omitempty"`
+	Branch  string `yaml:",omitempty"`
+	Shallow bool   `yaml:",omitempty"`
+}
+
 // Homebrew contains the brew section
 type Homebrew struct {
-	GitHub           Repo         `yaml:",omitempty"`
-	CommitAuthor     CommitAuthor `yaml:"commit_author,omitempty"`
-	Folder           string       `yaml:",omitempty"`
-	Caveats          string       `yaml:",omitempty"`
-	Plist            string       `yaml:",omitempty"`
-	Install          string       `yaml:",omitempty"`
-	Dependencies     []string     `yaml:",omitempty"`
-	Test             string       `yaml:",omitempty"`
-	Conflicts        []string     `yaml:",omitempty"`
-	Description      string       `yaml:",omitempty"`
-	Homepage         string       `yaml:",omitempty"`
-	SkipUpload       bool         `yaml:"skip_upload,omitempty"`
-	DownloadStrategy string       `yaml:"download_strategy,omitempty"`
+	GitHub           Repo                 `yaml:",omitempty"`
+	CommitAuthor     CommitAuthor         `yaml:"commit_author,omitempty"`
+	Folder           string               `yaml:",omitempty"`
+	Caveats          string               `yaml:",omitempty"`
+	Plist            string               `yaml:",omitempty"`
+	Service          string               `yaml:",omitempty"`
+	Install          string               `yaml:",omitempty"`
+	Dependencies     []HomebrewDependency `yaml:",omitempty"`
+	Test             string               `yaml:",omitempty"`
+	Conflicts        []string             `yaml:",omitempty"`
+	Description      string               `yaml:",omitempty"`
+	Homepage         string               `yaml:",omitempty"`
+	SkipUpload       string               `yaml:"skip_upload,omitempty"`
+	DownloadStrategy string               `yaml:"download_strategy,omitempty"`
+	IDs              []string             `yaml:"ids,omitempty"`
+
+	CommitMessageTemplate string  `yaml:"commit_msg_template,omitempty"`
+	Devel                 bool    `yaml:",omitempty"`
+	URLTemplate           string  `yaml:"url_template,omitempty"`
+	UseHardwareCPU        bool    `yaml:"use_hardware_cpu,omitempty"`
+	Git                   GitRepo `yaml:",omitempty"`
+	GitLab                Repo    `yaml:"gitlab,omitempty"`
+}
+
+// HomebrewDependency is a formula dependency, optionally typed
+type HomebrewDependency struct {
+	Name string `yaml:",omitempty"`
+	Type string `yaml:",omitempty"`
+}
+
+// UnmarshalYAML allows dependencies to be given either as a plain formula
+// name or as a name and type pair
+func (d *HomebrewDependency) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		d.Name = name
+		return nil
+	}
+	type plain HomebrewDependency
+	return unmarshal((*plain)(d))
 }
 
 // Scoop contains the scoop.sh section
 type Scoop struct {
-	Bucket       Repo         `yaml:",omitempty"`
-	CommitAuthor CommitAuthor `yaml:"commit_author,omitempty"`
-	Homepage     string       `yaml:",omitempty"`
-	Description  string       `yaml:",omitempty"`
-	License      string       `yaml:",omitempty"`
+	Bucket       Repo            `yaml:",omitempty"`
+	Git          GitRepo         `yaml:",omitempty"`
+	CommitAuthor CommitAuthor    `yaml:"commit_author,omitempty"`
+	Homepage     string          `yaml:",omitempty"`
+	Description  string          `yaml:",omitempty"`
+	License      ScoopLicense    `yaml:",omitempty"`
+	Checkver     ScoopCheckver   `yaml:",omitempty"`
+	Autoupdate   ScoopAutoupdate `yaml:",omitempty"`
+	PreInstall   StringArray     `yaml:"pre_install,omitempty"`
+	PostInstall  StringArray     `yaml:"post_install,omitempty"`
+	Persist      []StringArray   `yaml:",omitempty"`
+	SkipUpload   string          `yaml:"skip_upload,omitempty"`
+	Folder       string          `yaml:",omitempty"`
+
+	CommitMessageTemplate string `yaml:"commit_msg_template,omitempty"`
+}
+
+// StringArray is a list of strings that can also be given as a single string
+type StringArray []string
+
+// UnmarshalYAML allows a single string to be used as a one element list
+func (a *StringArray) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err == nil {
+		*a = []string{str}
+		return nil
+	}
+	var strs []string
+	if err := unmarshal(&strs); err != nil {
+		return err
+	}
+	*a = strs
+	return nil
+}
+
+// ScoopLicense is the license of a scoop app, either a well-known license
+// identifier or a custom license and its url
+type ScoopLicense struct {
+	Identifier string `yaml:",omitempty"`
+	URL        string `yaml:"url,omitempty"`
+}
+
+// UnmarshalYAML allows the license to be given as a plain identifier
+func (l *ScoopLicense) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var identifier string
+	if err := unmarshal(&identifier); err == nil {
+		l.Identifier = identifier
+		return nil
+	}
+	type plain ScoopLicense
+	return unmarshal((*plain)(l))
+}
+
+// ScoopCheckver tells scoop how to find newer versions of the app
+type ScoopCheckver struct {
+	GitHub   bool   `yaml:"github,omitempty"`
+	URL      string `yaml:",omitempty"`
+	Regex    string `yaml:",omitempty"`
+	JSONPath string `yaml:"jsonpath,omitempty"`
+}
+
+// ScoopAutoupdate tells scoop how to update the manifest to a newer version
+type ScoopAutoupdate struct {
+	URLTemplate string `yaml:"url_template,omitempty"`
 }
 
 // CommitAuthor is the author of a Git commit
@@ -77,6 +177,7 @@ type IgnoredBuild struct {
 
 // Build contains the build configuration section
 type Build struct {
+	ID      string         `yaml:",omitempty"`
 	Goos    []string       `yaml:",omitempty"`
 	Goarch  []string       `yaml:",omitempty"`
 	Goarm   []string       `yaml:",omitempty"`
@@ -99,6 +200,7 @@ type FormatOverride struct {
 
 // Archive config used for the archive
 type Archive struct {
+	ID           string            `yaml:"id,omitempty"`
 	NameTemplate string            `yaml:"name_template,omitempty"`
 	Replacements map[string]string `yaml:",omitempty"`
 
@@ -118,6 +220,7 @@ type Release struct {
 
 // FPM config
 type FPM struct {
+	ID           string            `yaml:"id,omitempty"`
 	NameTemplate string            `yaml:"name_template,omitempty"`
 	Replacements map[string]string `yaml:",omitempty"`
 
@@ -141,17 +244,38 @@ type Sign struct {
 	Cmd       string   `yaml:"cmd,omitempty"`
 	Args      []string `yaml:"args,omitempty"`
 	Signature string   `yaml:"signature,omitempty"`
+	Mode      string   `yaml:"mode,omitempty"`
 	Artifacts string   `yaml:"artifacts,omitempty"`
+	IDs       []string `yaml:"ids,omitempty"`
+	Env       []string `yaml:"env,omitempty"`
+
+	PassphraseEnv string        `yaml:"passphrase_env,omitempty"`
+	Timeout       time.Duration `yaml:"timeout,omitempty"`
+
+	Key            string `yaml:"key,omitempty"`
+	KeyEnv         string `yaml:"key_env,omitempty"`
+	TrustedComment string `yaml:"trusted_comment,omitempty"`
+
+	Verify     bool     `yaml:"verify,omitempty"`
+	VerifyCmd  string   `yaml:"verify_cmd,omitempty"`
+	VerifyArgs []string `yaml:"verify_args,omitempty"`
+	PublicKey  string   `yaml:"public_key,omitempty"`
 }
 
 // SnapcraftAppMetadata for the binaries that will be in the snap package
 type SnapcraftAppMetadata struct {
-	Plugs  []string
-	Daemon string
+	Binary    string   `yaml:",omitempty"`
+	Command   string   `yaml:",omitempty"`
+	Plugs     []string `yaml:",omitempty"`
+	Slots     []string `yaml:",omitempty"`
+	Daemon    string   `yaml:",omitempty"`
+	Args      string   `yaml:",omitempty"`
+	Completer string   `yaml:",omitempty"`
 }
 
 // Snapcraft config
 type Snapcraft struct {
+	ID           string            `yaml:"id,omitempty"`
 	NameTemplate string            `yaml:"name_template,omitempty"`
 	Replacements map[string]string `yaml:",omitempty"`
 
@@ -160,7 +284,28 @@ type Snapcraft struct {
 	Description string                          `yaml:",omitempty"`
 	Grade       string                          `yaml:",omitempty"`
 	Confinement string                          `yaml:",omitempty"`
+	Base        string                          `yaml:",omitempty"`
+	License     string                          `yaml:",omitempty"`
+	Extra       map[string]interface{}          `yaml:",omitempty"`
 	Apps        map[string]SnapcraftAppMetadata `yaml:",omitempty"`
+	Files       map[string]string               `yaml:",omitempty"`
+	Layout      map[string]map[string]string    `yaml:",omitempty"`
+
+	Publish          bool     `yaml:",omitempty"`
+	ChannelTemplates []string `yaml:"channel_templates,omitempty"`
+	Required         bool     `yaml:",omitempty"`
+}
+
+// Git config
+type Git struct {
+	TagPrefix                  string   `yaml:"tag_prefix,omitempty"`
+	TagRegex                   string   `yaml:"tag_regex,omitempty"`
+	SkipVersionCheck           bool     `yaml:"skip_version_check,omitempty"`
+	IgnoreDirtyPaths           []string `yaml:"ignore_dirty_paths,omitempty"`
+	Remote                     string   `yaml:"remote,omitempty"`
+	Unshallow                  bool     `yaml:"unshallow,omitempty"`
+	Reproducible               bool     `yaml:"reproducible,omitempty"`
+	PrereleaseAwarePreviousTag bool     `yaml:"prerelease_aware_previous_tag,omitempty"`
 }
 
 // Snapshot config
@@ -170,7 +315,12 @@ type Snapshot struct {
 
 // Checksum config
 type Checksum struct {
-	NameTemplate string `yaml:"name_template,omitempty"`
+	NameTemplate string   `yaml:"name_template,omitempty"`
+	Algorithm    string   `yaml:"algorithm,omitempty"`
+	Algorithms   []string `yaml:"algorithms,omitempty"`
+	Format       string   `yaml:"format,omitempty"`
+	Split        bool     `yaml:"split,omitempty"`
+	Artifacts    []string `yaml:"artifacts,omitempty"`
 }
 
 // Docker image config
@@ -181,10 +331,36 @@ type Docker struct {
 	Goarm          string   `yaml:",omitempty"`
 	Image          string   `yaml:",omitempty"`
 	Dockerfile     string   `yaml:",omitempty"`
-	Latest         bool     `yaml:",omitempty"`
+	Latest         string   `yaml:",omitempty"`
 	OldTagTemplate string   `yaml:"tag_template,omitempty"`
 	TagTemplates   []string `yaml:"tag_templates,omitempty"`
 	Files          []string `yaml:"extra_files,omitempty"`
+
+	BuildFlagTemplates []string          `yaml:"build_flag_templates,omitempty"`
+	SkipPush           string            `yaml:"skip_push,omitempty"`
+	Labels             map[string]string `yaml:",omitempty"`
+	OCILabels          bool              `yaml:"oci_labels,omitempty"`
+	Retry              DockerRetry       `yaml:",omitempty"`
+	Registry           string            `yaml:",omitempty"`
+	Username           string            `yaml:",omitempty"`
+	PasswordEnv        string            `yaml:"password_env,omitempty"`
+	BuildID            string            `yaml:"build_id,omitempty"`
+	Required           bool              `yaml:",omitempty"`
+	Use                string            `yaml:",omitempty"`
+	BuildFlags         []string          `yaml:"build_flags,omitempty"`
+	Cleanup            bool              `yaml:",omitempty"`
+}
+
+// DockerRetry config of the docker pushes
+type DockerRetry struct {
+	Attempts int           `yaml:",omitempty"`
+	Delay    time.Duration `yaml:",omitempty"`
+}
+
+// DockerManifest config
+type DockerManifest struct {
+	NameTemplate   string   `yaml:"name_template,omitempty"`
+	ImageTemplates []string `yaml:"image_templates,omitempty"`
 }
 
 // Artifactory server configuration
@@ -200,62 +376,155 @@ type Filters struct {
 	Exclude []string `yaml:",omitempty"`
 }
 
+// ChangelogGroup is a section of the changelog holding the commits whose
+// messages match its regexp. A group without a regexp catches all the
+// commits no other group matched.
+type ChangelogGroup struct {
+	Title  string `yaml:",omitempty"`
+	Regexp string `yaml:",omitempty"`
+	Order  int    `yaml:",omitempty"`
+}
+
 // Changelog Config
 type Changelog struct {
-	Filters Filters `yaml:",omitempty"`
-	Sort    string  `yaml:",omitempty"`
+	Filters         Filters          `yaml:",omitempty"`
+	Sort            string           `yaml:",omitempty"`
+	Groups          []ChangelogGroup `yaml:",omitempty"`
+	Format          string           `yaml:",omitempty"`
+	Skip            bool             `yaml:",omitempty"`
+	PreviousTag     string           `yaml:"previous_tag,omitempty"`
+	Merges          string           `yaml:",omitempty"`
+	NameTemplate    string           `yaml:"name_template,omitempty"`
+	Upload          bool             `yaml:",omitempty"`
+	Use             string           `yaml:",omitempty"`
+	Paths           []string         `yaml:",omitempty"`
+	Dedup           bool             `yaml:",omitempty"`
+	SkipCherryPicks bool             `yaml:"skip_cherry_picks,omitempty"`
 }
 
 // EnvFiles holds paths to files that contains environment variables
 // values like the github token for example
 type EnvFiles struct {
 	GitHubToken string `yaml:"github_token,omitempty"`
+	GitLabToken string `yaml:"gitlab_token,omitempty"`
+}
+
+// Before config
+type Before struct {
+	Hooks []string `yaml:",omitempty"`
+}
+
+// After config
+type After struct {
+	Hooks []string `yaml:",omitempty"`
+}
+
+// Metadata config
+type Metadata struct {
+	Skip bool `yaml:",omitempty"`
 }
 
 // Project includes all project configuration
 type Project struct {
-	ProjectName   string        `yaml:"project_name,omitempty"`
-	Release       Release       `yaml:",omitempty"`
-	Brew          Homebrew      `yaml:",omitempty"`
-	Scoop         Scoop         `yaml:",omitempty"`
-	Builds        []Build       `yaml:",omitempty"`
-	Archive       Archive       `yaml:",omitempty"`
-	FPM           FPM           `yaml:",omitempty"`
-	NFPM          FPM           `yaml:",omitempty"`
-	Snapcraft     Snapcraft     `yaml:",omitempty"`
-	Snapshot      Snapshot      `yaml:",omitempty"`
-	Checksum      Checksum      `yaml:",omitempty"`
-	Dockers       []Docker      `yaml:",omitempty"`
-	Artifactories []Artifactory `yaml:",omitempty"`
-	Changelog     Changelog     `yaml:",omitempty"`
-	Dist          string        `yaml:",omitempty"`
-	Sign          Sign          `yaml:",omitempty"`
-	EnvFiles      EnvFiles      `yaml:"env_files,omitempty"`
+	ProjectName     string           `yaml:"project_name,omitempty"`
+	Release         Release          `yaml:",omitempty"`
+	Brew            Homebrew         `yaml:",omitempty"`
+	Scoop           Scoop            `yaml:",omitempty"`
+	Builds          []Build          `yaml:",omitempty"`
+	Archive         Archive          `yaml:",omitempty"`
+	FPM             FPM              `yaml:",omitempty"`
+	NFPM            FPM              `yaml:",omitempty"`
+	Snapcraft       Snapcraft        `yaml:",omitempty"`
+	Snapshot        Snapshot         `yaml:",omitempty"`
+	Git             Git              `yaml:",omitempty"`
+	Checksum        Checksum         `yaml:",omitempty"`
+	Dockers         []Docker         `yaml:",omitempty"`
+	DockerManifests []DockerManifest `yaml:"docker_manifests,omitempty"`
+	Artifactories   []Artifactory    `yaml:",omitempty"`
+	Changelog       Changelog        `yaml:",omitempty"`
+	Dist            string           `yaml:",omitempty"`
+	Sign            Sign             `yaml:",omitempty"`
+	Signs           []Sign           `yaml:",omitempty"`
+	DockerSigns     []Sign           `yaml:"docker_signs,omitempty"`
+	EnvFiles        EnvFiles         `yaml:"env_files,omitempty"`
+	Include         StringArray      `yaml:",omitempty"`
+	PublishIf       string           `yaml:"publish_if,omitempty"`
+	Metadata        Metadata         `yaml:",omitempty"`
+	Before          Before           `yaml:",omitempty"`
+	After           After            `yaml:",omitempty"`
 
 	// this is a hack ¯\_(ツ)_/¯
 	SingleBuild Build `yaml:"build,omitempty"`
 
 	// should be set if using github enterprise
 	GitHubURLs GitHubURLs `yaml:"github_urls,omitempty"`
+
+	// should be set if using a self-hosted gitlab
+	GitLabURLs GitLabURLs `yaml:"gitlab_urls,omitempty"`
 }
 
 // Load config file
 func Load(file string) (config Project, err error) {
+	return load(file, true)
+}
+
+// LoadLax loads the config file like Load, but only warns about its unknown
+// fields, e.g. the fields of newer goreleaser versions
+func LoadLax(file string) (config Project, err error) {
+	return load(file, false)
+}
+
+func load(file string, strict bool) (config Project, err error) {
 	f, err := os.Open(file)
 	if err != nil {
 		return
 	}
+	defer f.Close() // nolint: errcheck
 	log.WithField("file", file).Info("loading config file")
-	return LoadReader(f)
+	return loadReader(f, file, strict)
 }
 
 // LoadReader config via io.Reader
 func LoadReader(fd io.Reader) (config Project, err error) {
+	return loadReader(fd, "", true)
+}
+
+// LoadReaderLax loads the config via io.Reader like LoadReader, but only
+// warns about its unknown fields
+func LoadReaderLax(fd io.Reader) (config Project, err error) {
+	return loadReader(fd, "", false)
+}
+
+// loadReader loads the config read from fd, merged over the configs it
+// includes, which are relative to the source file, or to the current
+// directory if the source is empty
+func loadReader(fd io.Reader, source string, strict bool) (config Project, err error) {
 	data, err := ioutil.ReadAll(fd)
 	if err != nil {
 		return config, err
 	}
-	err = yaml.UnmarshalStrict(data, &config)
+	config, err = unmarshal(data, strict)
+	if err == nil && len(config.Include) > 0 {
+		config, err = include(data, config, source, strict)
+	}
+	if err == nil {
+		err = expandEnv(&config, os.LookupEnv)
+	}
 	log.WithField("config", config).Debug("loaded config file")
 	return config, err
 }
+
+func unmarshal(data []byte, strict bool) (config Project, err error) {
+	err = yaml.UnmarshalStrict(data, &config)
+	if fields := unknownFields(data, err); fields != nil {
+		if strict {
+			return config, ErrUnknownFields{fields}
+		}
+		for _, field := range fields {
+			log.Warnf("ignoring unknown config field %s", field)
+		}
+		config = Project{}
+		err = yaml.Unmarshal(data, &config)
+	}
+	return config, err
+}
@@ -59,7 +59,7 @@ func TestFileNotFound(t *testing.T) {
 
 func TestInvalidFields(t *testing.T) {
 	_, err := Load("testdata/invalid_config.yml")
-	assert.EqualError(t, err, "yaml: unmarshal errors:\n  line 2: field invalid_yaml not found in struct config.Build")
+	assert.EqualError(t, err, "unknown fields in the config file, fix them or use --lax-config to ignore them:\n  line 2: build.invalid_yaml")
 }
 
 func TestInvalidYaml(t *testing.T) {
@@ -71,3 +71,85 @@ func TestConfigWithAnchors(t *testing.T) {
 	_, err := Load("testdata/anchor.yaml")
 	assert.NoError(t, err)
 }
+
+func TestLoadHomebrewDependencies(t *testing.T) {
+	var conf = `
+brew:
+  dependencies:
+    - git
+    - name: go
+      type: build
+`
+	prop, err := LoadReader(strings.NewReader(conf))
+	assert.NoError(t, err)
+	assert.Equal(t, []HomebrewDependency{
+		{Name: "git"},
+		{Name: "go", Type: "build"},
+	}, prop.Brew.Dependencies)
+}
+
+func TestLoadScoopInstallScripts(t *testing.T) {
+	var conf = `
+scoop:
+  pre_install: Write-Host "installing"
+  post_install:
+    - Write-Host "installed"
+    - Register-ScheduledTask foo
+`
+	prop, err := LoadReader(strings.NewReader(conf))
+	assert.NoError(t, err)
+	assert.Equal(t, StringArray{`Write-Host "installing"`}, prop.Scoop.PreInstall)
+	assert.Equal(t, StringArray{
+		`Write-Host "installed"`,
+		"Register-ScheduledTask foo",
+	}, prop.Scoop.PostInstall)
+}
+
+func TestLoadScoopPersist(t *testing.T) {
+	var conf = `
+scoop:
+  persist:
+    - data
+    - [config.json, conf/config.json]
+`
+	prop, err := LoadReader(strings.NewReader(conf))
+	assert.NoError(t, err)
+	assert.Equal(t, []StringArray{
+		{"data"},
+		{"config.json", "conf/config.json"},
+	}, prop.Scoop.Persist)
+}
+
+func TestLoadScoopSkipUpload(t *testing.T) {
+	for _, value := range []string{"true", "auto"} {
+		prop, err := LoadReader(strings.NewReader("scoop:\n  skip_upload: " + value))
+		assert.NoError(t, err)
+		assert.Equal(t, value, prop.Scoop.SkipUpload)
+	}
+}
+
+func TestLoadScoopLicense(t *testing.T) {
+	prop, err := LoadReader(strings.NewReader("scoop:\n  license: MIT"))
+	assert.NoError(t, err)
+	assert.Equal(t, ScoopLicense{Identifier: "MIT"}, prop.Scoop.License)
+
+	prop, err = LoadReader(strings.NewReader(`
+scoop:
+  license:
+    identifier: Freeware
+    url: https://example.com/license
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, ScoopLicense{
+		Identifier: "Freeware",
+		URL:        "https://example.com/license",
+	}, prop.Scoop.License)
+}
+
+func TestLoadHomebrewSkipUpload(t *testing.T) {
+	for _, value := range []string{"true", "auto"} {
+		prop, err := LoadReader(strings.NewReader("brew:\n  skip_upload: " + value))
+		assert.NoError(t, err)
+		assert.Equal(t, value, prop.Brew.SkipUpload)
+	}
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// expandable returns the string fields whose `$VAR` and `${VAR}` references
+// are expanded when the config is loaded, by their path. The templates,
+// commands, scripts and sign arguments are not, as they have their own `$`
+// placeholders or are given to a shell.
+func expandable(config *Project) map[string]*string {
+	var fields = map[string]*string{
+		"project_name":           &config.ProjectName,
+		"dist":                   &config.Dist,
+		"release.github.owner":   &config.Release.GitHub.Owner,
+		"release.github.name":    &config.Release.GitHub.Name,
+		"brew.github.owner":      &config.Brew.GitHub.Owner,
+		"brew.github.name":       &config.Brew.GitHub.Name,
+		"brew.folder":            &config.Brew.Folder,
+		"brew.homepage":          &config.Brew.Homepage,
+		"brew.description":       &config.Brew.Description,
+		"scoop.bucket.owner":     &config.Scoop.Bucket.Owner,
+		"scoop.bucket.name":      &config.Scoop.Bucket.Name,
+		"scoop.homepage":         &config.Scoop.Homepage,
+		"scoop.description":      &config.Scoop.Description,
+		"snapcraft.name":         &config.Snapcraft.Name,
+		"snapcraft.summary":      &config.Snapcraft.Summary,
+		"github_urls.api":        &config.GitHubURLs.API,
+		"github_urls.upload":     &config.GitHubURLs.Upload,
+		"github_urls.download":   &config.GitHubURLs.Download,
+		"gitlab_urls.api":        &config.GitLabURLs.API,
+		"env_files.github_token": &config.EnvFiles.GitHubToken,
+		"env_files.gitlab_token": &config.EnvFiles.GitLabToken,
+	}
+	for name, fpm := range map[string]*FPM{"fpm": &config.FPM, "nfpm": &config.NFPM} {
+		fields[name+".bindir"] = &fpm.Bindir
+		fields[name+".vendor"] = &fpm.Vendor
+		fields[name+".homepage"] = &fpm.Homepage
+		fields[name+".maintainer"] = &fpm.Maintainer
+		fields[name+".description"] = &fpm.Description
+		fields[name+".license"] = &fpm.License
+	}
+	for i := range config.Builds {
+		var build = &config.Builds[i]
+		fields[fmt.Sprintf("builds[%d].main", i)] = &build.Main
+	}
+	for i := range config.Dockers {
+		var docker = &config.Dockers[i]
+		fields[fmt.Sprintf("dockers[%d].image", i)] = &docker.Image
+		fields[fmt.Sprintf("dockers[%d].dockerfile", i)] = &docker.Dockerfile
+		fields[fmt.Sprintf("dockers[%d].registry", i)] = &docker.Registry
+		fields[fmt.Sprintf("dockers[%d].username", i)] = &docker.Username
+	}
+	for i := range config.Artifactories {
+		var artifactory = &config.Artifactories[i]
+		fields[fmt.Sprintf("artifactories[%d].username", i)] = &artifactory.Username
+	}
+	return fields
+}
+
+// expandEnv expands the environment variables referenced by the expandable
+// fields of the config, looking them up with the given func.
+func expandEnv(config *Project, lookup func(string) (string, bool)) error {
+	var fields = expandable(config)
+	var paths []string
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		var value = fields[path]
+		expanded, err := expand(*value, lookup)
+		if err != nil {
+			return fmt.Errorf("failed to expand %s: %s", path, err.Error())
+		}
+		*value = expanded
+	}
+	return nil
+}
+
+// expand replaces `$VAR` and `${VAR}` with the value of the variable, which
+// must be set, and `${VAR:-default}` with the value of the variable or the
+// default when it is unset or empty. `$$` is a literal `$`, as is a `$`
+// without a variable name after it.
+func expand(s string, lookup func(string) (string, bool)) (string, error) {
+	var out bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			out.WriteByte(s[i])
+			continue
+		}
+		var rest = s[i+1:]
+		switch {
+		case rest[0] == '$':
+			out.WriteByte('$')
+			i++
+		case rest[0] == '{':
+			var end = strings.IndexByte(rest, '}')
+			if end < 0 {
+				return "", fmt.Errorf("missing } in '%s'", s)
+			}
+			var name, def = rest[1:end], ""
+			var hasDefault bool
+			if idx := strings.Index(name, ":-"); idx >= 0 {
+				name, def, hasDefault = name[:idx], name[idx+2:], true
+			}
+			if !isName(name) {
+				return "", fmt.Errorf("invalid variable name '%s' in '%s'", name, s)
+			}
+			value, ok := lookup(name)
+			if hasDefault && value == "" {
+				value, ok = def, true
+			}
+			if !ok {
+				return "", fmt.Errorf("$%s is not set, use ${%s:-default} to give it a default", name, name)
+			}
+			out.WriteString(value)
+			i += end + 1
+		case isNameStart(rest[0]):
+			var end = 1
+			for end < len(rest) && (isNameStart(rest[end]) || rest[end] >= '0' && rest[end] <= '9') {
+				end++
+			}
+			var name = rest[:end]
+			value, ok := lookup(name)
+			if !ok {
+				return "", fmt.Errorf("$%s is not set, use ${%s:-default} to give it a default", name, name)
+			}
+			out.WriteString(value)
+			i += end
+		default:
+			out.WriteByte('$')
+		}
+	}
+	return out.String(), nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isName(s string) bool {
+	if s == "" || !isNameStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isNameStart(s[i]) && (s[i] < '0' || s[i] > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpand(t *testing.T) {
+	var env = map[string]string{
+		"HOME":     "/home/me",
+		"REGISTRY": "registry.example.com",
+		"EMPTY":    "",
+	}
+	var lookup = func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	for s, expected := range map[string]string{
+		"":                  "",
+		"dist":              "dist",
+		"$HOME/dist":        "/home/me/dist",
+		"${HOME}dist":       "/home/medist",
+		"$REGISTRY/app":     "registry.example.com/app",
+		"${NOPE:-/usr/bin}": "/usr/bin",
+		"${EMPTY:-default}": "default",
+		"${HOME:-/root}":    "/home/me",
+		"${NOPE:-}":         "",
+		"$EMPTY":            "",
+		"price: $$5":        "price: $5",
+		"$${HOME}":          "${HOME}",
+		"a $ b $":           "a $ b $",
+		"$1":                "$1",
+	} {
+		result, err := expand(s, lookup)
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, result, s)
+	}
+	for s, msg := range map[string]string{
+		"$NOPE/dist":    "$NOPE is not set, use ${NOPE:-default} to give it a default",
+		"${NOPE}":       "$NOPE is not set, use ${NOPE:-default} to give it a default",
+		"${HOME":        "missing } in '${HOME'",
+		"${1HOME}":      "invalid variable name '1HOME' in '${1HOME}'",
+		"${}":           "invalid variable name '' in '${}'",
+		"$HOME_DIR/foo": "$HOME_DIR is not set, use ${HOME_DIR:-default} to give it a default",
+	} {
+		_, err := expand(s, lookup)
+		assert.EqualError(t, err, msg, s)
+	}
+}
+
+func TestLoadReaderExpandsEnv(t *testing.T) {
+	assert.NoError(t, os.Setenv("GORELEASER_TEST_REGISTRY", "registry.example.com"))
+	defer os.Unsetenv("GORELEASER_TEST_REGISTRY") // nolint: errcheck
+	var conf = `
+dist: ${GORELEASER_TEST_DIST:-out}
+fpm:
+  bindir: /opt/$$bin
+dockers:
+  - image: $GORELEASER_TEST_REGISTRY/app
+    tag_templates:
+      - "{{ .Env.GORELEASER_TEST_REGISTRY }}"
+sign:
+  args: ["--output", "${signature}", "${artifact}"]
+`
+	config, err := LoadReader(strings.NewReader(conf))
+	assert.NoError(t, err)
+	assert.Equal(t, "out", config.Dist)
+	assert.Equal(t, "/opt/$bin", config.FPM.Bindir)
+	assert.Equal(t, "registry.example.com/app", config.Dockers[0].Image)
+	assert.Equal(t, "{{ .Env.GORELEASER_TEST_REGISTRY }}", config.Dockers[0].TagTemplates[0])
+	assert.Equal(t, []string{"--output", "${signature}", "${artifact}"}, config.Sign.Args)
+
+	_, err = LoadReader(strings.NewReader("dockers:\n  - image: $GORELEASER_TEST_NOPE/app\n"))
+	assert.EqualError(t, err, "failed to expand dockers[0].image: $GORELEASER_TEST_NOPE is not set, use ${GORELEASER_TEST_NOPE:-default} to give it a default")
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// includeClient fetches the configs included by URL
+var includeClient = &http.Client{Timeout: time.Minute}
+
+// include merges the config data over the configs it includes, in order,
+// each of them being merged over the ones it includes itself. The maps are
+// merged key by key, while the lists and the other values replace the ones
+// of the included configs.
+func include(data []byte, config Project, source string, strict bool) (Project, error) {
+	var ref = source
+	if ref != "" && !isURL(ref) {
+		abs, err := filepath.Abs(ref)
+		if err != nil {
+			return config, err
+		}
+		ref = abs
+	}
+	doc, err := resolve(data, config, ref, []string{ref}, strict)
+	if err != nil {
+		return config, err
+	}
+	bts, err := yaml.Marshal(doc)
+	if err != nil {
+		return config, err
+	}
+	// every config was checked on its own, with its line numbers
+	var merged Project
+	if err := yaml.Unmarshal(bts, &merged); err != nil {
+		return config, err
+	}
+	return merged, nil
+}
+
+// resolve returns the config data merged over its includes, without the
+// include field. The stack has the configs including this one, to detect
+// the cycles.
+func resolve(data []byte, config Project, ref string, stack []string, strict bool) (yaml.MapSlice, error) {
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var base yaml.MapSlice
+	for _, inc := range config.Include {
+		var incRef, err = includeRef(ref, inc)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range stack {
+			if s == incRef {
+				return nil, fmt.Errorf("config include cycle: %s", strings.Join(append(stack, incRef), " -> "))
+			}
+		}
+		log.WithField("include", incRef).Info("including config")
+		incData, err := read(incRef)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to include %s", inc)
+		}
+		incConfig, err := unmarshal(incData, strict)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to include %s", inc)
+		}
+		incDoc, err := resolve(incData, incConfig, incRef, append(stack, incRef), strict)
+		if err != nil {
+			return nil, err
+		}
+		base = merge(base, incDoc)
+	}
+	return merge(base, without(doc, "include")), nil
+}
+
+// includeRef returns the path or URL of the include, relative to the
+// config including it
+func includeRef(ref, inc string) (string, error) {
+	if isURL(inc) {
+		return inc, nil
+	}
+	if isURL(ref) {
+		base, err := url.Parse(ref)
+		if err != nil {
+			return "", err
+		}
+		rel, err := url.Parse(inc)
+		if err != nil {
+			return "", err
+		}
+		return base.ResolveReference(rel).String(), nil
+	}
+	if filepath.IsAbs(inc) {
+		return filepath.Clean(inc), nil
+	}
+	var dir = "."
+	if ref != "" {
+		dir = filepath.Dir(ref)
+	}
+	return filepath.Abs(filepath.Join(dir, inc))
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+func read(ref string) ([]byte, error) {
+	if !isURL(ref) {
+		return ioutil.ReadFile(ref)
+	}
+	resp, err := includeClient.Get(ref)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", ref, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// merge returns the base with the values of over, merging the maps both
+// have key by key
+func merge(base, over yaml.MapSlice) yaml.MapSlice {
+	var result = append(yaml.MapSlice{}, base...)
+	for _, item := range over {
+		var found bool
+		for i := range result {
+			if fmt.Sprint(result[i].Key) != fmt.Sprint(item.Key) {
+				continue
+			}
+			found = true
+			baseMap, baseIsMap := result[i].Value.(yaml.MapSlice)
+			overMap, overIsMap := item.Value.(yaml.MapSlice)
+			if baseIsMap && overIsMap {
+				result[i].Value = merge(baseMap, overMap)
+			} else {
+				result[i].Value = item.Value
+			}
+			break
+		}
+		if !found {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func without(doc yaml.MapSlice, key string) yaml.MapSlice {
+	var result yaml.MapSlice
+	for _, item := range doc {
+		if fmt.Sprint(item.Key) != key {
+			result = append(result, item)
+		}
+	}
+	return result
+}
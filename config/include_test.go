@@ -0,0 +1,135 @@
+package config
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeConfigs writes the configs by name to a temporary folder, returning
+// it
+func writeConfigs(t *testing.T, configs map[string]string) string {
+	folder, err := ioutil.TempDir("", "goreleaserconfig")
+	assert.NoError(t, err)
+	for name, content := range configs {
+		var path = filepath.Join(folder, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	return folder
+}
+
+func TestInclude(t *testing.T) {
+	var folder = writeConfigs(t, map[string]string{
+		"shared/base.yml": `
+include: common.yml
+archive:
+  format: tar.gz
+  files: [README.md, LICENSE]
+  replacements:
+    amd64: x86_64
+    darwin: macOS
+builds:
+  - goos: [linux, darwin]
+brew:
+  github:
+    owner: acme
+    name: homebrew-tap
+`,
+		"shared/common.yml": `
+project_name: common
+dist: out
+`,
+		".goreleaser.yml": `
+include: shared/base.yml
+project_name: mytool
+archive:
+  files: [README.md]
+  replacements:
+    darwin: Darwin
+builds:
+  - goos: [windows]
+`,
+	})
+	defer os.RemoveAll(folder) // nolint: errcheck
+	config, err := Load(filepath.Join(folder, ".goreleaser.yml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "mytool", config.ProjectName)
+	assert.Equal(t, "out", config.Dist)
+	assert.Equal(t, "tar.gz", config.Archive.Format)
+	assert.Equal(t, []string{"README.md"}, config.Archive.Files)
+	assert.Equal(t, map[string]string{"amd64": "x86_64", "darwin": "Darwin"}, config.Archive.Replacements)
+	assert.Len(t, config.Builds, 1)
+	assert.Equal(t, []string{"windows"}, config.Builds[0].Goos)
+	assert.Equal(t, Repo{Owner: "acme", Name: "homebrew-tap"}, config.Brew.GitHub)
+	assert.Empty(t, config.Include)
+}
+
+func TestIncludeOrder(t *testing.T) {
+	var folder = writeConfigs(t, map[string]string{
+		"a.yml": "project_name: a\ndist: a\n",
+		"b.yml": "project_name: b\n",
+		"c.yml": "include: [a.yml, b.yml]\n",
+	})
+	defer os.RemoveAll(folder) // nolint: errcheck
+	config, err := Load(filepath.Join(folder, "c.yml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "b", config.ProjectName)
+	assert.Equal(t, "a", config.Dist)
+}
+
+func TestIncludeCycle(t *testing.T) {
+	var folder = writeConfigs(t, map[string]string{
+		"a.yml": "include: b.yml\n",
+		"b.yml": "include: a.yml\n",
+	})
+	defer os.RemoveAll(folder) // nolint: errcheck
+	_, err := Load(filepath.Join(folder, "a.yml"))
+	var a, b = filepath.Join(folder, "a.yml"), filepath.Join(folder, "b.yml")
+	assert.EqualError(t, err, "config include cycle: "+a+" -> "+b+" -> "+a)
+}
+
+func TestIncludeErrors(t *testing.T) {
+	var folder = writeConfigs(t, map[string]string{
+		"unknown.yml": "archive:\n  nametemplate: foo\n",
+		"local.yml":   "include: unknown.yml\n",
+		"missing.yml": "include: nope.yml\n",
+	})
+	defer os.RemoveAll(folder) // nolint: errcheck
+	_, err := Load(filepath.Join(folder, "local.yml"))
+	assert.EqualError(t, err, "failed to include unknown.yml: unknown fields in the config file, fix them or use --lax-config to ignore them:\n  line 2: archive.nametemplate, did you mean archive.name_template?")
+	_, err = LoadLax(filepath.Join(folder, "local.yml"))
+	assert.NoError(t, err)
+	_, err = Load(filepath.Join(folder, "missing.yml"))
+	assert.EqualError(t, err, "failed to include nope.yml: open "+filepath.Join(folder, "nope.yml")+": no such file or directory")
+}
+
+func TestIncludeURL(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/configs/base.yml":
+			w.Write([]byte("include: common.yml\nproject_name: base\n")) // nolint: errcheck
+		case "/configs/common.yml":
+			w.Write([]byte("dist: common\n")) // nolint: errcheck
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	var folder = writeConfigs(t, map[string]string{
+		"local.yml": "include: " + srv.URL + "/configs/base.yml\n",
+		"404.yml":   "include: " + srv.URL + "/nope.yml\n",
+	})
+	defer os.RemoveAll(folder) // nolint: errcheck
+	config, err := Load(filepath.Join(folder, "local.yml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "base", config.ProjectName)
+	assert.Equal(t, "common", config.Dist)
+	_, err = Load(filepath.Join(folder, "404.yml"))
+	assert.EqualError(t, err, "failed to include "+srv.URL+"/nope.yml: GET "+srv.URL+"/nope.yml: 404 Not Found")
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// UnknownField is a field of the config file which isn't in the config
+// model, most likely a typo or a field of a newer goreleaser version
+type UnknownField struct {
+	// Line of the field in the config file, 0 if unknown
+	Line int
+	// Path of the field, e.g. `builds[0].goos`
+	Path string
+	// Suggestion is the path of the known field with a similar name, if any
+	Suggestion string
+}
+
+func (f UnknownField) String() string {
+	var s = f.Path
+	if f.Line > 0 {
+		s = fmt.Sprintf("line %d: %s", f.Line, f.Path)
+	}
+	if f.Suggestion != "" {
+		s += ", did you mean " + f.Suggestion + "?"
+	}
+	return s
+}
+
+// ErrUnknownFields happens when the config file has unknown fields
+type ErrUnknownFields struct {
+	Fields []UnknownField
+}
+
+func (e ErrUnknownFields) Error() string {
+	var out bytes.Buffer
+	out.WriteString("unknown fields in the config file, fix them or use --lax-config to ignore them:")
+	for _, field := range e.Fields {
+		out.WriteString("\n  " + field.String())
+	}
+	return out.String()
+}
+
+// unknownFieldRe matches the errors of the strict yaml unmarshaling about
+// the unknown fields
+var unknownFieldRe = regexp.MustCompile(`^line (\d+): field (.+) not found in struct .+$`)
+
+// unknownFields returns the unknown fields of the config data, with the
+// lines the strict unmarshaling error tells, or nil if the error is about
+// anything else than unknown fields.
+func unknownFields(data []byte, err error) []UnknownField {
+	terr, ok := err.(*yaml.TypeError)
+	if !ok {
+		return nil
+	}
+	type found struct {
+		line int
+		name string
+	}
+	var lines []found
+	for _, msg := range terr.Errors {
+		var match = unknownFieldRe.FindStringSubmatch(msg)
+		if match == nil {
+			return nil
+		}
+		line, _ := strconv.Atoi(match[1])
+		lines = append(lines, found{line, match[2]})
+	}
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+	var fields = walk(reflect.TypeOf(Project{}), doc, "")
+	if len(fields) == 0 {
+		return nil
+	}
+	// both the strict unmarshaling and the walk go through the fields in
+	// the order of the file
+	for i := range fields {
+		for j, l := range lines {
+			if fields[i].Path == l.name || strings.HasSuffix(fields[i].Path, "."+l.name) {
+				fields[i].Line = l.line
+				lines = append(lines[:j], lines[j+1:]...)
+				break
+			}
+		}
+	}
+	return fields
+}
+
+// walk goes through the yaml value along the type it is unmarshaled to,
+// returning its keys which aren't fields of the structs.
+func walk(t reflect.Type, value interface{}, path string) []UnknownField {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var result []UnknownField
+	switch t.Kind() {
+	case reflect.Struct:
+		// the structs with an UnmarshalYAML method, which take either a
+		// scalar or the struct fields, are walked as well
+		items, ok := value.(yaml.MapSlice)
+		if !ok {
+			return nil
+		}
+		var known = yamlFields(t)
+		for _, item := range items {
+			var key = fmt.Sprint(item.Key)
+			var fieldPath = join(path, key)
+			field, ok := known[key]
+			if !ok {
+				result = append(result, UnknownField{
+					Path:       fieldPath,
+					Suggestion: suggest(known, key, path),
+				})
+				continue
+			}
+			result = append(result, walk(field.Type, item.Value, fieldPath)...)
+		}
+	case reflect.Map:
+		items, ok := value.(yaml.MapSlice)
+		if !ok {
+			return nil
+		}
+		for _, item := range items {
+			result = append(result, walk(t.Elem(), item.Value, join(path, fmt.Sprint(item.Key)))...)
+		}
+	case reflect.Slice:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range items {
+			result = append(result, walk(t.Elem(), item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return result
+}
+
+// yamlFields returns the fields of the struct by their yaml name
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	var fields = map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		var field = t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		var name = strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// suggest returns the path of the known field whose name only differs from
+// the key by its case and separators, e.g. `name_template` for
+// `nametemplate`
+func suggest(known map[string]reflect.StructField, key, path string) string {
+	var normalize = strings.NewReplacer("_", "", "-", "", " ", "")
+	var want = normalize.Replace(strings.ToLower(key))
+	for name := range known {
+		if normalize.Replace(name) == want {
+			return join(path, name)
+		}
+	}
+	return ""
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnknownFields(t *testing.T) {
+	var conf = `
+project_name: foo
+archive:
+  nametemplate: "{{ .ProjectName }}"
+  replacements:
+    amd64: 64bit
+builds:
+  - goos: [linux]
+  - binary: foo
+    hooks:
+      pre: make
+      post_build: make clean
+brew:
+  dependencies:
+    - git
+    - name: go
+      tipe: optional
+nope: true
+`
+	_, err := LoadReader(strings.NewReader(conf))
+	assert.EqualError(t, err, `unknown fields in the config file, fix them or use --lax-config to ignore them:
+  line 4: archive.nametemplate, did you mean archive.name_template?
+  line 12: builds[1].hooks.post_build
+  line 17: brew.dependencies[1].tipe
+  line 18: nope`)
+	assert.Equal(t, []UnknownField{
+		{Line: 4, Path: "archive.nametemplate", Suggestion: "archive.name_template"},
+		{Line: 12, Path: "builds[1].hooks.post_build"},
+		{Line: 17, Path: "brew.dependencies[1].tipe"},
+		{Line: 18, Path: "nope"},
+	}, err.(ErrUnknownFields).Fields)
+}
+
+func TestUnknownFieldsLax(t *testing.T) {
+	var conf = `
+project_name: foo
+archive:
+  nametemplate: "{{ .ProjectName }}"
+  format: zip
+`
+	config, err := LoadReaderLax(strings.NewReader(conf))
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", config.ProjectName)
+	assert.Equal(t, "zip", config.Archive.Format)
+	assert.Empty(t, config.Archive.NameTemplate)
+}
+
+func TestUnknownFieldsOtherErrors(t *testing.T) {
+	// errors about anything else than unknown fields are left as they are
+	var conf = `
+builds:
+  - nope: true
+    goos: linux
+`
+	_, err := LoadReaderLax(strings.NewReader(conf))
+	assert.EqualError(t, err, "yaml: unmarshal errors:\n  line 3: field nope not found in struct config.Build\n  line 4: cannot unmarshal !!str `linux` into []string")
+}
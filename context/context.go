@@ -18,8 +18,20 @@ import (
 
 // GitInfo includes tags and diffs used in some point
 type GitInfo struct {
-	CurrentTag string
-	Commit     string
+	CurrentTag  string
+	PreviousTag string
+	Commit      string
+	CommitDate  time.Time
+	URL         string
+}
+
+// Semver has the components of the version, which are all empty when the
+// version isn't semver, e.g. with some tag regexes or snapshot names
+type Semver struct {
+	Major      string
+	Minor      string
+	Patch      string
+	Prerelease string
 }
 
 // Context carries along some data through the pipes
@@ -28,16 +40,23 @@ type Context struct {
 	Config       config.Project
 	Env          map[string]string
 	Token        string
+	GitLabToken  string
 	Git          GitInfo
 	Artifacts    artifact.Artifacts
 	ReleaseNotes string
 	Version      string
+	Semver       Semver
+	Date         time.Time
 	Validate     bool
 	Publish      bool
 	Snapshot     bool
 	RmDist       bool
 	Debug        bool
 	Parallelism  int
+
+	// SkipPublish is the reason publishing is disabled, when it isn't
+	// --skip-publish
+	SkipPublish string
 }
 
 // New context
@@ -57,11 +76,18 @@ func Wrap(ctx ctx.Context, config config.Project) *Context {
 		Context:     ctx,
 		Config:      config,
 		Env:         splitEnv(os.Environ()),
+		Date:        time.Now(),
 		Parallelism: 4,
 		Artifacts:   artifact.New(),
 	}
 }
 
+// IsPrerelease tells whether the version being released is a semver
+// prerelease
+func (ctx *Context) IsPrerelease() bool {
+	return ctx.Semver.Prerelease != ""
+}
+
 func splitEnv(env []string) map[string]string {
 	r := map[string]string{}
 	for _, e := range env {
@@ -22,3 +22,10 @@ func TestNewWithTimeout(t *testing.T) {
 	<-ctx.Done()
 	assert.EqualError(t, ctx.Err(), `context canceled`)
 }
+
+func TestIsPrerelease(t *testing.T) {
+	var ctx = New(config.Project{})
+	assert.False(t, ctx.IsPrerelease())
+	ctx.Semver = Semver{Major: "1", Minor: "2", Patch: "0", Prerelease: "rc1"}
+	assert.True(t, ctx.IsPrerelease())
+}
@@ -29,7 +29,10 @@ import (
 	"github.com/goreleaser/goreleaser/pipeline/env"
 	"github.com/goreleaser/goreleaser/pipeline/fpm"
 	"github.com/goreleaser/goreleaser/pipeline/git"
+	"github.com/goreleaser/goreleaser/pipeline/hooks"
+	"github.com/goreleaser/goreleaser/pipeline/metadata"
 	"github.com/goreleaser/goreleaser/pipeline/nfpm"
+	"github.com/goreleaser/goreleaser/pipeline/publishif"
 	"github.com/goreleaser/goreleaser/pipeline/release"
 	"github.com/goreleaser/goreleaser/pipeline/scoop"
 	"github.com/goreleaser/goreleaser/pipeline/sign"
@@ -45,25 +48,39 @@ func init() {
 	log.SetHandler(cli.Default)
 }
 
+// pipes of the build phase, which builds and packages the artifacts locally,
+// they always run
 var pipes = []pipeline.Piper{
 	defaults.Pipe{},        // load default configs
 	dist.Pipe{},            // ensure ./dist is clean
 	git.Pipe{},             // get and validate git repo state
+	publishif.Pipe{},       // disable publishing if the publish_if condition is false
 	effectiveconfig.Pipe{}, // writes the actual config (with defaults et al set) to dist
-	changelog.Pipe{},       // builds the release changelog
 	env.Pipe{},             // load and validate environment variables
+	hooks.BeforePipe{},     // run the global before hooks
+	changelog.Pipe{},       // builds the release changelog
 	build.Pipe{},           // build
 	archive.Pipe{},         // archive in tar.gz, zip or binary (which does no archiving at all)
 	fpm.Pipe{},             // archive via fpm (deb, rpm) using fpm
 	nfpm.Pipe{},            // archive via fpm (deb, rpm) using "native" go impl
 	snapcraft.Pipe{},       // archive via snapcraft (snap)
-	checksums.Pipe{},       // checksums of the files
 	sign.Pipe{},            // sign artifacts
-	docker.Pipe{},          // create and push docker images
-	artifactory.Pipe{},     // push to artifactory
-	release.Pipe{},         // release to github
-	brew.Pipe{},            // push to brew tap
-	scoop.Pipe{},           // push to scoop bucket
+	checksums.Pipe{},       // checksums of the files and their signatures
+	sign.ChecksumPipe{},    // sign checksums
+	docker.Pipe{},          // build and tag docker images
+}
+
+// publishPipes of the publish phase, which publishes the artifacts, they are
+// skipped with --skip-publish, on snapshots and when publish_if is false
+var publishPipes = []pipeline.Piper{
+	docker.PublishPipe{},    // push docker images
+	docker.ManifestPipe{},   // create and push docker manifest lists
+	sign.DockerPipe{},       // sign docker images
+	snapcraft.PublishPipe{}, // push snaps to the snap store
+	artifactory.Pipe{},      // push to artifactory
+	release.Pipe{},          // release to github
+	brew.Pipe{},             // push to brew tap
+	scoop.Pipe{},            // push to scoop bucket
 }
 
 // Flags interface represents an extractor of cli flags
@@ -77,20 +94,13 @@ type Flags interface {
 
 // Release runs the release process with the given flags
 func Release(flags Flags) error {
-	var file = getConfigFile(flags)
 	var notes = flags.String("release-notes")
 	if flags.Bool("debug") {
 		log.SetLevel(log.DebugLevel)
 	}
-	cfg, err := config.Load(file)
+	cfg, err := loadConfig(flags)
 	if err != nil {
-		// Allow file not found errors if config file was not
-		// explicitly specified
-		_, statErr := os.Stat(file)
-		if !os.IsNotExist(statErr) || flags.IsSet("config") {
-			return err
-		}
-		log.WithField("file", file).Warn("could not load config, using defaults")
+		return err
 	}
 	ctx, cancel := context.NewWithTimeout(cfg, flags.Duration("timeout"))
 	defer cancel()
@@ -99,6 +109,12 @@ func Release(flags Flags) error {
 	log.Debugf("parallelism: %v", ctx.Parallelism)
 	ctx.Validate = !flags.Bool("skip-validate")
 	ctx.Publish = !flags.Bool("skip-publish")
+	if flags.Bool("skip-changelog") {
+		ctx.Config.Changelog.Skip = true
+	}
+	if prev := flags.String("previous-tag"); prev != "" {
+		ctx.Config.Changelog.PreviousTag = prev
+	}
 	if notes != "" {
 		bts, err := ioutil.ReadFile(notes)
 		if err != nil {
@@ -119,17 +135,63 @@ func Release(flags Flags) error {
 
 func doRelease(ctx *context.Context) error {
 	defer restoreOutputPadding()
-	return ctrlc.Default.Run(ctx, func() error {
+	var err = ctrlc.Default.Run(ctx, func() error {
+		var distReady bool
 		for _, pipe := range pipes {
-			restoreOutputPadding()
-			log.Infof(color.New(color.Bold).Sprint(strings.ToUpper(pipe.String())))
-			cli.Default.Padding = increasedPadding
-			if err := handle(pipe.Run(ctx)); err != nil {
+			if err := runPipe(ctx, pipe); err != nil {
+				if distReady {
+					writeMetadata(ctx)
+				}
 				return err
 			}
+			if _, ok := pipe.(dist.Pipe); ok {
+				distReady = true
+			}
 		}
-		return nil
+		for _, pipe := range publishPipes {
+			if !ctx.Publish {
+				restoreOutputPadding()
+				log.WithField("reason", pipeline.SkipPublish(ctx).Error()).
+					Warnf("skipped %s", pipe.String())
+				continue
+			}
+			if err := runPipe(ctx, pipe); err != nil {
+				writeMetadata(ctx)
+				return err
+			}
+		}
+		// writes the artifacts and release metadata to dist
+		return runPipe(ctx, metadata.Pipe{})
 	})
+	return runAfterHooks(ctx, err)
+}
+
+func runPipe(ctx *context.Context, pipe pipeline.Piper) error {
+	restoreOutputPadding()
+	log.Infof(color.New(color.Bold).Sprint(strings.ToUpper(pipe.String())))
+	cli.Default.Padding = increasedPadding
+	return handle(pipe.Run(ctx))
+}
+
+// runAfterHooks runs the after hooks with the error of the release, which
+// their own failure doesn't mask
+func runAfterHooks(ctx *context.Context, err error) error {
+	var hookErr = runPipe(ctx, hooks.AfterPipe{Err: err})
+	if err != nil {
+		if hookErr != nil {
+			log.WithError(hookErr).Error("after hook failed")
+		}
+		return err
+	}
+	return hookErr
+}
+
+// writeMetadata writes the metadata of the artifacts produced before a pipe
+// failed, to help debugging it, only logging its own failure
+func writeMetadata(ctx *context.Context) {
+	if err := runPipe(ctx, metadata.Pipe{}); err != nil {
+		log.WithError(err).Error("failed to write the artifacts metadata")
+	}
 }
 
 func restoreOutputPadding() {
@@ -172,21 +234,66 @@ func InitProject(filename string) error {
 	return ioutil.WriteFile(filename, out, 0644)
 }
 
-func getConfigFile(flags Flags) string {
+// loadConfig loads the config file of the flags, or the one found in the
+// current directory, or the config given in the standard input with
+// `--config -`. Without a config file, the defaults are used.
+func loadConfig(flags Flags) (config.Project, error) {
+	var loadReader, load = config.LoadReader, config.Load
+	if flags.Bool("lax-config") {
+		loadReader, load = config.LoadReaderLax, config.LoadLax
+	}
+	file, err := getConfigFile(flags)
+	if err != nil {
+		return config.Project{}, err
+	}
+	if file == "-" {
+		log.Info("loading config from the standard input")
+		return loadReader(os.Stdin)
+	}
+	cfg, err := load(file)
+	if err != nil {
+		// Allow file not found errors if config file was not
+		// explicitly specified
+		_, statErr := os.Stat(file)
+		if !os.IsNotExist(statErr) || flags.IsSet("config") {
+			return cfg, err
+		}
+		log.WithField("file", file).Warn("could not load config, using defaults")
+	}
+	return cfg, nil
+}
+
+// configFiles are the names the config file is looked for with, in order
+var configFiles = []string{
+	".goreleaser.yml",
+	".goreleaser.yaml",
+	"goreleaser.yml",
+	"goreleaser.yaml",
+}
+
+// getConfigFile returns the config file of the flags, or the only one of
+// the config files in the current directory
+func getConfigFile(flags Flags) (string, error) {
 	var config = flags.String("config")
 	if flags.IsSet("config") {
-		return config
-	}
-	for _, f := range []string{
-		".goreleaser.yml",
-		".goreleaser.yaml",
-		"goreleaser.yml",
-		"goreleaser.yaml",
-	} {
+		return config, nil
+	}
+	var found []string
+	for _, f := range configFiles {
 		_, ferr := os.Stat(f)
 		if ferr == nil || os.IsExist(ferr) {
-			return f
+			found = append(found, f)
 		}
 	}
-	return config
+	switch len(found) {
+	case 0:
+		return config, nil
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf(
+			"found several config files: %s, remove all but one or choose one with --config",
+			strings.Join(found, ", "),
+		)
+	}
 }
@@ -1,6 +1,7 @@
 package goreleaserlib
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -9,7 +10,12 @@ import (
 	"time"
 
 	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/artifact"
 	"github.com/goreleaser/goreleaser/internal/testlib"
+	"github.com/goreleaser/goreleaser/pipeline"
+	"github.com/goreleaser/goreleaser/pipeline/defaults"
+	"github.com/goreleaser/goreleaser/pipeline/dist"
 	"github.com/stretchr/testify/assert"
 	yaml "gopkg.in/yaml.v2"
 )
@@ -55,11 +61,46 @@ func TestConfigFlagNotSetButExists(t *testing.T) {
 					filepath.Join(folder, name),
 				),
 			)
-			assert.Equal(t, name, getConfigFile(newFlags(t, testParams())))
+			file, err := getConfigFile(newFlags(t, testParams()))
+			assert.NoError(t, err)
+			assert.Equal(t, name, file)
 		})
 	}
 }
 
+func TestConfigFilesAmbiguous(t *testing.T) {
+	folder, back := setup(t)
+	defer back()
+	createFile(t, filepath.Join(folder, ".goreleaser.yaml"), "project_name: other")
+	_, err := getConfigFile(newFlags(t, testParams()))
+	assert.EqualError(t, err, "found several config files: .goreleaser.yaml, goreleaser.yml, remove all but one or choose one with --config")
+	assert.Error(t, Release(newFlags(t, testParams())))
+
+	var params = testParams()
+	params["config"] = ".goreleaser.yaml"
+	file, err := getConfigFile(newFlags(t, params))
+	assert.NoError(t, err)
+	assert.Equal(t, ".goreleaser.yaml", file)
+}
+
+func TestConfigFromStdin(t *testing.T) {
+	stdin, err := ioutil.TempFile("", "goreleaserstdin")
+	assert.NoError(t, err)
+	defer os.Remove(stdin.Name()) // nolint: errcheck
+	_, err = stdin.WriteString("project_name: fromstdin\n")
+	assert.NoError(t, err)
+	_, err = stdin.Seek(0, 0)
+	assert.NoError(t, err)
+	var original = os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = original }()
+	var params = testParams()
+	params["config"] = "-"
+	cfg, err := loadConfig(newFlags(t, params))
+	assert.NoError(t, err)
+	assert.Equal(t, "fromstdin", cfg.ProjectName)
+}
+
 func TestReleaseNotesFileDontExist(t *testing.T) {
 	params := testParams()
 	params["release-notes"] = "/this/also/wont/exist"
@@ -204,3 +245,111 @@ release:
 `
 	createFile(t, "goreleaser.yml", yaml)
 }
+
+type failingPipe struct{}
+
+func (failingPipe) String() string { return "failing" }
+
+func (failingPipe) Run(ctx *context.Context) error {
+	ctx.Artifacts.Add(artifact.Artifact{Name: "mybin", Path: "dist/mybin", Type: artifact.Binary})
+	return errors.New("failed")
+}
+
+func TestMetadataWrittenOnFailure(t *testing.T) {
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	var previous = pipes
+	defer func() { pipes = previous }()
+	var ctx = context.New(config.Project{Dist: filepath.Join(folder, "dist")})
+
+	pipes = []pipeline.Piper{failingPipe{}, dist.Pipe{}}
+	assert.EqualError(t, doRelease(ctx), "failed")
+	_, err := os.Stat(filepath.Join(folder, "dist", "artifacts.json"))
+	assert.True(t, os.IsNotExist(err), "the dist folder wasn't checked yet")
+
+	pipes = []pipeline.Piper{dist.Pipe{}, failingPipe{}}
+	assert.EqualError(t, doRelease(ctx), "failed")
+	bts, err := ioutil.ReadFile(filepath.Join(folder, "dist", "artifacts.json"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(bts), `"name": "mybin"`)
+	assert.FileExists(t, filepath.Join(folder, "dist", "metadata.json"))
+}
+
+func TestAfterHooks(t *testing.T) {
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	var previous = pipes
+	defer func() { pipes = previous }()
+	var after = config.After{
+		Hooks: []string{"touch {{ if .Success }}succeeded{{ else }}failed{{ end }}"},
+	}
+
+	pipes = []pipeline.Piper{}
+	var ctx = context.New(config.Project{After: after})
+	assert.NoError(t, doRelease(ctx))
+	assert.FileExists(t, filepath.Join(folder, "succeeded"))
+
+	// the hooks failing don't mask the release failure
+	pipes = []pipeline.Piper{failingPipe{}}
+	ctx = context.New(config.Project{After: config.After{
+		Hooks: append(after.Hooks, "false"),
+	}})
+	assert.EqualError(t, doRelease(ctx), "failed")
+	assert.FileExists(t, filepath.Join(folder, "failed"))
+
+	// but fail a successful release
+	pipes = []pipeline.Piper{}
+	ctx = context.New(config.Project{After: config.After{Hooks: []string{"false"}}})
+	assert.EqualError(t, doRelease(ctx), "hook 'false' failed: exit status 1")
+}
+
+type recordingPipe struct {
+	ran *[]string
+}
+
+func (recordingPipe) String() string { return "recording" }
+
+func (p recordingPipe) Run(ctx *context.Context) error {
+	*p.ran = append(*p.ran, ctx.Version)
+	return nil
+}
+
+func TestPublishPhase(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	var previous, previousPublish = pipes, publishPipes
+	defer func() { pipes, publishPipes = previous, previousPublish }()
+	var local, published []string
+	pipes = []pipeline.Piper{recordingPipe{&local}}
+	publishPipes = []pipeline.Piper{recordingPipe{&published}, recordingPipe{&published}}
+
+	var ctx = context.New(config.Project{})
+	ctx.Version = "1.0.0"
+	ctx.Publish = true
+	assert.NoError(t, doRelease(ctx))
+	assert.Equal(t, []string{"1.0.0"}, local)
+	assert.Equal(t, []string{"1.0.0", "1.0.0"}, published)
+
+	ctx = context.New(config.Project{})
+	ctx.Version = "1.0.1"
+	ctx.Publish = false
+	assert.NoError(t, doRelease(ctx))
+	assert.Equal(t, []string{"1.0.0", "1.0.1"}, local)
+	assert.Equal(t, []string{"1.0.0", "1.0.0"}, published, "publishing is skipped")
+}
+
+func TestMissingDockerfileFailsBeforeBuilds(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	var previous = pipes
+	defer func() { pipes = previous }()
+	var built []string
+	// the recording pipe stands for the builds, which come after the defaults
+	pipes = []pipeline.Piper{defaults.Pipe{}, recordingPipe{&built}}
+	var ctx = context.New(config.Project{
+		Release: config.Release{GitHub: config.Repo{Owner: "acme", Name: "mytool"}},
+		Dockers: []config.Docker{{Image: "acme/mytool", Dockerfile: "Dockerfile.nope"}},
+	})
+	assert.EqualError(t, doRelease(ctx), "docker acme/mytool: invalid dockerfile: stat Dockerfile.nope: no such file or directory")
+	assert.Empty(t, built)
+}
@@ -0,0 +1,108 @@
+package goreleaserlib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+
+	"github.com/goreleaser/goreleaser/checksum"
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/pipeline/sign"
+)
+
+// Verify checks the files of a downloaded release against its checksums
+// file, in either of the checksum formats, after verifying the signature of
+// the checksums file when one is given.
+func Verify(flags Flags) error {
+	var checksums = flags.String("checksums")
+	if checksums == "" {
+		return errors.New("the checksums file is not set, use --checksums")
+	}
+	var dir = flags.String("dir")
+	if dir == "" {
+		dir = "."
+	}
+	if flags.Bool("debug") {
+		log.SetLevel(log.DebugLevel)
+	}
+	if signature := flags.String("signature"); signature != "" {
+		var cmd = flags.String("signature-cmd")
+		if cmd == "" {
+			cmd = signatureCmd(signature)
+		}
+		var ctx = context.New(config.Project{})
+		if err := sign.VerifyFile(ctx, cmd, flags.String("public-key"), signature, checksums); err != nil {
+			return err
+		}
+		log.WithField("signature", signature).Info("OK")
+	}
+	file, err := os.Open(checksums)
+	if err != nil {
+		return err
+	}
+	defer file.Close() // nolint: errcheck
+	entries, err := checksum.Parse(file, flags.String("algorithm"))
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", checksums)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("%s has no checksums", checksums)
+	}
+	return verifyEntries(dir, entries, flags.Bool("ignore-missing"), checksums)
+}
+
+// verifyEntries checks the files of the dir against their checksums,
+// reporting each of them as OK, FAILED or MISSING.
+func verifyEntries(dir string, entries []checksum.Entry, ignoreMissing bool, checksums string) error {
+	var ok, failed, missing int
+	for _, entry := range entries {
+		var path = filepath.Join(dir, entry.Name)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			missing++
+			if !ignoreMissing {
+				log.WithField("file", entry.Name).Error("MISSING")
+			}
+			continue
+		}
+		sum, err := checksum.Calculate(entry.Algorithm, path)
+		if err != nil {
+			failed++
+			log.WithField("file", entry.Name).WithError(err).Error("FAILED")
+			continue
+		}
+		if sum != entry.Sum {
+			failed++
+			log.WithField("file", entry.Name).
+				WithField("expected", entry.Sum).
+				WithField("got", sum).
+				Error("FAILED")
+			continue
+		}
+		ok++
+		log.WithField("file", entry.Name).Info("OK")
+	}
+	if ok == 0 && failed == 0 {
+		// most likely the checksums file and the files weren't downloaded
+		// to the same directory
+		abs, _ := filepath.Abs(dir)
+		return fmt.Errorf("none of the files listed in %s are in %s", checksums, abs)
+	}
+	if failed > 0 || (missing > 0 && !ignoreMissing) {
+		return fmt.Errorf("verification failed: %d ok, %d failed, %d missing", ok, failed, missing)
+	}
+	return nil
+}
+
+// signatureCmd tells the command to verify the signature with from its
+// extension.
+func signatureCmd(signature string) string {
+	if strings.HasSuffix(signature, ".minisig") {
+		return "minisign"
+	}
+	return "gpg"
+}
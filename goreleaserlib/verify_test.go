@@ -0,0 +1,134 @@
+package goreleaserlib
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/goreleaser/goreleaser/checksum"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupVerify writes a release with a checksums file mixing both formats,
+// returning its directory.
+func setupVerify(t *testing.T) string {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var content string
+	for name, format := range map[string]string{
+		"mybin_linux_amd64.tar.gz": "gnu",
+		"mybin_darwin_amd64.zip":   "bsd",
+	} {
+		var path = filepath.Join(folder, name)
+		assert.NoError(t, ioutil.WriteFile(path, []byte(name), 0644))
+		sum, err := checksum.Calculate("sha256", path)
+		assert.NoError(t, err)
+		content += checksum.Line(format, "sha256", sum, name)
+	}
+	var checksums = filepath.Join(folder, "checksums.txt")
+	assert.NoError(t, ioutil.WriteFile(checksums, []byte(content), 0644))
+	return folder
+}
+
+func verifyParams(folder string) map[string]string {
+	return map[string]string{
+		"checksums": filepath.Join(folder, "checksums.txt"),
+		"dir":       folder,
+	}
+}
+
+func TestVerify(t *testing.T) {
+	var folder = setupVerify(t)
+	defer os.RemoveAll(folder)
+	assert.NoError(t, Verify(newFlags(t, verifyParams(folder))))
+}
+
+func TestVerifyFailed(t *testing.T) {
+	var folder = setupVerify(t)
+	defer os.RemoveAll(folder)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, "mybin_darwin_amd64.zip"), []byte("tampered"), 0644))
+	assert.EqualError(t, Verify(newFlags(t, verifyParams(folder))), "verification failed: 1 ok, 1 failed, 0 missing")
+}
+
+func TestVerifyMissing(t *testing.T) {
+	var folder = setupVerify(t)
+	defer os.RemoveAll(folder)
+	assert.NoError(t, os.Remove(filepath.Join(folder, "mybin_linux_amd64.tar.gz")))
+	var params = verifyParams(folder)
+	assert.EqualError(t, Verify(newFlags(t, params)), "verification failed: 1 ok, 0 failed, 1 missing")
+	params["ignore-missing"] = "true"
+	assert.NoError(t, Verify(newFlags(t, params)))
+}
+
+func TestVerifyWrongDir(t *testing.T) {
+	var folder = setupVerify(t)
+	defer os.RemoveAll(folder)
+	empty, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(empty)
+	var params = verifyParams(folder)
+	params["dir"] = empty
+	params["ignore-missing"] = "true"
+	assert.EqualError(
+		t,
+		Verify(newFlags(t, params)),
+		"none of the files listed in "+params["checksums"]+" are in "+empty,
+	)
+}
+
+func TestVerifyInvalidChecksums(t *testing.T) {
+	var folder = setupVerify(t)
+	defer os.RemoveAll(folder)
+	var params = verifyParams(folder)
+	assert.NoError(t, ioutil.WriteFile(params["checksums"], []byte("mybin.tar.gz: OK\n"), 0644))
+	assert.EqualError(
+		t,
+		Verify(newFlags(t, params)),
+		"failed to read "+params["checksums"]+`: line 1: not a checksum line: "mybin.tar.gz: OK"`,
+	)
+	assert.NoError(t, ioutil.WriteFile(params["checksums"], []byte("\n"), 0644))
+	assert.EqualError(t, Verify(newFlags(t, params)), params["checksums"]+" has no checksums")
+}
+
+func TestVerifyNoChecksums(t *testing.T) {
+	assert.EqualError(t, Verify(newFlags(t, map[string]string{})), "the checksums file is not set, use --checksums")
+}
+
+func TestVerifySignature(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg not present in $PATH")
+	}
+	var keyring = filepath.Join("..", "pipeline", "sign", "testdata", "gnupg")
+	assert.NoError(t, os.Chmod(keyring, 0700))
+	var folder = setupVerify(t)
+	defer os.RemoveAll(folder)
+	var params = verifyParams(folder)
+	params["signature"] = params["checksums"] + ".sig"
+	params["public-key"] = filepath.Join(folder, "key.asc")
+	/* #nosec */
+	out, err := exec.Command("gpg", "--homedir", keyring, "--batch", "--output", params["signature"], "--detach-sig", params["checksums"]).CombinedOutput()
+	assert.NoError(t, err, string(out))
+	/* #nosec */
+	key, err := exec.Command("gpg", "--homedir", keyring, "--armor", "--export", "nopass").Output()
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(params["public-key"], key, 0644))
+	assert.NoError(t, Verify(newFlags(t, params)))
+
+	f, err := os.OpenFile(params["checksums"], os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = f.WriteString("\n")
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+	err = Verify(newFlags(t, params))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "sign: signature "+params["signature"]+" doesn't verify with gpg")
+	}
+}
+
+func TestSignatureCmd(t *testing.T) {
+	assert.Equal(t, "minisign", signatureCmd("checksums.txt.minisig"))
+	assert.Equal(t, "gpg", signatureCmd("checksums.txt.sig"))
+	assert.Equal(t, "gpg", signatureCmd("checksums.txt.asc"))
+}
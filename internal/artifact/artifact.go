@@ -2,12 +2,14 @@
 package artifact
 
 import (
+	"regexp"
 	"sync"
 
 	"github.com/apex/log"
 )
 
 // Type defines the type of an artifact
+//
 //go:generate stringer -type=Type
 type Type int
 
@@ -26,6 +28,17 @@ const (
 	Checksum
 	// Signature is a signature file
 	Signature
+	// Changelog is the rendered changelog file
+	Changelog
+	// BrewTap is a homebrew formula
+	BrewTap
+	// ScoopManifest is a scoop.sh app manifest
+	ScoopManifest
+	// DockerManifest is a docker manifest list
+	DockerManifest
+	// DockerDigests is the file listing the digests of the pushed docker
+	// images
+	DockerDigests
 )
 
 // Artifact represents an artifact and its relevant info
@@ -36,7 +49,34 @@ type Artifact struct {
 	Goarch string
 	Goarm  string
 	Type   Type
-	Extra  map[string]string
+	// ID of the config which produced the artifact, e.g. the build id
+	ID string
+	// Extra has the data of the artifact other pipes need, by name, e.g. the
+	// binary name or the docker image digest, read with the Extra* methods
+	Extra map[string]interface{}
+	// Checksums of the artifact, by algorithm, once calculated
+	Checksums map[string]string
+}
+
+// ExtraString returns the extra value of the key, or an empty string if it
+// isn't set or isn't a string
+func (a Artifact) ExtraString(key string) string {
+	s, _ := a.Extra[key].(string)
+	return s
+}
+
+// ExtraBool returns the extra value of the key, or false if it isn't set or
+// isn't a bool
+func (a Artifact) ExtraBool(key string) bool {
+	b, _ := a.Extra[key].(bool)
+	return b
+}
+
+// ExtraStrings returns the extra value of the key, or nil if it isn't set or
+// isn't a list of strings
+func (a Artifact) ExtraStrings(key string) []string {
+	list, _ := a.Extra[key].([]string)
+	return list
 }
 
 // Artifacts is a list of artifacts
@@ -77,9 +117,55 @@ func (artifacts *Artifacts) Add(a Artifact) {
 		"path": a.Path,
 		"type": a.Type,
 	}).Info("added new artifact")
+	// the list has its own copy, so the caller may keep using its map
+	if a.Extra != nil {
+		var extra = make(map[string]interface{}, len(a.Extra))
+		for k, v := range a.Extra {
+			extra[k] = v
+		}
+		a.Extra = extra
+	}
 	artifacts.items = append(artifacts.items, a)
 }
 
+// SetExtra safely sets the extra value of the key on the artifacts of the
+// list with the name, path and type of the given one
+func (artifacts *Artifacts) SetExtra(a Artifact, key string, value interface{}) {
+	artifacts.lock.Lock()
+	defer artifacts.lock.Unlock()
+	for i, item := range artifacts.items {
+		if item.Name != a.Name || item.Path != a.Path || item.Type != a.Type {
+			continue
+		}
+		var extra = map[string]interface{}{}
+		for k, v := range item.Extra {
+			extra[k] = v
+		}
+		extra[key] = value
+		artifacts.items[i].Extra = extra
+	}
+}
+
+// SetChecksums safely records the checksums, by algorithm, of the artifacts
+// of the list with the name, path and type of the given one
+func (artifacts *Artifacts) SetChecksums(a Artifact, sums map[string]string) {
+	artifacts.lock.Lock()
+	defer artifacts.lock.Unlock()
+	for i, item := range artifacts.items {
+		if item.Name != a.Name || item.Path != a.Path || item.Type != a.Type {
+			continue
+		}
+		var checksums = map[string]string{}
+		for algorithm, sum := range item.Checksums {
+			checksums[algorithm] = sum
+		}
+		for algorithm, sum := range sums {
+			checksums[algorithm] = sum
+		}
+		artifacts.items[i].Checksums = checksums
+	}
+}
+
 // Filter defines an artifact filter which can be used within the Filter
 // function
 type Filter func(a Artifact) bool
@@ -105,6 +191,26 @@ func ByGoarm(s string) Filter {
 	}
 }
 
+// ByIDs is a predefined filter that filters by the given ids
+func ByIDs(ids ...string) Filter {
+	return func(a Artifact) bool {
+		for _, id := range ids {
+			if a.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ByNameRegexp is a predefined filter that filters by the names matching
+// the given regexp
+func ByNameRegexp(re *regexp.Regexp) Filter {
+	return func(a Artifact) bool {
+		return re.MatchString(a.Name)
+	}
+}
+
 // ByType is a predefined filter that filters by the given type
 func ByType(t Type) Filter {
 	return func(a Artifact) bool {
@@ -149,3 +255,15 @@ func (artifacts *Artifacts) Filter(filter Filter) Artifacts {
 	}
 	return result
 }
+
+// Count returns the number of artifacts the filter matches, so a filter
+// matching nothing can be told apart and reported
+func (artifacts *Artifacts) Count(filter Filter) int {
+	var count int
+	for _, a := range artifacts.items {
+		if filter(a) {
+			count++
+		}
+	}
+	return count
+}
@@ -2,6 +2,7 @@ package artifact
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -135,3 +136,91 @@ func TestGroupByPlatform(t *testing.T) {
 	assert.Len(t, groups["linuxamd64"], 2)
 	assert.Len(t, groups["linuxarm6"], 1)
 }
+
+func TestSetChecksums(t *testing.T) {
+	var artifacts = New()
+	var archive = Artifact{Name: "foo.tar.gz", Path: "dist/foo.tar.gz", Type: UploadableArchive}
+	artifacts.Add(archive)
+	artifacts.Add(Artifact{Name: "foo.tar.gz", Path: "dist/foo.tar.gz", Type: Signature})
+	artifacts.SetChecksums(archive, map[string]string{"sha256": "abc"})
+	artifacts.SetChecksums(archive, map[string]string{"md5": "def"})
+	assert.Equal(t, map[string]string{"sha256": "abc", "md5": "def"}, artifacts.List()[0].Checksums)
+	assert.Nil(t, artifacts.List()[1].Checksums)
+	// the artifacts given before aren't changed
+	assert.Nil(t, archive.Checksums)
+}
+
+func TestExtra(t *testing.T) {
+	var a = Artifact{
+		Extra: map[string]interface{}{
+			"Binary": "foo",
+			"Pushed": true,
+			"Files":  []string{"README.md", "foo"},
+		},
+	}
+	assert.Equal(t, "foo", a.ExtraString("Binary"))
+	assert.True(t, a.ExtraBool("Pushed"))
+	assert.Equal(t, []string{"README.md", "foo"}, a.ExtraStrings("Files"))
+	// unset or of another type
+	assert.Empty(t, a.ExtraString("Nope"))
+	assert.Empty(t, a.ExtraString("Pushed"))
+	assert.False(t, a.ExtraBool("Binary"))
+	assert.Nil(t, a.ExtraStrings("Binary"))
+	assert.Empty(t, Artifact{}.ExtraString("Binary"))
+}
+
+func TestSetExtra(t *testing.T) {
+	var artifacts = New()
+	var extra = map[string]interface{}{"ID": "cli"}
+	var image = Artifact{Name: "foo:v1", Path: "foo:v1", Type: DockerImage, Extra: extra}
+	artifacts.Add(image)
+	artifacts.Add(Artifact{Name: "foo:v1", Path: "foo:v1", Type: DockerManifest})
+	// the list has its own copy of the map
+	extra["ID"] = "other"
+	artifacts.SetExtra(image, "Digest", "sha256:abc")
+	assert.Equal(t, map[string]interface{}{"ID": "cli", "Digest": "sha256:abc"}, artifacts.List()[0].Extra)
+	assert.Nil(t, artifacts.List()[1].Extra)
+	assert.Equal(t, map[string]interface{}{"ID": "other"}, image.Extra)
+
+	var g errgroup.Group
+	for i := 0; i < 10; i++ {
+		i := i
+		g.Go(func() error {
+			artifacts.SetExtra(image, fmt.Sprintf("Key%d", i), i)
+			return nil
+		})
+	}
+	assert.NoError(t, g.Wait())
+	assert.Len(t, artifacts.List()[0].Extra, 12)
+}
+
+func TestFilterByIDsAndName(t *testing.T) {
+	var artifacts = New()
+	for _, a := range []Artifact{
+		{Name: "cli", Goos: "linux", Goarch: "amd64", Type: Binary, ID: "cli"},
+		{Name: "cli", Goos: "darwin", Goarch: "amd64", Type: Binary, ID: "cli"},
+		{Name: "daemon", Goos: "linux", Goarch: "amd64", Type: Binary, ID: "daemon"},
+		{Name: "cli_linux_amd64.tar.gz", Goos: "linux", Goarch: "amd64", Type: UploadableArchive},
+		{Name: "cli_darwin_amd64.tar.gz", Goos: "darwin", Goarch: "amd64", Type: UploadableArchive},
+	} {
+		artifacts.Add(a)
+	}
+	assert.Len(t, artifacts.Filter(ByIDs("cli")).List(), 2)
+	assert.Len(t, artifacts.Filter(ByIDs("cli", "daemon")).List(), 3)
+	assert.Len(t, artifacts.Filter(And(ByIDs("cli", "daemon"), ByGoos("linux"))).List(), 2)
+	assert.Len(t, artifacts.Filter(ByNameRegexp(regexp.MustCompile(`\.tar\.gz$`))).List(), 2)
+	assert.Len(t, artifacts.Filter(And(
+		ByType(UploadableArchive),
+		ByNameRegexp(regexp.MustCompile(`^cli_linux`)),
+	)).List(), 1)
+
+	var filtered = artifacts.Filter(ByIDs("cli", "daemon"))
+	var groups = filtered.GroupByPlatform()
+	assert.Len(t, groups["linuxamd64"], 2)
+	assert.Len(t, groups["darwinamd64"], 1)
+
+	assert.Equal(t, 3, artifacts.Count(ByType(Binary)))
+	assert.Equal(t, 1, artifacts.Count(ByIDs("daemon")))
+	assert.Equal(t, 0, artifacts.Count(ByIDs("nope")))
+	assert.Equal(t, 0, artifacts.Count(And(ByIDs("daemon"), ByGoos("darwin"))))
+}
@@ -82,7 +82,8 @@ func (*Builder) Build(ctx *context.Context, build config.Build, options api.Opti
 		Goos:   target.os,
 		Goarch: target.arch,
 		Goarm:  target.arm,
-		Extra: map[string]string{
+		ID:     build.ID,
+		Extra: map[string]interface{}{
 			"Binary": build.Binary,
 			"Ext":    options.Ext,
 		},
@@ -92,17 +93,33 @@ func (*Builder) Build(ctx *context.Context, build config.Build, options api.Opti
 
 func ldflags(ctx *context.Context, build config.Build) (string, error) {
 	var data = struct {
-		Commit  string
-		Tag     string
-		Version string
-		Date    string
-		Env     map[string]string
+		Commit          string
+		Tag             string
+		Version         string
+		Date            string
+		CommitDate      string
+		CommitTimestamp int64
+		Major           string
+		Minor           string
+		Patch           string
+		Prerelease      string
+		IsSnapshot      bool
+		IsPrerelease    bool
+		Env             map[string]string
 	}{
-		Commit:  ctx.Git.Commit,
-		Tag:     ctx.Git.CurrentTag,
-		Version: ctx.Version,
-		Date:    time.Now().UTC().Format(time.RFC3339),
-		Env:     ctx.Env,
+		Commit:          ctx.Git.Commit,
+		Tag:             ctx.Git.CurrentTag,
+		Version:         ctx.Version,
+		Major:           ctx.Semver.Major,
+		Minor:           ctx.Semver.Minor,
+		Patch:           ctx.Semver.Patch,
+		Prerelease:      ctx.Semver.Prerelease,
+		IsSnapshot:      ctx.Snapshot,
+		IsPrerelease:    ctx.Semver.Prerelease != "",
+		Date:            ctx.Date.UTC().Format(time.RFC3339),
+		CommitDate:      ctx.Git.CommitDate.UTC().Format(time.RFC3339),
+		CommitTimestamp: ctx.Git.CommitDate.Unix(),
+		Env:             ctx.Env,
 	}
 	var out bytes.Buffer
 	t, err := template.New("ldflags").
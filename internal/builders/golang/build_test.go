@@ -6,6 +6,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	api "github.com/goreleaser/goreleaser/build"
 	"github.com/goreleaser/goreleaser/config"
@@ -77,6 +78,7 @@ func TestBuild(t *testing.T) {
 	var config = config.Project{
 		Builds: []config.Build{
 			{
+				ID:     "foo-id",
 				Binary: "foo",
 				Targets: []string{
 					"linux_amd64",
@@ -109,7 +111,8 @@ func TestBuild(t *testing.T) {
 			Goos:   "linux",
 			Goarch: "amd64",
 			Type:   artifact.Binary,
-			Extra: map[string]string{
+			ID:     "foo-id",
+			Extra: map[string]interface{}{
 				"Ext":    "",
 				"Binary": "foo",
 			},
@@ -120,7 +123,8 @@ func TestBuild(t *testing.T) {
 			Goos:   "darwin",
 			Goarch: "amd64",
 			Type:   artifact.Binary,
-			Extra: map[string]string{
+			ID:     "foo-id",
+			Extra: map[string]interface{}{
 				"Ext":    "",
 				"Binary": "foo",
 			},
@@ -132,7 +136,8 @@ func TestBuild(t *testing.T) {
 			Goarch: "arm",
 			Goarm:  "6",
 			Type:   artifact.Binary,
-			Extra: map[string]string{
+			ID:     "foo-id",
+			Extra: map[string]interface{}{
 				"Ext":    "",
 				"Binary": "foo",
 			},
@@ -143,7 +148,8 @@ func TestBuild(t *testing.T) {
 			Goos:   "windows",
 			Goarch: "amd64",
 			Type:   artifact.Binary,
-			Extra: map[string]string{
+			ID:     "foo-id",
+			Extra: map[string]interface{}{
 				"Ext":    ".exe",
 				"Binary": "foo",
 			},
@@ -306,7 +312,7 @@ func TestLdFlagsFullTemplate(t *testing.T) {
 	var config = config.Project{
 		Builds: []config.Build{
 			{
-				Ldflags: `-s -w -X main.version={{.Version}} -X main.tag={{.Tag}} -X main.date={{.Date}} -X main.commit={{.Commit}} -X "main.foo={{.Env.FOO}}"`,
+				Ldflags: `-s -w -X main.version={{.Version}} -X main.tag={{.Tag}} -X main.date={{.Date}} -X main.commit={{.Commit}} -X "main.foo={{.Env.FOO}}" -X main.commitDate={{.CommitDate}} -X main.commitTimestamp={{.CommitTimestamp}}`,
 			},
 		},
 	}
@@ -314,8 +320,10 @@ func TestLdFlagsFullTemplate(t *testing.T) {
 		Git: context.GitInfo{
 			CurrentTag: "v1.2.3",
 			Commit:     "123",
+			CommitDate: time.Date(2018, 4, 30, 10, 0, 0, 0, time.UTC),
 		},
 		Version: "1.2.3",
+		Date:    time.Date(2018, 5, 1, 12, 30, 0, 0, time.UTC),
 		Config:  config,
 		Env:     map[string]string{"FOO": "123"},
 	}
@@ -325,8 +333,10 @@ func TestLdFlagsFullTemplate(t *testing.T) {
 	assert.Contains(t, flags, "-X main.version=1.2.3")
 	assert.Contains(t, flags, "-X main.tag=v1.2.3")
 	assert.Contains(t, flags, "-X main.commit=123")
-	assert.Contains(t, flags, "-X main.date=")
+	assert.Contains(t, flags, "-X main.date=2018-05-01T12:30:00Z")
 	assert.Contains(t, flags, `-X "main.foo=123"`)
+	assert.Contains(t, flags, "-X main.commitDate=2018-04-30T10:00:00Z")
+	assert.Contains(t, flags, "-X main.commitTimestamp=1525082400")
 }
 
 func TestInvalidTemplate(t *testing.T) {
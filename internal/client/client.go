@@ -4,6 +4,7 @@ package client
 import (
 	"bytes"
 	"os"
+	"time"
 
 	"github.com/goreleaser/goreleaser/config"
 	"github.com/goreleaser/goreleaser/context"
@@ -16,9 +17,22 @@ type Info struct {
 	URL         string
 }
 
+// PullRequest is a merged pull request
+type PullRequest struct {
+	Number      int
+	Title       string
+	Author      string
+	URL         string
+	MergeCommit string
+}
+
 // Client interface
 type Client interface {
 	CreateRelease(ctx *context.Context, body string) (releaseID int64, err error)
-	CreateFile(ctx *context.Context, commitAuthor config.CommitAuthor, repo config.Repo, content bytes.Buffer, path string) (err error)
+	CreateFile(ctx *context.Context, commitAuthor config.CommitAuthor, repo config.Repo, content bytes.Buffer, path, message string) (err error)
 	Upload(ctx *context.Context, releaseID int64, name string, file *os.File) (err error)
+	MergedPullRequests(ctx *context.Context, repo config.Repo, since time.Time) (prs []PullRequest, err error)
+	// GetFile returns the contents of the given file, or no contents at all
+	// if the file does not exist in the repository.
+	GetFile(ctx *context.Context, repo config.Repo, path string) (content []byte, err error)
 }
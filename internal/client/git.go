@@ -0,0 +1,162 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/git"
+)
+
+// gitClient reads and writes files in a clone of a repository instead of
+// using the contents API, for repositories hosted on plain git servers.
+// Everything else is done by the wrapped client.
+type gitClient struct {
+	Client
+	cfg   config.GitRepo
+	token string
+	dir   string
+}
+
+// NewGit returns a client that reads and writes files in a clone of the
+// given repository, made in dir, and delegates everything else to client
+func NewGit(ctx *context.Context, client Client, cfg config.GitRepo, dir string) Client {
+	return &gitClient{
+		Client: client,
+		cfg:    cfg,
+		token:  ctx.Token,
+		dir:    dir,
+	}
+}
+
+// GetFile returns the file from the clone
+func (t *gitClient) GetFile(ctx *context.Context, repo config.Repo, path string) ([]byte, error) {
+	if err := t.clone(); err != nil {
+		return nil, err
+	}
+	bts, err := ioutil.ReadFile(filepath.Join(t.dir, filepath.FromSlash(path)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return bts, err
+}
+
+// CreateFile commits the file to the clone, and pushes it
+func (t *gitClient) CreateFile(
+	ctx *context.Context,
+	commitAuthor config.CommitAuthor,
+	repo config.Repo,
+	content bytes.Buffer,
+	path,
+	message string,
+) error {
+	if err := t.clone(); err != nil {
+		return err
+	}
+	var file = filepath.Join(t.dir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(file, content.Bytes(), 0644); err != nil {
+		return err
+	}
+	if err := t.git("add", "--", path); err != nil {
+		return fmt.Errorf("failed to add %s: %s", path, err)
+	}
+	out, err := git.Run("-C", t.dir, "status", "--porcelain")
+	if err != nil {
+		return fmt.Errorf("failed to check the status of %s: %s", t.cfg.URL, t.redact(err.Error()))
+	}
+	if strings.TrimSpace(out) == "" {
+		log.WithField("file", path).Info("file did not change, nothing to push")
+		return nil
+	}
+	if err := t.git(
+		"-c", "user.name="+commitAuthor.Name,
+		"-c", "user.email="+commitAuthor.Email,
+		"-c", "commit.gpgSign=false",
+		"commit", "-m", message,
+	); err != nil {
+		return fmt.Errorf("failed to commit %s: %s", path, err)
+	}
+	var ref = "HEAD"
+	if t.cfg.Branch != "" {
+		ref = "HEAD:" + t.cfg.Branch
+	}
+	if err := t.git("push", "origin", ref); err != nil {
+		return fmt.Errorf("failed to push to %s: %s", t.cfg.URL, err)
+	}
+	return nil
+}
+
+func (t *gitClient) clone() error {
+	if _, err := os.Stat(t.dir); err == nil {
+		return nil
+	}
+	var args = []string{"clone"}
+	if t.cfg.Shallow {
+		args = append(args, "--depth", "1")
+	}
+	if t.cfg.Branch != "" {
+		args = append(args, "--branch", t.cfg.Branch)
+	}
+	log.WithField("repo", t.cfg.URL).Info("cloning")
+	args = append(t.credentials(), append(args, t.cfg.URL, t.dir)...)
+	if _, err := git.RunEnv(t.env(), args...); err != nil {
+		return fmt.Errorf("failed to clone %s: %s", t.cfg.URL, t.redact(err.Error()))
+	}
+	return nil
+}
+
+// tokenEnv is the environment variable giving the token to the credential
+// helper, so it's never in the git arguments nor in the clone config
+const tokenEnv = "GORELEASER_GIT_TOKEN"
+
+// credentials returns the git options answering the https credentials with
+// the token, only for the command they are given to: the other credential
+// helpers are reset, so the token isn't stored by them either.
+func (t *gitClient) credentials() []string {
+	if !t.useToken() {
+		return nil
+	}
+	return []string{
+		"-c", "credential.helper=",
+		"-c", `credential.helper=!f() { echo username=oauth2; echo "password=$` + tokenEnv + `"; }; f`,
+	}
+}
+
+func (t *gitClient) env() []string {
+	if !t.useToken() {
+		return nil
+	}
+	return []string{tokenEnv + "=" + t.token, "GIT_TERMINAL_PROMPT=0"}
+}
+
+// useToken tells whether the token authenticates the git commands, which is
+// for https urls only
+func (t *gitClient) useToken() bool {
+	return t.token != "" && strings.HasPrefix(t.cfg.URL, "https://")
+}
+
+func (t *gitClient) git(args ...string) error {
+	args = append([]string{"-C", t.dir}, append(t.credentials(), args...)...)
+	if _, err := git.RunEnv(t.env(), args...); err != nil {
+		return fmt.Errorf("%s", t.redact(err.Error()))
+	}
+	return nil
+}
+
+// redact removes the token from the git output
+func (t *gitClient) redact(s string) string {
+	if t.token == "" {
+		return s
+	}
+	return strings.Replace(s, t.token, "***", -1)
+}
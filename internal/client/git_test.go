@@ -0,0 +1,147 @@
+package client
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/git"
+	"github.com/stretchr/testify/assert"
+)
+
+// bareRepo creates a bare repository with one commit on the given branch,
+// returning its file:// url.
+func bareRepo(t *testing.T, branch string) string {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var bare = filepath.Join(folder, "tap.git")
+	var work = filepath.Join(folder, "work")
+	for _, args := range [][]string{
+		{"init", "--bare", bare},
+		{"init", work},
+		{"-C", work, "checkout", "-b", branch},
+		{
+			"-C", work,
+			"-c", "user.name=test", "-c", "user.email=test@example.com",
+			"-c", "commit.gpgSign=false",
+			"commit", "--allow-empty", "-m", "init",
+		},
+		{"-C", work, "push", bare, branch},
+	} {
+		_, err := git.Run(args...)
+		assert.NoError(t, err, args)
+	}
+	return "file://" + bare
+}
+
+func TestGit(t *testing.T) {
+	var url = bareRepo(t, "main")
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{})
+	var tap = NewGit(ctx, nil, config.GitRepo{
+		URL:     url,
+		Branch:  "main",
+		Shallow: true,
+	}, filepath.Join(folder, "homebrew-tap"))
+
+	bts, err := tap.GetFile(ctx, config.Repo{}, "Formula/foo.rb")
+	assert.NoError(t, err)
+	assert.Empty(t, bts)
+
+	var author = config.CommitAuthor{Name: "bot", Email: "bot@example.com"}
+	var content bytes.Buffer
+	content.WriteString("class Foo < Formula\nend\n")
+	assert.NoError(t, tap.CreateFile(ctx, author, config.Repo{}, content, "Formula/foo.rb", "foo 1.0.0"))
+
+	var bare = url[len("file://"):]
+	out, err := git.Run("--git-dir", bare, "show", "main:Formula/foo.rb")
+	assert.NoError(t, err)
+	assert.Equal(t, "class Foo < Formula\nend\n", out)
+	out, err = git.Run("--git-dir", bare, "log", "-1", "--format=%an <%ae> %s", "main")
+	assert.NoError(t, err)
+	assert.Equal(t, "bot <bot@example.com> foo 1.0.0\n", out)
+
+	bts, err = tap.GetFile(ctx, config.Repo{}, "Formula/foo.rb")
+	assert.NoError(t, err)
+	assert.Equal(t, "class Foo < Formula\nend\n", string(bts))
+
+	// pushing the same formula again is a no-op
+	content.WriteString("class Foo < Formula\nend\n")
+	assert.NoError(t, tap.CreateFile(ctx, author, config.Repo{}, content, "Formula/foo.rb", "foo 1.0.0"))
+}
+
+func TestGitCloneFails(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{})
+	var tap = NewGit(ctx, nil, config.GitRepo{
+		URL: "file://" + filepath.Join(folder, "nope.git"),
+	}, filepath.Join(folder, "homebrew-tap"))
+	var content bytes.Buffer
+	err = tap.CreateFile(ctx, config.CommitAuthor{}, config.Repo{}, content, "foo.rb", "foo")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to clone")
+	assert.Contains(t, err.Error(), "nope.git")
+	_, err = os.Stat(filepath.Join(folder, "homebrew-tap"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestGitCredentials(t *testing.T) {
+	var tap = &gitClient{
+		cfg:   config.GitRepo{URL: "https://git.example.com/tools/homebrew-tap.git"},
+		token: "secret",
+	}
+	for _, arg := range tap.credentials() {
+		assert.NotContains(t, arg, "secret")
+	}
+	/* #nosec */
+	var cmd = exec.Command("git", append(tap.credentials(), "credential", "fill")...)
+	cmd.Env = append(os.Environ(), tap.env()...)
+	cmd.Stdin = strings.NewReader("protocol=https\nhost=git.example.com\n\n")
+	out, err := cmd.CombinedOutput()
+	assert.NoError(t, err, string(out))
+	assert.Contains(t, string(out), "username=oauth2\npassword=secret\n")
+	assert.Equal(t, "failed: ***", tap.redact("failed: secret"))
+
+	tap.cfg.URL = "git@git.example.com:tools/homebrew-tap.git"
+	assert.Empty(t, tap.credentials())
+	assert.Empty(t, tap.env())
+}
+
+func TestGitCloneKeepsNoToken(t *testing.T) {
+	var bare = bareRepo(t, "main")
+	home, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	// the https url is cloned from the bare repository instead
+	var url = "https://git.example.com/tools/homebrew-tap.git"
+	assert.NoError(t, ioutil.WriteFile(
+		filepath.Join(home, ".gitconfig"),
+		[]byte("[url \""+bare+"\"]\n\tinsteadOf = "+url+"\n"),
+		0644,
+	))
+	var previous = os.Getenv("HOME")
+	defer func() {
+		assert.NoError(t, os.Setenv("HOME", previous))
+	}()
+	assert.NoError(t, os.Setenv("HOME", home))
+
+	var ctx = context.New(config.Project{})
+	ctx.Token = "secret"
+	var dir = filepath.Join(home, "homebrew-tap")
+	var tap = NewGit(ctx, nil, config.GitRepo{URL: url, Branch: "main"}, dir)
+	var content bytes.Buffer
+	content.WriteString("class Foo < Formula\nend\n")
+	assert.NoError(t, tap.CreateFile(ctx, config.CommitAuthor{Name: "bot", Email: "bot@example.com"}, config.Repo{}, content, "Formula/foo.rb", "foo 1.0.0"))
+
+	bts, err := ioutil.ReadFile(filepath.Join(dir, ".git", "config"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(bts), url)
+	assert.NotContains(t, string(bts), "secret")
+}
@@ -2,8 +2,10 @@ package client
 
 import (
 	"bytes"
+	"fmt"
 	"net/url"
 	"os"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/google/go-github/github"
@@ -43,7 +45,8 @@ func (c *githubClient) CreateFile(
 	commitAuthor config.CommitAuthor,
 	repo config.Repo,
 	content bytes.Buffer,
-	path string,
+	path,
+	message string,
 ) error {
 	options := &github.RepositoryContentFileOptions{
 		Committer: &github.CommitAuthor{
@@ -51,9 +54,17 @@ func (c *githubClient) CreateFile(
 			Email: github.String(commitAuthor.Email),
 		},
 		Content: content.Bytes(),
-		Message: github.String(
-			ctx.Config.ProjectName + " version " + ctx.Git.CurrentTag,
-		),
+		Message: github.String(message),
+	}
+	if repo.Branch != "" {
+		_, res, err := c.client.Repositories.GetBranch(ctx, repo.Owner, repo.Name, repo.Branch)
+		if res != nil && res.StatusCode == 404 {
+			return fmt.Errorf("branch %s doesn't exist in %s", repo.Branch, repo)
+		}
+		if err != nil {
+			return err
+		}
+		options.Branch = github.String(repo.Branch)
 	}
 
 	file, _, res, err := c.client.Repositories.GetContents(
@@ -61,7 +72,7 @@ func (c *githubClient) CreateFile(
 		repo.Owner,
 		repo.Name,
 		path,
-		&github.RepositoryContentGetOptions{},
+		&github.RepositoryContentGetOptions{Ref: repo.Branch},
 	)
 	if err != nil && res.StatusCode != 404 {
 		return err
@@ -88,6 +99,28 @@ func (c *githubClient) CreateFile(
 	return err
 }
 
+func (c *githubClient) GetFile(
+	ctx *context.Context,
+	repo config.Repo,
+	path string,
+) ([]byte, error) {
+	file, _, res, err := c.client.Repositories.GetContents(
+		ctx,
+		repo.Owner,
+		repo.Name,
+		path,
+		&github.RepositoryContentGetOptions{Ref: repo.Branch},
+	)
+	if res != nil && res.StatusCode == 404 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	content, err := file.GetContent()
+	return []byte(content), err
+}
+
 func (c *githubClient) CreateRelease(ctx *context.Context, body string) (int64, error) {
 	var release *github.RepositoryRelease
 	title, err := releaseTitle(ctx)
@@ -145,3 +178,42 @@ func (c *githubClient) Upload(
 	)
 	return err
 }
+
+func (c *githubClient) MergedPullRequests(
+	ctx *context.Context,
+	repo config.Repo,
+	since time.Time,
+) ([]PullRequest, error) {
+	var result []PullRequest
+	var opts = &github.PullRequestListOptions{
+		State:       "closed",
+		Sort:        "updated",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		prs, res, err := c.client.PullRequests.List(ctx, repo.Owner, repo.Name, opts)
+		if err != nil {
+			return result, err
+		}
+		for _, pr := range prs {
+			if pr.GetUpdatedAt().Before(since) {
+				return result, nil
+			}
+			if pr.MergedAt == nil {
+				continue
+			}
+			result = append(result, PullRequest{
+				Number:      pr.GetNumber(),
+				Title:       pr.GetTitle(),
+				Author:      pr.GetUser().GetLogin(),
+				URL:         pr.GetHTMLURL(),
+				MergeCommit: pr.GetMergeCommitSHA(),
+			})
+		}
+		if res.NextPage == 0 {
+			return result, nil
+		}
+		opts.Page = res.NextPage
+	}
+}
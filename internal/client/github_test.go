@@ -0,0 +1,87 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeGitHub struct {
+	refs     []string
+	branches []string
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/v3/repos/user/bucket/branches/staging":
+		w.Write([]byte(`{"name": "staging"}`)) // nolint: errcheck
+	case "/api/v3/repos/user/bucket/contents/bucket/foo.json":
+		if r.Method == http.MethodGet {
+			f.refs = append(f.refs, r.URL.Query().Get("ref"))
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body struct {
+			Branch string `json:"branch"`
+		}
+		json.NewDecoder(r.Body).Decode(&body) // nolint: errcheck
+		f.branches = append(f.branches, body.Branch)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`)) // nolint: errcheck
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Not Found"}`)) // nolint: errcheck
+	}
+}
+
+func newFakeGitHub(t *testing.T, fake *fakeGitHub) (*context.Context, Client, func()) {
+	var server = httptest.NewServer(fake)
+	var ctx = context.New(config.Project{
+		GitHubURLs: config.GitHubURLs{
+			API:    server.URL + "/api/v3/",
+			Upload: server.URL + "/api/uploads/",
+		},
+	})
+	client, err := NewGitHub(ctx)
+	assert.NoError(t, err)
+	return ctx, client, server.Close
+}
+
+func TestGitHubCreateFileOnBranch(t *testing.T) {
+	var fake = &fakeGitHub{}
+	ctx, client, done := newFakeGitHub(t, fake)
+	defer done()
+	var repo = config.Repo{Owner: "user", Name: "bucket", Branch: "staging"}
+	var content bytes.Buffer
+	content.WriteString("{}")
+	assert.NoError(t, client.CreateFile(ctx, config.CommitAuthor{}, repo, content, "bucket/foo.json", "foo: update to 1.0.0"))
+	assert.Equal(t, []string{"staging"}, fake.refs)
+	assert.Equal(t, []string{"staging"}, fake.branches)
+}
+
+func TestGitHubCreateFileOnMissingBranch(t *testing.T) {
+	var fake = &fakeGitHub{}
+	ctx, client, done := newFakeGitHub(t, fake)
+	defer done()
+	var repo = config.Repo{Owner: "user", Name: "bucket", Branch: "nope"}
+	var err = client.CreateFile(ctx, config.CommitAuthor{}, repo, bytes.Buffer{}, "bucket/foo.json", "foo")
+	assert.EqualError(t, err, "branch nope doesn't exist in user/bucket")
+	assert.Empty(t, fake.refs)
+}
+
+func TestGitHubGetFileOnBranch(t *testing.T) {
+	var fake = &fakeGitHub{}
+	ctx, client, done := newFakeGitHub(t, fake)
+	defer done()
+	var repo = config.Repo{Owner: "user", Name: "bucket", Branch: "staging"}
+	bts, err := client.GetFile(ctx, repo, "bucket/foo.json")
+	assert.NoError(t, err)
+	assert.Empty(t, bts)
+	assert.Equal(t, []string{"staging"}, fake.refs)
+}
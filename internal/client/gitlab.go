@@ -0,0 +1,187 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+)
+
+// ErrNotSupported happens when calling a method a provider doesn't implement
+var ErrNotSupported = errors.New("not supported by this provider")
+
+type gitlabClient struct {
+	api    string
+	token  string
+	client *http.Client
+}
+
+// NewGitLab returns a gitlab client implementation, which can only read and
+// commit files in a repository for now
+func NewGitLab(ctx *context.Context) (Client, error) {
+	if _, err := url.Parse(ctx.Config.GitLabURLs.API); err != nil {
+		return &gitlabClient{}, err
+	}
+	return &gitlabClient{
+		api:    strings.TrimSuffix(ctx.Config.GitLabURLs.API, "/"),
+		token:  ctx.GitLabToken,
+		client: http.DefaultClient,
+	}, nil
+}
+
+type gitlabProject struct {
+	DefaultBranch string `json:"default_branch"`
+}
+
+type gitlabFile struct {
+	Content string `json:"content"`
+}
+
+type gitlabCommitFile struct {
+	Branch        string `json:"branch"`
+	Content       string `json:"content"`
+	Encoding      string `json:"encoding"`
+	CommitMessage string `json:"commit_message"`
+	AuthorName    string `json:"author_name"`
+	AuthorEmail   string `json:"author_email"`
+}
+
+func (c *gitlabClient) CreateFile(
+	ctx *context.Context,
+	commitAuthor config.CommitAuthor,
+	repo config.Repo,
+	content bytes.Buffer,
+	path,
+	message string,
+) error {
+	branch, err := c.branch(ctx, repo)
+	if err != nil {
+		return err
+	}
+	var body = gitlabCommitFile{
+		Branch:        branch,
+		Content:       base64.StdEncoding.EncodeToString(content.Bytes()),
+		Encoding:      "base64",
+		CommitMessage: message,
+		AuthorName:    commitAuthor.Name,
+		AuthorEmail:   commitAuthor.Email,
+	}
+	var file = c.fileURL(repo, path)
+	status, err := c.do(ctx, http.MethodGet, file+"?ref="+url.QueryEscape(branch), nil, nil)
+	if err != nil && status != http.StatusNotFound {
+		return err
+	}
+	var method = http.MethodPut
+	if status == http.StatusNotFound {
+		method = http.MethodPost
+	}
+	_, err = c.do(ctx, method, file, body, nil)
+	return err
+}
+
+func (c *gitlabClient) GetFile(ctx *context.Context, repo config.Repo, path string) ([]byte, error) {
+	branch, err := c.branch(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	var file gitlabFile
+	status, err := c.do(
+		ctx,
+		http.MethodGet,
+		c.fileURL(repo, path)+"?ref="+url.QueryEscape(branch),
+		nil,
+		&file,
+	)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(file.Content)
+}
+
+func (c *gitlabClient) CreateRelease(ctx *context.Context, body string) (int64, error) {
+	return 0, ErrNotSupported
+}
+
+func (c *gitlabClient) Upload(ctx *context.Context, releaseID int64, name string, file *os.File) error {
+	return ErrNotSupported
+}
+
+func (c *gitlabClient) MergedPullRequests(ctx *context.Context, repo config.Repo, since time.Time) ([]PullRequest, error) {
+	return nil, ErrNotSupported
+}
+
+// branch returns the branch of the repository to read and commit the files
+// in, which is the default branch of the project unless one is configured
+func (c *gitlabClient) branch(ctx *context.Context, repo config.Repo) (string, error) {
+	if repo.Branch != "" {
+		var url = c.projectURL(repo) + "/repository/branches/" + url.PathEscape(repo.Branch)
+		status, err := c.do(ctx, http.MethodGet, url, nil, nil)
+		if status == http.StatusNotFound {
+			return "", fmt.Errorf("branch %s doesn't exist in %s", repo.Branch, repo)
+		}
+		return repo.Branch, err
+	}
+	var project gitlabProject
+	if _, err := c.do(ctx, http.MethodGet, c.projectURL(repo), nil, &project); err != nil {
+		return "", err
+	}
+	return project.DefaultBranch, nil
+}
+
+func (c *gitlabClient) projectURL(repo config.Repo) string {
+	return c.api + "/projects/" + url.PathEscape(repo.String())
+}
+
+func (c *gitlabClient) fileURL(repo config.Repo, path string) string {
+	return c.projectURL(repo) + "/repository/files/" + url.PathEscape(path)
+}
+
+// do sends the request, decoding the json response into result if given. It
+// returns the response status code, if any.
+func (c *gitlabClient) do(ctx *context.Context, method, url string, body, result interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		bts, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(bts)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+	req.Header.Set("Content-Type", "application/json")
+	res, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close() // nolint: errcheck
+	bts, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return res.StatusCode, err
+	}
+	if res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("%s %s: %s: %s", method, url, res.Status, string(bts))
+	}
+	if result == nil {
+		return res.StatusCode, nil
+	}
+	return res.StatusCode, json.Unmarshal(bts, result)
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeGitLab struct {
+	files   map[string]string
+	commits []gitlabCommitFile
+	methods []string
+	refs    []string
+}
+
+func (f *fakeGitLab) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch r.URL.EscapedPath() {
+	case "/api/v4/projects/group%2Ftap":
+		json.NewEncoder(w).Encode(gitlabProject{DefaultBranch: "main"}) // nolint: errcheck
+	case "/api/v4/projects/group%2Ftap/repository/branches/staging":
+		w.Write([]byte(`{"name": "staging"}`)) // nolint: errcheck
+	case "/api/v4/projects/group%2Ftap/repository/files/Formula%2Ffoo.rb":
+		if r.Method == http.MethodGet {
+			f.refs = append(f.refs, r.URL.Query().Get("ref"))
+		}
+		if r.Method != http.MethodGet {
+			var commit gitlabCommitFile
+			json.NewDecoder(r.Body).Decode(&commit) // nolint: errcheck
+			f.commits = append(f.commits, commit)
+			f.methods = append(f.methods, r.Method)
+			content, _ := base64.StdEncoding.DecodeString(commit.Content)
+			f.files["Formula/foo.rb"] = string(content)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		content, ok := f.files["Formula/foo.rb"]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(gitlabFile{ // nolint: errcheck
+			Content: base64.StdEncoding.EncodeToString([]byte(content)),
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestGitLabFiles(t *testing.T) {
+	var fake = &fakeGitLab{files: map[string]string{}}
+	var server = httptest.NewServer(fake)
+	defer server.Close()
+	var ctx = context.New(config.Project{
+		GitLabURLs: config.GitLabURLs{API: server.URL + "/api/v4/"},
+	})
+	ctx.GitLabToken = "secret"
+	client, err := NewGitLab(ctx)
+	assert.NoError(t, err)
+	var repo = config.Repo{Owner: "group", Name: "tap"}
+	var author = config.CommitAuthor{Name: "bot", Email: "bot@example.com"}
+
+	content, err := client.GetFile(ctx, repo, "Formula/foo.rb")
+	assert.NoError(t, err)
+	assert.Empty(t, content)
+
+	for _, formula := range []string{"v1", "v2"} {
+		var buf bytes.Buffer
+		buf.WriteString(formula)
+		assert.NoError(t, client.CreateFile(ctx, author, repo, buf, "Formula/foo.rb", "foo "+formula))
+		content, err = client.GetFile(ctx, repo, "Formula/foo.rb")
+		assert.NoError(t, err)
+		assert.Equal(t, formula, string(content))
+	}
+	assert.Equal(t, []string{http.MethodPost, http.MethodPut}, fake.methods)
+	for _, ref := range fake.refs {
+		assert.Equal(t, "main", ref)
+	}
+	assert.Equal(t, "main", fake.commits[1].Branch)
+	assert.Equal(t, "foo v2", fake.commits[1].CommitMessage)
+	assert.Equal(t, "bot", fake.commits[1].AuthorName)
+	assert.Equal(t, "bot@example.com", fake.commits[1].AuthorEmail)
+}
+
+func TestGitLabFilesOnBranch(t *testing.T) {
+	var fake = &fakeGitLab{files: map[string]string{}}
+	var server = httptest.NewServer(fake)
+	defer server.Close()
+	var ctx = context.New(config.Project{
+		GitLabURLs: config.GitLabURLs{API: server.URL + "/api/v4/"},
+	})
+	ctx.GitLabToken = "secret"
+	client, err := NewGitLab(ctx)
+	assert.NoError(t, err)
+	var repo = config.Repo{Owner: "group", Name: "tap", Branch: "staging"}
+	var buf bytes.Buffer
+	buf.WriteString("v1")
+	assert.NoError(t, client.CreateFile(ctx, config.CommitAuthor{}, repo, buf, "Formula/foo.rb", "foo v1"))
+	content, err := client.GetFile(ctx, repo, "Formula/foo.rb")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(content))
+	assert.Equal(t, []string{"staging", "staging"}, fake.refs)
+	assert.Equal(t, "staging", fake.commits[0].Branch)
+
+	repo.Branch = "nope"
+	assert.EqualError(t, client.CreateFile(ctx, config.CommitAuthor{}, repo, buf, "Formula/foo.rb", "foo v1"), "branch nope doesn't exist in group/tap")
+	_, err = client.GetFile(ctx, repo, "Formula/foo.rb")
+	assert.EqualError(t, err, "branch nope doesn't exist in group/tap")
+	assert.Len(t, fake.commits, 1)
+}
+
+func TestGitLabErrors(t *testing.T) {
+	var server = httptest.NewServer(&fakeGitLab{files: map[string]string{}})
+	defer server.Close()
+	var ctx = context.New(config.Project{
+		GitLabURLs: config.GitLabURLs{API: server.URL + "/api/v4"},
+	})
+	ctx.GitLabToken = "wrong"
+	client, err := NewGitLab(ctx)
+	assert.NoError(t, err)
+	var repo = config.Repo{Owner: "group", Name: "tap"}
+	_, err = client.GetFile(ctx, repo, "Formula/foo.rb")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+	assert.Error(t, client.CreateFile(ctx, config.CommitAuthor{}, repo, bytes.Buffer{}, "Formula/foo.rb", "foo"))
+	_, err = client.CreateRelease(ctx, "")
+	assert.Equal(t, ErrNotSupported, err)
+}
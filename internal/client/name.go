@@ -16,11 +16,19 @@ func releaseTitle(ctx *context.Context) (string, error) {
 		return "", err
 	}
 	err = t.Execute(&out, struct {
-		ProjectName, Tag, Version string
+		ProjectName, Tag, Version       string
+		Major, Minor, Patch, Prerelease string
+		IsSnapshot, IsPrerelease        bool
 	}{
-		ProjectName: ctx.Config.ProjectName,
-		Tag:         ctx.Git.CurrentTag,
-		Version:     ctx.Version,
+		ProjectName:  ctx.Config.ProjectName,
+		Tag:          ctx.Git.CurrentTag,
+		Version:      ctx.Version,
+		Major:        ctx.Semver.Major,
+		Minor:        ctx.Semver.Minor,
+		Patch:        ctx.Semver.Patch,
+		Prerelease:   ctx.Semver.Prerelease,
+		IsSnapshot:   ctx.Snapshot,
+		IsPrerelease: ctx.Semver.Prerelease != "",
 	})
 	return out.String(), err
 }
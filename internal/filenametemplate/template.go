@@ -4,7 +4,9 @@ package filenametemplate
 
 import (
 	"bytes"
+	"io/ioutil"
 	"text/template"
+	"time"
 
 	"github.com/goreleaser/goreleaser/context"
 	"github.com/goreleaser/goreleaser/internal/artifact"
@@ -12,32 +14,57 @@ import (
 
 // Fields contains all accepted fields in the template string
 type Fields struct {
-	Version     string
-	Tag         string
-	ProjectName string
-	Env         map[string]string
-	Os          string
-	Arch        string
-	Arm         string
-	Binary      string
+	Version      string
+	Tag          string
+	ProjectName  string
+	Env          map[string]string
+	Os           string
+	Arch         string
+	Arm          string
+	Binary       string
+	ArtifactName string
+	// Major, Minor, Patch and Prerelease are the components of the version,
+	// empty when it is not semver
+	Major        string
+	Minor        string
+	Patch        string
+	Prerelease   string
+	IsSnapshot   bool
+	IsPrerelease bool
+	// CommitDate is RFC3339 formatted, CommitTimestamp in unix seconds
+	CommitDate      string
+	CommitTimestamp int64
+	// Algorithm is only set for the checksums file name
+	Algorithm string
 }
 
 // NewFields returns a Fields instances filled with the data provided
 func NewFields(ctx *context.Context, replacements map[string]string, artifacts ...artifact.Artifact) Fields {
 	// This will fail if artifacts is empty - should never be though...
-	var binary = artifacts[0].Extra["Binary"]
+	var binary = artifacts[0].ExtraString("Binary")
+	var name = artifacts[0].Name
 	if len(artifacts) > 1 {
 		binary = ctx.Config.ProjectName
+		name = ""
 	}
 	return Fields{
-		Env:         ctx.Env,
-		Version:     ctx.Version,
-		Tag:         ctx.Git.CurrentTag,
-		ProjectName: ctx.Config.ProjectName,
-		Os:          replace(replacements, artifacts[0].Goos),
-		Arch:        replace(replacements, artifacts[0].Goarch),
-		Arm:         replace(replacements, artifacts[0].Goarm),
-		Binary:      binary,
+		Env:             ctx.Env,
+		Version:         ctx.Version,
+		Tag:             ctx.Git.CurrentTag,
+		ProjectName:     ctx.Config.ProjectName,
+		Os:              replace(replacements, artifacts[0].Goos),
+		Arch:            replace(replacements, artifacts[0].Goarch),
+		Arm:             replace(replacements, artifacts[0].Goarm),
+		Binary:          binary,
+		ArtifactName:    name,
+		Major:           ctx.Semver.Major,
+		Minor:           ctx.Semver.Minor,
+		Patch:           ctx.Semver.Patch,
+		Prerelease:      ctx.Semver.Prerelease,
+		IsSnapshot:      ctx.Snapshot,
+		IsPrerelease:    ctx.Semver.Prerelease != "",
+		CommitDate:      ctx.Git.CommitDate.UTC().Format(time.RFC3339),
+		CommitTimestamp: ctx.Git.CommitDate.Unix(),
 	}
 }
 
@@ -53,6 +80,16 @@ func Apply(tmpl string, fields Fields) (string, error) {
 	return out.String(), err
 }
 
+// Check parses the given template and evaluates it with empty fields, so
+// syntax errors and unknown fields are reported before the fields are known.
+func Check(tmpl string) error {
+	t, err := template.New(tmpl).Parse(tmpl)
+	if err != nil {
+		return err
+	}
+	return t.Execute(ioutil.Discard, Fields{})
+}
+
 func replace(replacements map[string]string, original string) string {
 	result := replacements[original]
 	if result == "" {
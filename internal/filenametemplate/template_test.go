@@ -2,6 +2,7 @@ package filenametemplate
 
 import (
 	"testing"
+	"time"
 
 	"github.com/goreleaser/goreleaser/config"
 	"github.com/goreleaser/goreleaser/context"
@@ -18,25 +19,29 @@ func TestTemplate(t *testing.T) {
 	}
 	ctx.Version = "1.0.0"
 	ctx.Git.CurrentTag = "v1.0.0"
+	ctx.Git.CommitDate = time.Unix(1500000000, 0)
 	var artifact = artifact.Artifact{
 		Name:   "not-this-binary",
 		Goarch: "amd64",
 		Goos:   "linux",
 		Goarm:  "6",
-		Extra: map[string]string{
+		Extra: map[string]interface{}{
 			"Binary": "binary",
 		},
 	}
 	var fields = NewFields(ctx, map[string]string{"linux": "Linux"}, artifact)
 	for expect, tmpl := range map[string]string{
-		"bar":    "{{.Env.FOO}}",
-		"Linux":  "{{.Os}}",
-		"amd64":  "{{.Arch}}",
-		"6":      "{{.Arm}}",
-		"1.0.0":  "{{.Version}}",
-		"v1.0.0": "{{.Tag}}",
-		"binary": "{{.Binary}}",
-		"proj":   "{{.ProjectName}}",
+		"bar":                  "{{.Env.FOO}}",
+		"Linux":                "{{.Os}}",
+		"amd64":                "{{.Arch}}",
+		"6":                    "{{.Arm}}",
+		"1.0.0":                "{{.Version}}",
+		"v1.0.0":               "{{.Tag}}",
+		"binary":               "{{.Binary}}",
+		"proj":                 "{{.ProjectName}}",
+		"not-this-binary":      "{{.ArtifactName}}",
+		"2017-07-14T02:40:00Z": "{{.CommitDate}}",
+		"1500000000":           "{{.CommitTimestamp}}",
 	} {
 		tmpl := tmpl
 		expect := expect
@@ -49,6 +54,23 @@ func TestTemplate(t *testing.T) {
 	}
 }
 
+func TestTemplatePrerelease(t *testing.T) {
+	var ctx = context.New(config.Project{})
+	var artifact = artifact.Artifact{Goos: "linux", Goarch: "amd64"}
+	for prerelease, expect := range map[string]string{
+		"":     "stable",
+		"rc.1": "edge rc.1",
+	} {
+		ctx.Semver.Prerelease = prerelease
+		result, err := Apply(
+			"{{ if .IsPrerelease }}edge {{ .Prerelease }}{{ else }}stable{{ end }}",
+			NewFields(ctx, map[string]string{}, artifact),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, expect, result, prerelease)
+	}
+}
+
 func TestNewFields(t *testing.T) {
 	var ctx = context.New(config.Project{
 		ProjectName: "proj",
@@ -60,12 +82,13 @@ func TestNewFields(t *testing.T) {
 		Goarch: "amd64",
 		Goos:   "linux",
 		Goarm:  "6",
-		Extra: map[string]string{
+		Extra: map[string]interface{}{
 			"Binary": "binary",
 		},
 	}
 	var fields = NewFields(ctx, map[string]string{}, artifact, artifact)
 	assert.Equal(t, "proj", fields.Binary)
+	assert.Empty(t, fields.ArtifactName)
 }
 
 func TestInvalidTemplate(t *testing.T) {
@@ -76,6 +99,12 @@ func TestInvalidTemplate(t *testing.T) {
 	assert.EqualError(t, err, `template: {{.Foo}:1: unexpected "}" in operand`)
 }
 
+func TestCheck(t *testing.T) {
+	assert.NoError(t, Check("{{ .ProjectName }}_{{ .Env.NOPE }}_{{ .Algorithm }}"))
+	assert.Error(t, Check("{{ .ProjectName }"))
+	assert.Error(t, Check("{{ .Foo }}"))
+}
+
 func TestEnvNotFound(t *testing.T) {
 	var ctx = context.New(config.Project{})
 	var fields = NewFields(ctx, map[string]string{}, artifact.Artifact{})
@@ -4,6 +4,7 @@ package git
 import (
 	"bytes"
 	"errors"
+	"os"
 	"os/exec"
 	"strings"
 
@@ -18,8 +19,17 @@ func IsRepo() bool {
 
 // Run runs a git command and returns its output or errors
 func Run(args ...string) (string, error) {
+	return RunEnv(nil, args...)
+}
+
+// RunEnv runs a git command with the given environment variables, on top of
+// the current ones, and returns its output or errors
+func RunEnv(env []string, args ...string) (string, error) {
 	/* #nosec */
 	var cmd = exec.Command("git", args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	log.WithField("args", args).Debug("running git")
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
@@ -32,6 +42,16 @@ func Run(args ...string) (string, error) {
 	return stdout.String(), nil
 }
 
+// DescribeArgs returns the args of git describe with the given flags, only
+// considering the tags starting with the given prefix, if any
+func DescribeArgs(prefix string, flags ...string) []string {
+	var args = append([]string{"describe"}, flags...)
+	if prefix != "" {
+		args = append(args, "--match", prefix+"*")
+	}
+	return args
+}
+
 // Clean the output
 func Clean(output string, err error) (string, error) {
 	return strings.Replace(strings.Split(output, "\n")[0], "'", "", -1), err
@@ -22,6 +22,12 @@ func TestGit(t *testing.T) {
 	)
 }
 
+func TestRunEnv(t *testing.T) {
+	out, err := RunEnv([]string{"GIT_AUTHOR_NAME=somebody", "GIT_AUTHOR_EMAIL=somebody@example.com"}, "var", "GIT_AUTHOR_IDENT")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "somebody")
+}
+
 func TestRepo(t *testing.T) {
 	assert.True(t, IsRepo(), "goreleaser folder should be a git repo")
 
@@ -34,3 +40,8 @@ func TestClean(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "asdasd ssadas", out)
 }
+
+func TestDescribeArgs(t *testing.T) {
+	assert.Equal(t, []string{"describe", "--tags"}, DescribeArgs("", "--tags"))
+	assert.Equal(t, []string{"describe", "--tags", "--match", "mytool/*"}, DescribeArgs("mytool/", "--tags"))
+}
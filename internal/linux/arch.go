@@ -1,7 +1,10 @@
 // Package linux contains functions that are useful to generate linux packages.
 package linux
 
-import "strings"
+import (
+	"sort"
+	"strings"
+)
 
 // Arch converts a goarch to a linux-compatible arch
 func Arch(key string) string {
@@ -17,3 +20,43 @@ func Arch(key string) string {
 	}
 	return key
 }
+
+// snapArchs maps goarchs, suffixed with the goarm for arm, to the snap
+// architectures. ARMv6 binaries also run on the ARMv7 armhf devices.
+var snapArchs = map[string]string{
+	"amd64":   "amd64",
+	"386":     "i386",
+	"arm64":   "arm64",
+	"arm6":    "armhf",
+	"arm7":    "armhf",
+	"ppc64le": "ppc64el",
+	"s390x":   "s390x",
+}
+
+// SnapArch converts a goarch and goarm to a snap architecture, reporting
+// whether snaps support it at all.
+func SnapArch(goarch, goarm string) (string, bool) {
+	var key = goarch
+	if goarch == "arm" {
+		if goarm == "" {
+			goarm = "6"
+		}
+		key += goarm
+	}
+	arch, ok := snapArchs[key]
+	return arch, ok
+}
+
+// SnapArchs returns the snap architectures supported, sorted.
+func SnapArchs() []string {
+	var seen = map[string]bool{}
+	var archs []string
+	for _, arch := range snapArchs {
+		if !seen[arch] {
+			seen[arch] = true
+			archs = append(archs, arch)
+		}
+	}
+	sort.Strings(archs)
+	return archs
+}
@@ -20,3 +20,37 @@ func TestArch(t *testing.T) {
 		})
 	}
 }
+
+func TestSnapArch(t *testing.T) {
+	for from, to := range map[[2]string]string{
+		{"amd64", ""}:   "amd64",
+		{"386", ""}:     "i386",
+		{"arm64", ""}:   "arm64",
+		{"arm", ""}:     "armhf",
+		{"arm", "6"}:    "armhf",
+		{"arm", "7"}:    "armhf",
+		{"ppc64le", ""}: "ppc64el",
+		{"s390x", ""}:   "s390x",
+	} {
+		t.Run(fmt.Sprintf("%s%s to %s", from[0], from[1], to), func(t *testing.T) {
+			arch, ok := SnapArch(from[0], from[1])
+			assert.True(t, ok)
+			assert.Equal(t, to, arch)
+		})
+	}
+}
+
+func TestSnapArchUnsupported(t *testing.T) {
+	for _, from := range [][2]string{
+		{"arm", "5"},
+		{"mips", ""},
+		{"ppc64", ""},
+	} {
+		_, ok := SnapArch(from[0], from[1])
+		assert.False(t, ok, from)
+	}
+}
+
+func TestSnapArchs(t *testing.T) {
+	assert.Equal(t, []string{"amd64", "arm64", "armhf", "i386", "ppc64el", "s390x"}, SnapArchs())
+}
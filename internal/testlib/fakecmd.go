@@ -0,0 +1,35 @@
+package testlib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// FakeCommand puts a script with the given name first in the $PATH, which
+// records its arguments in a calls file next to it and then runs the given
+// shell code. It returns the arguments of each call and a function
+// restoring the $PATH.
+func FakeCommand(t *testing.T, name, script string) (calls func() []string, back func()) {
+	folder, err := ioutil.TempDir("", "fake"+name)
+	assert.NoError(t, err)
+	var file = filepath.Join(folder, "calls")
+	assert.NoError(t, ioutil.WriteFile(
+		filepath.Join(folder, name),
+		[]byte("#!/bin/sh\necho \"$@\" >> "+file+"\n"+script+"\n"),
+		0755,
+	))
+	var path = os.Getenv("PATH")
+	assert.NoError(t, os.Setenv("PATH", folder+string(os.PathListSeparator)+path))
+	return func() []string {
+			bts, _ := ioutil.ReadFile(file)
+			return strings.Split(strings.TrimSpace(string(bts)), "\n")
+		}, func() {
+			assert.NoError(t, os.Setenv("PATH", path))
+			assert.NoError(t, os.RemoveAll(folder))
+		}
+}
@@ -0,0 +1,20 @@
+package testlib
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeCommand(t *testing.T) {
+	calls, back := FakeCommand(t, "mytool", `[ "$1" = "fail" ] && exit 1; echo done`)
+	out, err := exec.Command("mytool", "first", "call").CombinedOutput()
+	assert.NoError(t, err)
+	assert.Equal(t, "done\n", string(out))
+	assert.Error(t, exec.Command("mytool", "fail").Run())
+	assert.Equal(t, []string{"first call", "fail"}, calls())
+	back()
+	_, err = exec.LookPath("mytool")
+	assert.Error(t, err)
+}
@@ -34,9 +34,13 @@ func main() {
 	app.Flags = []cli.Flag{
 		cli.StringFlag{
 			Name:  "config, file, c, f",
-			Usage: "Load configuration from `FILE`",
+			Usage: "Load configuration from `FILE`, or from the standard input with -",
 			Value: ".goreleaser.yml",
 		},
+		cli.BoolFlag{
+			Name:  "lax-config",
+			Usage: "Warn about the unknown fields of the config file instead of failing",
+		},
 		cli.StringFlag{
 			Name:  "release-notes",
 			Usage: "Load custom release notes from a markdown `FILE`",
@@ -49,6 +53,14 @@ func main() {
 			Name:  "skip-publish",
 			Usage: "Skip all publishing pipes of the release",
 		},
+		cli.BoolFlag{
+			Name:  "skip-changelog",
+			Usage: "Skip the changelog generation",
+		},
+		cli.StringFlag{
+			Name:  "previous-tag",
+			Usage: "Build the changelog from the given `TAG` instead of the previous one",
+		},
 		cli.BoolFlag{
 			Name:  "snapshot",
 			Usage: "Generate an unversioned snapshot release",
@@ -99,6 +111,53 @@ func main() {
 				return nil
 			},
 		},
+		{
+			Name:  "verify",
+			Usage: "verify the files of a downloaded release against its checksums",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "checksums",
+					Usage: "Load the checksums from `FILE`",
+				},
+				cli.StringFlag{
+					Name:  "dir, d",
+					Usage: "Look for the files to verify in `DIR`",
+					Value: ".",
+				},
+				cli.StringFlag{
+					Name:  "algorithm",
+					Usage: "Checksum `ALGORITHM` of the lines which don't name it, guessed from the length of the sums by default",
+				},
+				cli.BoolFlag{
+					Name:  "ignore-missing",
+					Usage: "Don't fail for the listed files which aren't in the directory",
+				},
+				cli.StringFlag{
+					Name:  "signature",
+					Usage: "Verify the checksums file against the signature in `FILE` first",
+				},
+				cli.StringFlag{
+					Name:  "public-key",
+					Usage: "Verify the signature with the public key in `FILE`",
+				},
+				cli.StringFlag{
+					Name:  "signature-cmd",
+					Usage: "Verify the signature with `CMD`, gpg, or minisign for .minisig files, by default",
+				},
+				cli.BoolFlag{
+					Name:  "debug",
+					Usage: "Enable debug mode",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if err := goreleaserlib.Verify(c); err != nil {
+					log.WithError(err).Error(bold.Sprint("verification failed"))
+					return cli.NewExitError("\n", 1)
+				}
+				log.Info(bold.Sprint("verification succeeded"))
+				return nil
+			},
+		},
 	}
 	if err := app.Run(os.Args); err != nil {
 		log.WithError(err).Fatal("failed")
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/apex/log"
@@ -98,11 +99,24 @@ func create(ctx *context.Context, binaries []artifact.Artifact) error {
 	if err != nil {
 		return fmt.Errorf("failed to find files to archive: %s", err.Error())
 	}
+	if ctx.Config.Git.Reproducible {
+		if err := touch(ctx, files, binaries); err != nil {
+			return err
+		}
+		// the binaries of a platform are built in parallel, so their order
+		// changes from a run to another
+		binaries = append([]artifact.Artifact{}, binaries...)
+		sort.Slice(binaries, func(i, j int) bool {
+			return binaries[i].Name < binaries[j].Name
+		})
+	}
+	var names []string
 	for _, f := range files {
 		log.Debugf("adding %s", f)
 		if err = a.Add(wrap(ctx, f, folder), f); err != nil {
 			return fmt.Errorf("failed to add %s to the archive: %s", f, err.Error())
 		}
+		names = append(names, filepath.ToSlash(f))
 	}
 	for _, binary := range binaries {
 		var bin = wrap(ctx, binary.Name, folder)
@@ -110,18 +124,44 @@ func create(ctx *context.Context, binaries []artifact.Artifact) error {
 		if err := a.Add(bin, binary.Path); err != nil {
 			return fmt.Errorf("failed to add %s -> %s to the archive: %s", binary.Path, binary.Name, err.Error())
 		}
+		names = append(names, binary.Name)
+	}
+	var extra = map[string]interface{}{
+		// files inside the archive, not wrapped in its folder
+		"Files": names,
+	}
+	if ctx.Config.Archive.WrapInDirectory {
+		extra["WrappedIn"] = folder
 	}
 	ctx.Artifacts.Add(artifact.Artifact{
 		Type:   artifact.UploadableArchive,
+		ID:     ctx.Config.Archive.ID,
 		Name:   folder + "." + format,
 		Path:   archivePath,
 		Goos:   binaries[0].Goos,
 		Goarch: binaries[0].Goarch,
 		Goarm:  binaries[0].Goarm,
+		Extra:  extra,
 	})
 	return nil
 }
 
+// touch sets the modification time of the files and binaries to the build
+// date, the archives taking the times of the entries from the files, so
+// every archive of the same commit has the same entries.
+func touch(ctx *context.Context, files []string, binaries []artifact.Artifact) error {
+	var paths = append([]string{}, files...)
+	for _, binary := range binaries {
+		paths = append(paths, binary.Path)
+	}
+	for _, path := range paths {
+		if err := os.Chtimes(path, ctx.Date, ctx.Date); err != nil {
+			return fmt.Errorf("failed to set the time of %s: %s", path, err.Error())
+		}
+	}
+	return nil
+}
+
 func skip(ctx *context.Context, binaries []artifact.Artifact) error {
 	for _, binary := range binaries {
 		log.WithField("binary", binary.Name).Info("skip archiving")
@@ -131,7 +171,7 @@ func skip(ctx *context.Context, binaries []artifact.Artifact) error {
 			return err
 		}
 		binary.Type = artifact.UploadableBinary
-		binary.Name = name + binary.Extra["Ext"]
+		binary.Name = name + binary.ExtraString("Ext")
 		ctx.Artifacts.Add(binary)
 	}
 	return nil
@@ -2,11 +2,14 @@ package archive
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/goreleaser/goreleaser/config"
 	"github.com/goreleaser/goreleaser/context"
@@ -39,6 +42,7 @@ func TestRunPipe(t *testing.T) {
 					Dist:        dist,
 					ProjectName: "foobar",
 					Archive: config.Archive{
+						ID:           "default",
 						NameTemplate: defaultNameTemplate,
 						Files: []string{
 							"README.*",
@@ -58,7 +62,7 @@ func TestRunPipe(t *testing.T) {
 				Name:   "mybin",
 				Path:   filepath.Join(dist, "darwinamd64", "mybin"),
 				Type:   artifact.Binary,
-				Extra: map[string]string{
+				Extra: map[string]interface{}{
 					"Binary": "mybin",
 				},
 			})
@@ -68,7 +72,7 @@ func TestRunPipe(t *testing.T) {
 				Name:   "mybin.exe",
 				Path:   filepath.Join(dist, "windowsamd64", "mybin.exe"),
 				Type:   artifact.Binary,
-				Extra: map[string]string{
+				Extra: map[string]interface{}{
 					"Binary":    "mybin",
 					"Extension": ".exe",
 				},
@@ -81,6 +85,8 @@ func TestRunPipe(t *testing.T) {
 			windows := archives.Filter(artifact.ByGoos("windows")).List()[0]
 			assert.Equal(tt, "foobar_0.0.1_darwin_amd64."+format, darwin.Name)
 			assert.Equal(tt, "foobar_0.0.1_windows_amd64.zip", windows.Name)
+			assert.Equal(tt, []string{"README.md", "mybin"}, darwin.ExtraStrings("Files"))
+			assert.Equal(tt, "default", darwin.ID)
 			assert.Len(tt, archives.List(), 2)
 		})
 	}
@@ -132,7 +138,7 @@ func TestRunPipeBinary(t *testing.T) {
 		Name:   "mybin",
 		Path:   filepath.Join(dist, "darwinamd64", "mybin"),
 		Type:   artifact.Binary,
-		Extra: map[string]string{
+		Extra: map[string]interface{}{
 			"Binary": "mybin",
 		},
 	})
@@ -142,7 +148,7 @@ func TestRunPipeBinary(t *testing.T) {
 		Name:   "mybin.exe",
 		Path:   filepath.Join(dist, "windowsamd64", "mybin.exe"),
 		Type:   artifact.Binary,
-		Extra: map[string]string{
+		Extra: map[string]interface{}{
 			"Binary": "mybin",
 			"Ext":    ".exe",
 		},
@@ -172,7 +178,7 @@ func TestRunPipeDistRemoved(t *testing.T) {
 		Name:   "mybin.exe",
 		Path:   filepath.Join("/path/to/nope", "windowsamd64", "mybin.exe"),
 		Type:   artifact.Binary,
-		Extra: map[string]string{
+		Extra: map[string]interface{}{
 			"Binary":    "mybin",
 			"Extension": ".exe",
 		},
@@ -206,7 +212,7 @@ func TestRunPipeInvalidGlob(t *testing.T) {
 		Name:   "mybin",
 		Path:   filepath.Join("dist", "darwinamd64", "mybin"),
 		Type:   artifact.Binary,
-		Extra: map[string]string{
+		Extra: map[string]interface{}{
 			"Binary": "mybin",
 		},
 	})
@@ -242,11 +248,14 @@ func TestRunPipeWrap(t *testing.T) {
 		Name:   "mybin",
 		Path:   filepath.Join("dist", "darwinamd64", "mybin"),
 		Type:   artifact.Binary,
-		Extra: map[string]string{
+		Extra: map[string]interface{}{
 			"Binary": "mybin",
 		},
 	})
 	assert.NoError(t, Pipe{}.Run(ctx))
+	var archives = ctx.Artifacts.Filter(artifact.ByType(artifact.UploadableArchive)).List()
+	assert.Len(t, archives, 1)
+	assert.Equal(t, "foo", archives[0].Extra["WrappedIn"])
 
 	// Check archive contents
 	f, err := os.Open(filepath.Join(dist, "foo.tar.gz"))
@@ -266,6 +275,63 @@ func TestRunPipeWrap(t *testing.T) {
 	}
 }
 
+func TestRunPipeReproducible(t *testing.T) {
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	var dist = filepath.Join(folder, "dist")
+	assert.NoError(t, os.MkdirAll(filepath.Join(dist, "linuxamd64"), 0755))
+	for _, bin := range []string{"mybin", "otherbin"} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dist, "linuxamd64", bin), []byte(bin), 0755))
+	}
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, "README.md"), []byte("readme"), 0644))
+	var date = time.Unix(1500000000, 0).UTC()
+	var run = func(bins ...string) []byte {
+		var ctx = context.New(
+			config.Project{
+				Dist: dist,
+				Git:  config.Git{Reproducible: true},
+				Archive: config.Archive{
+					NameTemplate: "foo",
+					Format:       "tar.gz",
+					Files:        []string{"README.*"},
+				},
+			},
+		)
+		ctx.Date = date
+		for _, bin := range bins {
+			ctx.Artifacts.Add(artifact.Artifact{
+				Goos:   "linux",
+				Goarch: "amd64",
+				Name:   bin,
+				Path:   filepath.Join(dist, "linuxamd64", bin),
+				Type:   artifact.Binary,
+			})
+		}
+		assert.NoError(t, Pipe{}.Run(ctx))
+		var archive = ctx.Artifacts.Filter(artifact.ByType(artifact.UploadableArchive)).List()[0]
+		assert.Equal(t, []string{"README.md", "mybin", "otherbin"}, archive.ExtraStrings("Files"))
+		bts, err := ioutil.ReadFile(archive.Path)
+		assert.NoError(t, err)
+		return bts
+	}
+	var first = run("mybin", "otherbin")
+	var later = time.Now()
+	assert.NoError(t, os.Chtimes(filepath.Join(folder, "README.md"), later, later))
+	assert.Equal(t, first, run("otherbin", "mybin"))
+
+	gr, err := gzip.NewReader(bytes.NewReader(first))
+	assert.NoError(t, err)
+	r := tar.NewReader(gr)
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, date, h.ModTime.UTC(), h.Name)
+	}
+}
+
 func TestDefault(t *testing.T) {
 	var ctx = &context.Context{
 		Config: config.Project{
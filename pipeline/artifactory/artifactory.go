@@ -109,7 +109,7 @@ func (Pipe) Run(ctx *context.Context) error {
 
 func doRun(ctx *context.Context) error {
 	if !ctx.Publish {
-		return pipeline.ErrSkipPublish
+		return pipeline.SkipPublish(ctx)
 	}
 
 	// Handle every configured artifactory instance
@@ -207,9 +207,15 @@ func uploadAsset(ctx *context.Context, instance config.Artifactory, artifact art
 // targetData is used as a template struct for
 // Artifactory.Target
 type targetData struct {
-	Version     string
-	Tag         string
-	ProjectName string
+	Version      string
+	Tag          string
+	ProjectName  string
+	Major        string
+	Minor        string
+	Patch        string
+	Prerelease   string
+	IsSnapshot   bool
+	IsPrerelease bool
 
 	// Only supported in mode binary
 	Os   string
@@ -221,9 +227,15 @@ type targetData struct {
 // Those variables can be replaced by the given context, goos, goarch, goarm and more
 func resolveTargetTemplate(ctx *context.Context, artifactory config.Artifactory, artifact artifact.Artifact) (string, error) {
 	data := targetData{
-		Version:     ctx.Version,
-		Tag:         ctx.Git.CurrentTag,
-		ProjectName: ctx.Config.ProjectName,
+		Version:      ctx.Version,
+		Tag:          ctx.Git.CurrentTag,
+		ProjectName:  ctx.Config.ProjectName,
+		Major:        ctx.Semver.Major,
+		Minor:        ctx.Semver.Minor,
+		Patch:        ctx.Semver.Patch,
+		Prerelease:   ctx.Semver.Prerelease,
+		IsSnapshot:   ctx.Snapshot,
+		IsPrerelease: ctx.Semver.Prerelease != "",
 	}
 
 	if artifactory.Mode == modeBinary {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"text/template"
 
@@ -16,14 +17,38 @@ import (
 	"github.com/goreleaser/goreleaser/context"
 	"github.com/goreleaser/goreleaser/internal/artifact"
 	"github.com/goreleaser/goreleaser/internal/client"
+	"github.com/goreleaser/goreleaser/internal/filenametemplate"
 	"github.com/goreleaser/goreleaser/pipeline"
 )
 
 // ErrNoDarwin64Build when there is no build for darwin_amd64
-var ErrNoDarwin64Build = errors.New("brew tap requires one darwin amd64 build")
+var ErrNoDarwin64Build = errors.New("brew tap requires one darwin amd64 or arm64 build")
 
 // ErrTooManyDarwin64Builds when there are too many builds for darwin_amd64
-var ErrTooManyDarwin64Builds = errors.New("brew tap requires at most one darwin amd64 build")
+// or darwin_arm64
+var ErrTooManyDarwin64Builds = errors.New("brew tap requires at most one darwin build per architecture")
+
+// ErrArchives happens when the archives found can't be used by the formula,
+// listing the archives that were considered
+type ErrArchives struct {
+	Reason     error
+	Filters    string
+	Candidates []artifact.Artifact
+}
+
+func (e ErrArchives) Error() string {
+	var names []string
+	for _, a := range e.Candidates {
+		names = append(names, fmt.Sprintf("%s (%s/%s%s)", a.Name, a.Goos, a.Goarch, a.Goarm))
+	}
+	if len(names) == 0 {
+		names = append(names, "none")
+	}
+	return fmt.Sprintf(
+		"%s, looked for %s, found: %s",
+		e.Reason.Error(), e.Filters, strings.Join(names, ", "),
+	)
+}
 
 // Pipe for brew deployment
 type Pipe struct{}
@@ -34,11 +59,21 @@ func (Pipe) String() string {
 
 // Run the pipe
 func (Pipe) Run(ctx *context.Context) error {
-	client, err := client.NewGitHub(ctx)
+	if ctx.Config.Brew.GitLab.Name != "" {
+		c, err := client.NewGitLab(ctx)
+		if err != nil {
+			return err
+		}
+		return doRun(ctx, c)
+	}
+	c, err := client.NewGitHub(ctx)
 	if err != nil {
 		return err
 	}
-	return doRun(ctx, client)
+	if ctx.Config.Brew.Git.URL != "" {
+		return doRun(ctx, client.NewGit(ctx, c, ctx.Config.Brew.Git, filepath.Join(ctx.Config.Dist, "homebrew-tap")))
+	}
+	return doRun(ctx, c)
 }
 
 // Default sets the pipe defaults
@@ -57,6 +92,9 @@ func (Pipe) Default(ctx *context.Context) error {
 		ctx.Config.Brew.Install = strings.Join(installs, "\n")
 	}
 
+	if ctx.Config.Brew.CommitMessageTemplate == "" {
+		ctx.Config.Brew.CommitMessageTemplate = "{{ .ProjectName }} version {{ .Tag }}"
+	}
 	if ctx.Config.Brew.CommitAuthor.Name == "" {
 		ctx.Config.Brew.CommitAuthor.Name = "goreleaserbot"
 	}
@@ -67,12 +105,24 @@ func (Pipe) Default(ctx *context.Context) error {
 }
 
 func isBrewBuild(build config.Build) bool {
+	if !contains(build.Goos, "darwin") {
+		return false
+	}
+	for _, goarch := range []string{"amd64", "arm64"} {
+		if contains(build.Goarch, goarch) && !isIgnored(build, goarch) {
+			return true
+		}
+	}
+	return false
+}
+
+func isIgnored(build config.Build, goarch string) bool {
 	for _, ignore := range build.Ignore {
-		if ignore.Goos == "darwin" && ignore.Goarch == "amd64" {
-			return false
+		if ignore.Goos == "darwin" && ignore.Goarch == goarch {
+			return true
 		}
 	}
-	return contains(build.Goos, "darwin") && contains(build.Goarch, "amd64")
+	return false
 }
 
 func contains(ss []string, s string) bool {
@@ -85,62 +135,131 @@ func contains(ss []string, s string) bool {
 }
 
 func doRun(ctx *context.Context, client client.Client) error {
-	if ctx.Config.Brew.GitHub.Name == "" {
+	if tapRepo(ctx).Name == "" && ctx.Config.Brew.Git.URL == "" {
 		return pipeline.Skip("brew section is not configured")
 	}
 	if ctx.Config.Archive.Format == "binary" {
 		return pipeline.Skip("archive format is binary")
 	}
 
-	var archives = ctx.Artifacts.Filter(
-		artifact.And(
-			artifact.ByGoos("darwin"),
-			artifact.ByGoarch("amd64"),
-			artifact.ByGoarm(""),
-			artifact.ByType(artifact.UploadableArchive),
-		),
-	).List()
-	if len(archives) == 0 {
-		return ErrNoDarwin64Build
-	}
-	if len(archives) > 1 {
-		return ErrTooManyDarwin64Builds
+	archives, err := darwinArchives(ctx)
+	if err != nil {
+		return err
 	}
 
-	content, err := buildFormula(ctx, client, archives[0])
+	content, err := buildFormula(ctx, client, archives)
 	if err != nil {
 		return err
 	}
 
-	var filename = ctx.Config.ProjectName + ".rb"
+	var filename = formulaFileFor(ctx)
 	var path = filepath.Join(ctx.Config.Dist, filename)
 	log.WithField("formula", path).Info("writing")
 	if err := ioutil.WriteFile(path, content.Bytes(), 0644); err != nil {
 		return err
 	}
+	ctx.Artifacts.Add(artifact.Artifact{
+		Type: artifact.BrewTap,
+		Name: filename,
+		Path: path,
+	})
 
-	if ctx.Config.Brew.SkipUpload {
+	if ctx.Config.Brew.SkipUpload == "true" {
 		return pipeline.Skip("brew.skip_upload is set")
 	}
+	if ctx.Config.Brew.SkipUpload == "auto" && ctx.IsPrerelease() {
+		return pipeline.Skip("prerelease detected with 'auto' upload, skipping homebrew publish")
+	}
 	if !ctx.Publish {
-		return pipeline.ErrSkipPublish
+		return pipeline.SkipPublish(ctx)
 	}
 	if ctx.Config.Release.Draft {
 		return pipeline.Skip("release is marked as draft")
 	}
 
-	path = filepath.Join(ctx.Config.Brew.Folder, filename)
+	msg, err := filenametemplate.Apply(
+		ctx.Config.Brew.CommitMessageTemplate,
+		filenametemplate.NewFields(ctx, nil, archives[0]),
+	)
+	if err != nil {
+		return err
+	}
+
+	path = tapPath(ctx)
 	log.WithField("formula", path).
-		WithField("repo", ctx.Config.Brew.GitHub.String()).
+		WithField("repo", tapRepo(ctx).String()).
+		WithField("message", msg).
 		Info("pushing")
-	return client.CreateFile(ctx, ctx.Config.Brew.CommitAuthor, ctx.Config.Brew.GitHub, content, path)
+	return client.CreateFile(ctx, ctx.Config.Brew.CommitAuthor, tapRepo(ctx), content, path, msg)
+}
+
+func formulaFileFor(ctx *context.Context) string {
+	return strings.ToLower(ctx.Config.ProjectName) + ".rb"
+}
+
+// tapRepo is the repository of the tap, either on gitlab or github
+func tapRepo(ctx *context.Context) config.Repo {
+	if ctx.Config.Brew.GitLab.Name != "" {
+		return ctx.Config.Brew.GitLab
+	}
+	return ctx.Config.Brew.GitHub
 }
 
-func buildFormula(ctx *context.Context, client client.Client, artifact artifact.Artifact) (bytes.Buffer, error) {
-	data, err := dataFor(ctx, client, artifact)
+// tapPath is the path of the formula inside the tap repository. The
+// contents api creates the intermediate folders as needed, but always
+// expects forward slashes.
+func tapPath(ctx *context.Context) string {
+	return filepath.ToSlash(filepath.Join(ctx.Config.Brew.Folder, formulaFileFor(ctx)))
+}
+
+// darwinArchives returns the darwin archives the formula installs, at most
+// one per architecture, amd64 first. When the brew config has ids, only the
+// archives with one of them are considered.
+func darwinArchives(ctx *context.Context) ([]artifact.Artifact, error) {
+	var ids = ctx.Config.Brew.IDs
+	var byArchive = artifact.ByType(artifact.UploadableArchive)
+	var described string
+	if len(ids) > 0 {
+		byArchive = artifact.And(byArchive, artifact.ByIDs(ids...))
+		described = " with the ids " + strings.Join(ids, ", ")
+	}
+	var result []artifact.Artifact
+	for _, goarch := range []string{"amd64", "arm64"} {
+		var archives = ctx.Artifacts.Filter(
+			artifact.And(
+				artifact.ByGoos("darwin"),
+				artifact.ByGoarch(goarch),
+				artifact.ByGoarm(""),
+				byArchive,
+			),
+		).List()
+		if len(archives) > 1 {
+			return result, ErrArchives{
+				Reason:     ErrTooManyDarwin64Builds,
+				Filters:    "archives for darwin/" + goarch + described,
+				Candidates: archives,
+			}
+		}
+		result = append(result, archives...)
+	}
+	if len(result) == 0 {
+		return result, ErrArchives{
+			Reason:     ErrNoDarwin64Build,
+			Filters:    "archives for darwin/amd64 and darwin/arm64" + described,
+			Candidates: ctx.Artifacts.Filter(artifact.ByType(artifact.UploadableArchive)).List(),
+		}
+	}
+	return result, nil
+}
+
+func buildFormula(ctx *context.Context, client client.Client, archives []artifact.Artifact) (bytes.Buffer, error) {
+	data, err := dataFor(ctx, client, archives)
 	if err != nil {
 		return bytes.Buffer{}, err
 	}
+	if ctx.Config.Brew.Devel && ctx.IsPrerelease() {
+		return buildDevelFormula(ctx, client, data)
+	}
 	return doBuildFormula(data)
 }
 
@@ -153,38 +272,174 @@ func doBuildFormula(data templateData) (out bytes.Buffer, err error) {
 	return
 }
 
-func dataFor(ctx *context.Context, client client.Client, artifact artifact.Artifact) (result templateData, err error) {
-	sum, err := checksum.SHA256(artifact.Path)
+func dataFor(ctx *context.Context, client client.Client, archives []artifact.Artifact) (result templateData, err error) {
+	var cfg = ctx.Config.Brew
+	if err = checkDependencies(cfg.Dependencies); err != nil {
+		return
+	}
+	var fields = filenametemplate.NewFields(ctx, nil, archives[0])
+	desc, err := filenametemplate.Apply(cfg.Description, fields)
 	if err != nil {
 		return
 	}
-	var cfg = ctx.Config.Brew
-	return templateData{
+	homepage, err := filenametemplate.Apply(cfg.Homepage, fields)
+	if err != nil {
+		return
+	}
+	caveats, err := filenametemplate.Apply(cfg.Caveats, fields)
+	if err != nil {
+		return
+	}
+	install, err := filenametemplate.Apply(cfg.Install, fields)
+	if err != nil {
+		return
+	}
+	test, err := filenametemplate.Apply(cfg.Test, fields)
+	if err != nil {
+		return
+	}
+	result = templateData{
 		Name:             formulaNameFor(ctx.Config.ProjectName),
-		DownloadURL:      ctx.Config.GitHubURLs.Download,
-		Desc:             cfg.Description,
-		Homepage:         cfg.Homepage,
-		Repo:             ctx.Config.Release.GitHub,
+		Desc:             desc,
+		Homepage:         homepage,
 		Tag:              ctx.Git.CurrentTag,
 		Version:          ctx.Version,
-		Caveats:          cfg.Caveats,
-		File:             artifact.Name,
-		SHA256:           sum,
+		Caveats:          heredoc(caveats),
 		Dependencies:     cfg.Dependencies,
 		Conflicts:        cfg.Conflicts,
-		Plist:            cfg.Plist,
-		Install:          split(cfg.Install),
-		Tests:            split(cfg.Test),
+		Plist:            heredoc(cfg.Plist),
+		Service:          split(cfg.Service),
+		Install:          split(install),
+		Tests:            split(test),
 		DownloadStrategy: cfg.DownloadStrategy,
-	}, nil
+		HardwareCPU:      cfg.UseHardwareCPU,
+	}
+	for _, archive := range archives {
+		for _, file := range missingFiles(result.Install, archive) {
+			log.WithField("file", file).
+				WithField("archive", archive.Name).
+				Warn("file used in brew install is not in the archive")
+		}
+		sum, err := checksum.Artifact("sha256", archive)
+		if err != nil {
+			return result, err
+		}
+		url, err := urlFor(ctx, filenametemplate.NewFields(ctx, nil, archive), archive)
+		if err != nil {
+			return result, err
+		}
+		var dl = &download{URL: url, SHA256: sum}
+		if len(archives) == 1 {
+			result.URL = dl.URL
+			result.SHA256 = dl.SHA256
+		} else if archive.Goarch == "arm64" {
+			result.Arm = dl
+		} else {
+			result.Intel = dl
+		}
+	}
+	return result, nil
+}
+
+var installRe = regexp.MustCompile(`\.install\s*\(?\s*"([^"]+)"`)
+
+// missingFiles returns the files used in the install block that are not
+// inside the archive, as the formula would fail to install.
+func missingFiles(lines []string, archive artifact.Artifact) (missing []string) {
+	var contents = archive.ExtraStrings("Files")
+	if contents == nil {
+		return
+	}
+	for _, line := range lines {
+		for _, match := range installRe.FindAllStringSubmatch(line, -1) {
+			var file = strings.TrimPrefix(match[1], "./")
+			if strings.Contains(file, "#{") || inArchive(contents, file) {
+				continue
+			}
+			missing = append(missing, file)
+		}
+	}
+	return
+}
+
+func inArchive(contents []string, file string) bool {
+	for _, f := range contents {
+		if f == file || strings.HasPrefix(f, strings.TrimSuffix(file, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+var dependencyTypes = []string{"build", "optional", "recommended"}
+
+func checkDependencies(deps []config.HomebrewDependency) error {
+	for _, dep := range deps {
+		if dep.Type != "" && !contains(dependencyTypes, dep.Type) {
+			return fmt.Errorf(
+				"invalid type %q for brew dependency %s, should be one of %s",
+				dep.Type, dep.Name, strings.Join(dependencyTypes, ", "),
+			)
+		}
+	}
+	return nil
+}
+
+// urlFor returns the url the archive is downloaded from, which defaults to
+// its release asset.
+func urlFor(ctx *context.Context, fields filenametemplate.Fields, artifact artifact.Artifact) (string, error) {
+	if ctx.Config.Brew.URLTemplate != "" {
+		return filenametemplate.Apply(ctx.Config.Brew.URLTemplate, fields)
+	}
+	return fmt.Sprintf(
+		"%s/%s/%s/releases/download/%s/%s",
+		ctx.Config.GitHubURLs.Download,
+		ctx.Config.Release.GitHub.Owner,
+		ctx.Config.Release.GitHub.Name,
+		ctx.Git.CurrentTag,
+		artifact.Name,
+	), nil
 }
 
 func split(s string) []string {
-	return strings.Split(strings.TrimSpace(s), "\n")
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// heredoc splits s in lines to be used inside a ruby heredoc, escaping
+// backslashes so they are not taken as escape sequences. Interpolations
+// like #{opt_bin} are kept as is.
+func heredoc(s string) []string {
+	var lines = split(s)
+	for i, line := range lines {
+		lines[i] = strings.Replace(line, `\`, `\\`, -1)
+	}
+	return lines
 }
 
+var (
+	classSeparatorRe = regexp.MustCompile(`[-_.\s]([a-zA-Z0-9])`)
+	classVersionRe   = regexp.MustCompile(`(.)@(\d)`)
+)
+
+// formulaNameFor converts the formula name to its ruby class name, the
+// same way homebrew does, e.g. openssl@1.1 becomes OpensslAT11.
 func formulaNameFor(name string) string {
-	name = strings.Replace(name, "-", " ", -1)
-	name = strings.Replace(name, "_", " ", -1)
-	return strings.Replace(strings.Title(name), " ", "", -1)
+	name = strings.ToLower(name)
+	if name != "" {
+		name = strings.ToUpper(name[:1]) + name[1:]
+	}
+	name = classSeparatorRe.ReplaceAllStringFunc(name, func(s string) string {
+		return strings.ToUpper(s[1:])
+	})
+	name = strings.Replace(name, "+", "x", -1)
+	name = classVersionRe.ReplaceAllString(name, "${1}AT${2}")
+	// ruby constants can't start with a digit
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "Formula" + name
+	}
+	return name
 }
@@ -7,10 +7,12 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/goreleaser/goreleaser/config"
 	"github.com/goreleaser/goreleaser/context"
 	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/client"
 	"github.com/goreleaser/goreleaser/internal/testlib"
 	"github.com/stretchr/testify/assert"
 )
@@ -33,19 +35,32 @@ func TestSimpleName(t *testing.T) {
 	assert.Equal(t, formulaNameFor("binary"), "Binary")
 }
 
+func TestFormulaNameFor(t *testing.T) {
+	for name, class := range map[string]string{
+		"go-jira":        "GoJira",
+		"my-tool2":       "MyTool2",
+		"x86_64-elf-gcc": "X8664ElfGcc",
+		"openssl@1.1":    "OpensslAT11",
+		"mytool@1":       "MytoolAT1",
+		"libxml++":       "Libxmlxx",
+		"a+b+c":          "Axbxc",
+		"node.js":        "NodeJs",
+		"MyTool":         "Mytool",
+		"4ti2":           "Formula4ti2",
+		"7zip":           "Formula7zip",
+	} {
+		assert.Equal(t, class, formulaNameFor(name), name)
+	}
+}
+
 var defaultTemplateData = templateData{
-	Desc:        "Some desc",
-	Homepage:    "https://google.com",
-	DownloadURL: "https://github.com",
-	Name:        "Test",
-	Repo: config.Repo{
-		Owner: "caarlos0",
-		Name:  "test",
-	},
-	Tag:     "v0.1.3",
-	Version: "0.1.3",
-	File:    "test_Darwin_x86_64.tar.gz",
-	SHA256:  "1633f61598ab0791e213135923624eb342196b3494909c91899bcd0560f84c68",
+	Desc:     "Some desc",
+	Homepage: "https://google.com",
+	URL:      "https://github.com/caarlos0/test/releases/download/v0.1.3/test_Darwin_x86_64.tar.gz",
+	Name:     "Test",
+	Tag:      "v0.1.3",
+	Version:  "0.1.3",
+	SHA256:   "1633f61598ab0791e213135923624eb342196b3494909c91899bcd0560f84c68",
 }
 
 func assertDefaultTemplateData(t *testing.T, formulae string) {
@@ -58,10 +73,14 @@ func assertDefaultTemplateData(t *testing.T, formulae string) {
 
 func TestFullFormulae(t *testing.T) {
 	data := defaultTemplateData
-	data.Caveats = "Here are some caveats"
-	data.Dependencies = []string{"gtk+"}
+	data.Caveats = []string{"Here are some caveats", "and some more"}
+	data.Dependencies = []config.HomebrewDependency{
+		{Name: "gtk+"},
+		{Name: "go", Type: "build"},
+	}
 	data.Conflicts = []string{"svn"}
-	data.Plist = "it works"
+	data.Plist = []string{"it works"}
+	data.Service = []string{`run [opt_bin/"foo", "serve"]`, "keep_alive true"}
 	data.Install = []string{"custom install script", "another install script"}
 	data.Tests = []string{`system "#{bin}/foo -version"`}
 	out, err := doBuildFormula(data)
@@ -85,11 +104,45 @@ func TestFormulaeSimple(t *testing.T) {
 	assert.NotContains(t, formulae, "def caveats")
 	assert.NotContains(t, formulae, "depends_on")
 	assert.NotContains(t, formulae, "def plist;")
+	assert.NotContains(t, formulae, "test do")
+}
+
+func TestHeredoc(t *testing.T) {
+	assert.Equal(t, []string{`a\\b`, `  #{bin}`}, heredoc("a\\b\n  #{bin}\n"))
+	assert.Empty(t, heredoc(""))
+}
+
+func TestMissingFiles(t *testing.T) {
+	var archive = artifact.Artifact{
+		Name: "bin.tar.gz",
+		Extra: map[string]interface{}{
+			"Files": []string{"README.md", "completions/foo.bash", "man/foo.1", "foo"},
+		},
+	}
+	assert.Empty(t, missingFiles([]string{
+		`bin.install "foo"`,
+		`bin.install "./foo" => "bar"`,
+		`bash_completion.install "completions/foo.bash"`,
+		`man1.install "man/foo.1"`,
+		`man.install "man"`,
+		`bin.install "#{name}"`,
+		`prefix.install_metafiles`,
+	}, archive))
+	assert.Equal(t, []string{"completions/foo.zsh", "foo.1"}, missingFiles([]string{
+		`zsh_completion.install "completions/foo.zsh"`,
+		`man1.install("foo.1")`,
+	}, archive))
+	assert.Empty(t, missingFiles(
+		[]string{`bin.install "nope"`},
+		artifact.Artifact{Name: "bin.tar.gz"},
+	))
 }
 
 func TestSplit(t *testing.T) {
 	var parts = split("system \"true\"\nsystem \"#{bin}/foo -h\"")
 	assert.Equal(t, []string{"system \"true\"", "system \"#{bin}/foo -h\""}, parts)
+	assert.Empty(t, split(""))
+	assert.Empty(t, split("  \n "))
 }
 
 func TestRunPipe(t *testing.T) {
@@ -118,14 +171,17 @@ func TestRunPipe(t *testing.T) {
 					Owner: "test",
 					Name:  "test",
 				},
-				Description:  "A run pipe test formula",
-				Homepage:     "https://github.com/goreleaser",
-				Caveats:      "don't do this",
-				Test:         "system \"true\"\nsystem \"#{bin}/foo -h\"",
-				Plist:        `<xml>whatever</xml>`,
-				Dependencies: []string{"zsh", "bash"},
-				Conflicts:    []string{"gtk+", "qt"},
-				Install:      `bin.install "foo"`,
+				Description: "A run pipe test formula",
+				Homepage:    "https://github.com/goreleaser",
+				Caveats:     "don't do this\nrun {{ .ProjectName }} init after upgrading to {{ .Version }}",
+				Test:        "system \"true\"\nassert_match \"{{ .Version }}\", shell_output(\"#{bin}/foo -v\")",
+				Plist:       "<xml>\n  <string>#{opt_bin}/foo</string>\n  <string>C:\\foo</string>\n</xml>",
+				Dependencies: []config.HomebrewDependency{
+					{Name: "zsh"},
+					{Name: "bash", Type: "recommended"},
+				},
+				Conflicts: []string{"gtk+", "qt"},
+				Install:   "bin.install \"foo\"\nman1.install \"{{ .ProjectName }}.1\"",
 			},
 		},
 		Publish: true,
@@ -199,6 +255,119 @@ func TestRunPipe(t *testing.T) {
 	})
 }
 
+func TestRunPipeMultiArch(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		Dist:        folder,
+		ProjectName: "multi-arch",
+		Release: config.Release{
+			GitHub: config.Repo{
+				Owner: "test",
+				Name:  "test",
+			},
+		},
+		GitHubURLs: config.GitHubURLs{
+			Download: "https://github.com",
+		},
+		Brew: config.Homebrew{
+			GitHub: config.Repo{
+				Owner: "test",
+				Name:  "test",
+			},
+			Description: "A multi arch formula",
+			Homepage:    "https://github.com/goreleaser",
+			Install:     `bin.install "foo"`,
+		},
+	})
+	ctx.Git.CurrentTag = "v1.0.1"
+	ctx.Version = "1.0.1"
+	ctx.Publish = true
+	for _, goarch := range []string{"amd64", "arm64"} {
+		var path = filepath.Join(folder, "bin_"+goarch+".tar.gz")
+		assert.NoError(t, ioutil.WriteFile(path, []byte(goarch), 0644))
+		ctx.Artifacts.Add(artifact.Artifact{
+			Name:   "bin_" + goarch + ".tar.gz",
+			Path:   path,
+			Goos:   "darwin",
+			Goarch: goarch,
+			Type:   artifact.UploadableArchive,
+		})
+	}
+
+	for golden, hardware := range map[string]bool{
+		"testdata/run_pipe_multiarch.rb.golden":          false,
+		"testdata/run_pipe_multiarch_hardware.rb.golden": true,
+	} {
+		t.Run(golden, func(tt *testing.T) {
+			ctx.Config.Brew.UseHardwareCPU = hardware
+			client := &DummyClient{}
+			assert.NoError(tt, doRun(ctx, client))
+			if *update {
+				ioutil.WriteFile(golden, []byte(client.Content), 0644)
+			}
+			bts, err := ioutil.ReadFile(golden)
+			assert.NoError(tt, err)
+			assert.Equal(tt, string(bts), client.Content)
+		})
+	}
+}
+
+func TestRunPipeArm64Only(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var path = filepath.Join(folder, "bin.tar.gz")
+	_, err = os.Create(path)
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		Dist:        folder,
+		ProjectName: "foo",
+		Brew: config.Homebrew{
+			GitHub: config.Repo{
+				Owner: "test",
+				Name:  "test",
+			},
+		},
+	})
+	ctx.Publish = true
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:   "bin.tar.gz",
+		Path:   path,
+		Goos:   "darwin",
+		Goarch: "arm64",
+		Type:   artifact.UploadableArchive,
+	})
+	client := &DummyClient{}
+	assert.NoError(t, doRun(ctx, client))
+	assert.Contains(t, client.Content, "  url \"")
+	assert.NotContains(t, client.Content, "on_arm")
+}
+
+func TestRunPipeStoredChecksum(t *testing.T) {
+	var ctx = context.New(config.Project{
+		ProjectName: "foo",
+		Brew: config.Homebrew{
+			GitHub: config.Repo{
+				Owner: "test",
+				Name:  "test",
+			},
+		},
+	})
+	ctx.Publish = true
+	// the archive isn't read when the checksums pipe recorded its sha256
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:      "bin.tar.gz",
+		Path:      "/does/not/exist/bin.tar.gz",
+		Goos:      "darwin",
+		Goarch:    "amd64",
+		Type:      artifact.UploadableArchive,
+		Checksums: map[string]string{"sha256": "0123456789abcdef"},
+	})
+	client := &DummyClient{}
+	assert.NoError(t, doRun(ctx, client))
+	assert.Contains(t, client.Content, `sha256 "0123456789abcdef"`)
+}
+
 func TestRunPipeNoDarwin64Build(t *testing.T) {
 	var ctx = &context.Context{
 		Config: config.Project{
@@ -215,7 +384,36 @@ func TestRunPipeNoDarwin64Build(t *testing.T) {
 		Publish: true,
 	}
 	client := &DummyClient{}
-	assert.Equal(t, ErrNoDarwin64Build, doRun(ctx, client))
+	var err = doRun(ctx, client)
+	assert.Equal(t, ErrNoDarwin64Build, err.(ErrArchives).Reason)
+	assert.EqualError(t, err, "brew tap requires one darwin amd64 or arm64 build, looked for archives for darwin/amd64 and darwin/arm64, found: none")
+	assert.False(t, client.CreatedFile)
+}
+
+func TestRunPipeOnlyOtherPlatforms(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Brew: config.Homebrew{
+			GitHub: config.Repo{
+				Owner: "test",
+				Name:  "test",
+			},
+		},
+	})
+	ctx.Publish = true
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:   "bin_linux.tar.gz",
+		Goos:   "linux",
+		Goarch: "amd64",
+		Type:   artifact.UploadableArchive,
+	})
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:   "bin_darwin",
+		Goos:   "darwin",
+		Goarch: "amd64",
+		Type:   artifact.Binary,
+	})
+	client := &DummyClient{}
+	assert.EqualError(t, doRun(ctx, client), "brew tap requires one darwin amd64 or arm64 build, looked for archives for darwin/amd64 and darwin/arm64, found: bin_linux.tar.gz (linux/amd64)")
 	assert.False(t, client.CreatedFile)
 }
 
@@ -249,7 +447,67 @@ func TestRunPipeMultipleDarwin64Build(t *testing.T) {
 		Type:   artifact.UploadableArchive,
 	})
 	client := &DummyClient{}
-	assert.Equal(t, ErrTooManyDarwin64Builds, doRun(ctx, client))
+	var err = doRun(ctx, client)
+	assert.Equal(t, ErrTooManyDarwin64Builds, err.(ErrArchives).Reason)
+	assert.EqualError(t, err, "brew tap requires at most one darwin build per architecture, looked for archives for darwin/amd64, found: bin1 (darwin/amd64), bin2 (darwin/amd64)")
+	assert.False(t, client.CreatedFile)
+}
+
+func TestRunPipeDarwin64BuildByIDs(t *testing.T) {
+	var ctx = context.New(
+		config.Project{
+			Archive: config.Archive{
+				Format: "tar.gz",
+			},
+			Brew: config.Homebrew{
+				GitHub: config.Repo{
+					Owner: "test",
+					Name:  "test",
+				},
+				IDs: []string{"bar"},
+			},
+		},
+	)
+	ctx.Publish = true
+	for _, id := range []string{"foo", "bar"} {
+		ctx.Artifacts.Add(artifact.Artifact{
+			Name:      "bin_" + id + ".tar.gz",
+			ID:        id,
+			Goos:      "darwin",
+			Goarch:    "amd64",
+			Type:      artifact.UploadableArchive,
+			Checksums: map[string]string{"sha256": "0123456789abcdef"},
+		})
+	}
+	client := &DummyClient{}
+	assert.NoError(t, doRun(ctx, client))
+	assert.True(t, client.CreatedFile)
+	assert.Contains(t, client.Content, "bin_bar.tar.gz")
+	assert.NotContains(t, client.Content, "bin_foo.tar.gz")
+}
+
+func TestRunPipeNoDarwin64BuildByIDs(t *testing.T) {
+	var ctx = context.New(
+		config.Project{
+			Brew: config.Homebrew{
+				GitHub: config.Repo{
+					Owner: "test",
+					Name:  "test",
+				},
+				IDs: []string{"nope"},
+			},
+		},
+	)
+	ctx.Publish = true
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:   "bin.tar.gz",
+		ID:     "foo",
+		Goos:   "darwin",
+		Goarch: "amd64",
+		Type:   artifact.UploadableArchive,
+	})
+	client := &DummyClient{}
+	assert.EqualError(t, doRun(ctx, client), "brew tap requires one darwin amd64 or arm64 build, looked for archives for darwin/amd64 and darwin/arm64 with the ids nope, found: bin.tar.gz (darwin/amd64)")
 	assert.False(t, client.CreatedFile)
 }
 
@@ -322,11 +580,18 @@ func TestRunPipeNoUpload(t *testing.T) {
 	}
 	t.Run("skip upload", func(tt *testing.T) {
 		ctx.Publish = true
-		ctx.Config.Brew.SkipUpload = true
+		ctx.Config.Brew.SkipUpload = "true"
+		assertNoPublish(tt)
+	})
+	t.Run("skip upload auto on prerelease", func(tt *testing.T) {
+		ctx.Publish = true
+		ctx.Config.Brew.SkipUpload = "auto"
+		ctx.Semver = context.Semver{Major: "1", Minor: "0", Patch: "0", Prerelease: "rc1"}
 		assertNoPublish(tt)
 	})
 	t.Run("skip publish", func(tt *testing.T) {
 		ctx.Publish = false
+		ctx.Semver = context.Semver{}
 		assertNoPublish(tt)
 	})
 	t.Run("draft release", func(tt *testing.T) {
@@ -334,6 +599,213 @@ func TestRunPipeNoUpload(t *testing.T) {
 		ctx.Config.Release.Draft = true
 		assertNoPublish(tt)
 	})
+	bts, err := ioutil.ReadFile(filepath.Join(folder, "foo.rb"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(bts), "test do")
+}
+
+func TestRunPipeUploadAuto(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		Dist:        folder,
+		ProjectName: "foo",
+		Brew: config.Homebrew{
+			GitHub: config.Repo{
+				Owner: "test",
+				Name:  "test",
+			},
+			SkipUpload:            "auto",
+			CommitMessageTemplate: "{{ .ProjectName }} {{ .Version }}",
+			CommitAuthor: config.CommitAuthor{
+				Name:  "bot",
+				Email: "bot@example.com",
+			},
+		},
+	})
+	ctx.Publish = true
+	ctx.Version = "1.2.3"
+	var path = filepath.Join(folder, "whatever.tar.gz")
+	_, err = os.Create(path)
+	assert.NoError(t, err)
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:   "bin",
+		Path:   path,
+		Goos:   "darwin",
+		Goarch: "amd64",
+		Type:   artifact.UploadableArchive,
+	})
+	client := &DummyClient{}
+	assert.NoError(t, doRun(ctx, client))
+	assert.True(t, client.CreatedFile)
+	assert.Equal(t, "foo 1.2.3", client.Message)
+	assert.Equal(t, "foo.rb", client.Path)
+	assert.Equal(t, "bot", client.Author.Name)
+	assert.Equal(t, "bot@example.com", client.Author.Email)
+	var formulas = ctx.Artifacts.Filter(artifact.ByType(artifact.BrewTap)).List()
+	assert.Len(t, formulas, 1)
+	assert.Equal(t, "foo.rb", formulas[0].Name)
+	assert.Equal(t, filepath.Join(folder, "foo.rb"), formulas[0].Path)
+}
+
+func TestRunPipeURLTemplate(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var path = filepath.Join(folder, "bin.tar.gz")
+	_, err = os.Create(path)
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		Dist:        folder,
+		ProjectName: "foo",
+		Brew: config.Homebrew{
+			GitHub: config.Repo{
+				Owner: "test",
+				Name:  "test",
+			},
+			URLTemplate:      "https://dl.example.com/{{ .ProjectName }}/{{ .Tag }}/{{ .ArtifactName }}",
+			DownloadStrategy: "CustomDownloadStrategy",
+		},
+	})
+	ctx.Git.CurrentTag = "v1.2.3"
+	ctx.Publish = true
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:   "bin.tar.gz",
+		Path:   path,
+		Goos:   "darwin",
+		Goarch: "amd64",
+		Type:   artifact.UploadableArchive,
+	})
+	client := &DummyClient{}
+	assert.NoError(t, doRun(ctx, client))
+	assert.Contains(t, client.Content, `url "https://dl.example.com/foo/v1.2.3/bin.tar.gz", :using => CustomDownloadStrategy`)
+	assert.Contains(t, client.Content, `sha256 "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"`)
+
+	ctx.Config.Brew.URLTemplate = "{{ .Nope }}"
+	assert.Error(t, doRun(ctx, client))
+}
+
+func TestRunPipeGitLabTap(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var path = filepath.Join(folder, "bin.tar.gz")
+	_, err = os.Create(path)
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		Dist:        folder,
+		ProjectName: "foo",
+		Release: config.Release{
+			GitHub: config.Repo{
+				Owner: "test",
+				Name:  "test",
+			},
+		},
+		GitHubURLs: config.GitHubURLs{
+			Download: "https://github.example.com",
+		},
+		Brew: config.Homebrew{
+			GitLab: config.Repo{
+				Owner: "group/subgroup",
+				Name:  "tap",
+			},
+		},
+	})
+	ctx.Git.CurrentTag = "v1.0.0"
+	ctx.Publish = true
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:   "bin.tar.gz",
+		Path:   path,
+		Goos:   "darwin",
+		Goarch: "amd64",
+		Type:   artifact.UploadableArchive,
+	})
+	client := &DummyClient{}
+	assert.NoError(t, doRun(ctx, client))
+	assert.Equal(t, "group/subgroup/tap", client.Repo.String())
+	assert.Contains(t, client.Content, `url "https://github.example.com/test/test/releases/download/v1.0.0/bin.tar.gz"`)
+}
+
+func TestRunPipeFolder(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var path = filepath.Join(folder, "bin.tar.gz")
+	_, err = os.Create(path)
+	assert.NoError(t, err)
+	for tap, expected := range map[string]string{
+		"":                   "foo.rb",
+		"Formula":            "Formula/foo.rb",
+		"Formula/":           "Formula/foo.rb",
+		"Formula/tools/cli/": "Formula/tools/cli/foo.rb",
+	} {
+		var ctx = context.New(config.Project{
+			Dist:        folder,
+			ProjectName: "Foo",
+			Brew: config.Homebrew{
+				GitHub: config.Repo{
+					Owner: "test",
+					Name:  "test",
+				},
+				Folder: tap,
+			},
+		})
+		ctx.Publish = true
+		ctx.Artifacts.Add(artifact.Artifact{
+			Name:   "bin.tar.gz",
+			Path:   path,
+			Goos:   "darwin",
+			Goarch: "amd64",
+			Type:   artifact.UploadableArchive,
+		})
+		client := &DummyClient{}
+		assert.NoError(t, doRun(ctx, client))
+		assert.Equal(t, expected, client.Path)
+		// the formula is always written to the root of the dist folder
+		_, err := os.Stat(filepath.Join(folder, "foo.rb"))
+		assert.NoError(t, err)
+	}
+}
+
+func TestRunPipeInvalidTemplates(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var path = filepath.Join(folder, "bin.tar.gz")
+	_, err = os.Create(path)
+	assert.NoError(t, err)
+	for name, brew := range map[string]config.Homebrew{
+		"description": {Description: "{{ .Nope }}"},
+		"homepage":    {Homepage: "{{ .Nope }}"},
+		"caveats":     {Caveats: "{{ .Nope"},
+		"test":        {Test: "{{ .Nope }}"},
+		"install":     {Install: "{{ .Nope }}"},
+		"commit message": {
+			CommitMessageTemplate: "{{ .Nope }}",
+		},
+		"dependency type": {Dependencies: []config.HomebrewDependency{
+			{Name: "git", Type: "runtime"},
+		}},
+	} {
+		t.Run(name, func(tt *testing.T) {
+			brew.GitHub = config.Repo{
+				Owner: "test",
+				Name:  "test",
+			}
+			var ctx = context.New(config.Project{
+				Dist:        folder,
+				ProjectName: "foo",
+				Brew:        brew,
+			})
+			ctx.Artifacts.Add(artifact.Artifact{
+				Name:   "bin.tar.gz",
+				Path:   path,
+				Goos:   "darwin",
+				Goarch: "amd64",
+				Type:   artifact.UploadableArchive,
+			})
+			ctx.Publish = true
+			client := &DummyClient{}
+			assert.Error(tt, doRun(ctx, client))
+			assert.False(tt, client.CreatedFile)
+		})
+	}
 }
 
 func TestRunPipeFormatBinary(t *testing.T) {
@@ -374,26 +846,44 @@ func TestDefault(t *testing.T) {
 					Goos:   []string{"linux"},
 					Goarch: []string{"amd64"},
 				},
+				{
+					Binary: "baz",
+					Goos:   []string{"darwin"},
+					Goarch: []string{"amd64", "arm64"},
+					Ignore: []config.IgnoredBuild{
+						{Goos: "darwin", Goarch: "amd64"},
+					},
+				},
 			},
 		},
 	}
 	assert.NoError(t, Pipe{}.Default(ctx))
 	assert.NotEmpty(t, ctx.Config.Brew.CommitAuthor.Name)
 	assert.NotEmpty(t, ctx.Config.Brew.CommitAuthor.Email)
-	assert.Equal(t, `bin.install "foo"`, ctx.Config.Brew.Install)
+	assert.Equal(t, "bin.install \"foo\"\nbin.install \"baz\"", ctx.Config.Brew.Install)
+	assert.Equal(t, "{{ .ProjectName }} version {{ .Tag }}", ctx.Config.Brew.CommitMessageTemplate)
 }
 
 type DummyClient struct {
 	CreatedFile bool
 	Content     string
+	Author      config.CommitAuthor
+	Message     string
+	Path        string
+	Formula     string
+	Repo        config.Repo
 }
 
 func (client *DummyClient) CreateRelease(ctx *context.Context, body string) (releaseID int64, err error) {
 	return
 }
 
-func (client *DummyClient) CreateFile(ctx *context.Context, commitAuthor config.CommitAuthor, repo config.Repo, content bytes.Buffer, path string) (err error) {
+func (client *DummyClient) CreateFile(ctx *context.Context, commitAuthor config.CommitAuthor, repo config.Repo, content bytes.Buffer, path, message string) (err error) {
 	client.CreatedFile = true
+	client.Author = commitAuthor
+	client.Message = message
+	client.Path = path
+	client.Repo = repo
 	bts, _ := ioutil.ReadAll(&content)
 	client.Content = string(bts)
 	return
@@ -402,3 +892,11 @@ func (client *DummyClient) CreateFile(ctx *context.Context, commitAuthor config.
 func (client *DummyClient) Upload(ctx *context.Context, releaseID int64, name string, file *os.File) (err error) {
 	return
 }
+
+func (client *DummyClient) MergedPullRequests(ctx *context.Context, repo config.Repo, since time.Time) (prs []client.PullRequest, err error) {
+	return
+}
+
+func (client *DummyClient) GetFile(ctx *context.Context, repo config.Repo, path string) (content []byte, err error) {
+	return []byte(client.Formula), nil
+}
@@ -0,0 +1,85 @@
+package brew
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"text/template"
+
+	"github.com/apex/log"
+
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/client"
+)
+
+// ErrNoStableStanza happens when the devel block can't be added to the
+// formula in the tap because the end of its stable stanza wasn't found
+var ErrNoStableStanza = errors.New("could not find the stable stanza in the current formula")
+
+// the on_arm and on_intel blocks can't be used inside a devel block, so
+// the hardware check is always used there.
+const develTemplate = `  devel do
+    {{- if .URL }}
+    url "{{ .URL }}"
+    {{- if .DownloadStrategy }}, :using => {{ .DownloadStrategy }}{{- end }}
+    {{- end }}
+    version "{{ .Version }}"
+    {{- if .SHA256 }}
+    sha256 "{{ .SHA256 }}"
+    {{- end }}
+    {{- if and .Arm .Intel }}
+    if Hardware::CPU.arm?
+      url "{{ .Arm.URL }}"
+      {{- if .DownloadStrategy }}, :using => {{ .DownloadStrategy }}{{- end }}
+      sha256 "{{ .Arm.SHA256 }}"
+    else
+      url "{{ .Intel.URL }}"
+      {{- if .DownloadStrategy }}, :using => {{ .DownloadStrategy }}{{- end }}
+      sha256 "{{ .Intel.SHA256 }}"
+    end
+    {{- end }}
+  end
+`
+
+var (
+	develRe  = regexp.MustCompile(`(?ms)^  devel do\n.*?^  end\n`)
+	stableRe = regexp.MustCompile(`(?m)^  (depends_on|conflicts_with|def install)\b`)
+)
+
+// buildDevelFormula only replaces the devel block of the formula currently
+// in the tap, keeping its stable stanza.
+func buildDevelFormula(ctx *context.Context, client client.Client, data templateData) (out bytes.Buffer, err error) {
+	current, err := client.GetFile(ctx, tapRepo(ctx), tapPath(ctx))
+	if err != nil {
+		return
+	}
+	if len(current) == 0 {
+		log.Warn("no formula in the tap yet, the prerelease will be the stable version")
+		return doBuildFormula(data)
+	}
+	tmpl, err := template.New(data.Name).Parse(develTemplate)
+	if err != nil {
+		return
+	}
+	var devel bytes.Buffer
+	if err = tmpl.Execute(&devel, data); err != nil {
+		return
+	}
+	formula, err := mergeDevel(string(current), devel.String())
+	if err != nil {
+		return
+	}
+	_, err = out.WriteString(formula)
+	return
+}
+
+func mergeDevel(formula, devel string) (string, error) {
+	if develRe.MatchString(formula) {
+		return develRe.ReplaceAllLiteralString(formula, devel), nil
+	}
+	var loc = stableRe.FindStringIndex(formula)
+	if loc == nil {
+		return "", ErrNoStableStanza
+	}
+	return formula[:loc[0]] + devel + "\n" + formula[loc[0]:], nil
+}
@@ -0,0 +1,113 @@
+package brew
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeDevel(t *testing.T) {
+	var devel = "  devel do\n    version \"2.0.0-rc1\"\n  end\n"
+	var stable = "class Foo < Formula\n  version \"1.0.0\"\n  sha256 \"abc\"\n\n  def install\n  end\nend\n"
+	merged, err := mergeDevel(stable, devel)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"class Foo < Formula\n  version \"1.0.0\"\n  sha256 \"abc\"\n\n"+devel+"\n  def install\n  end\nend\n",
+		merged,
+	)
+
+	var newDevel = "  devel do\n    version \"2.0.0-rc2\"\n  end\n"
+	replaced, err := mergeDevel(merged, newDevel)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"class Foo < Formula\n  version \"1.0.0\"\n  sha256 \"abc\"\n\n"+newDevel+"\n  def install\n  end\nend\n",
+		replaced,
+	)
+
+	_, err = mergeDevel("class Foo < Formula\nend\n", devel)
+	assert.EqualError(t, err, ErrNoStableStanza.Error())
+}
+
+func TestRunPipeDevel(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var path = filepath.Join(folder, "bin.tar.gz")
+	_, err = os.Create(path)
+	assert.NoError(t, err)
+	stable, err := ioutil.ReadFile("testdata/run_pipe.rb.golden")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		Dist:        folder,
+		ProjectName: "run-pipe",
+		Release: config.Release{
+			GitHub: config.Repo{
+				Owner: "test",
+				Name:  "test",
+			},
+		},
+		GitHubURLs: config.GitHubURLs{
+			Download: "https://github.com",
+		},
+		Brew: config.Homebrew{
+			GitHub: config.Repo{
+				Owner: "test",
+				Name:  "test",
+			},
+			Devel: true,
+		},
+	})
+	ctx.Git.CurrentTag = "v1.1.0-rc1"
+	ctx.Version = "1.1.0-rc1"
+	ctx.Semver = context.Semver{Major: "1", Minor: "1", Patch: "0", Prerelease: "rc1"}
+	ctx.Publish = true
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:   "bin.tar.gz",
+		Path:   path,
+		Goos:   "darwin",
+		Goarch: "amd64",
+		Type:   artifact.UploadableArchive,
+	})
+
+	t.Run("add devel block", func(tt *testing.T) {
+		client := &DummyClient{Formula: string(stable)}
+		assert.NoError(tt, doRun(ctx, client))
+		var golden = "testdata/run_pipe_devel.rb.golden"
+		if *update {
+			ioutil.WriteFile(golden, []byte(client.Content), 0644)
+		}
+		bts, err := ioutil.ReadFile(golden)
+		assert.NoError(tt, err)
+		assert.Equal(tt, string(bts), client.Content)
+	})
+
+	t.Run("replace devel block", func(tt *testing.T) {
+		bts, err := ioutil.ReadFile("testdata/run_pipe_devel.rb.golden")
+		assert.NoError(tt, err)
+		client := &DummyClient{Formula: string(bts)}
+		assert.NoError(tt, doRun(ctx, client))
+		assert.Equal(tt, string(bts), client.Content)
+	})
+
+	t.Run("no formula in the tap", func(tt *testing.T) {
+		client := &DummyClient{}
+		assert.NoError(tt, doRun(ctx, client))
+		assert.NotContains(tt, client.Content, "devel do")
+		assert.Contains(tt, client.Content, `version "1.1.0-rc1"`)
+	})
+
+	t.Run("devel disabled", func(tt *testing.T) {
+		ctx.Config.Brew.Devel = false
+		client := &DummyClient{Formula: string(stable)}
+		assert.NoError(tt, doRun(ctx, client))
+		assert.NotContains(tt, client.Content, "devel do")
+		assert.NotContains(tt, client.Content, "def caveats")
+	})
+}
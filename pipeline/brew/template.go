@@ -6,37 +6,80 @@ type templateData struct {
 	Name             string
 	Desc             string
 	Homepage         string
-	DownloadURL      string
-	Repo             config.Repo // FIXME: will not work for anything but github right now.
+	URL              string
 	Tag              string
 	Version          string
-	Caveats          string
-	File             string
+	Caveats          []string
 	SHA256           string
-	Plist            string
+	Plist            []string
 	DownloadStrategy string
 	Install          []string
-	Dependencies     []string
+	Dependencies     []config.HomebrewDependency
 	Conflicts        []string
 	Tests            []string
+	Service          []string
+	Arm              *download
+	Intel            *download
+	HardwareCPU      bool
+}
+
+// download is the archive of one architecture, when the formula has more
+// than one.
+type download struct {
+	URL    string
+	SHA256 string
 }
 
 const formulaTemplate = `class {{ .Name }} < Formula
   desc "{{ .Desc }}"
   homepage "{{ .Homepage }}"
-  url "{{ .DownloadURL }}/{{ .Repo.Owner }}/{{ .Repo.Name }}/releases/download/{{ .Tag }}/{{ .File }}"
+  {{- if .URL }}
+  url "{{ .URL }}"
   {{- if .DownloadStrategy }}, :using => {{ .DownloadStrategy }}{{- end }}
+  {{- end }}
   version "{{ .Version }}"
+  {{- if .SHA256 }}
   sha256 "{{ .SHA256 }}"
+  {{- end }}
+
+  {{- if and .Arm .Intel }}
+  {{- if .HardwareCPU }}
+
+  if Hardware::CPU.arm?
+    url "{{ .Arm.URL }}"
+    {{- if .DownloadStrategy }}, :using => {{ .DownloadStrategy }}{{- end }}
+    sha256 "{{ .Arm.SHA256 }}"
+  else
+    url "{{ .Intel.URL }}"
+    {{- if .DownloadStrategy }}, :using => {{ .DownloadStrategy }}{{- end }}
+    sha256 "{{ .Intel.SHA256 }}"
+  end
+  {{- else }}
+
+  on_macos do
+    on_arm do
+      url "{{ .Arm.URL }}"
+      {{- if .DownloadStrategy }}, :using => {{ .DownloadStrategy }}{{- end }}
+      sha256 "{{ .Arm.SHA256 }}"
+    end
+    on_intel do
+      url "{{ .Intel.URL }}"
+      {{- if .DownloadStrategy }}, :using => {{ .DownloadStrategy }}{{- end }}
+      sha256 "{{ .Intel.SHA256 }}"
+    end
+  end
+  {{- end }}
+  {{- end }}
 
   {{- if .Dependencies }}
-  {{ range $index, $element := .Dependencies }}
-  depends_on "{{ . }}"
+{{ range $index, $element := .Dependencies }}
+  depends_on "{{ .Name }}"
+  {{- with .Type }} => :{{ . }}{{ end }}
   {{- end }}
   {{- end -}}
 
   {{- if .Conflicts }}
-  {{ range $index, $element := .Conflicts }}
+{{ range $index, $element := .Conflicts }}
   conflicts_with "{{ . }}"
   {{- end }}
   {{- end }}
@@ -49,8 +92,11 @@ const formulaTemplate = `class {{ .Name }} < Formula
 
   {{- if .Caveats }}
 
-  def caveats
-    "{{ .Caveats }}"
+  def caveats; <<~EOS
+    {{- range $index, $element := .Caveats }}
+    {{ . -}}
+    {{- end }}
+    EOS
   end
   {{- end -}}
 
@@ -59,11 +105,22 @@ const formulaTemplate = `class {{ .Name }} < Formula
   plist_options :startup => false
 
   def plist; <<~EOS
-    {{ .Plist }}
+    {{- range $index, $element := .Plist }}
+    {{ . -}}
+    {{- end }}
     EOS
   end
   {{- end -}}
 
+  {{- if .Service }}
+
+  service do
+    {{- range $index, $element := .Service }}
+    {{ . -}}
+    {{- end }}
+  end
+  {{- end -}}
+
   {{- if .Tests }}
 
   test do
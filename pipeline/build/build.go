@@ -51,6 +51,16 @@ func (Pipe) Default(ctx *context.Context) error {
 			buildWithDefaults(ctx, ctx.Config.SingleBuild),
 		}
 	}
+	var ids = map[string]int{}
+	for _, build := range ctx.Config.Builds {
+		if build.ID == "" {
+			continue
+		}
+		ids[build.ID]++
+		if ids[build.ID] == 2 {
+			return errors.Errorf("found multiple builds with the ID '%s', build IDs must be unique", build.ID)
+		}
+	}
 	return nil
 }
 
@@ -121,17 +131,33 @@ func doBuild(ctx *context.Context, build config.Build, target string) error {
 
 func binary(ctx *context.Context, build config.Build) (string, error) {
 	var data = struct {
-		Commit  string
-		Tag     string
-		Version string
-		Date    string
-		Env     map[string]string
+		Commit          string
+		Tag             string
+		Version         string
+		Date            string
+		CommitDate      string
+		CommitTimestamp int64
+		Major           string
+		Minor           string
+		Patch           string
+		Prerelease      string
+		IsSnapshot      bool
+		IsPrerelease    bool
+		Env             map[string]string
 	}{
-		Commit:  ctx.Git.Commit,
-		Tag:     ctx.Git.CurrentTag,
-		Version: ctx.Version,
-		Date:    time.Now().UTC().Format(time.RFC3339),
-		Env:     ctx.Env,
+		Commit:          ctx.Git.Commit,
+		Tag:             ctx.Git.CurrentTag,
+		Version:         ctx.Version,
+		Major:           ctx.Semver.Major,
+		Minor:           ctx.Semver.Minor,
+		Patch:           ctx.Semver.Patch,
+		Prerelease:      ctx.Semver.Prerelease,
+		IsSnapshot:      ctx.Snapshot,
+		IsPrerelease:    ctx.Semver.Prerelease != "",
+		Date:            ctx.Date.UTC().Format(time.RFC3339),
+		CommitDate:      ctx.Git.CommitDate.UTC().Format(time.RFC3339),
+		CommitTimestamp: ctx.Git.CommitDate.Unix(),
+		Env:             ctx.Env,
 	}
 	var out bytes.Buffer
 	t, err := template.New("binary").
@@ -320,3 +320,16 @@ func exists(file string) bool {
 	_, err := os.Stat(file)
 	return !os.IsNotExist(err)
 }
+
+func TestDefaultDuplicateIDs(t *testing.T) {
+	var ctx = &context.Context{
+		Config: config.Project{
+			Builds: []config.Build{
+				{ID: "cli", Binary: "foo"},
+				{Binary: "bar"},
+				{ID: "cli", Binary: "baz"},
+			},
+		},
+	}
+	assert.EqualError(t, Pipe{}.Default(ctx), "found multiple builds with the ID 'cli', build IDs must be unique")
+}
@@ -2,20 +2,47 @@
 package changelog
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
+	"github.com/apex/log"
+	"github.com/goreleaser/goreleaser/config"
 	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/client"
 	"github.com/goreleaser/goreleaser/internal/git"
 	"github.com/goreleaser/goreleaser/pipeline"
+	"github.com/masterminds/semver"
 )
 
 // ErrInvalidSortDirection happens when the sort order is invalid
 var ErrInvalidSortDirection = errors.New("invalid sort direction")
 
+// ErrInvalidMerges happens when the merges mode is invalid
+var ErrInvalidMerges = errors.New("invalid merges mode, must be one of include, exclude or only")
+
+// ErrInvalidUse happens when the changelog source is invalid
+var ErrInvalidUse = errors.New("invalid changelog source, must be either git or github, use changelog.previous_tag to build the changelog from a given tag or ref")
+
+// ErrInvalidPreviousTag happens when the previous tag given to build the
+// changelog doesn't exist
+type ErrInvalidPreviousTag struct {
+	tag string
+}
+
+func (e ErrInvalidPreviousTag) Error() string {
+	return fmt.Sprintf("previous tag %v does not exist in the repository", e.tag)
+}
+
 // Pipe for checksums
 type Pipe struct{}
 
@@ -23,25 +50,123 @@ func (Pipe) String() string {
 	return "generating changelog"
 }
 
+// Default sets the pipe defaults
+func (Pipe) Default(ctx *context.Context) error {
+	if ctx.Config.Changelog.Format == "" {
+		ctx.Config.Changelog.Format = "{{ .ShortSHA }} {{ .Subject }}"
+		if ctx.Config.Changelog.Use == "github" {
+			ctx.Config.Changelog.Format = "{{ .ShortSHA }} {{ .Subject }}" +
+				"{{ with .Number }} ([#{{ . }}]({{ $.URL }})) @{{ $.AuthorName }}{{ end }}"
+		}
+	}
+	if ctx.Config.Changelog.NameTemplate == "" {
+		ctx.Config.Changelog.NameTemplate = "CHANGELOG.md"
+	}
+	return nil
+}
+
 // Run the pipe
 func (Pipe) Run(ctx *context.Context) error {
+	c, err := client.NewGitHub(ctx)
+	if err != nil {
+		return err
+	}
+	return doRun(ctx, c)
+}
+
+func doRun(ctx *context.Context, c client.Client) error {
 	if ctx.ReleaseNotes != "" {
+		if err := write(ctx); err != nil {
+			return err
+		}
 		return pipeline.Skip("release notes already provided via --release-notes")
 	}
 	if ctx.Snapshot {
 		return pipeline.Skip("not available for snapshots")
 	}
+	if ctx.Config.Changelog.Skip {
+		return pipeline.Skip("changelog.skip is set")
+	}
 	if err := checkSortDirection(ctx.Config.Changelog.Sort); err != nil {
 		return err
 	}
-	entries, err := buildChangelog(ctx)
+	if err := checkMerges(ctx.Config.Changelog.Merges); err != nil {
+		return err
+	}
+	if err := checkUse(ctx.Config.Changelog.Use); err != nil {
+		return err
+	}
+	tmpl, err := template.New("changelog").Option("missingkey=error").Parse(ctx.Config.Changelog.Format)
+	if err != nil {
+		return err
+	}
+	entries, err := buildChangelog(ctx, c)
 	if err != nil {
 		return err
 	}
-	ctx.ReleaseNotes = fmt.Sprintf("## Changelog\n\n%v", strings.Join(entries, "\n"))
+	notes, err := formatChangelog(ctx, tmpl, entries)
+	if err != nil {
+		return err
+	}
+	if url := compareURL(ctx); url != "" {
+		notes = fmt.Sprintf("%s\n\n**Full Changelog**: %s", notes, url)
+	}
+	ctx.ReleaseNotes = notes
+	return write(ctx)
+}
+
+// write writes the release notes to the dist folder and adds the file to
+// the artifacts list
+func write(ctx *context.Context) error {
+	filename, err := filenameFor(ctx)
+	if err != nil {
+		return err
+	}
+	var path = filepath.Join(ctx.Config.Dist, filename)
+	log.WithField("file", path).Info("writing")
+	if err := ioutil.WriteFile(path, []byte(ctx.ReleaseNotes), 0644); err != nil {
+		return err
+	}
+	ctx.Artifacts.Add(artifact.Artifact{
+		Type: artifact.Changelog,
+		Path: path,
+		Name: filename,
+	})
 	return nil
 }
 
+// compareURL returns the GitHub URL comparing the previous and current tags,
+// or an empty string if there is no previous tag to compare with
+func compareURL(ctx *context.Context) string {
+	var repo = ctx.Config.Release.GitHub.String()
+	if ctx.Git.PreviousTag == "" || repo == "" {
+		return ""
+	}
+	return fmt.Sprintf(
+		"%s/%s/compare/%s...%s",
+		ctx.Config.GitHubURLs.Download,
+		repo,
+		ctx.Git.PreviousTag,
+		ctx.Git.CurrentTag,
+	)
+}
+
+func checkUse(mode string) error {
+	switch mode {
+	case "", "git", "github":
+		return nil
+	}
+	return ErrInvalidUse
+}
+
+func checkMerges(mode string) error {
+	switch mode {
+	case "", "include", "exclude", "only":
+		return nil
+	}
+	return ErrInvalidMerges
+}
+
 func checkSortDirection(mode string) error {
 	switch mode {
 	case "":
@@ -54,21 +179,167 @@ func checkSortDirection(mode string) error {
 	return ErrInvalidSortDirection
 }
 
-func buildChangelog(ctx *context.Context) ([]string, error) {
-	log, err := getChangelog(ctx.Git.CurrentTag)
+func buildChangelog(ctx *context.Context, c client.Client) ([]entry, error) {
+	log, err := getChangelog(ctx)
 	if err != nil {
 		return nil, err
 	}
-	var entries = strings.Split(log, "\n")
-	entries = entries[0 : len(entries)-1]
+	var lines = strings.Split(log, "\n")
+	var entries []entry
+	for _, line := range lines[0 : len(lines)-1] {
+		entries = append(entries, parseEntry(line))
+	}
+	if ctx.Config.Changelog.SkipCherryPicks {
+		entries, err = removeCherryPicks(ctx, entries)
+		if err != nil {
+			return entries, err
+		}
+	}
+	if ctx.Config.Changelog.Use == "github" {
+		entries = pullRequestEntries(ctx, c, entries)
+	}
 	entries, err = filterEntries(ctx, entries)
 	if err != nil {
 		return entries, err
 	}
+	if ctx.Config.Changelog.Dedup {
+		entries = dedup(entries)
+	}
 	return sortEntries(ctx, entries), nil
 }
 
-func filterEntries(ctx *context.Context, entries []string) ([]string, error) {
+var (
+	cherryPickedRegexp = regexp.MustCompile(`(?i)\s*\(cherry picked from commit [0-9a-f]+\)\s*$`)
+	backportRegexp     = regexp.MustCompile(`(?i)^\s*\[backport\]\s*`)
+)
+
+// dedup removes the entries whose normalized subject was already seen,
+// keeping the first one
+func dedup(entries []entry) []entry {
+	var seen = map[string]bool{}
+	var result []entry
+	for _, entry := range entries {
+		var subject = cherryPickedRegexp.ReplaceAllString(entry.Subject, "")
+		subject = strings.TrimSpace(backportRegexp.ReplaceAllString(subject, ""))
+		if seen[subject] {
+			continue
+		}
+		seen[subject] = true
+		result = append(result, entry)
+	}
+	return result
+}
+
+// removeCherryPicks removes the entries whose patch was already released from
+// another branch, according to git cherry: they are compared with each tag
+// that isn't in the current tag history, like the tags of the other release
+// branches the commits were cherry-picked to or from.
+func removeCherryPicks(ctx *context.Context, entries []entry) ([]entry, error) {
+	out, err := git.Run("tag", "--no-merged", ctx.Git.CurrentTag)
+	if err != nil {
+		return entries, err
+	}
+	var picked = map[string]bool{}
+	for _, tag := range strings.Fields(out) {
+		var args = []string{"cherry", tag, ctx.Git.CurrentTag}
+		if ctx.Git.PreviousTag != "" {
+			args = append(args, ctx.Git.PreviousTag)
+		}
+		out, err := git.Run(args...)
+		if err != nil {
+			return entries, err
+		}
+		for _, line := range strings.Split(out, "\n") {
+			if strings.HasPrefix(line, "- ") {
+				picked[strings.TrimPrefix(line, "- ")] = true
+			}
+		}
+	}
+	var result []entry
+	for _, entry := range entries {
+		if !picked[entry.SHA] {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+// pullRequestEntries replaces the given commits with the pull requests they
+// merged, leaving out the commits of the merged branches. The other commits,
+// like the ones pushed directly, are kept as they are. If the pull requests
+// can't be retrieved from GitHub, the commits are returned untouched.
+func pullRequestEntries(ctx *context.Context, c client.Client, entries []entry) []entry {
+	if ctx.Token == "" {
+		log.Warn("GITHUB_TOKEN is not set, building the changelog from git log instead")
+		return entries
+	}
+	since, err := previousTagDate(ctx)
+	if err != nil {
+		log.WithError(err).Warn("failed to get the previous tag date, building the changelog from git log instead")
+		return entries
+	}
+	prs, err := c.MergedPullRequests(ctx, ctx.Config.Release.GitHub, since)
+	if err != nil {
+		log.WithError(err).Warn("failed to get pull requests from GitHub, building the changelog from git log instead")
+		return entries
+	}
+	var bySHA = map[string]client.PullRequest{}
+	for _, pr := range prs {
+		bySHA[pr.MergeCommit] = pr
+	}
+	var branches = map[string]bool{}
+	for _, commit := range entries {
+		if _, ok := bySHA[commit.SHA]; !ok {
+			continue
+		}
+		// fails for the root commit, which has no branch to merge anyway
+		out, err := git.Run("rev-list", commit.SHA+"^1.."+commit.SHA)
+		if err != nil {
+			continue
+		}
+		for _, sha := range strings.Fields(out) {
+			branches[sha] = true
+		}
+	}
+	var result []entry
+	for _, commit := range entries {
+		pr, ok := bySHA[commit.SHA]
+		if !ok {
+			if !branches[commit.SHA] {
+				result = append(result, commit)
+			}
+			continue
+		}
+		result = append(result, entry{
+			SHA:        commit.SHA,
+			ShortSHA:   commit.ShortSHA,
+			Subject:    pr.Title,
+			AuthorName: pr.Author,
+			Number:     pr.Number,
+			URL:        pr.URL,
+		})
+	}
+	return result
+}
+
+// previousTagDate returns the commit date of the previous tag, or the zero
+// time if there is no previous tag
+func previousTagDate(ctx *context.Context) (time.Time, error) {
+	if ctx.Git.PreviousTag == "" {
+		return time.Time{}, nil
+	}
+	out, err := git.Clean(git.Run("log", "-1", "--format=%ct", ctx.Git.PreviousTag))
+	if err != nil {
+		return time.Time{}, err
+	}
+	timestamp, err := strconv.ParseInt(out, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(timestamp, 0), nil
+}
+
+func filterEntries(ctx *context.Context, entries []entry) ([]entry, error) {
 	for _, filter := range ctx.Config.Changelog.Filters.Exclude {
 		r, err := regexp.Compile(filter)
 		if err != nil {
@@ -79,59 +350,194 @@ func filterEntries(ctx *context.Context, entries []string) ([]string, error) {
 	return entries, nil
 }
 
-func sortEntries(ctx *context.Context, entries []string) []string {
+func sortEntries(ctx *context.Context, entries []entry) []entry {
 	var direction = ctx.Config.Changelog.Sort
-	if direction == "" {
+	if direction == "" || direction == "desc" {
+		// git log already lists the newest commits first
 		return entries
 	}
-	var result = make([]string, len(entries))
-	copy(result, entries)
-	sort.Slice(result, func(i, j int) bool {
-		_, imsg := extractCommitInfo(result[i])
-		_, jmsg := extractCommitInfo(result[j])
-		if direction == "asc" {
-			return strings.Compare(imsg, jmsg) < 0
+	var result = make([]entry, len(entries))
+	for i, entry := range entries {
+		result[len(entries)-1-i] = entry
+	}
+	return result
+}
+
+func formatChangelog(ctx *context.Context, tmpl *template.Template, entries []entry) (string, error) {
+	if len(entries) == 0 {
+		return "## Changelog\n\nNo notable changes.", nil
+	}
+	if len(ctx.Config.Changelog.Groups) == 0 {
+		lines, err := formatEntries(tmpl, entries)
+		if err != nil {
+			return "", err
 		}
-		return strings.Compare(imsg, jmsg) > 0
+		return fmt.Sprintf("## Changelog\n\n%v", strings.Join(lines, "\n")), nil
+	}
+	sections, err := groupEntries(ctx.Config.Changelog.Groups, entries)
+	if err != nil {
+		return "", err
+	}
+	var result = []string{"## Changelog"}
+	for _, section := range sections {
+		lines, err := formatEntries(tmpl, section.entries)
+		if err != nil {
+			return "", err
+		}
+		result = append(result, fmt.Sprintf(
+			"### %s\n\n%s", section.title, strings.Join(lines, "\n"),
+		))
+	}
+	return strings.Join(result, "\n\n"), nil
+}
+
+func formatEntries(tmpl *template.Template, entries []entry) ([]string, error) {
+	var lines []string
+	for _, entry := range entries {
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, entry); err != nil {
+			return lines, err
+		}
+		lines = append(lines, out.String())
+	}
+	return lines, nil
+}
+
+// groupEntries puts each entry in the first group, by order, whose regexp
+// matches its subject. Entries no group matches go to the first group without
+// a regexp or, if there is none, to a trailing "Others" section.
+// Sections without entries are omitted.
+func groupEntries(groups []config.ChangelogGroup, entries []entry) ([]section, error) {
+	var sorted = make([]config.ChangelogGroup, len(groups))
+	copy(sorted, groups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Order < sorted[j].Order
 	})
-	return result
+	var sections = make([]section, len(sorted))
+	var filters = make([]*regexp.Regexp, len(sorted))
+	var catchAll = -1
+	for i, group := range sorted {
+		sections[i].title = group.Title
+		if group.Regexp == "" {
+			if catchAll == -1 {
+				catchAll = i
+			}
+			continue
+		}
+		r, err := regexp.Compile(group.Regexp)
+		if err != nil {
+			return nil, err
+		}
+		filters[i] = r
+	}
+	if catchAll == -1 {
+		sections = append(sections, section{title: "Others"})
+		catchAll = len(sections) - 1
+	}
+	for _, entry := range entries {
+		var idx = catchAll
+		for i, filter := range filters {
+			if filter != nil && filter.MatchString(entry.Subject) {
+				idx = i
+				break
+			}
+		}
+		sections[idx].entries = append(sections[idx].entries, entry)
+	}
+	var result []section
+	for _, section := range sections {
+		if len(section.entries) > 0 {
+			result = append(result, section)
+		}
+	}
+	return result, nil
+}
+
+type section struct {
+	title   string
+	entries []entry
 }
 
-func remove(filter *regexp.Regexp, entries []string) (result []string) {
+func remove(filter *regexp.Regexp, entries []entry) (result []entry) {
 	for _, entry := range entries {
-		_, msg := extractCommitInfo(entry)
-		if !filter.MatchString(msg) {
+		if !filter.MatchString(entry.Subject) {
 			result = append(result, entry)
 		}
 	}
 	return result
 }
 
-func extractCommitInfo(line string) (hash, msg string) {
-	ss := strings.Split(line, " ")
-	return ss[0], strings.Join(ss[1:], " ")
+// entry is a commit or pull request in the changelog, it holds the fields
+// available in the changelog format template
+type entry struct {
+	SHA         string
+	ShortSHA    string
+	Subject     string
+	AuthorName  string
+	AuthorEmail string
+	Number      int
+	URL         string
+}
+
+// gitLogFormat must match the fields order expected by parseEntry
+const gitLogFormat = "--pretty=tformat:%H%x1f%h%x1f%an%x1f%ae%x1f%s"
+
+func parseEntry(line string) entry {
+	var fields = strings.SplitN(line, "\x1f", 5)
+	for len(fields) < 5 {
+		fields = append(fields, "")
+	}
+	return entry{
+		SHA:         fields[0],
+		ShortSHA:    fields[1],
+		AuthorName:  fields[2],
+		AuthorEmail: fields[3],
+		Subject:     fields[4],
+	}
 }
 
-func getChangelog(tag string) (string, error) {
-	prev, err := previous(tag)
+func getChangelog(ctx *context.Context) (string, error) {
+	var tag = ctx.Git.CurrentTag
+	prev, err := previous(ctx)
 	if err != nil {
 		return "", err
 	}
 	if !prev.Tag {
-		return gitLog(prev.SHA, tag)
+		return gitLog(ctx, prev.SHA, tag)
 	}
-	return gitLog(fmt.Sprintf("%v..%v", prev.SHA, tag))
+	ctx.Git.PreviousTag = prev.SHA
+	return gitLog(ctx, fmt.Sprintf("%v..%v", prev.SHA, tag))
 }
 
-func gitLog(refs ...string) (string, error) {
-	var args = []string{"log", "--pretty=oneline", "--abbrev-commit", "--no-decorate"}
+func gitLog(ctx *context.Context, refs ...string) (string, error) {
+	var args = []string{"log", gitLogFormat, "--no-decorate"}
+	switch ctx.Config.Changelog.Merges {
+	case "exclude":
+		args = append(args, "--no-merges")
+	case "only":
+		args = append(args, "--merges")
+	}
 	args = append(args, refs...)
+	if len(ctx.Config.Changelog.Paths) > 0 {
+		args = append(args, "--")
+		args = append(args, ctx.Config.Changelog.Paths...)
+	}
 	return git.Run(args...)
 }
 
-func previous(tag string) (result ref, err error) {
+func previous(ctx *context.Context) (result ref, err error) {
+	if prev := ctx.Config.Changelog.PreviousTag; prev != "" {
+		if _, err := git.Run("rev-parse", "--verify", "--quiet", prev+"^{commit}"); err != nil {
+			return result, ErrInvalidPreviousTag{prev}
+		}
+		return ref{Tag: true, SHA: prev}, nil
+	}
 	result.Tag = true
-	result.SHA, err = git.Clean(git.Run("describe", "--tags", "--abbrev=0", tag+"^"))
+	if ctx.Config.Git.PrereleaseAwarePreviousTag {
+		result.SHA, err = previousStable(ctx.Config.Git.TagPrefix, ctx.Git.CurrentTag)
+	} else {
+		result.SHA, err = describe(ctx.Config.Git.TagPrefix, ctx.Git.CurrentTag+"^")
+	}
 	if err != nil {
 		result.Tag = false
 		result.SHA, err = git.Clean(git.Run("rev-list", "--max-parents=0", "HEAD"))
@@ -139,6 +545,58 @@ func previous(tag string) (result ref, err error) {
 	return
 }
 
+// describe returns the closest tag of the commit with the given prefix
+func describe(prefix, commit string) (string, error) {
+	var args = git.DescribeArgs(prefix, "--tags", "--abbrev=0")
+	return git.Clean(git.Run(append(args, commit)...))
+}
+
+// previousStable returns the closest tag before the given one whose
+// version has no prerelease, going past the prerelease tags. When a commit
+// has several stable tags, the one with the highest version wins.
+func previousStable(prefix, tag string) (string, error) {
+	var commit = tag + "^"
+	for {
+		closest, err := describe(prefix, commit)
+		if err != nil {
+			return "", err
+		}
+		out, err := git.Run("tag", "--points-at", closest+"^{commit}", "--list", prefix+"*")
+		if err != nil {
+			return "", err
+		}
+		if stable := highestStable(prefix, strings.Fields(out)); stable != "" {
+			return stable, nil
+		}
+		log.WithField("tag", closest).Debug("skipping prerelease tag")
+		commit = closest + "^"
+	}
+}
+
+// highestStable returns the tag with the highest version without a
+// prerelease, the tags which aren't semver counting as stable, or an empty
+// string if all the tags are prereleases
+func highestStable(prefix string, tags []string) string {
+	var result string
+	var resultVersion *semver.Version
+	for _, tag := range tags {
+		v, err := semver.NewVersion(strings.TrimPrefix(tag, prefix))
+		if err != nil {
+			if result == "" {
+				result = tag
+			}
+			continue
+		}
+		if v.Prerelease() != "" {
+			continue
+		}
+		if resultVersion == nil || v.GreaterThan(resultVersion) {
+			result, resultVersion = tag, v
+		}
+	}
+	return result
+}
+
 type ref struct {
 	Tag bool
 	SHA string
@@ -1,11 +1,22 @@
 package changelog
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/goreleaser/goreleaser/config"
 	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/client"
+	"github.com/goreleaser/goreleaser/internal/git"
 	"github.com/goreleaser/goreleaser/internal/testlib"
 	"github.com/stretchr/testify/assert"
 )
@@ -15,9 +26,17 @@ func TestDescription(t *testing.T) {
 }
 
 func TestChangelogProvidedViaFlag(t *testing.T) {
-	var ctx = context.New(config.Project{})
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	var ctx = context.New(config.Project{
+		Dist: folder,
+	})
 	ctx.ReleaseNotes = "c0ff33 foo bar"
+	assert.NoError(t, Pipe{}.Default(ctx))
 	testlib.AssertSkipped(t, Pipe{}.Run(ctx))
+	bts, err := ioutil.ReadFile(filepath.Join(folder, "CHANGELOG.md"))
+	assert.NoError(t, err)
+	assert.Equal(t, "c0ff33 foo bar", string(bts))
 }
 
 func TestSnapshot(t *testing.T) {
@@ -26,6 +45,53 @@ func TestSnapshot(t *testing.T) {
 	testlib.AssertSkipped(t, Pipe{}.Run(ctx))
 }
 
+func TestSkip(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Changelog: config.Changelog{
+			Skip: true,
+		},
+	})
+	testlib.AssertSkipped(t, Pipe{}.Run(ctx))
+	assert.Empty(t, ctx.ReleaseNotes)
+}
+
+func TestSkipWithReleaseNotesProvided(t *testing.T) {
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	var ctx = context.New(config.Project{
+		Dist: folder,
+		Changelog: config.Changelog{
+			Skip: true,
+		},
+	})
+	ctx.ReleaseNotes = "c0ff33 foo bar"
+	assert.NoError(t, Pipe{}.Default(ctx))
+	testlib.AssertSkipped(t, Pipe{}.Run(ctx))
+	assert.Equal(t, "c0ff33 foo bar", ctx.ReleaseNotes)
+}
+
+func TestChangelogTagPrefix(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "mytool/v0.0.1")
+	testlib.GitCommit(t, "added feature 1")
+	testlib.GitTag(t, "othersvc/v0.1.0")
+	testlib.GitCommit(t, "fixed bug 2")
+	testlib.GitTag(t, "mytool/v0.0.2")
+	var ctx = context.New(config.Project{
+		Git: config.Git{TagPrefix: "mytool/"},
+	})
+	ctx.Git.CurrentTag = "mytool/v0.0.2"
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.Equal(t, "mytool/v0.0.1", ctx.Git.PreviousTag)
+	assert.Contains(t, ctx.ReleaseNotes, "added feature 1")
+	assert.Contains(t, ctx.ReleaseNotes, "fixed bug 2")
+	assert.NotContains(t, ctx.ReleaseNotes, "first")
+}
+
 func TestChangelog(t *testing.T) {
 	_, back := testlib.Mktmp(t)
 	defer back()
@@ -54,6 +120,7 @@ func TestChangelog(t *testing.T) {
 		},
 	})
 	ctx.Git.CurrentTag = "v0.0.2"
+	assert.NoError(t, Pipe{}.Default(ctx))
 	assert.NoError(t, Pipe{}.Run(ctx))
 	assert.Contains(t, ctx.ReleaseNotes, "## Changelog")
 	assert.NotContains(t, ctx.ReleaseNotes, "first")
@@ -65,6 +132,102 @@ func TestChangelog(t *testing.T) {
 	assert.NotContains(t, ctx.ReleaseNotes, "from goreleaser/some-branch")
 }
 
+func TestChangelogPreviousTag(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "v0.0.1")
+	testlib.GitCommit(t, "added feature 1")
+	testlib.GitTag(t, "v0.0.2")
+	testlib.GitCommit(t, "fixed bug 2")
+	testlib.GitTag(t, "v0.0.3")
+	var ctx = context.New(config.Project{
+		Changelog: config.Changelog{
+			PreviousTag: "v0.0.1",
+		},
+	})
+	ctx.Git.CurrentTag = "v0.0.3"
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.NotContains(t, ctx.ReleaseNotes, "first")
+	assert.Contains(t, ctx.ReleaseNotes, "added feature 1")
+	assert.Contains(t, ctx.ReleaseNotes, "fixed bug 2")
+}
+
+func TestChangelogPreviousTagDoesntExist(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "v0.0.1")
+	testlib.GitCommit(t, "added feature 1")
+	testlib.GitTag(t, "v0.0.2")
+	var ctx = context.New(config.Project{
+		Changelog: config.Changelog{
+			PreviousTag: "v0.0.0",
+		},
+	})
+	ctx.Git.CurrentTag = "v0.0.2"
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.EqualError(t, Pipe{}.Run(ctx), "previous tag v0.0.0 does not exist in the repository")
+	assert.Empty(t, ctx.ReleaseNotes)
+}
+
+func TestChangelogCompareURL(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "v0.0.1")
+	testlib.GitCommit(t, "added feature 1")
+	testlib.GitTag(t, "v0.0.2")
+	var ctx = context.New(config.Project{
+		Release: config.Release{
+			GitHub: config.Repo{
+				Owner: "goreleaser",
+				Name:  "goreleaser",
+			},
+		},
+		GitHubURLs: config.GitHubURLs{
+			Download: "https://github.com",
+		},
+	})
+	ctx.Git.CurrentTag = "v0.0.2"
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.Equal(t, "v0.0.1", ctx.Git.PreviousTag)
+	assert.Contains(
+		t,
+		ctx.ReleaseNotes,
+		"\n\n**Full Changelog**: https://github.com/goreleaser/goreleaser/compare/v0.0.1...v0.0.2",
+	)
+}
+
+func TestChangelogCompareURLFirstRelease(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "v0.0.1")
+	var ctx = context.New(config.Project{
+		Release: config.Release{
+			GitHub: config.Repo{
+				Owner: "goreleaser",
+				Name:  "goreleaser",
+			},
+		},
+		GitHubURLs: config.GitHubURLs{
+			Download: "https://github.com",
+		},
+	})
+	ctx.Git.CurrentTag = "v0.0.1"
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.Empty(t, ctx.Git.PreviousTag)
+	assert.NotContains(t, ctx.ReleaseNotes, "Full Changelog")
+}
+
 func TestChangelogSort(t *testing.T) {
 	f, back := testlib.Mktmp(t)
 	log.Info(f)
@@ -93,35 +256,675 @@ func TestChangelogSort(t *testing.T) {
 				"c: commit",
 			},
 		},
-		{
+	} {
+		t.Run("changelog sort='"+cfg.Sort+"'", func(t *testing.T) {
+			ctx.Config.Changelog.Sort = cfg.Sort
+			entries, err := buildChangelog(ctx, &DummyClient{})
+			assert.NoError(t, err)
+			assert.Len(t, entries, len(cfg.Entries))
+			var changes []string
+			for _, entry := range entries {
+				changes = append(changes, entry.Subject)
+			}
+			assert.EqualValues(t, cfg.Entries, changes)
+		})
+	}
+}
+
+func TestChangelogSortByCommitOrder(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "whatever")
+	testlib.GitTag(t, "v0.9.9")
+	testlib.GitCommit(t, "c: commit")
+	testlib.GitCommit(t, "a: commit")
+	testlib.GitCommit(t, "b: commit")
+	testlib.GitTag(t, "v1.0.0")
+	var ctx = context.New(config.Project{
+		Changelog: config.Changelog{},
+	})
+	ctx.Git.CurrentTag = "v1.0.0"
+
+	for sort, expected := range map[string][]string{
+		"asc":  {"c: commit", "a: commit", "b: commit"},
+		"desc": {"b: commit", "a: commit", "c: commit"},
+	} {
+		t.Run(sort, func(t *testing.T) {
+			ctx.Config.Changelog.Sort = sort
+			entries, err := buildChangelog(ctx, &DummyClient{})
+			assert.NoError(t, err)
+			var changes []string
+			for _, entry := range entries {
+				changes = append(changes, entry.Subject)
+			}
+			assert.EqualValues(t, expected, changes)
+		})
+	}
+}
+
+func TestChangelogSortSameTimestamp(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	// all commits share the same timestamp, so only git order can tell them
+	// apart
+	defer func() {
+		assert.NoError(t, os.Unsetenv("GIT_AUTHOR_DATE"))
+		assert.NoError(t, os.Unsetenv("GIT_COMMITTER_DATE"))
+	}()
+	assert.NoError(t, os.Setenv("GIT_AUTHOR_DATE", "2018-02-18T10:00:00Z"))
+	assert.NoError(t, os.Setenv("GIT_COMMITTER_DATE", "2018-02-18T10:00:00Z"))
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "whatever")
+	testlib.GitTag(t, "v0.9.9")
+	testlib.GitCommit(t, "first")
+	testlib.GitCommit(t, "second")
+	testlib.GitCommit(t, "third")
+	testlib.GitTag(t, "v1.0.0")
+	var ctx = context.New(config.Project{
+		Changelog: config.Changelog{
 			Sort: "asc",
-			Entries: []string{
-				"a: commit",
-				"b: commit",
-				"c: commit",
+		},
+	})
+	ctx.Git.CurrentTag = "v1.0.0"
+	entries, err := buildChangelog(ctx, &DummyClient{})
+	assert.NoError(t, err)
+	var changes []string
+	for _, entry := range entries {
+		changes = append(changes, entry.Subject)
+	}
+	assert.EqualValues(t, []string{"first", "second", "third"}, changes)
+}
+
+func TestChangelogGroups(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "v0.0.1")
+	testlib.GitCommit(t, "feat: added feature 1")
+	testlib.GitCommit(t, "fix: fixed bug 2")
+	testlib.GitCommit(t, "chore: whatever")
+	testlib.GitCommit(t, "feat: added feature 3")
+	testlib.GitTag(t, "v0.0.2")
+	var ctx = context.New(config.Project{
+		Changelog: config.Changelog{
+			Groups: []config.ChangelogGroup{
+				{Title: "Others", Order: 2},
+				{Title: "Bug fixes", Regexp: "^fix", Order: 1},
+				{Title: "Features", Regexp: "^feat", Order: 0},
+				{Title: "Docs", Regexp: "^docs", Order: 3},
 			},
 		},
-		{
-			Sort: "desc",
-			Entries: []string{
-				"c: commit",
-				"b: commit",
-				"a: commit",
+	})
+	ctx.Git.CurrentTag = "v0.0.2"
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	var sections = strings.Split(ctx.ReleaseNotes, "\n\n")
+	assert.Equal(t, []string{
+		"## Changelog",
+		"### Features",
+		sections[2],
+		"### Bug fixes",
+		sections[4],
+		"### Others",
+		sections[6],
+	}, sections)
+	assert.Contains(t, sections[2], "feat: added feature 3")
+	assert.Contains(t, sections[2], "feat: added feature 1")
+	assert.Contains(t, sections[4], "fix: fixed bug 2")
+	assert.Contains(t, sections[6], "chore: whatever")
+	assert.NotContains(t, ctx.ReleaseNotes, "Docs")
+}
+
+func TestChangelogGroupsDefaultCatchAll(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "v0.0.1")
+	testlib.GitCommit(t, "feat: added feature 1")
+	testlib.GitCommit(t, "chore: whatever")
+	testlib.GitTag(t, "v0.0.2")
+	var ctx = context.New(config.Project{
+		Changelog: config.Changelog{
+			Groups: []config.ChangelogGroup{
+				{Title: "Features", Regexp: "^feat"},
 			},
 		},
+	})
+	ctx.Git.CurrentTag = "v0.0.2"
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.Contains(t, ctx.ReleaseNotes, "### Features")
+	assert.Contains(t, ctx.ReleaseNotes, "### Others")
+	assert.Contains(t, ctx.ReleaseNotes, "chore: whatever")
+}
+
+func TestChangelogGroupsInvalidRegex(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "v0.0.1")
+	testlib.GitCommit(t, "feat: added feature 1")
+	testlib.GitTag(t, "v0.0.2")
+	var ctx = context.New(config.Project{
+		Changelog: config.Changelog{
+			Groups: []config.ChangelogGroup{
+				{Title: "Features", Regexp: "(?iasdr4qasd)not a valid regex i guess"},
+			},
+		},
+	})
+	ctx.Git.CurrentTag = "v0.0.2"
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.EqualError(t, Pipe{}.Run(ctx), "error parsing regexp: invalid or unsupported Perl syntax: `(?ia`")
+	assert.Empty(t, ctx.ReleaseNotes)
+}
+
+func TestChangelogFormat(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "v0.0.1")
+	testlib.GitCommit(t, "fix: thing")
+	testlib.GitCommit(t, "docs: whatever")
+	testlib.GitTag(t, "v0.0.2")
+	var ctx = context.New(config.Project{
+		Changelog: config.Changelog{
+			Format: "* {{ .Subject }} (@{{ .AuthorName }}) {{ len .SHA }}",
+			Filters: config.Filters{
+				Exclude: []string{"^docs:"},
+			},
+		},
+	})
+	ctx.Git.CurrentTag = "v0.0.2"
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.Equal(t, "## Changelog\n\n* fix: thing (@GoReleaser) 40", ctx.ReleaseNotes)
+}
+
+func TestChangelogInvalidFormat(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Changelog: config.Changelog{
+			Format: "{{ .Subject }",
+		},
+	})
+	assert.Error(t, Pipe{}.Run(ctx))
+	assert.Empty(t, ctx.ReleaseNotes)
+}
+
+func TestDefault(t *testing.T) {
+	var ctx = context.New(config.Project{})
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.Equal(t, "{{ .ShortSHA }} {{ .Subject }}", ctx.Config.Changelog.Format)
+	assert.Equal(t, "CHANGELOG.md", ctx.Config.Changelog.NameTemplate)
+}
+
+func TestDefaultSet(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Changelog: config.Changelog{
+			Format: "{{ .Subject }}",
+		},
+	})
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.Equal(t, "{{ .Subject }}", ctx.Config.Changelog.Format)
+}
+
+func TestChangelogMerges(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "v0.0.1")
+	testlib.GitCommit(t, "added feature 1")
+	for _, args := range [][]string{
+		{"checkout", "-b", "some-branch"},
+		{"commit", "--allow-empty", "-m", "fixed bug 2"},
+		{"checkout", "master"},
+		{"merge", "--no-ff", "-m", "Merge branch some-branch", "some-branch"},
 	} {
-		t.Run("changelog sort='"+cfg.Sort+"'", func(t *testing.T) {
-			ctx.Config.Changelog.Sort = cfg.Sort
-			entries, err := buildChangelog(ctx)
+		_, err := git.Run(append([]string{
+			"-c", "user.name=GoReleaser",
+			"-c", "user.email=test@goreleaser.github.com",
+			"-c", "commit.gpgSign=false",
+		}, args...)...)
+		assert.NoError(t, err)
+	}
+	testlib.GitTag(t, "v0.0.2")
+	for _, cfg := range []struct {
+		Merges  string
+		Entries []string
+	}{
+		{
+			Merges:  "",
+			Entries: []string{"Merge branch some-branch", "fixed bug 2", "added feature 1"},
+		},
+		{
+			Merges:  "include",
+			Entries: []string{"Merge branch some-branch", "fixed bug 2", "added feature 1"},
+		},
+		{
+			Merges:  "exclude",
+			Entries: []string{"fixed bug 2", "added feature 1"},
+		},
+		{
+			Merges:  "only",
+			Entries: []string{"Merge branch some-branch"},
+		},
+	} {
+		t.Run("changelog merges='"+cfg.Merges+"'", func(t *testing.T) {
+			var ctx = context.New(config.Project{
+				Changelog: config.Changelog{
+					Merges: cfg.Merges,
+				},
+			})
+			ctx.Git.CurrentTag = "v0.0.2"
+			entries, err := buildChangelog(ctx, &DummyClient{})
 			assert.NoError(t, err)
-			assert.Len(t, entries, len(cfg.Entries))
 			var changes []string
-			for _, line := range entries {
-				_, msg := extractCommitInfo(line)
-				changes = append(changes, msg)
+			for _, entry := range entries {
+				changes = append(changes, entry.Subject)
 			}
-			assert.EqualValues(t, cfg.Entries, changes)
+			assert.ElementsMatch(t, cfg.Entries, changes)
+		})
+	}
+}
+
+func TestChangelogInvalidMerges(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Changelog: config.Changelog{
+			Merges: "sometimes",
+		},
+	})
+	assert.EqualError(t, Pipe{}.Run(ctx), ErrInvalidMerges.Error())
+}
+
+func TestChangelogFile(t *testing.T) {
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "v0.0.1")
+	testlib.GitCommit(t, "added feature 1")
+	testlib.GitTag(t, "v0.0.2")
+	var ctx = context.New(config.Project{
+		Dist:        folder,
+		ProjectName: "foo",
+		Changelog: config.Changelog{
+			NameTemplate: "{{ .ProjectName }}_{{ .Tag }}_CHANGELOG.md",
+		},
+	})
+	ctx.Git.CurrentTag = "v0.0.2"
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	var artifacts = ctx.Artifacts.Filter(artifact.ByType(artifact.Changelog)).List()
+	assert.Len(t, artifacts, 1)
+	assert.Equal(t, "foo_v0.0.2_CHANGELOG.md", artifacts[0].Name)
+	assert.Equal(t, filepath.Join(folder, "foo_v0.0.2_CHANGELOG.md"), artifacts[0].Path)
+	bts, err := ioutil.ReadFile(artifacts[0].Path)
+	assert.NoError(t, err)
+	assert.Equal(t, ctx.ReleaseNotes, string(bts))
+}
+
+func TestChangelogFileInvalidNameTemplate(t *testing.T) {
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	var ctx = context.New(config.Project{
+		Dist: folder,
+		Changelog: config.Changelog{
+			NameTemplate: "{{ .Nope }}",
+		},
+	})
+	ctx.ReleaseNotes = "c0ff33 foo bar"
+	assert.Error(t, Pipe{}.Run(ctx))
+	assert.Empty(t, ctx.Artifacts.List())
+}
+
+func TestChangelogFromPullRequests(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "v0.0.1")
+	testlib.GitCommit(t, "Merge pull request #1 from foo/feature")
+	feature, err := git.Clean(git.Run("rev-parse", "HEAD"))
+	assert.NoError(t, err)
+	testlib.GitCommit(t, "pushed directly")
+	pushed, err := git.Clean(git.Run("rev-parse", "HEAD"))
+	assert.NoError(t, err)
+	testlib.GitCommit(t, "docs: squashed (#2)")
+	docs, err := git.Clean(git.Run("rev-parse", "HEAD"))
+	assert.NoError(t, err)
+	testlib.GitTag(t, "v0.0.2")
+	var ctx = context.New(config.Project{
+		Release: config.Release{
+			GitHub: config.Repo{
+				Owner: "goreleaser",
+				Name:  "goreleaser",
+			},
+		},
+		Changelog: config.Changelog{
+			Use: "github",
+			Filters: config.Filters{
+				Exclude: []string{"^docs:"},
+			},
+		},
+	})
+	ctx.Git.CurrentTag = "v0.0.2"
+	ctx.Token = "some-token"
+	var c = &DummyClient{
+		PullRequests: []client.PullRequest{
+			{
+				Number:      1,
+				Title:       "feat: added feature 1",
+				Author:      "alice",
+				URL:         "https://github.com/goreleaser/goreleaser/pull/1",
+				MergeCommit: feature,
+			},
+			{
+				Number:      2,
+				Title:       "docs: squashed",
+				Author:      "bob",
+				URL:         "https://github.com/goreleaser/goreleaser/pull/2",
+				MergeCommit: docs,
+			},
+			{
+				Number:      3,
+				Title:       "from another release",
+				Author:      "bob",
+				URL:         "https://github.com/goreleaser/goreleaser/pull/3",
+				MergeCommit: "c0ff33",
+			},
+		},
+	}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, doRun(ctx, c))
+	assert.Equal(t, "goreleaser/goreleaser", c.Repo.String())
+	assert.Contains(t, ctx.ReleaseNotes, fmt.Sprintf(
+		"## Changelog\n\n%s pushed directly\n%s feat: added feature 1 ([#1](https://github.com/goreleaser/goreleaser/pull/1)) @alice\n\n",
+		pushed[:7], feature[:7],
+	))
+	assert.NotContains(t, ctx.ReleaseNotes, "docs")
+	assert.NotContains(t, ctx.ReleaseNotes, "from another release")
+}
+
+func TestChangelogFromPullRequestsAndCommits(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	var gitRun = func(args ...string) string {
+		out, err := git.Run(append([]string{
+			"-c", "user.name=GoReleaser",
+			"-c", "user.email=test@goreleaser.github.com",
+			"-c", "commit.gpgSign=false",
+		}, args...)...)
+		assert.NoError(t, err)
+		return out
+	}
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "v0.0.1")
+	gitRun("checkout", "-b", "feature")
+	gitRun("commit", "--allow-empty", "-m", "wip on the feature")
+	gitRun("checkout", "master")
+	testlib.GitCommit(t, "hotfix pushed directly")
+	gitRun("merge", "--no-ff", "-m", "Merge pull request #1 from foo/feature", "feature")
+	merge, err := git.Clean(git.Run("rev-parse", "HEAD"))
+	assert.NoError(t, err)
+	testlib.GitCommit(t, "another direct push")
+	testlib.GitTag(t, "v0.0.2")
+	var ctx = context.New(config.Project{
+		Release: config.Release{
+			GitHub: config.Repo{Owner: "goreleaser", Name: "goreleaser"},
+		},
+		Changelog: config.Changelog{Use: "github"},
+	})
+	ctx.Git.CurrentTag = "v0.0.2"
+	ctx.Token = "some-token"
+	var c = &DummyClient{
+		PullRequests: []client.PullRequest{
+			{
+				Number:      1,
+				Title:       "feat: the feature",
+				Author:      "alice",
+				URL:         "https://github.com/goreleaser/goreleaser/pull/1",
+				MergeCommit: merge,
+			},
+		},
+	}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, doRun(ctx, c))
+	assert.Contains(t, ctx.ReleaseNotes, "feat: the feature ([#1](https://github.com/goreleaser/goreleaser/pull/1)) @alice")
+	assert.Contains(t, ctx.ReleaseNotes, "hotfix pushed directly")
+	assert.Contains(t, ctx.ReleaseNotes, "another direct push")
+	assert.NotContains(t, ctx.ReleaseNotes, "wip on the feature")
+	assert.NotContains(t, ctx.ReleaseNotes, "Merge pull request")
+}
+
+func TestChangelogFromPullRequestsFallback(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "v0.0.1")
+	testlib.GitCommit(t, "added feature 1")
+	testlib.GitTag(t, "v0.0.2")
+	for name, tt := range map[string]struct {
+		token  string
+		client *DummyClient
+	}{
+		"no token": {
+			client: &DummyClient{},
+		},
+		"api failure": {
+			token:  "some-token",
+			client: &DummyClient{FailToListPullRequests: true},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var ctx = context.New(config.Project{
+				Changelog: config.Changelog{
+					Use: "github",
+				},
+			})
+			ctx.Git.CurrentTag = "v0.0.2"
+			ctx.Token = tt.token
+			assert.NoError(t, Pipe{}.Default(ctx))
+			assert.NoError(t, doRun(ctx, tt.client))
+			assert.Contains(t, ctx.ReleaseNotes, "added feature 1")
+		})
+	}
+}
+
+func TestChangelogInvalidUse(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Changelog: config.Changelog{
+			Use: "svn",
+		},
+	})
+	assert.EqualError(t, Pipe{}.Run(ctx), ErrInvalidUse.Error())
+}
+
+func TestChangelogUseWithATag(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Changelog: config.Changelog{
+			Use: "v1.2.0",
+		},
+	})
+	var err = Pipe{}.Run(ctx)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "use changelog.previous_tag")
+}
+
+func TestChangelogPaths(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "v0.0.1")
+	for _, file := range []string{"tools/mycli/main.go", "services/api/main.go", "internal/shared/lib.go"} {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		assert.NoError(t, ioutil.WriteFile(file, []byte("package main"), 0644))
+		testlib.GitAdd(t)
+		testlib.GitCommit(t, "changed "+file)
+	}
+	testlib.GitTag(t, "v0.0.2")
+	var ctx = context.New(config.Project{
+		Changelog: config.Changelog{
+			Paths: []string{"tools/mycli/", "internal/shared/"},
+		},
+	})
+	ctx.Git.CurrentTag = "v0.0.2"
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.Contains(t, ctx.ReleaseNotes, "changed tools/mycli/main.go")
+	assert.Contains(t, ctx.ReleaseNotes, "changed internal/shared/lib.go")
+	assert.NotContains(t, ctx.ReleaseNotes, "changed services/api/main.go")
+}
+
+func TestChangelogPathsNoChanges(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "v0.0.1")
+	testlib.GitCommit(t, "added feature 1")
+	testlib.GitTag(t, "v0.0.2")
+	var ctx = context.New(config.Project{
+		Changelog: config.Changelog{
+			Paths: []string{"tools/mycli/"},
+		},
+	})
+	ctx.Git.CurrentTag = "v0.0.2"
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.Equal(t, "## Changelog\n\nNo notable changes.", ctx.ReleaseNotes)
+}
+
+func TestChangelogDedup(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "v0.0.1")
+	testlib.GitCommit(t, "fix: thing")
+	testlib.GitCommit(t, "feat: other thing")
+	testlib.GitCommit(t, "[backport] fix: thing")
+	testlib.GitCommit(t, "fix: thing (cherry picked from commit 5e3b1e9)")
+	testlib.GitTag(t, "v0.0.2")
+	for _, dedup := range []bool{true, false} {
+		var ctx = context.New(config.Project{
+			Changelog: config.Changelog{
+				Dedup: dedup,
+			},
+		})
+		ctx.Git.CurrentTag = "v0.0.2"
+		entries, err := buildChangelog(ctx, &DummyClient{})
+		assert.NoError(t, err)
+		var changes []string
+		for _, entry := range entries {
+			changes = append(changes, entry.Subject)
+		}
+		if dedup {
+			assert.Equal(t, []string{
+				"fix: thing (cherry picked from commit 5e3b1e9)",
+				"feat: other thing",
+			}, changes)
+		} else {
+			assert.Len(t, changes, 4)
+		}
+	}
+}
+
+func TestChangelogSkipCherryPicks(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "v0.0.1")
+	var run = func(args ...string) {
+		_, err := git.Run(append([]string{
+			"-c", "user.name=GoReleaser",
+			"-c", "user.email=test@goreleaser.github.com",
+			"-c", "commit.gpgSign=false",
+		}, args...)...)
+		assert.NoError(t, err)
+	}
+	run("checkout", "-b", "release")
+	assert.NoError(t, ioutil.WriteFile("fix.txt", []byte("fix"), 0644))
+	testlib.GitAdd(t)
+	run("commit", "-m", "fix: thing")
+	testlib.GitTag(t, "v0.1.0")
+	run("checkout", "master")
+	run("cherry-pick", "-x", "release")
+	testlib.GitCommit(t, "feat: other thing")
+	testlib.GitTag(t, "v0.0.2")
+	for _, skip := range []bool{true, false} {
+		var ctx = context.New(config.Project{
+			Changelog: config.Changelog{
+				PreviousTag:     "v0.1.0",
+				SkipCherryPicks: skip,
+			},
 		})
+		ctx.Git.CurrentTag = "v0.0.2"
+		entries, err := buildChangelog(ctx, &DummyClient{})
+		assert.NoError(t, err)
+		var changes []string
+		for _, entry := range entries {
+			changes = append(changes, entry.Subject)
+		}
+		if skip {
+			assert.Equal(t, []string{"feat: other thing"}, changes)
+		} else {
+			assert.Equal(t, []string{"feat: other thing", "fix: thing"}, changes)
+		}
+	}
+}
+
+func TestChangelogSkipBackports(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	testlib.GitTag(t, "v1.0.0")
+	var run = func(args ...string) {
+		_, err := git.Run(append([]string{
+			"-c", "user.name=GoReleaser",
+			"-c", "user.email=test@goreleaser.github.com",
+			"-c", "commit.gpgSign=false",
+		}, args...)...)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, ioutil.WriteFile("fix.txt", []byte("fix"), 0644))
+	testlib.GitAdd(t)
+	run("commit", "-m", "fix: thing")
+	testlib.GitCommit(t, "feat: other thing")
+	// the fix is backported to the 1.0 release branch and released there
+	// first
+	run("checkout", "-b", "release-1.0", "v1.0.0")
+	run("cherry-pick", "-x", "master~1")
+	testlib.GitTag(t, "v1.0.1")
+	run("checkout", "master")
+	testlib.GitTag(t, "v1.1.0")
+	for _, skip := range []bool{true, false} {
+		var ctx = context.New(config.Project{
+			Changelog: config.Changelog{
+				SkipCherryPicks: skip,
+			},
+		})
+		ctx.Git.CurrentTag = "v1.1.0"
+		entries, err := buildChangelog(ctx, &DummyClient{})
+		assert.NoError(t, err)
+		var changes []string
+		for _, entry := range entries {
+			changes = append(changes, entry.Subject)
+		}
+		if skip {
+			assert.Equal(t, []string{"feat: other thing"}, changes)
+		} else {
+			assert.Equal(t, []string{"feat: other thing", "fix: thing"}, changes)
+		}
 	}
 }
 
@@ -150,6 +953,7 @@ func TestChangelogOfFirstRelease(t *testing.T) {
 	testlib.GitTag(t, "v0.0.1")
 	var ctx = context.New(config.Project{})
 	ctx.Git.CurrentTag = "v0.0.1"
+	assert.NoError(t, Pipe{}.Default(ctx))
 	assert.NoError(t, Pipe{}.Run(ctx))
 	assert.Contains(t, ctx.ReleaseNotes, "## Changelog")
 	for _, msg := range msgs {
@@ -175,6 +979,7 @@ func TestChangelogFilterInvalidRegex(t *testing.T) {
 		},
 	})
 	ctx.Git.CurrentTag = "v0.0.4"
+	assert.NoError(t, Pipe{}.Default(ctx))
 	assert.EqualError(t, Pipe{}.Run(ctx), "error parsing regexp: invalid or unsupported Perl syntax: `(?ia`")
 }
 
@@ -187,3 +992,121 @@ func TestChangelogNoTags(t *testing.T) {
 	assert.Error(t, Pipe{}.Run(ctx))
 	assert.Empty(t, ctx.ReleaseNotes)
 }
+
+type DummyClient struct {
+	FailToListPullRequests bool
+	PullRequests           []client.PullRequest
+	Repo                   config.Repo
+}
+
+func (client *DummyClient) CreateRelease(ctx *context.Context, body string) (releaseID int64, err error) {
+	return
+}
+
+func (client *DummyClient) CreateFile(ctx *context.Context, commitAuthor config.CommitAuthor, repo config.Repo, content bytes.Buffer, path, message string) (err error) {
+	return
+}
+
+func (client *DummyClient) Upload(ctx *context.Context, releaseID int64, name string, file *os.File) (err error) {
+	return
+}
+
+func (client *DummyClient) MergedPullRequests(ctx *context.Context, repo config.Repo, since time.Time) (prs []client.PullRequest, err error) {
+	if client.FailToListPullRequests {
+		return nil, errors.New("failed to list pull requests")
+	}
+	client.Repo = repo
+	return client.PullRequests, nil
+}
+
+func (client *DummyClient) GetFile(ctx *context.Context, repo config.Repo, path string) (content []byte, err error) {
+	return
+}
+
+func TestChangelogPrereleaseAwarePreviousTag(t *testing.T) {
+	for _, tt := range []struct {
+		name, prefix, tag, previous string
+		aware                       bool
+		tags                        [][]string
+	}{
+		{
+			name:     "skips the prereleases",
+			tag:      "v1.3.0",
+			previous: "v1.2.0",
+			aware:    true,
+			tags:     [][]string{{"v1.2.0"}, {"v1.3.0-rc.1"}, {"v1.3.0-rc.2"}, {"v1.3.0"}},
+		},
+		{
+			name:     "keeps the prereleases when disabled",
+			tag:      "v1.3.0",
+			previous: "v1.3.0-rc.2",
+			tags:     [][]string{{"v1.2.0"}, {"v1.3.0-rc.1"}, {"v1.3.0-rc.2"}, {"v1.3.0"}},
+		},
+		{
+			name:     "prerelease current tag",
+			tag:      "v1.3.0-rc.2",
+			previous: "v1.2.0",
+			aware:    true,
+			tags:     [][]string{{"v1.2.0"}, {"v1.3.0-rc.1"}, {"v1.3.0-rc.2"}},
+		},
+		{
+			name:     "only the tags with the prefix",
+			prefix:   "mytool/",
+			tag:      "mytool/v1.3.0",
+			previous: "mytool/v1.2.0",
+			aware:    true,
+			tags: [][]string{
+				{"mytool/v1.2.0"}, {"othersvc/v2.0.0"}, {"mytool/v1.3.0-rc.1"}, {"mytool/v1.3.0"},
+			},
+		},
+		{
+			name:     "highest stable tag of the commit",
+			tag:      "v1.3.0",
+			previous: "v1.2.1",
+			aware:    true,
+			tags:     [][]string{{"v1.2.0"}, {"v1.2.1", "v1.3.0-rc.1", "v1.1.9"}, {"v1.3.0"}},
+		},
+		{
+			name:     "non semver tags are stable",
+			tag:      "v1.3.0",
+			previous: "nightly",
+			aware:    true,
+			tags:     [][]string{{"v1.2.0"}, {"nightly", "v1.3.0-rc.1"}, {"v1.3.0"}},
+		},
+		{
+			name:  "only prereleases before",
+			tag:   "v1.0.0",
+			aware: true,
+			tags:  [][]string{{"v1.0.0-rc.1"}, {"v1.0.0-rc.2"}, {"v1.0.0"}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, back := testlib.Mktmp(t)
+			defer back()
+			testlib.GitInit(t)
+			testlib.GitCommit(t, "first")
+			for _, tags := range tt.tags {
+				testlib.GitCommit(t, "commit for "+tags[0])
+				for _, tag := range tags {
+					testlib.GitTag(t, tag)
+				}
+			}
+			var ctx = context.New(config.Project{
+				Git: config.Git{
+					TagPrefix:                  tt.prefix,
+					PrereleaseAwarePreviousTag: tt.aware,
+				},
+			})
+			ctx.Git.CurrentTag = tt.tag
+			assert.NoError(t, Pipe{}.Default(ctx))
+			assert.NoError(t, Pipe{}.Run(ctx))
+			assert.Equal(t, tt.previous, ctx.Git.PreviousTag)
+			assert.Contains(t, ctx.ReleaseNotes, "commit for "+tt.tag)
+			if tt.previous == "" {
+				assert.Contains(t, ctx.ReleaseNotes, "first")
+			} else {
+				assert.NotContains(t, ctx.ReleaseNotes, "commit for "+tt.previous)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package changelog
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/goreleaser/goreleaser/context"
+)
+
+func filenameFor(ctx *context.Context) (string, error) {
+	var out bytes.Buffer
+	t, err := template.New("changelog").
+		Option("missingkey=error").
+		Parse(ctx.Config.Changelog.NameTemplate)
+	if err != nil {
+		return "", err
+	}
+	err = t.Execute(&out, struct {
+		ProjectName  string
+		Tag          string
+		Version      string
+		Major        string
+		Minor        string
+		Patch        string
+		Prerelease   string
+		IsSnapshot   bool
+		IsPrerelease bool
+		Env          map[string]string
+	}{
+		ProjectName:  ctx.Config.ProjectName,
+		Tag:          ctx.Git.CurrentTag,
+		Version:      ctx.Version,
+		Major:        ctx.Semver.Major,
+		Minor:        ctx.Semver.Minor,
+		Patch:        ctx.Semver.Patch,
+		Prerelease:   ctx.Semver.Prerelease,
+		IsSnapshot:   ctx.Snapshot,
+		IsPrerelease: ctx.Semver.Prerelease != "",
+		Env:          ctx.Env,
+	})
+	return out.String(), err
+}
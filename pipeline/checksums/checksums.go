@@ -2,15 +2,20 @@ package checksums
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/apex/log"
+	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/goreleaser/goreleaser/checksum"
 	"github.com/goreleaser/goreleaser/context"
 	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/filenametemplate"
 )
 
 // Pipe for checksums
@@ -22,60 +27,223 @@ func (Pipe) String() string {
 
 // Default sets the pipe defaults
 func (Pipe) Default(ctx *context.Context) error {
-	if ctx.Config.Checksum.NameTemplate == "" {
-		ctx.Config.Checksum.NameTemplate = "{{ .ProjectName }}_{{ .Version }}_checksums.txt"
+	var cfg = &ctx.Config.Checksum
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = "sha256"
+		if len(cfg.Algorithms) > 0 {
+			cfg.Algorithm = cfg.Algorithms[0]
+		}
+	}
+	if len(cfg.Algorithms) == 0 {
+		cfg.Algorithms = []string{cfg.Algorithm}
+	}
+	if cfg.NameTemplate == "" {
+		cfg.NameTemplate = "{{ .ProjectName }}_{{ .Version }}_checksums.txt"
+		// each algorithm has its own checksums file
+		if len(cfg.Algorithms) > 1 {
+			cfg.NameTemplate = "{{ .ProjectName }}_{{ .Version }}_{{ .Algorithm }}_checksums.txt"
+		}
+	}
+	if cfg.Format == "" {
+		cfg.Format = "gnu"
+	}
+	if cfg.Format != "gnu" && cfg.Format != "bsd" {
+		return fmt.Errorf("invalid checksum format '%s', must be gnu or bsd", cfg.Format)
+	}
+	if len(cfg.Artifacts) == 0 {
+		// the artifacts are signed before the checksums, so their
+		// signatures are listed too, the checksums files are signed after.
+		cfg.Artifacts = []string{"archive", "binary", "package", "signature", "changelog"}
+	}
+	for _, kind := range cfg.Artifacts {
+		if _, ok := kinds[kind]; !ok {
+			return fmt.Errorf("invalid checksum artifacts '%s', must be archive, binary, package, signature or changelog", kind)
+		}
+	}
+	if err := filenametemplate.Check(cfg.NameTemplate); err != nil {
+		return errors.Wrapf(err, "invalid checksum name template '%s'", cfg.NameTemplate)
+	}
+	for _, algorithm := range append([]string{cfg.Algorithm}, cfg.Algorithms...) {
+		if err := checkAlgorithm(algorithm); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+func checkAlgorithm(name string) error {
+	for _, algorithm := range checksum.Algorithms() {
+		if algorithm == name {
+			return nil
+		}
+	}
+	return fmt.Errorf(
+		"invalid checksum algorithm '%s', must be one of %s",
+		name,
+		strings.Join(checksum.Algorithms(), ", "),
+	)
+}
+
+// kinds of the artifacts which can be checksummed
+var kinds = map[string]artifact.Filter{
+	"archive":   artifact.ByType(artifact.UploadableArchive),
+	"binary":    artifact.ByType(artifact.UploadableBinary),
+	"package":   artifact.ByType(artifact.LinuxPackage),
+	"signature": artifact.ByType(artifact.Signature),
+	"changelog": artifact.ByType(artifact.Changelog),
+}
+
 // Run the pipe
 func (Pipe) Run(ctx *context.Context) (err error) {
-	filename, err := filenameFor(ctx)
+	var algorithms = ctx.Config.Checksum.Algorithms
+	filenames, err := filenamesFor(ctx)
 	if err != nil {
 		return err
 	}
-	file, err := os.OpenFile(
-		filepath.Join(ctx.Config.Dist, filename),
-		os.O_APPEND|os.O_WRONLY|os.O_CREATE|os.O_TRUNC,
-		0444,
-	)
+	var artifacts = artifactsFor(ctx)
+	splits, err := splitFiles(ctx, filenames, artifacts)
 	if err != nil {
 		return err
 	}
-	defer file.Close() // nolint: errcheck
 
+	// each artifact is read once, whatever the number of algorithms
 	var g errgroup.Group
 	var semaphore = make(chan bool, ctx.Parallelism)
-	for _, artifact := range ctx.Artifacts.Filter(
-		artifact.Or(
-			artifact.ByType(artifact.UploadableArchive),
-			artifact.ByType(artifact.UploadableBinary),
-			artifact.ByType(artifact.LinuxPackage),
-		),
-	).List() {
+	var sums = make([]map[string]string, len(artifacts))
+	for i, a := range artifacts {
 		semaphore <- true
-		artifact := artifact
+		i, a := i, a
 		g.Go(func() error {
 			defer func() {
 				<-semaphore
 			}()
-			return checksums(ctx, file, artifact)
+			log.WithField("file", a.Name).Info("checksumming")
+			sum, err := checksum.CalculateAll(algorithms, a.Path)
+			sums[i] = sum
+			return err
 		})
 	}
-	ctx.Artifacts.Add(artifact.Artifact{
-		Type: artifact.Checksum,
-		Path: file.Name(),
-		Name: filename,
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	for i, a := range artifacts {
+		ctx.Artifacts.SetChecksums(a, sums[i])
+	}
+
+	for j, algorithm := range algorithms {
+		var lines []string
+		for i, a := range artifacts {
+			var line = checksum.Line(ctx.Config.Checksum.Format, algorithm, sums[i][algorithm], a.Name)
+			lines = append(lines, line)
+			if split, ok := splits[a.Name]; ok {
+				if err := ioutil.WriteFile(split[j], []byte(line), 0644); err != nil {
+					return err
+				}
+			}
+		}
+		if err := writeChecksums(filepath.Join(ctx.Config.Dist, filenames[j]), strings.Join(lines, "")); err != nil {
+			return err
+		}
+	}
+	for j, algorithm := range algorithms {
+		ctx.Artifacts.Add(artifact.Artifact{
+			Type:  artifact.Checksum,
+			Path:  filepath.Join(ctx.Config.Dist, filenames[j]),
+			Name:  filenames[j],
+			Extra: map[string]interface{}{"Algorithm": algorithm},
+		})
+	}
+	for _, a := range artifacts {
+		for j, split := range splits[a.Name] {
+			ctx.Artifacts.Add(artifact.Artifact{
+				Type:  artifact.Checksum,
+				Path:  split,
+				Name:  filepath.Base(split),
+				Extra: map[string]interface{}{"Algorithm": algorithms[j]},
+			})
+		}
+	}
+	return nil
+}
+
+// filenamesFor renders the name of the checksums file of each algorithm.
+func filenamesFor(ctx *context.Context) ([]string, error) {
+	var filenames []string
+	var seen = map[string]string{}
+	for _, algorithm := range ctx.Config.Checksum.Algorithms {
+		filename, err := filenameFor(ctx, algorithm)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := seen[filename]; ok {
+			return nil, fmt.Errorf("the %s and %s checksums would both be written to %s, use {{ .Algorithm }} in the name template", other, algorithm, filename)
+		}
+		seen[filename] = algorithm
+		filenames = append(filenames, filename)
+	}
+	return filenames, nil
+}
+
+// artifactsFor returns the artifacts to checksum, sorted by name so the
+// checksums files are the same on every run. The changelog is only
+// checksummed when it is uploaded.
+func artifactsFor(ctx *context.Context) []artifact.Artifact {
+	var filters []artifact.Filter
+	for _, kind := range ctx.Config.Checksum.Artifacts {
+		if kind == "changelog" && !ctx.Config.Changelog.Upload {
+			continue
+		}
+		filters = append(filters, kinds[kind])
+	}
+	if len(filters) == 0 {
+		return nil
+	}
+	var artifacts = ctx.Artifacts.Filter(artifact.Or(filters...)).List()
+	sort.SliceStable(artifacts, func(i, j int) bool {
+		return artifacts[i].Name < artifacts[j].Name
 	})
-	return g.Wait()
+	return artifacts
 }
 
-func checksums(ctx *context.Context, file *os.File, artifact artifact.Artifact) error {
-	log.WithField("file", artifact.Name).Info("checksumming")
-	sha, err := checksum.SHA256(artifact.Path)
+func writeChecksums(path, content string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0444)
 	if err != nil {
 		return err
 	}
-	_, err = file.WriteString(fmt.Sprintf("%v  %v\n", sha, artifact.Name))
-	return err
+	if _, err := file.WriteString(content); err != nil {
+		file.Close() // nolint: errcheck
+		return err
+	}
+	return file.Close()
+}
+
+// splitFiles returns the paths of the checksum files of each artifact, one
+// per algorithm, by artifact name, when the checksums are split. Signatures
+// don't get their own checksum files, they are only in the combined ones.
+func splitFiles(ctx *context.Context, filenames []string, artifacts []artifact.Artifact) (map[string][]string, error) {
+	var splits = map[string][]string{}
+	if !ctx.Config.Checksum.Split {
+		return splits, nil
+	}
+	var taken = map[string]string{}
+	for _, filename := range filenames {
+		taken[filename] = "the checksums file"
+	}
+	for _, a := range ctx.Artifacts.List() {
+		taken[a.Name] = "the artifact " + a.Name
+	}
+	for _, a := range artifacts {
+		if a.Type == artifact.Signature {
+			continue
+		}
+		for _, algorithm := range ctx.Config.Checksum.Algorithms {
+			var name = a.Name + "." + algorithm
+			if what, ok := taken[name]; ok {
+				return nil, fmt.Errorf("checksum file %s of %s would overwrite %s", name, a.Name, what)
+			}
+			taken[name] = "the checksum file of " + a.Name
+			splits[a.Name] = append(splits[a.Name], filepath.Join(ctx.Config.Dist, name))
+		}
+	}
+	return splits, nil
 }
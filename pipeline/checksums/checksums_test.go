@@ -2,7 +2,10 @@ package checksums
 
 import (
 	"io/ioutil"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/goreleaser/goreleaser/config"
@@ -42,6 +45,7 @@ func TestPipe(t *testing.T) {
 		Path: file,
 		Type: artifact.UploadableArchive,
 	})
+	assert.NoError(t, Pipe{}.Default(ctx))
 	assert.NoError(t, Pipe{}.Run(ctx))
 	var artifacts []string
 	for _, a := range ctx.Artifacts.List() {
@@ -52,6 +56,75 @@ func TestPipe(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, string(bts), "61d034473102d7dac305902770471fd50f4c5b26f6831a56dd90b5184b3c30fc  binary")
 	assert.Contains(t, string(bts), "61d034473102d7dac305902770471fd50f4c5b26f6831a56dd90b5184b3c30fc  binary.tar.gz")
+	for _, a := range ctx.Artifacts.Filter(artifact.ByType(artifact.UploadableArchive)).List() {
+		assert.Equal(t, map[string]string{"sha256": "61d034473102d7dac305902770471fd50f4c5b26f6831a56dd90b5184b3c30fc"}, a.Checksums)
+	}
+}
+
+func TestPipeSignatures(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var file = filepath.Join(folder, "binary")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("some string"), 0644))
+	var ctx = context.New(config.Project{
+		Dist:        folder,
+		ProjectName: "binary",
+		Checksum: config.Checksum{
+			NameTemplate: "checksums.txt",
+		},
+	})
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name: "binary.tar.gz",
+		Path: file,
+		Type: artifact.UploadableArchive,
+	})
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name: "binary.tar.gz.sig",
+		Path: file,
+		Type: artifact.Signature,
+	})
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	bts, err := ioutil.ReadFile(filepath.Join(folder, "checksums.txt"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(bts), "61d034473102d7dac305902770471fd50f4c5b26f6831a56dd90b5184b3c30fc  binary.tar.gz\n")
+	assert.Contains(t, string(bts), "61d034473102d7dac305902770471fd50f4c5b26f6831a56dd90b5184b3c30fc  binary.tar.gz.sig\n")
+}
+
+func TestPipeChangelog(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var file = filepath.Join(folder, "CHANGELOG.md")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("some string"), 0644))
+	for _, upload := range []bool{true, false} {
+		var ctx = context.New(
+			config.Project{
+				Dist:        folder,
+				ProjectName: "binary",
+				Checksum: config.Checksum{
+					NameTemplate: "checksums.txt",
+				},
+				Changelog: config.Changelog{
+					Upload: upload,
+				},
+			},
+		)
+		ctx.Artifacts.Add(artifact.Artifact{
+			Name: "CHANGELOG.md",
+			Path: file,
+			Type: artifact.Changelog,
+		})
+		assert.NoError(t, Pipe{}.Default(ctx))
+		assert.NoError(t, Pipe{}.Run(ctx))
+		bts, err := ioutil.ReadFile(filepath.Join(folder, "checksums.txt"))
+		assert.NoError(t, err)
+		if upload {
+			assert.Contains(t, string(bts), "CHANGELOG.md")
+		} else {
+			assert.NotContains(t, string(bts), "CHANGELOG.md")
+		}
+		assert.NoError(t, os.Remove(filepath.Join(folder, "checksums.txt")))
+	}
 }
 
 func TestPipeFileNotExist(t *testing.T) {
@@ -70,39 +143,66 @@ func TestPipeFileNotExist(t *testing.T) {
 		Path: "/nope",
 		Type: artifact.UploadableBinary,
 	})
+	assert.NoError(t, Pipe{}.Default(ctx))
 	err = Pipe{}.Run(ctx)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "/nope: no such file or directory")
 }
 
 func TestPipeInvalidNameTemplate(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var ctx = context.New(
+		config.Project{
+			Dist:        folder,
+			ProjectName: "name",
+			Checksum: config.Checksum{
+				NameTemplate: "{{.Env.NOPE}}",
+			},
+		},
+	)
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name: "whatever",
+		Type: artifact.UploadableBinary,
+	})
+	assert.NoError(t, Pipe{}.Default(ctx))
+	err = Pipe{}.Run(ctx)
+	assert.EqualError(t, err, `template: {{.Env.NOPE}}:1:6: executing "{{.Env.NOPE}}" at <.Env.NOPE>: map has no entry for key "NOPE"`)
+}
+
+func TestDefaultInvalidNameTemplate(t *testing.T) {
 	for template, eerr := range map[string]string{
-		"{{ .Pro }_checksums.txt": `template: checksums:1: unexpected "}" in operand`,
-		"{{.Env.NOPE}}":           `template: checksums:1:6: executing "checksums" at <.Env.NOPE>: map has no entry for key "NOPE"`,
+		"{{ .Pro }_checksums.txt":  `invalid checksum name template '{{ .Pro }_checksums.txt': template: {{ .Pro }_checksums.txt:1: unexpected "}" in operand`,
+		"{{ .Pro }}_checksums.txt": `invalid checksum name template '{{ .Pro }}_checksums.txt': template: {{ .Pro }}_checksums.txt:1:3: executing "{{ .Pro }}_checksums.txt" at <.Pro>: can't evaluate field Pro in type filenametemplate.Fields`,
 	} {
-		t.Run(template, func(tt *testing.T) {
-			folder, err := ioutil.TempDir("", "goreleasertest")
-			assert.NoError(tt, err)
-			var ctx = context.New(
-				config.Project{
-					Dist:        folder,
-					ProjectName: "name",
-					Checksum: config.Checksum{
-						NameTemplate: template,
-					},
+		t.Run(template, func(t *testing.T) {
+			var ctx = context.New(config.Project{
+				Checksum: config.Checksum{
+					NameTemplate: template,
 				},
-			)
-			ctx.Artifacts.Add(artifact.Artifact{
-				Name: "whatever",
-				Type: artifact.UploadableBinary,
 			})
-			err = Pipe{}.Run(ctx)
-			assert.Error(tt, err)
-			assert.Equal(tt, eerr, err.Error())
+			assert.EqualError(t, Pipe{}.Default(ctx), eerr)
 		})
 	}
 }
 
+func TestPipeVersionInName(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		Dist:        folder,
+		ProjectName: "mytool",
+	})
+	ctx.Version = "1.2.3"
+	ctx.Git.CurrentTag = "v1.2.3"
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	var checksums = ctx.Artifacts.Filter(artifact.ByType(artifact.Checksum)).List()
+	assert.Len(t, checksums, 1)
+	assert.Equal(t, "mytool_1.2.3_checksums.txt", checksums[0].Name)
+	assert.Equal(t, filepath.Join(folder, "mytool_1.2.3_checksums.txt"), checksums[0].Path)
+}
+
 func TestPipeCouldNotOpenChecksumsTxt(t *testing.T) {
 	folder, err := ioutil.TempDir("", "goreleasertest")
 	assert.NoError(t, err)
@@ -116,13 +216,275 @@ func TestPipeCouldNotOpenChecksumsTxt(t *testing.T) {
 			},
 		},
 	)
+	var whatever = filepath.Join(folder, "whatever")
+	assert.NoError(t, ioutil.WriteFile(whatever, []byte("some string"), 0644))
 	ctx.Artifacts.Add(artifact.Artifact{
 		Name: "whatever",
+		Path: whatever,
 		Type: artifact.UploadableBinary,
 	})
+	assert.NoError(t, Pipe{}.Default(ctx))
 	err = Pipe{}.Run(ctx)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "/checksums.txt: permission denied")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "/checksums.txt: permission denied")
+	}
+}
+
+func TestPipeAlgorithm(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var file = filepath.Join(folder, "binary")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("some string"), 0644))
+	for algorithm, sum := range map[string]string{
+		"sha256":  "61d034473102d7dac305902770471fd50f4c5b26f6831a56dd90b5184b3c30fc",
+		"sha512":  "14925e01a7a0cf0801aa95fe52d542b578af58ae7997ada66db3a6eae68a329d50600a5b7b442eabf4ea77ea8ef5fe40acf2ab31d47311b2a232c4f64009aac1",
+		"sha1":    "8b45e4bd1c6acb88bebf6407d16205f567e62a3e",
+		"md5":     "5ac749fbeec93607fc28d666be85e73a",
+		"crc32":   "f94d3859",
+		"blake2b": "5823d250906e17854136b9a9381712377d6b9f81dc694db47a9edfb2a48f6964b3f2e2b3c5f7560d13f2137480c79ba3cfc3d5f1453bc6691eb04def115cae08",
+	} {
+		t.Run(algorithm, func(t *testing.T) {
+			var ctx = context.New(config.Project{
+				Dist:        folder,
+				ProjectName: "binary",
+				Checksum: config.Checksum{
+					NameTemplate: "{{ .ProjectName }}_{{ .Algorithm }}sums.txt",
+					Algorithm:    algorithm,
+				},
+			})
+			ctx.Artifacts.Add(artifact.Artifact{
+				Name: "binary",
+				Path: file,
+				Type: artifact.UploadableBinary,
+			})
+			assert.NoError(t, Pipe{}.Default(ctx))
+			assert.NoError(t, Pipe{}.Run(ctx))
+			bts, err := ioutil.ReadFile(filepath.Join(folder, "binary_"+algorithm+"sums.txt"))
+			assert.NoError(t, err)
+			assert.Equal(t, sum+"  binary\n", string(bts))
+		})
+	}
+}
+
+func splitContext(t *testing.T, name string) (*context.Context, string) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var file = filepath.Join(folder, "binary")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("some string"), 0644))
+	var ctx = context.New(config.Project{
+		Dist:        folder,
+		ProjectName: "binary",
+		Checksum: config.Checksum{
+			NameTemplate: name,
+			Split:        true,
+		},
+	})
+	for _, a := range []artifact.Artifact{
+		{Name: "binary.tar.gz", Type: artifact.UploadableArchive},
+		{Name: "binary.deb", Type: artifact.LinuxPackage},
+		{Name: "binary.tar.gz.sig", Type: artifact.Signature},
+	} {
+		a.Path = file
+		ctx.Artifacts.Add(a)
+	}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	return ctx, folder
+}
+
+func TestPipeSplit(t *testing.T) {
+	ctx, folder := splitContext(t, "checksums.txt")
+	assert.NoError(t, Pipe{}.Run(ctx))
+	var sum = "61d034473102d7dac305902770471fd50f4c5b26f6831a56dd90b5184b3c30fc"
+	for _, name := range []string{"binary.tar.gz", "binary.deb"} {
+		bts, err := ioutil.ReadFile(filepath.Join(folder, name+".sha256"))
+		assert.NoError(t, err)
+		assert.Equal(t, sum+"  "+name+"\n", string(bts))
+	}
+	_, err := os.Stat(filepath.Join(folder, "binary.tar.gz.sig.sha256"))
+	assert.True(t, os.IsNotExist(err), "signatures should not get their own checksum file")
+	bts, err := ioutil.ReadFile(filepath.Join(folder, "checksums.txt"))
+	assert.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(bts)), "\n"), 3)
+	var names []string
+	for _, a := range ctx.Artifacts.Filter(artifact.ByType(artifact.Checksum)).List() {
+		names = append(names, a.Name)
+	}
+	assert.Equal(t, []string{"checksums.txt", "binary.deb.sha256", "binary.tar.gz.sha256"}, names)
+}
+
+func TestPipeSplitAlgorithm(t *testing.T) {
+	ctx, folder := splitContext(t, "checksums.txt")
+	ctx.Config.Checksum.Algorithm = "md5"
+	ctx.Config.Checksum.Algorithms = nil
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	bts, err := ioutil.ReadFile(filepath.Join(folder, "binary.deb.md5"))
+	assert.NoError(t, err)
+	assert.Equal(t, "5ac749fbeec93607fc28d666be85e73a  binary.deb\n", string(bts))
+}
+
+func TestPipeSplitCollision(t *testing.T) {
+	ctx, _ := splitContext(t, "binary.deb.sha256")
+	assert.EqualError(t, Pipe{}.Run(ctx), "checksum file binary.deb.sha256 of binary.deb would overwrite the checksums file")
+
+	ctx, folder := splitContext(t, "checksums.txt")
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name: "binary.tar.gz.sha256",
+		Path: filepath.Join(folder, "binary"),
+		Type: artifact.UploadableBinary,
+	})
+	assert.EqualError(t, Pipe{}.Run(ctx), "checksum file binary.tar.gz.sha256 of binary.tar.gz would overwrite the artifact binary.tar.gz.sha256")
+	_, err := os.Stat(filepath.Join(folder, "checksums.txt"))
+	assert.True(t, os.IsNotExist(err), "nothing should be written on collisions")
+}
+
+func selectionContext(t *testing.T, kinds ...string) (*context.Context, string) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var file = filepath.Join(folder, "binary")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("some string"), 0644))
+	var ctx = context.New(config.Project{
+		Dist:        folder,
+		ProjectName: "binary",
+		Checksum: config.Checksum{
+			NameTemplate: "checksums.txt",
+			Artifacts:    kinds,
+		},
+	})
+	for _, a := range []artifact.Artifact{
+		{Name: "binary_linux_amd64.tar.gz", Type: artifact.UploadableArchive},
+		{Name: "binary_windows_amd64.exe", Type: artifact.UploadableBinary},
+		{Name: "binary_1.0.0_amd64.snap", Type: artifact.LinuxPackage},
+		{Name: "binary_1.0.0_amd64.deb", Type: artifact.LinuxPackage},
+		{Name: "binary_darwin_amd64.tar.gz", Type: artifact.UploadableArchive},
+		{Name: "binary_darwin_amd64.tar.gz.sig", Type: artifact.Signature},
+		{Name: "binary", Type: artifact.Binary},
+	} {
+		a.Path = file
+		ctx.Artifacts.Add(a)
+	}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	return ctx, folder
+}
+
+func listed(t *testing.T, folder string) []string {
+	bts, err := ioutil.ReadFile(filepath.Join(folder, "checksums.txt"))
+	assert.NoError(t, err)
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(string(bts)), "\n") {
+		names = append(names, strings.Fields(line)[1])
+	}
+	return names
+}
+
+func TestPipeAllUploadables(t *testing.T) {
+	ctx, folder := selectionContext(t)
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.Equal(t, []string{
+		"binary_1.0.0_amd64.deb",
+		"binary_1.0.0_amd64.snap",
+		"binary_darwin_amd64.tar.gz",
+		"binary_darwin_amd64.tar.gz.sig",
+		"binary_linux_amd64.tar.gz",
+		"binary_windows_amd64.exe",
+	}, listed(t, folder))
+}
+
+func TestPipeSameOnEveryRun(t *testing.T) {
+	ctx, folder := selectionContext(t)
+	ctx.Parallelism = 4
+	assert.NoError(t, Pipe{}.Run(ctx))
+	first, err := ioutil.ReadFile(filepath.Join(folder, "checksums.txt"))
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, os.Remove(filepath.Join(folder, "checksums.txt")))
+		assert.NoError(t, Pipe{}.Run(ctx))
+		bts, err := ioutil.ReadFile(filepath.Join(folder, "checksums.txt"))
+		assert.NoError(t, err)
+		assert.Equal(t, string(first), string(bts))
+	}
+}
+
+func TestPipeArtifactsSelection(t *testing.T) {
+	ctx, folder := selectionContext(t, "package")
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.Equal(t, []string{"binary_1.0.0_amd64.deb", "binary_1.0.0_amd64.snap"}, listed(t, folder))
+}
+
+func TestDefaultInvalidArtifacts(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Checksum: config.Checksum{
+			Artifacts: []string{"archive", "docker"},
+		},
+	})
+	assert.EqualError(t, Pipe{}.Default(ctx), "invalid checksum artifacts 'docker', must be archive, binary, package, signature or changelog")
+}
+
+func TestPipeAlgorithms(t *testing.T) {
+	ctx, folder := splitContext(t, "")
+	ctx.Config.Checksum = config.Checksum{Algorithms: []string{"sha256", "md5"}, Split: true}
+	ctx.Version = "1.0.0"
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.Equal(t, "sha256", ctx.Config.Checksum.Algorithm)
+	assert.NoError(t, Pipe{}.Run(ctx))
+	for algorithm, sum := range map[string]string{
+		"sha256": "61d034473102d7dac305902770471fd50f4c5b26f6831a56dd90b5184b3c30fc",
+		"md5":    "5ac749fbeec93607fc28d666be85e73a",
+	} {
+		bts, err := ioutil.ReadFile(filepath.Join(folder, "binary_1.0.0_"+algorithm+"_checksums.txt"))
+		assert.NoError(t, err)
+		assert.Equal(t, sum+"  binary.deb\n"+sum+"  binary.tar.gz\n"+sum+"  binary.tar.gz.sig\n", string(bts))
+		bts, err = ioutil.ReadFile(filepath.Join(folder, "binary.deb."+algorithm))
+		assert.NoError(t, err)
+		assert.Equal(t, sum+"  binary.deb\n", string(bts))
+	}
+	var names []string
+	for _, a := range ctx.Artifacts.Filter(artifact.ByType(artifact.Checksum)).List() {
+		names = append(names, a.Name+" "+a.ExtraString("Algorithm"))
+	}
+	assert.Equal(t, []string{
+		"binary_1.0.0_sha256_checksums.txt sha256",
+		"binary_1.0.0_md5_checksums.txt md5",
+		"binary.deb.sha256 sha256",
+		"binary.deb.md5 md5",
+		"binary.tar.gz.sha256 sha256",
+		"binary.tar.gz.md5 md5",
+	}, names)
+}
+
+func TestPipeAlgorithmsSameName(t *testing.T) {
+	ctx, _ := splitContext(t, "checksums.txt")
+	ctx.Config.Checksum.Algorithms = []string{"sha256", "sha512"}
+	assert.EqualError(t, Pipe{}.Run(ctx), "the sha256 and sha512 checksums would both be written to checksums.txt, use {{ .Algorithm }} in the name template")
+}
+
+func TestDefaultInvalidAlgorithms(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Checksum: config.Checksum{
+			Algorithms: []string{"sha512", "sha3"},
+		},
+	})
+	assert.EqualError(t, Pipe{}.Default(ctx), "invalid checksum algorithm 'sha3', must be one of blake2b, crc32, md5, sha1, sha256, sha512")
+}
+
+func TestDefaultInvalidAlgorithm(t *testing.T) {
+	var ctx = &context.Context{
+		Config: config.Project{
+			Checksum: config.Checksum{
+				Algorithm: "sha3",
+			},
+		},
+	}
+	assert.EqualError(t, Pipe{}.Default(ctx), "invalid checksum algorithm 'sha3', must be one of blake2b, crc32, md5, sha1, sha256, sha512")
+}
+
+func TestDefaultInvalidFormat(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Checksum: config.Checksum{
+			Format: "sfv",
+		},
+	})
+	assert.EqualError(t, Pipe{}.Default(ctx), "invalid checksum format 'sfv', must be gnu or bsd")
 }
 
 func TestDefault(t *testing.T) {
@@ -137,6 +499,9 @@ func TestDefault(t *testing.T) {
 		"{{ .ProjectName }}_{{ .Version }}_checksums.txt",
 		ctx.Config.Checksum.NameTemplate,
 	)
+	assert.Equal(t, "sha256", ctx.Config.Checksum.Algorithm)
+	assert.Equal(t, "gnu", ctx.Config.Checksum.Format)
+	assert.Equal(t, []string{"archive", "binary", "package", "signature", "changelog"}, ctx.Config.Checksum.Artifacts)
 }
 
 func TestDefaultSet(t *testing.T) {
@@ -150,3 +515,71 @@ func TestDefaultSet(t *testing.T) {
 	assert.NoError(t, Pipe{}.Default(ctx))
 	assert.Equal(t, "checksums.txt", ctx.Config.Checksum.NameTemplate)
 }
+
+// TestPipeFormats checks both formats against the mixed fixture, which both
+// sha256sum and shasum accept, and the written files against the tools.
+func TestPipeFormats(t *testing.T) {
+	var fixture = filepath.Join("testdata", "mixed")
+	var tools [][]string
+	for _, tool := range [][]string{{"sha256sum", "-c"}, {"shasum", "-a", "256", "-c"}} {
+		if _, err := exec.LookPath(tool[0]); err == nil {
+			tools = append(tools, tool)
+		}
+	}
+	var check = func(dir, file string) {
+		for _, tool := range tools {
+			/* #nosec */
+			var cmd = exec.Command(tool[0], append(tool[1:], file)...)
+			cmd.Dir = dir
+			out, err := cmd.CombinedOutput()
+			assert.NoError(t, err, "%s: %s", tool[0], string(out))
+		}
+	}
+	check(fixture, "SHA256SUMS")
+	bts, err := ioutil.ReadFile(filepath.Join(fixture, "SHA256SUMS"))
+	assert.NoError(t, err)
+	var lines = strings.SplitAfter(string(bts), "\n")
+
+	for _, tt := range []struct {
+		format, line string
+		name         string
+	}{
+		{"gnu", lines[0], "a.txt"},
+		{"bsd", lines[1], "b.txt"},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			folder, err := ioutil.TempDir("", "goreleasertest")
+			assert.NoError(t, err)
+			defer os.RemoveAll(folder)
+			var ctx = context.New(config.Project{
+				Dist: folder,
+				Checksum: config.Checksum{
+					NameTemplate: "SHA256SUMS",
+					Format:       tt.format,
+					Split:        true,
+				},
+			})
+			for _, name := range []string{"a.txt", "b.txt"} {
+				bts, err := ioutil.ReadFile(filepath.Join(fixture, name))
+				assert.NoError(t, err)
+				var path = filepath.Join(folder, name)
+				assert.NoError(t, ioutil.WriteFile(path, bts, 0644))
+				ctx.Artifacts.Add(artifact.Artifact{
+					Name: name,
+					Path: path,
+					Type: artifact.UploadableArchive,
+				})
+			}
+			assert.NoError(t, Pipe{}.Default(ctx))
+			assert.NoError(t, Pipe{}.Run(ctx))
+			bts, err := ioutil.ReadFile(filepath.Join(folder, "SHA256SUMS"))
+			assert.NoError(t, err)
+			assert.Contains(t, string(bts), tt.line)
+			split, err := ioutil.ReadFile(filepath.Join(folder, tt.name+".sha256"))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.line, string(split))
+			check(folder, "SHA256SUMS")
+			check(folder, tt.name+".sha256")
+		})
+	}
+}
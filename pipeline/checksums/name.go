@@ -1,30 +1,13 @@
 package checksums
 
 import (
-	"bytes"
-	"text/template"
-
 	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/filenametemplate"
 )
 
-func filenameFor(ctx *context.Context) (string, error) {
-	var out bytes.Buffer
-	t, err := template.New("checksums").
-		Option("missingkey=error").
-		Parse(ctx.Config.Checksum.NameTemplate)
-	if err != nil {
-		return "", err
-	}
-	err = t.Execute(&out, struct {
-		ProjectName string
-		Tag         string
-		Version     string
-		Env         map[string]string
-	}{
-		ProjectName: ctx.Config.ProjectName,
-		Tag:         ctx.Git.CurrentTag,
-		Version:     ctx.Version,
-		Env:         ctx.Env,
-	})
-	return out.String(), err
+func filenameFor(ctx *context.Context, algorithm string) (string, error) {
+	var fields = filenametemplate.NewFields(ctx, nil, artifact.Artifact{})
+	fields.Algorithm = algorithm
+	return filenametemplate.Apply(ctx.Config.Checksum.NameTemplate, fields)
 }
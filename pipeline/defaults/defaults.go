@@ -10,10 +10,12 @@ import (
 	"github.com/goreleaser/goreleaser/pipeline/artifactory"
 	"github.com/goreleaser/goreleaser/pipeline/brew"
 	"github.com/goreleaser/goreleaser/pipeline/build"
+	"github.com/goreleaser/goreleaser/pipeline/changelog"
 	"github.com/goreleaser/goreleaser/pipeline/checksums"
 	"github.com/goreleaser/goreleaser/pipeline/docker"
 	"github.com/goreleaser/goreleaser/pipeline/env"
 	"github.com/goreleaser/goreleaser/pipeline/fpm"
+	"github.com/goreleaser/goreleaser/pipeline/git"
 	"github.com/goreleaser/goreleaser/pipeline/nfpm"
 	"github.com/goreleaser/goreleaser/pipeline/release"
 	"github.com/goreleaser/goreleaser/pipeline/scoop"
@@ -32,7 +34,9 @@ func (Pipe) String() string {
 var defaulters = []pipeline.Defaulter{
 	env.Pipe{},
 	snapshot.Pipe{},
+	git.Pipe{},
 	release.Pipe{},
+	changelog.Pipe{},
 	archive.Pipe{},
 	build.Pipe{},
 	fpm.Pipe{},
@@ -41,6 +45,7 @@ var defaulters = []pipeline.Defaulter{
 	checksums.Pipe{},
 	sign.Pipe{},
 	docker.Pipe{},
+	sign.DockerPipe{},
 	artifactory.Pipe{},
 	brew.Pipe{},
 	scoop.Pipe{},
@@ -58,10 +63,17 @@ func (Pipe) Run(ctx *context.Context) error {
 		}
 	}
 	if ctx.Config.ProjectName == "" {
-		ctx.Config.ProjectName = ctx.Config.Release.GitHub.Name
+		name, err := projectName(ctx)
+		if err != nil {
+			return err
+		}
+		ctx.Config.ProjectName = name
 	}
 	if ctx.Config.GitHubURLs.Download == "" {
 		ctx.Config.GitHubURLs.Download = "https://github.com"
 	}
+	if ctx.Config.GitLabURLs.API == "" {
+		ctx.Config.GitLabURLs.API = "https://gitlab.com/api/v4"
+	}
 	return nil
 }
@@ -1,6 +1,8 @@
 package defaults
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/goreleaser/goreleaser/config"
@@ -37,6 +39,7 @@ func TestFillBasicData(t *testing.T) {
 	assert.Contains(t, ctx.Config.Brew.Install, "bin.install \"goreleaser\"")
 	assert.Empty(t, ctx.Config.Dockers)
 	assert.Equal(t, "https://github.com", ctx.Config.GitHubURLs.Download)
+	assert.Equal(t, "https://gitlab.com/api/v4", ctx.Config.GitLabURLs.API)
 	assert.NotEmpty(
 		t,
 		ctx.Config.Archive.NameTemplate,
@@ -51,6 +54,7 @@ func TestFillPartial(t *testing.T) {
 	defer back()
 	testlib.GitInit(t)
 	testlib.GitRemoteAdd(t, "git@github.com:goreleaser/goreleaser.git")
+	assert.NoError(t, ioutil.WriteFile("Dockerfile", []byte("FROM scratch\n"), 0644))
 
 	var ctx = &context.Context{
 		Config: config.Project{
@@ -95,3 +99,58 @@ func TestFillPartial(t *testing.T) {
 	assert.Equal(t, "disttt", ctx.Config.Dist)
 	assert.NotEqual(t, "https://github.com", ctx.Config.GitHubURLs.Download)
 }
+
+func TestProjectNameFromGoModule(t *testing.T) {
+	for mod, name := range map[string]string{
+		"module github.com/goreleaser/mytool\n":                      "mytool",
+		"module \"github.com/goreleaser/mytool/v2\"\n":               "mytool",
+		"// a comment\nmodule example.com/tool.v1\n\nrequire x v1\n": "tool.v1",
+		"module mytool\n": "mytool",
+	} {
+		folder, back := testlib.Mktmp(t)
+		testlib.GitInit(t)
+		testlib.GitRemoteAdd(t, "git@github.com:goreleaser/goreleaser.git")
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, "go.mod"), []byte(mod), 0644))
+		var ctx = context.New(config.Project{})
+		assert.NoError(t, Pipe{}.Run(ctx))
+		assert.Equal(t, name, ctx.Config.ProjectName, mod)
+		back()
+	}
+}
+
+func TestProjectNameFromRemote(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitRemoteAdd(t, "git@github.com:goreleaser/goreleaser.git")
+	var ctx = context.New(config.Project{})
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.Equal(t, "goreleaser", ctx.Config.ProjectName)
+}
+
+func TestProjectNameFromWorkingDirectory(t *testing.T) {
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	name, err := projectName(context.New(config.Project{}))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Base(folder), name)
+}
+
+func TestProjectNameExplicit(t *testing.T) {
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitRemoteAdd(t, "git@github.com:goreleaser/goreleaser.git")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, "go.mod"), []byte("module foo/bar\n"), 0644))
+	var ctx = context.New(config.Project{ProjectName: "mine"})
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.Equal(t, "mine", ctx.Config.ProjectName)
+}
+
+func TestProjectNameInvalid(t *testing.T) {
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, "go.mod"), []byte("module foo/_bar\n"), 0644))
+	_, err := projectName(context.New(config.Project{}))
+	assert.EqualError(t, err, "project name '_bar' inferred from go.mod is invalid: it must start with a letter or a digit and have only letters, digits and . _ + -, set project_name")
+}
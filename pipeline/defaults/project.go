@@ -0,0 +1,64 @@
+package defaults
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/goreleaser/goreleaser/context"
+)
+
+// validProjectName matches the names usable in the archive and package
+// names, which fpm and the package managers accept as well
+var validProjectName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+-]*$`)
+
+var (
+	moduleRe       = regexp.MustCompile(`(?m)^\s*module\s+"?([^"\s]+)"?`)
+	majorVersionRe = regexp.MustCompile(`^v[0-9]+$`)
+)
+
+// projectName derives the project name from the go module path, the
+// release repository or the working directory, in that order
+func projectName(ctx *context.Context) (string, error) {
+	var name, source = moduleName(), "go.mod"
+	if name == "" {
+		name, source = ctx.Config.Release.GitHub.Name, "the release repository"
+	}
+	if name == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		name, source = filepath.Base(wd), "the working directory"
+	}
+	if !validProjectName.MatchString(name) {
+		return "", fmt.Errorf(
+			"project name '%s' inferred from %s is invalid: it must start with a letter or a digit and have only letters, digits and . _ + -, set project_name",
+			name, source,
+		)
+	}
+	log.WithField("project_name", name).WithField("from", source).Info("inferred project name")
+	return name, nil
+}
+
+// moduleName returns the last element of the go.mod module path, without
+// its major version suffix, or an empty string if there is no go.mod
+func moduleName() string {
+	bts, err := ioutil.ReadFile("go.mod")
+	if err != nil {
+		return ""
+	}
+	var match = moduleRe.FindSubmatch(bts)
+	if match == nil {
+		return ""
+	}
+	var parts = strings.Split(string(match[1]), "/")
+	if len(parts) > 1 && majorVersionRe.MatchString(parts[len(parts)-1]) {
+		parts = parts[:len(parts)-1]
+	}
+	return parts[len(parts)-1]
+}
@@ -3,15 +3,20 @@ package docker
 
 import (
 	"bytes"
+	stdctx "context"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/apex/log"
-	"github.com/masterminds/semver"
+	"github.com/mattn/go-zglob"
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
 
@@ -25,6 +30,13 @@ import (
 // ErrNoDocker is shown when docker cannot be found in $PATH
 var ErrNoDocker = errors.New("docker not present in $PATH")
 
+// availableTimeout is how long the docker daemon has to answer before it's
+// considered unavailable
+const availableTimeout = 10 * time.Second
+
+// tagRe matches the valid docker tags
+var tagRe = regexp.MustCompile(`^\w[\w.-]{0,127}$`)
+
 // Pipe for docker
 type Pipe struct{}
 
@@ -49,21 +61,60 @@ func (Pipe) Default(ctx *context.Context) error {
 		if docker.Goarch == "" {
 			docker.Goarch = "amd64"
 		}
-		if docker.Latest {
+		// same default as the builds
+		if docker.Goarch == "arm" && docker.Goarm == "" {
+			docker.Goarm = "6"
+		}
+		switch docker.Latest {
+		case "true":
 			deprecate.Notice("docker.latest")
 			docker.TagTemplates = append(docker.TagTemplates, "latest")
+			docker.Latest = "always"
+		case "", "false":
+			docker.Latest = "auto"
+		case "auto", "always", "never":
+		default:
+			return fmt.Errorf("docker %s: invalid latest '%s', must be auto, always or never", docker.Image, docker.Latest)
+		}
+		if docker.Dockerfile == "" {
+			docker.Dockerfile = "Dockerfile"
+		}
+		switch docker.Use {
+		case "":
+			docker.Use = "docker"
+		case "docker", "buildx", "podman":
+		default:
+			return fmt.Errorf("docker %s: invalid use '%s', must be docker, buildx or podman", docker.Image, docker.Use)
+		}
+		// the image is only rendered on Run, as the git info isn't known yet,
+		// but invalid templates and missing env vars fail already here.
+		if docker.Image != "" {
+			if _, err := apply(ctx, "image", docker.Image); err != nil {
+				return errors.Wrapf(err, "docker %s: invalid image template", docker.Image)
+			}
+			// checked here so a missing dockerfile fails before the builds
+			if _, err := os.Stat(docker.Dockerfile); err != nil {
+				return errors.Wrapf(err, "docker %s: invalid dockerfile", docker.Image)
+			}
+		}
+		if docker.Retry.Attempts == 0 {
+			docker.Retry.Attempts = 3
+		}
+		if docker.Retry.Delay == 0 {
+			docker.Retry.Delay = 10 * time.Second
+		}
+		if docker.Binary == "" && len(ctx.Config.Builds) == 1 {
+			docker.Binary = ctx.Config.Builds[0].Binary
 		}
 	}
-	// only set defaults if there is exacly 1 docker setup in the config file.
+	// only guess the binary if there is exacly 1 docker setup in the config
+	// file.
 	if len(ctx.Config.Dockers) != 1 {
 		return nil
 	}
-	if ctx.Config.Dockers[0].Binary == "" {
+	if ctx.Config.Dockers[0].Binary == "" && len(ctx.Config.Builds) > 0 {
 		ctx.Config.Dockers[0].Binary = ctx.Config.Builds[0].Binary
 	}
-	if ctx.Config.Dockers[0].Dockerfile == "" {
-		ctx.Config.Dockers[0].Dockerfile = "Dockerfile"
-	}
 	return nil
 }
 
@@ -72,42 +123,110 @@ func (Pipe) Run(ctx *context.Context) error {
 	if len(ctx.Config.Dockers) == 0 || ctx.Config.Dockers[0].Image == "" {
 		return pipeline.Skip("docker section is not configured")
 	}
-	_, err := exec.LookPath("docker")
+	for i := range ctx.Config.Dockers {
+		var docker = &ctx.Config.Dockers[i]
+		if docker.Image == "" {
+			return fmt.Errorf("docker #%d: image is not set", i+1)
+		}
+		image, err := apply(ctx, "image", docker.Image)
+		if err != nil {
+			return errors.Wrapf(err, "docker #%d: failed to execute image template '%s'", i+1, docker.Image)
+		}
+		if image == "" {
+			return fmt.Errorf("docker #%d: image template '%s' rendered empty", i+1, docker.Image)
+		}
+		docker.Image = image
+	}
+	for _, command := range commands(ctx) {
+		if err := available(ctx, command); err != nil {
+			if !ctx.Publish && !required(ctx) {
+				return pipeline.Skip(err.Error())
+			}
+			return err
+		}
+	}
+	logins, err := login(ctx)
 	if err != nil {
-		return ErrNoDocker
+		return err
+	}
+	if err := doRun(ctx); err != nil {
+		// the publish phase won't run to log out
+		logout(ctx, logins)
+		return err
+	}
+	return nil
+}
+
+// command returns the binary building, tagging and pushing the image: buildx
+// is a docker plugin, so it's docker as well, unless podman is used.
+func command(docker config.Docker) string {
+	if docker.Use == "podman" {
+		return "podman"
 	}
-	return doRun(ctx)
+	return "docker"
+}
+
+// commands returns the binaries used by all the docker images
+func commands(ctx *context.Context) []string {
+	var result []string
+	var seen = map[string]bool{}
+	for _, docker := range ctx.Config.Dockers {
+		var command = command(docker)
+		if !seen[command] {
+			seen[command] = true
+			result = append(result, command)
+		}
+	}
+	return result
+}
+
+// available checks that the command is installed and that it answers, which
+// needs the daemon when it's docker.
+func available(ctx *context.Context, command string) error {
+	if _, err := exec.LookPath(command); err != nil {
+		if command == "docker" {
+			return ErrNoDocker
+		}
+		return fmt.Errorf("%s not present in $PATH", command)
+	}
+	timeout, cancel := stdctx.WithTimeout(ctx, availableTimeout)
+	defer cancel()
+	/* #nosec */
+	var cmd = exec.CommandContext(timeout, command, "version")
+	log.WithField("cmd", cmd.Args).Debug("running")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "%s is not available: \n%s", command, string(out))
+	}
+	return nil
+}
+
+// required tells whether any docker image must be built, even on snapshots
+// and with --skip-publish
+func required(ctx *context.Context) bool {
+	for _, docker := range ctx.Config.Dockers {
+		if docker.Required {
+			return true
+		}
+	}
+	return false
 }
 
 func doRun(ctx *context.Context) error {
 	var g errgroup.Group
 	sem := make(chan bool, ctx.Parallelism)
-	for _, docker := range ctx.Config.Dockers {
-		docker := docker
+	for i, docker := range ctx.Config.Dockers {
+		i, docker := i, docker
 		sem <- true
 		g.Go(func() error {
 			defer func() {
 				<-sem
 			}()
-			log.WithField("docker", docker).Debug("looking for binaries matching")
-			var binaries = ctx.Artifacts.Filter(
-				artifact.And(
-					artifact.ByGoos(docker.Goos),
-					artifact.ByGoarch(docker.Goarch),
-					artifact.ByGoarm(docker.Goarm),
-					artifact.ByType(artifact.Binary),
-					func(a artifact.Artifact) bool {
-						return a.Extra["Binary"] == docker.Binary
-					},
-				),
-			).List()
-			if len(binaries) == 0 {
-				log.Warnf("no binaries found for %s", docker.Binary)
+			binary, err := findBinary(ctx, docker)
+			if err != nil {
+				return errors.Wrapf(err, "docker %s (%s)", docker.Image, docker.Dockerfile)
 			}
-			for _, binary := range binaries {
-				if err := process(ctx, docker, binary); err != nil {
-					return err
-				}
+			if err := process(ctx, i, binary); err != nil {
+				return errors.Wrapf(err, "docker %s (%s)", docker.Image, docker.Dockerfile)
 			}
 			return nil
 		})
@@ -115,60 +234,292 @@ func doRun(ctx *context.Context) error {
 	return g.Wait()
 }
 
+// findBinary returns the only binary matching the platform, binary name and
+// build ID of the docker image, failing when there are none or many.
+func findBinary(ctx *context.Context, docker config.Docker) (artifact.Artifact, error) {
+	var filters = []artifact.Filter{
+		artifact.ByType(artifact.Binary),
+		artifact.ByGoos(docker.Goos),
+		artifact.ByGoarch(docker.Goarch),
+		artifact.ByGoarm(docker.Goarm),
+	}
+	if docker.Binary != "" {
+		filters = append(filters, func(a artifact.Artifact) bool {
+			return a.ExtraString("Binary") == docker.Binary
+		})
+	}
+	if docker.BuildID != "" {
+		var byID = artifact.ByIDs(docker.BuildID)
+		if ctx.Artifacts.Count(artifact.And(artifact.ByType(artifact.Binary), byID)) == 0 {
+			return artifact.Artifact{}, fmt.Errorf("no binaries built by the build with the id %s", docker.BuildID)
+		}
+		filters = append(filters, byID)
+	}
+	var wanted = fmt.Sprintf(
+		"binary=%s goos=%s goarch=%s goarm=%s build_id=%s",
+		docker.Binary, docker.Goos, docker.Goarch, docker.Goarm, docker.BuildID,
+	)
+	log.WithField("image", docker.Image).Debugf("looking for binaries matching %s", wanted)
+	var binaries = ctx.Artifacts.Filter(artifact.And(filters...)).List()
+	if len(binaries) == 1 {
+		return binaries[0], nil
+	}
+	var candidates = binaries
+	var msg = fmt.Sprintf("%d binaries found matching %s", len(binaries), wanted)
+	if len(binaries) == 0 {
+		candidates = ctx.Artifacts.Filter(artifact.ByType(artifact.Binary)).List()
+		msg = fmt.Sprintf("no binaries found matching %s", wanted)
+	}
+	var names []string
+	for _, binary := range candidates {
+		names = append(names, describeBinary(binary))
+	}
+	return artifact.Artifact{}, fmt.Errorf("%s, candidates are: %s", msg, strings.Join(names, ", "))
+}
+
+func describeBinary(binary artifact.Artifact) string {
+	var platform = binary.Goos + "/" + binary.Goarch
+	if binary.Goarm != "" {
+		platform += "/v" + binary.Goarm
+	}
+	var desc = fmt.Sprintf("%s (%s", binary.Path, platform)
+	if binary.ID != "" {
+		desc += ", build " + binary.ID
+	}
+	return desc + ")"
+}
+
 func tagName(ctx *context.Context, tagTemplate string) (string, error) {
-	var out bytes.Buffer
-	t, err := template.New("tag").Option("missingkey=error").Parse(tagTemplate)
-	if err != nil {
-		return "", err
+	return apply(ctx, "tag", tagTemplate)
+}
+
+func buildFlags(ctx *context.Context, docker config.Docker) ([]string, error) {
+	var flags []string
+	for _, flagTemplate := range docker.BuildFlagTemplates {
+		flag, err := apply(ctx, "build flag", flagTemplate)
+		if err != nil {
+			return flags, errors.Wrapf(err, "failed to execute build flag template '%s'", flagTemplate)
+		}
+		flags = append(flags, flag)
+	}
+	return flags, nil
+}
+
+// labels renders the docker labels as `--label` flags, sorted by name. The
+// labels set in the config override the OCI ones with the same name.
+func labels(ctx *context.Context, docker config.Docker) ([]string, error) {
+	var values = map[string]string{}
+	if docker.OCILabels {
+		values["org.opencontainers.image.version"] = ctx.Version
+		values["org.opencontainers.image.revision"] = ctx.Git.Commit
+		values["org.opencontainers.image.created"] = ctx.Date.UTC().Format(time.RFC3339)
+		if ctx.Git.URL != "" {
+			values["org.opencontainers.image.source"] = ctx.Git.URL
+		}
 	}
-	sv, err := semver.NewVersion(ctx.Git.CurrentTag)
+	for name, tmpl := range docker.Labels {
+		value, err := apply(ctx, "label", tmpl)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to execute label template '%s'", tmpl)
+		}
+		values[name] = value
+	}
+	var flags []string
+	for name, value := range values {
+		flags = append(flags, fmt.Sprintf("--label=%s=%s", name, value))
+	}
+	sort.Strings(flags)
+	return flags, nil
+}
+
+func apply(ctx *context.Context, name, tmpl string) (string, error) {
+	var out bytes.Buffer
+	t, err := template.New(name).Option("missingkey=error").Parse(tmpl)
 	if err != nil {
 		return "", err
 	}
 	data := struct {
-		Version, Tag        string
-		Major, Minor, Patch int64
-		Env                 map[string]string
+		ProjectName                       string
+		Version, Tag, Commit, ShortCommit string
+		Major, Minor, Patch, Prerelease   string
+		IsSnapshot, IsPrerelease          bool
+		Date, CommitDate                  string
+		CommitTimestamp                   int64
+		Env                               map[string]string
 	}{
-		Version: ctx.Version,
-		Tag:     ctx.Git.CurrentTag,
-		Env:     ctx.Env,
-		Major:   sv.Major(),
-		Minor:   sv.Minor(),
-		Patch:   sv.Patch(),
+		ProjectName:     ctx.Config.ProjectName,
+		Version:         ctx.Version,
+		Date:            ctx.Date.UTC().Format(time.RFC3339),
+		CommitDate:      ctx.Git.CommitDate.UTC().Format(time.RFC3339),
+		CommitTimestamp: ctx.Git.CommitDate.Unix(),
+		Tag:             ctx.Git.CurrentTag,
+		Commit:          ctx.Git.Commit,
+		ShortCommit:     shortCommit(ctx.Git.Commit),
+		Env:             ctx.Env,
+		// the components are empty when the version is not semver, e.g. on
+		// snapshots, so the tags using them are skipped
+		Major:        ctx.Semver.Major,
+		Minor:        ctx.Semver.Minor,
+		Patch:        ctx.Semver.Patch,
+		Prerelease:   ctx.Semver.Prerelease,
+		IsSnapshot:   ctx.Snapshot,
+		IsPrerelease: ctx.Semver.Prerelease != "",
 	}
 	err = t.Execute(&out, data)
-	return out.String(), err
+	return strings.TrimSpace(out.String()), err
 }
 
-func process(ctx *context.Context, docker config.Docker, artifact artifact.Artifact) error {
-	var root = filepath.Dir(artifact.Path)
-	var dockerfile = filepath.Join(root, filepath.Base(docker.Dockerfile))
-	var images []string
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+func process(ctx *context.Context, index int, binary artifact.Artifact) error {
+	var docker = ctx.Config.Dockers[index]
+	var tags []string
 	for _, tagTemplate := range docker.TagTemplates {
 		tag, err := tagName(ctx, tagTemplate)
 		if err != nil {
 			return errors.Wrapf(err, "failed to execute tag template '%s'", tagTemplate)
 		}
+		if !tagRe.MatchString(tag) {
+			log.WithField("image", docker.Image).
+				Warnf("tag template '%s' rendered to an invalid tag '%s', skipping", tagTemplate, tag)
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	var images []string
+	for _, tag := range latest(ctx, docker, tags) {
 		images = append(images, fmt.Sprintf("%s:%s", docker.Image, tag))
 	}
-	if err := os.Link(docker.Dockerfile, dockerfile); err != nil {
-		return errors.Wrap(err, "failed to link dockerfile")
+	if len(images) == 0 {
+		return fmt.Errorf("no tags to build %s with, all tag templates rendered empty or invalid tags", docker.Image)
 	}
-	for _, file := range docker.Files {
-		if err := link(file, filepath.Join(root, filepath.Base(file))); err != nil {
-			return errors.Wrapf(err, "failed to link extra file '%s'", file)
-		}
+	flags, err := labels(ctx, docker)
+	if err != nil {
+		return err
+	}
+	extraFlags, err := buildFlags(ctx, docker)
+	if err != nil {
+		return err
+	}
+	flags = append(flags, extraFlags...)
+	flags = append(flags, docker.BuildFlags...)
+	root, err := ioutil.TempDir(ctx.Config.Dist, "docker")
+	if err != nil {
+		return errors.Wrap(err, "failed to create the docker build folder")
+	}
+	// the build folder is only needed while building, and a lot of them
+	// add up on long-lived CI agents.
+	defer removeFolder(root)
+	dockerfile, err := prepare(root, docker, binary)
+	if err != nil {
+		return err
 	}
-	if err := dockerBuild(ctx, root, dockerfile, images[0]); err != nil {
+	if err := dockerBuild(ctx, docker, root, dockerfile, images[0], flags); err != nil {
 		return err
 	}
 	for _, img := range images[1:] {
-		if err := dockerTag(ctx, images[0], img); err != nil {
+		if err := dockerTag(ctx, docker, images[0], img); err != nil {
 			return err
 		}
 	}
-	return publish(ctx, docker, images)
+	// the images are pushed by the publish pipe
+	for _, image := range images {
+		ctx.Artifacts.Add(artifact.Artifact{
+			Type:   artifact.DockerImage,
+			Name:   image,
+			Path:   image,
+			Goarch: docker.Goarch,
+			Goos:   docker.Goos,
+			Goarm:  docker.Goarm,
+			Extra: map[string]interface{}{
+				"Pushed":         false,
+				dockerIndexExtra: index,
+			},
+		})
+	}
+	return nil
+}
+
+func removeFolder(folder string) {
+	if err := os.RemoveAll(folder); err != nil {
+		log.WithError(err).WithField("folder", folder).Warn("failed to remove the docker build folder")
+	}
+}
+
+// latest adds or removes the latest tag according to docker.latest. On auto,
+// the latest tag from the tag templates is only kept when the current
+// version has no semver prerelease component, e.g. `v1.2.0` but not `v1.2.0-rc1`.
+func latest(ctx *context.Context, docker config.Docker, tags []string) []string {
+	var log = log.WithField("image", docker.Image)
+	var result []string
+	var found bool
+	for _, tag := range tags {
+		if tag == "latest" {
+			found = true
+			continue
+		}
+		result = append(result, tag)
+	}
+	switch docker.Latest {
+	case "always":
+		log.Info("tagging latest because docker.latest is always")
+		if !found {
+			return append(tags, "latest")
+		}
+		return tags
+	case "never":
+		log.Info("not tagging latest because docker.latest is never")
+		return result
+	}
+	if !found {
+		return tags
+	}
+	if ctx.IsPrerelease() {
+		log.Infof("not tagging latest because %s is a prerelease", ctx.Git.CurrentTag)
+		return result
+	}
+	log.Infof("tagging latest because %s is not a prerelease", ctx.Git.CurrentTag)
+	return tags
+}
+
+// prepare links the binary, the Dockerfile and the extra files into the
+// folder the image is built in. Each image is built in its own folder, so
+// images built from the same binary don't share their Dockerfile and extra
+// files.
+func prepare(root string, docker config.Docker, artifact artifact.Artifact) (dockerfile string, err error) {
+	if err := os.Link(artifact.Path, filepath.Join(root, artifact.Name)); err != nil {
+		return "", errors.Wrap(err, "failed to link binary")
+	}
+	dockerfile = filepath.Join(root, filepath.Base(docker.Dockerfile))
+	if err := os.Link(docker.Dockerfile, dockerfile); err != nil {
+		return "", errors.Wrap(err, "failed to link dockerfile")
+	}
+	for _, pattern := range docker.Files {
+		files, err := zglob.Glob(pattern)
+		if err == nil && len(files) == 0 {
+			err = os.ErrNotExist
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to link extra file '%s'", pattern)
+		}
+		for _, file := range files {
+			// files keep their path relative to the project root, so the
+			// Dockerfile can copy them from the same place.
+			var dst = filepath.Join(root, file)
+			if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+				return "", errors.Wrapf(err, "failed to link extra file '%s'", file)
+			}
+			if err := link(file, dst); err != nil {
+				return "", errors.Wrapf(err, "failed to link extra file '%s'", file)
+			}
+		}
+	}
+	return dockerfile, nil
 }
 
 // walks the src, recreating dirs and hard-linking files
@@ -194,23 +545,31 @@ func link(src, dest string) error {
 	})
 }
 
-func publish(ctx *context.Context, docker config.Docker, images []string) error {
-	if !ctx.Publish {
-		log.Warn("skipping push because --skip-publish is set")
-		return nil
+// buildArgs returns the arguments of the build command. buildx builds for the
+// platform of the image and loads it in docker, so it's tagged and pushed
+// like the images built by docker, honoring skip_push.
+func buildArgs(docker config.Docker, root, dockerfile, image string, flags []string) []string {
+	var args = []string{"build"}
+	if docker.Use == "buildx" {
+		args = []string{"buildx", "build", "--load", "--platform", platform(docker)}
 	}
-	for _, image := range images {
-		if err := dockerPush(ctx, docker, image); err != nil {
-			return err
-		}
+	args = append(args, "-f", dockerfile, "-t", image)
+	args = append(args, flags...)
+	return append(args, root)
+}
+
+func platform(docker config.Docker) string {
+	var platform = docker.Goos + "/" + docker.Goarch
+	if docker.Goarm != "" {
+		platform += "/v" + docker.Goarm
 	}
-	return nil
+	return platform
 }
 
-func dockerBuild(ctx *context.Context, root, dockerfile, image string) error {
+func dockerBuild(ctx *context.Context, docker config.Docker, root, dockerfile, image string, flags []string) error {
 	log.WithField("image", image).Info("building docker image")
 	/* #nosec */
-	var cmd = exec.CommandContext(ctx, "docker", "build", "-f", dockerfile, "-t", image, root)
+	var cmd = exec.CommandContext(ctx, command(docker), buildArgs(docker, root, dockerfile, image, flags)...)
 	log.WithField("cmd", cmd.Args).Debug("running")
 	out, err := cmd.CombinedOutput()
 	if err != nil {
@@ -220,10 +579,10 @@ func dockerBuild(ctx *context.Context, root, dockerfile, image string) error {
 	return nil
 }
 
-func dockerTag(ctx *context.Context, image, tag string) error {
+func dockerTag(ctx *context.Context, docker config.Docker, image, tag string) error {
 	log.WithField("image", image).WithField("tag", tag).Info("tagging docker image")
 	/* #nosec */
-	var cmd = exec.CommandContext(ctx, "docker", "tag", image, tag)
+	var cmd = exec.CommandContext(ctx, command(docker), "tag", image, tag)
 	log.WithField("cmd", cmd.Args).Debug("running")
 	out, err := cmd.CombinedOutput()
 	if err != nil {
@@ -232,24 +591,3 @@ func dockerTag(ctx *context.Context, image, tag string) error {
 	log.Debugf("docker tag output: \n%s", string(out))
 	return nil
 }
-
-func dockerPush(ctx *context.Context, docker config.Docker, image string) error {
-	log.WithField("image", image).Info("pushing docker image")
-	/* #nosec */
-	var cmd = exec.CommandContext(ctx, "docker", "push", image)
-	log.WithField("cmd", cmd.Args).Debug("running")
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return errors.Wrapf(err, "failed to push docker image: \n%s", string(out))
-	}
-	log.Debugf("docker push output: \n%s", string(out))
-	ctx.Artifacts.Add(artifact.Artifact{
-		Type:   artifact.DockerImage,
-		Name:   image,
-		Path:   image,
-		Goarch: docker.Goarch,
-		Goos:   docker.Goos,
-		Goarm:  docker.Goarm,
-	})
-	return nil
-}
@@ -6,12 +6,15 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/goreleaser/goreleaser/config"
 	"github.com/goreleaser/goreleaser/context"
 	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/testlib"
 	"github.com/goreleaser/goreleaser/pipeline"
 	"github.com/stretchr/testify/assert"
 )
@@ -188,7 +191,7 @@ func TestRunPipe(t *testing.T) {
 					"{{.Tag}}",
 					"latest",
 				},
-				Latest: true,
+				Latest: "true",
 			},
 			expect: []string{
 				"docker.io/nope:latest",
@@ -196,20 +199,6 @@ func TestRunPipe(t *testing.T) {
 			},
 			assertError: shouldErr(`requested access to the resource is denied`),
 		},
-		"dockerfile_doesnt_exist": {
-			publish: true,
-			docker: config.Docker{
-				Image:      "whatever",
-				Goos:       "linux",
-				Goarch:     "amd64",
-				Binary:     "mybin",
-				Dockerfile: "testdata/Dockerfilezzz",
-				TagTemplates: []string{
-					"{{.Tag}}",
-				},
-			},
-			assertError: shouldErr(`failed to link dockerfile`),
-		},
 		"extra_file_doesnt_exist": {
 			publish: true,
 			docker: config.Docker{
@@ -236,7 +225,7 @@ func TestRunPipe(t *testing.T) {
 				Binary:     "mybinnnn",
 				Dockerfile: "testdata/Dockerfile",
 			},
-			assertError: shouldNotErr,
+			assertError: shouldErr("no binaries found matching binary=mybinnnn goos=darwin goarch=amd64"),
 		},
 	}
 
@@ -278,7 +267,7 @@ func TestRunPipe(t *testing.T) {
 						Goarch: arch,
 						Goos:   os,
 						Type:   artifact.Binary,
-						Extra: map[string]string{
+						Extra: map[string]interface{}{
 							"Binary": "mybin",
 						},
 					})
@@ -290,7 +279,11 @@ func TestRunPipe(t *testing.T) {
 				_ = exec.Command("docker", "rmi", img).Run()
 			}
 
-			docker.assertError(t, Pipe{}.Run(ctx))
+			err = Pipe{}.Run(ctx)
+			if err == nil {
+				err = PublishPipe{}.Run(ctx)
+			}
+			docker.assertError(t, err)
 
 			// this might should not fail as the image should have been created when
 			// the step ran
@@ -303,6 +296,598 @@ func TestRunPipe(t *testing.T) {
 	}
 }
 
+func TestFindBinary(t *testing.T) {
+	var ctx = context.New(config.Project{})
+	for _, binary := range []artifact.Artifact{
+		{Path: "dist/cli_linux_amd64/mytool", Goos: "linux", Goarch: "amd64"},
+		{Path: "dist/cli_linux_arm64/mytool", Goos: "linux", Goarch: "arm64"},
+		{Path: "dist/cli_linux_arm_6/mytool", Goos: "linux", Goarch: "arm", Goarm: "6"},
+		{Path: "dist/server_linux_amd64/mytool", Goos: "linux", Goarch: "amd64", ID: "server"},
+	} {
+		binary.Type = artifact.Binary
+		binary.Name = "mytool"
+		if binary.ID == "" {
+			binary.ID = "cli"
+		}
+		binary.Extra = map[string]interface{}{"Binary": "mytool"}
+		ctx.Artifacts.Add(binary)
+	}
+	ctx.Artifacts.Add(artifact.Artifact{
+		Type: artifact.UploadableArchive, Path: "dist/mytool_linux_arm64.tar.gz", Goos: "linux", Goarch: "arm64",
+	})
+
+	for name, tt := range map[string]struct {
+		docker config.Docker
+		path   string
+		err    string
+	}{
+		"arm64": {
+			docker: config.Docker{Binary: "mytool", Goos: "linux", Goarch: "arm64"},
+			path:   "dist/cli_linux_arm64/mytool",
+		},
+		"armv6": {
+			docker: config.Docker{Binary: "mytool", Goos: "linux", Goarch: "arm", Goarm: "6"},
+			path:   "dist/cli_linux_arm_6/mytool",
+		},
+		"build id": {
+			docker: config.Docker{Binary: "mytool", Goos: "linux", Goarch: "amd64", BuildID: "server"},
+			path:   "dist/server_linux_amd64/mytool",
+		},
+		"any binary name": {
+			docker: config.Docker{Goos: "linux", Goarch: "amd64", BuildID: "cli"},
+			path:   "dist/cli_linux_amd64/mytool",
+		},
+		"many": {
+			docker: config.Docker{Binary: "mytool", Goos: "linux", Goarch: "amd64"},
+			err:    "2 binaries found matching binary=mytool goos=linux goarch=amd64 goarm= build_id=, candidates are: dist/cli_linux_amd64/mytool (linux/amd64, build cli), dist/server_linux_amd64/mytool (linux/amd64, build server)",
+		},
+		"unknown build id": {
+			docker: config.Docker{Goos: "linux", Goarch: "amd64", BuildID: "nope"},
+			err:    "no binaries built by the build with the id nope",
+		},
+		"none": {
+			docker: config.Docker{Binary: "mytool", Goos: "linux", Goarch: "386"},
+			err:    "no binaries found matching binary=mytool goos=linux goarch=386 goarm= build_id=, candidates are: dist/cli_linux_amd64/mytool (linux/amd64, build cli), dist/cli_linux_arm64/mytool (linux/arm64, build cli), dist/cli_linux_arm_6/mytool (linux/arm/v6, build cli), dist/server_linux_amd64/mytool (linux/amd64, build server)",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			binary, err := findBinary(ctx, tt.docker)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.path, binary.Path)
+		})
+	}
+}
+
+func TestBuildArgs(t *testing.T) {
+	var flags = []string{"--build-arg=VERSION=1.2.3", "--no-cache"}
+	for use, expected := range map[string][]string{
+		"docker": {"build", "-f", "dist/docker1/Dockerfile", "-t", "acme/mytool:1.2.3", "--build-arg=VERSION=1.2.3", "--no-cache", "dist/docker1"},
+		"podman": {"build", "-f", "dist/docker1/Dockerfile", "-t", "acme/mytool:1.2.3", "--build-arg=VERSION=1.2.3", "--no-cache", "dist/docker1"},
+		"buildx": {"buildx", "build", "--load", "--platform", "linux/arm/v7", "-f", "dist/docker1/Dockerfile", "-t", "acme/mytool:1.2.3", "--build-arg=VERSION=1.2.3", "--no-cache", "dist/docker1"},
+	} {
+		var docker = config.Docker{Use: use, Goos: "linux", Goarch: "arm", Goarm: "7"}
+		assert.Equal(t, expected, buildArgs(docker, "dist/docker1", "dist/docker1/Dockerfile", "acme/mytool:1.2.3", flags), use)
+	}
+	assert.Equal(t, "podman", command(config.Docker{Use: "podman"}))
+	assert.Equal(t, "docker", command(config.Docker{Use: "buildx"}))
+	assert.Equal(t, "docker", command(config.Docker{Use: "docker"}))
+}
+
+func TestPodmanNotInPath(t *testing.T) {
+	calls, back := testlib.FakeCommand(t, "docker", "")
+	defer back()
+	var ctx = context.New(config.Project{
+		Dockers: []config.Docker{
+			{Image: "a/b", Dockerfile: "testdata/Dockerfile", Use: "buildx"},
+			{Image: "a/c", Dockerfile: "testdata/Dockerfile", Use: "podman"},
+		},
+	})
+	ctx.Publish = true
+	if _, err := exec.LookPath("podman"); err == nil {
+		t.Skip("podman is installed")
+	}
+	assert.EqualError(t, Pipe{}.Run(ctx), "podman not present in $PATH")
+	assert.Equal(t, []string{"version"}, calls())
+}
+
+func TestDefaultInvalidUse(t *testing.T) {
+	var ctx = &context.Context{
+		Config: config.Project{
+			Dockers: []config.Docker{
+				{Image: "acme/mytool", Use: "kaniko"},
+			},
+		},
+	}
+	assert.EqualError(t, Pipe{}.Default(ctx), "docker acme/mytool: invalid use 'kaniko', must be docker, buildx or podman")
+}
+
+func TestDefaultArm(t *testing.T) {
+	var ctx = &context.Context{
+		Config: config.Project{
+			Dockers: []config.Docker{
+				{Image: "acme/mytool", Dockerfile: "testdata/Dockerfile", Goarch: "arm"},
+				{Image: "acme/mytool", Dockerfile: "testdata/Dockerfile", Goarch: "arm", Goarm: "7"},
+			},
+		},
+	}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.Equal(t, "6", ctx.Config.Dockers[0].Goarm)
+	assert.Equal(t, "7", ctx.Config.Dockers[1].Goarm)
+}
+
+func TestTagName(t *testing.T) {
+	var ctx = context.New(config.Project{})
+	ctx.Version = "1.2.3-rc.1"
+	ctx.Semver = context.Semver{Major: "1", Minor: "2", Patch: "3", Prerelease: "rc.1"}
+	ctx.Git = context.GitInfo{
+		CurrentTag: "v1.2.3-rc.1",
+		Commit:     "a1b2c3d4e5f6",
+	}
+	for tmpl, expected := range map[string]string{
+		"{{ .Version }}":                                   "1.2.3-rc.1",
+		"v{{ .Major }}.{{ .Minor }}":                       "v1.2",
+		"{{ .Major }}.{{ .Minor }}.{{ .Patch }}":           "1.2.3",
+		"{{ if .IsPrerelease }}{{ .Prerelease }}{{ end }}": "rc.1",
+		"{{ .ShortCommit }}":                               "a1b2c3d",
+		"{{ .Commit }}":                                    "a1b2c3d4e5f6",
+	} {
+		tag, err := tagName(ctx, tmpl)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, tag)
+	}
+
+	ctx.Version = "SNAPSHOT-a1b2c3d"
+	ctx.Semver = context.Semver{}
+	ctx.Snapshot = true
+	ctx.Git.CurrentTag = "a1b2c3d"
+	tag, err := tagName(ctx, "{{ if .IsSnapshot }}snapshot{{ end }}")
+	assert.NoError(t, err)
+	assert.Equal(t, "snapshot", tag)
+	tag, err = tagName(ctx, "{{ .Major }}.{{ .Minor }}")
+	assert.NoError(t, err)
+	assert.Equal(t, ".", tag)
+	assert.False(t, tagRe.MatchString(tag))
+}
+
+func TestBuildFlags(t *testing.T) {
+	var ctx = context.New(config.Project{})
+	ctx.Version = "1.2.3"
+	ctx.Git = context.GitInfo{
+		CurrentTag: "v1.2.3",
+		Commit:     "a1b2c3d4e5f6",
+	}
+	flags, err := buildFlags(ctx, config.Docker{
+		BuildFlagTemplates: []string{
+			"--build-arg=VERSION={{ .Version }}",
+			"--label=org.label-schema.vcs-ref={{ .ShortCommit }}",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"--build-arg=VERSION=1.2.3",
+		"--label=org.label-schema.vcs-ref=a1b2c3d",
+	}, flags)
+
+	_, err = buildFlags(ctx, config.Docker{
+		BuildFlagTemplates: []string{"--build-arg=FOO={{ .Env.NOPE }}"},
+	})
+	assert.EqualError(t, err, `failed to execute build flag template '--build-arg=FOO={{ .Env.NOPE }}': template: build flag:1:23: executing "build flag" at <.Env.NOPE>: map has no entry for key "NOPE"`)
+}
+
+func TestLabels(t *testing.T) {
+	var ctx = context.New(config.Project{})
+	ctx.Version = "1.2.3"
+	ctx.Date = time.Date(2018, 5, 1, 12, 30, 0, 0, time.UTC)
+	ctx.Git = context.GitInfo{
+		CurrentTag: "v1.2.3",
+		Commit:     "a1b2c3d4e5f6",
+		URL:        "https://github.com/acme/mytool.git",
+	}
+	flags, err := labels(ctx, config.Docker{
+		OCILabels: true,
+		Labels: map[string]string{
+			"org.opencontainers.image.source": "https://acme.com/mytool",
+			"com.acme.tag":                    "{{ .Tag }}",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"--label=com.acme.tag=v1.2.3",
+		"--label=org.opencontainers.image.created=2018-05-01T12:30:00Z",
+		"--label=org.opencontainers.image.revision=a1b2c3d4e5f6",
+		"--label=org.opencontainers.image.source=https://acme.com/mytool",
+		"--label=org.opencontainers.image.version=1.2.3",
+	}, flags)
+
+	flags, err = labels(ctx, config.Docker{})
+	assert.NoError(t, err)
+	assert.Empty(t, flags)
+
+	_, err = labels(ctx, config.Docker{
+		Labels: map[string]string{"foo": "{{ .Env.NOPE }}"},
+	})
+	assert.EqualError(t, err, `failed to execute label template '{{ .Env.NOPE }}': template: label:1:7: executing "label" at <.Env.NOPE>: map has no entry for key "NOPE"`)
+}
+
+func TestProcessInvalidBuildFlags(t *testing.T) {
+	folder, err := ioutil.TempDir("", "dockertest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{Dist: folder})
+	ctx.Version = "1.2.3"
+	ctx.Git = context.GitInfo{CurrentTag: "v1.2.3"}
+	ctx.Config.Dockers = []config.Docker{
+		{
+			Image:              "acme/mytool",
+			TagTemplates:       []string{"{{ .Version }}"},
+			BuildFlagTemplates: []string{"{{ .Nope }}"},
+		},
+	}
+	err = process(ctx, 0, artifact.Artifact{})
+	assert.Error(t, err)
+	files, err := ioutil.ReadDir(folder)
+	assert.NoError(t, err)
+	assert.Empty(t, files, "nothing should be prepared for the build")
+}
+
+func processContext(t *testing.T) (*context.Context, artifact.Artifact, func()) {
+	folder, back := testlib.Mktmp(t)
+	var dist = filepath.Join(folder, "dist")
+	assert.NoError(t, os.Mkdir(dist, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, "mybin"), []byte("mybin"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, "Dockerfile"), []byte("FROM scratch"), 0644))
+	var ctx = context.New(config.Project{Dist: dist})
+	ctx.Publish = true
+	ctx.Version = "1.2.3"
+	ctx.Git = context.GitInfo{CurrentTag: "v1.2.3"}
+	return ctx, artifact.Artifact{Name: "mybin", Path: filepath.Join(folder, "mybin")}, back
+}
+
+func TestProcessCleanup(t *testing.T) {
+	for name, tt := range map[string]struct {
+		cleanup  bool
+		skipPush string
+		calls    []string
+	}{
+		"cleanup": {
+			cleanup: true,
+			calls: []string{
+				"build", "tag", "push", "push", "rmi acme/mytool:1.2.3 acme/mytool:latest",
+			},
+		},
+		"no cleanup": {
+			calls: []string{"build", "tag", "push", "push"},
+		},
+		"cleanup without push": {
+			cleanup:  true,
+			skipPush: "true",
+			calls:    []string{"build", "tag"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx, binary, back := processContext(t)
+			defer back()
+			calls, backDocker := testlib.FakeCommand(t, "docker", `[ "$1" = "rmi" ] && exit 1; exit 0`)
+			defer backDocker()
+			ctx.Config.Dockers = []config.Docker{
+				{
+					Image:        "acme/mytool",
+					Dockerfile:   "Dockerfile",
+					TagTemplates: []string{"{{ .Version }}", "latest"},
+					Cleanup:      tt.cleanup,
+					SkipPush:     tt.skipPush,
+					Retry:        config.DockerRetry{Attempts: 1},
+				},
+			}
+			assert.NoError(t, process(ctx, 0, binary))
+			assert.Len(t, calls(), 2, "the images are only built and tagged")
+			assert.NoError(t, PublishPipe{}.Run(ctx))
+			var commands []string
+			for _, call := range calls() {
+				if strings.HasPrefix(call, "rmi") {
+					commands = append(commands, call)
+					continue
+				}
+				commands = append(commands, strings.Fields(call)[0])
+			}
+			assert.Equal(t, tt.calls, commands)
+			files, err := ioutil.ReadDir(ctx.Config.Dist)
+			assert.NoError(t, err)
+			assert.Empty(t, files, "build folders should be removed")
+		})
+	}
+}
+
+func TestProcessBuildFailsCleanup(t *testing.T) {
+	ctx, binary, back := processContext(t)
+	defer back()
+	_, backDocker := testlib.FakeCommand(t, "docker", `echo "failed to build"; exit 1`)
+	defer backDocker()
+	ctx.Config.Dockers = []config.Docker{
+		{
+			Image:        "acme/mytool",
+			Dockerfile:   "Dockerfile",
+			TagTemplates: []string{"{{ .Version }}"},
+		},
+	}
+	var err = process(ctx, 0, binary)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to build docker image")
+	files, err := ioutil.ReadDir(ctx.Config.Dist)
+	assert.NoError(t, err)
+	assert.Empty(t, files, "build folders should be removed")
+}
+
+func TestPrepare(t *testing.T) {
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	var dist = filepath.Join(folder, "dist")
+	for _, dir := range []string{"dist", "build/docker", "config/nested"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(folder, dir), 0755))
+	}
+	for _, file := range []string{
+		"dist/mybin",
+		"build/docker/Dockerfile.release",
+		"entrypoint.sh",
+		"config/defaults.yml",
+		"config/nested/more.yml",
+	} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, file), []byte(file), 0644))
+	}
+	root, err := ioutil.TempDir(dist, "docker")
+	assert.NoError(t, err)
+	dockerfile, err := prepare(root, config.Docker{
+		Dockerfile: "build/docker/Dockerfile.release",
+		Files:      []string{"entrypoint.sh", "config/*.yml", "config/nested"},
+	}, artifact.Artifact{
+		Name: "mybin",
+		Path: filepath.Join(dist, "mybin"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "Dockerfile.release"), dockerfile)
+	for _, file := range []string{
+		"mybin",
+		"Dockerfile.release",
+		"entrypoint.sh",
+		"config/defaults.yml",
+		"config/nested/more.yml",
+	} {
+		_, err := os.Stat(filepath.Join(root, file))
+		assert.NoError(t, err, file)
+	}
+
+	root, err = ioutil.TempDir(dist, "docker")
+	assert.NoError(t, err)
+	_, err = prepare(root, config.Docker{
+		Dockerfile: "build/docker/Dockerfile.release",
+		Files:      []string{"config/*.json"},
+	}, artifact.Artifact{
+		Name: "mybin",
+		Path: filepath.Join(dist, "mybin"),
+	})
+	assert.EqualError(t, err, "failed to link extra file 'config/*.json': file does not exist")
+}
+
+func TestLatest(t *testing.T) {
+	for name, tt := range map[string]struct {
+		latest     string
+		tag        string
+		prerelease string
+		tags       []string
+		expect     []string
+	}{
+		"auto":                        {"auto", "v1.2.0", "", []string{"1.2.0", "latest"}, []string{"1.2.0", "latest"}},
+		"auto on prerelease":          {"auto", "v1.2.0-rc1", "rc1", []string{"1.2.0-rc1", "latest"}, []string{"1.2.0-rc1"}},
+		"auto on prefixed prerelease": {"auto", "mytool/v1.2.0-rc1", "rc1", []string{"1.2.0-rc1", "latest"}, []string{"1.2.0-rc1"}},
+		"auto without latest":         {"auto", "v1.2.0", "", []string{"1.2.0"}, []string{"1.2.0"}},
+		"empty on prerelease":         {"", "v1.2.0-rc1", "rc1", []string{"1.2.0-rc1", "latest"}, []string{"1.2.0-rc1"}},
+		"always":                      {"always", "v1.2.0-rc1", "rc1", []string{"1.2.0-rc1"}, []string{"1.2.0-rc1", "latest"}},
+		"always with latest":          {"always", "v1.2.0", "", []string{"latest", "1.2.0"}, []string{"latest", "1.2.0"}},
+		"never":                       {"never", "v1.2.0", "", []string{"1.2.0", "latest"}, []string{"1.2.0"}},
+		"auto on non semver tags":     {"auto", "a1b2c3d", "", []string{"a1b2c3d", "latest"}, []string{"a1b2c3d", "latest"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var ctx = context.New(config.Project{})
+			ctx.Git.CurrentTag = tt.tag
+			ctx.Semver.Prerelease = tt.prerelease
+			var docker = config.Docker{Image: "acme/mytool", Latest: tt.latest}
+			assert.Equal(t, tt.expect, latest(ctx, docker, tt.tags))
+		})
+	}
+}
+
+func TestSkipPush(t *testing.T) {
+	for name, tt := range map[string]struct {
+		publish    bool
+		skipPush   string
+		prerelease string
+		reason     string
+	}{
+		"push":                {true, "", "", ""},
+		"push false":          {true, "false", "", ""},
+		"skip publish":        {false, "", "", "--skip-publish or --snapshot is set"},
+		"skip push":           {true, "true", "", "docker.skip_push is set"},
+		"auto on rc tag":      {true, "auto", "rc1", "this is a prerelease and docker.skip_push is auto"},
+		"auto not prerelease": {true, "auto", "", ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// the release config is left as is: release.prerelease is about
+			// the GitHub release, not the tag
+			var ctx = context.New(config.Project{})
+			ctx.Git.CurrentTag = "v1.0.0"
+			ctx.Semver = context.Semver{Major: "1", Minor: "0", Patch: "0", Prerelease: tt.prerelease}
+			if tt.prerelease != "" {
+				ctx.Git.CurrentTag += "-" + tt.prerelease
+			}
+			ctx.Publish = tt.publish
+			assert.Equal(t, tt.reason, skipPush(ctx, config.Docker{SkipPush: tt.skipPush}))
+		})
+	}
+}
+
+// addImages adds the images to the artifacts as process does, returning
+// all the images
+func addImages(ctx *context.Context, docker config.Docker, images ...string) []artifact.Artifact {
+	ctx.Config.Dockers = append(ctx.Config.Dockers, docker)
+	var index = len(ctx.Config.Dockers) - 1
+	for _, image := range images {
+		ctx.Artifacts.Add(artifact.Artifact{
+			Type:  artifact.DockerImage,
+			Name:  image,
+			Path:  image,
+			Extra: map[string]interface{}{"Pushed": false, dockerIndexExtra: index},
+		})
+	}
+	return ctx.Artifacts.Filter(artifact.ByType(artifact.DockerImage)).List()
+}
+
+func TestProcessDoesntPush(t *testing.T) {
+	ctx, binary, back := processContext(t)
+	defer back()
+	calls, backDocker := testlib.FakeCommand(t, "docker", "")
+	defer backDocker()
+	var docker = config.Docker{
+		Image:        "acme/mytool",
+		Dockerfile:   "Dockerfile",
+		TagTemplates: []string{"{{ .Version }}"},
+	}
+	ctx.Config.Dockers = []config.Docker{docker}
+	assert.NoError(t, process(ctx, 0, binary))
+	assert.Len(t, calls(), 1)
+	assert.True(t, strings.HasPrefix(calls()[0], "build "))
+	var images = ctx.Artifacts.Filter(artifact.ByType(artifact.DockerImage)).List()
+	assert.Len(t, images, 1)
+	assert.Equal(t, "acme/mytool:1.2.3", images[0].Name)
+	// the extras end up in artifacts.json, so the docker config isn't there
+	assert.Equal(t, map[string]interface{}{"Pushed": false, dockerIndexExtra: 0}, images[0].Extra)
+	assert.Equal(t, docker, dockerOf(ctx, images[0]))
+}
+
+func TestPublishPipeSkip(t *testing.T) {
+	var ctx = context.New(config.Project{})
+	testlib.AssertSkipped(t, PublishPipe{}.Run(ctx))
+	addImages(ctx, config.Docker{Image: "acme/mytool"}, "acme/mytool:1.2.3")
+	var err = PublishPipe{}.Run(ctx)
+	testlib.AssertSkipped(t, err)
+	assert.Equal(t, pipeline.ErrSkipPublish, err)
+}
+
+func TestPublishSkipped(t *testing.T) {
+	var ctx = context.New(config.Project{})
+	ctx.Publish = true
+	var images = addImages(ctx, config.Docker{
+		Image:    "acme/mytool",
+		SkipPush: "true",
+	}, "acme/mytool:1.2.3", "acme/mytool:latest")
+	assert.NoError(t, publish(ctx, images))
+	var dockers = ctx.Artifacts.Filter(artifact.ByType(artifact.DockerImage)).List()
+	assert.Len(t, dockers, 2)
+	for _, docker := range dockers {
+		assert.Equal(t, false, docker.Extra["Pushed"])
+	}
+}
+
+func TestPushRetry(t *testing.T) {
+	calls, back := testlib.FakeCommand(t, "docker", `
+if [ "$(wc -l < "$(dirname "$0")/calls")" -lt 3 ]; then
+	echo "received unexpected HTTP status: 502 Bad Gateway"
+	exit 1
+fi
+echo "1.2.3: digest: `+testDigest+` size: 528"`)
+	defer back()
+	var ctx = context.New(config.Project{})
+	var retry = config.DockerRetry{Attempts: 3, Delay: time.Millisecond}
+	digest, err := dockerPush(ctx, config.Docker{Retry: retry}, "acme/mytool:1.2.3")
+	assert.NoError(t, err)
+	assert.Equal(t, testDigest, digest)
+	assert.Len(t, calls(), 3)
+}
+
+func TestPushRetryGivesUp(t *testing.T) {
+	calls, back := testlib.FakeCommand(t, "docker", `echo "Get https://registry-1.docker.io/v2/: net/http: TLS handshake timeout"; exit 1`)
+	defer back()
+	var ctx = context.New(config.Project{})
+	var retry = config.DockerRetry{Attempts: 2, Delay: time.Millisecond}
+	var _, err = dockerPush(ctx, config.Docker{Retry: retry}, "acme/mytool:1.2.3")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "TLS handshake timeout")
+	assert.Len(t, calls(), 2)
+}
+
+func TestPushDoesntRetryAuthErrors(t *testing.T) {
+	calls, back := testlib.FakeCommand(t, "docker", `echo "denied: requested access to the resource is denied"; exit 1`)
+	defer back()
+	var ctx = context.New(config.Project{})
+	var retry = config.DockerRetry{Attempts: 3, Delay: time.Millisecond}
+	var _, err = dockerPush(ctx, config.Docker{Retry: retry}, "acme/mytool:1.2.3")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "requested access to the resource is denied")
+	assert.Len(t, calls(), 1)
+}
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestPublishDigests(t *testing.T) {
+	_, back := testlib.FakeCommand(t, "docker", `echo "The push refers to repository [docker.io/acme/mytool]"
+echo "latest: digest: `+testDigest+` size: 528"`)
+	defer back()
+	folder, err := ioutil.TempDir("", "dockertest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{Dist: folder})
+	ctx.Publish = true
+	var docker = config.Docker{Image: "acme/mytool", Retry: config.DockerRetry{Attempts: 1}}
+	addImages(ctx, docker, "acme/mytool:1.2.3", "acme/mytool:latest")
+	var images = addImages(ctx, config.Docker{Image: "acme/other", SkipPush: "true"}, "acme/other:1.2.3")
+	assert.NoError(t, publish(ctx, images))
+	for _, image := range ctx.Artifacts.Filter(artifact.ByType(artifact.DockerImage)).List() {
+		if image.Name == "acme/other:1.2.3" {
+			assert.Empty(t, image.Extra["Digest"])
+			continue
+		}
+		assert.Equal(t, testDigest, image.Extra["Digest"])
+	}
+
+	assert.NoError(t, writeDigests(ctx))
+	bts, err := ioutil.ReadFile(filepath.Join(folder, "digests.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, testDigest+"  acme/mytool:1.2.3\n"+testDigest+"  acme/mytool:latest\n", string(bts))
+	var digests = ctx.Artifacts.Filter(artifact.ByType(artifact.DockerDigests)).List()
+	assert.Len(t, digests, 1)
+	assert.Equal(t, "digests.txt", digests[0].Name)
+	assert.Empty(t, ctx.Artifacts.Filter(artifact.ByType(artifact.Checksum)).List())
+}
+
+func TestWriteDigestsNothingPushed(t *testing.T) {
+	folder, err := ioutil.TempDir("", "dockertest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{Dist: folder})
+	ctx.Artifacts.Add(artifact.Artifact{
+		Type:  artifact.DockerImage,
+		Name:  "acme/mytool:1.2.3",
+		Extra: map[string]interface{}{"Pushed": false},
+	})
+	assert.NoError(t, writeDigests(ctx))
+	_, err = os.Stat(filepath.Join(folder, "digests.txt"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestProcessNoTags(t *testing.T) {
+	var ctx = context.New(config.Project{})
+	ctx.Version = "SNAPSHOT-a1b2c3d"
+	ctx.Git = context.GitInfo{CurrentTag: "a1b2c3d"}
+	ctx.Config.Dockers = []config.Docker{
+		{
+			Image:        "acme/mytool",
+			TagTemplates: []string{"{{ .Major }}", "{{ .Major }}.{{ .Minor }}"},
+		},
+	}
+	var err = process(ctx, 0, artifact.Artifact{})
+	assert.EqualError(t, err, "no tags to build acme/mytool with, all tag templates rendered empty or invalid tags")
+}
+
 func TestDescription(t *testing.T) {
 	assert.NotEmpty(t, Pipe{}.String())
 }
@@ -321,6 +906,68 @@ func TestNoDockerWithoutImageName(t *testing.T) {
 	}))))
 }
 
+func TestDockerWithoutImageName(t *testing.T) {
+	assert.EqualError(t, Pipe{}.Run(context.New(config.Project{
+		Dockers: []config.Docker{
+			{Image: "a/b", Dockerfile: "testdata/Dockerfile"},
+			{Goos: "linux"},
+		},
+	})), "docker #2: image is not set")
+}
+
+func TestDockerfileDoesntExist(t *testing.T) {
+	var err = Pipe{}.Default(context.New(config.Project{
+		Dockers: []config.Docker{
+			{Image: "a/b", Dockerfile: "testdata/Dockerfile"},
+			{Image: "a/c", Dockerfile: "build/docker/Dockerfile.nope"},
+		},
+	}))
+	assert.EqualError(t, err, "docker a/c: invalid dockerfile: stat build/docker/Dockerfile.nope: no such file or directory")
+}
+
+func TestImageTemplate(t *testing.T) {
+	_, back := testlib.FakeCommand(t, "docker", "")
+	defer back()
+	var ctx = context.New(config.Project{
+		ProjectName: "mytool",
+		Dockers: []config.Docker{
+			{
+				Image:      "registry.acme.com/{{ .Env.CHANNEL }}/{{ .ProjectName }}",
+				Dockerfile: "testdata/Dockerfile",
+			},
+		},
+	})
+	ctx.Env = map[string]string{"CHANNEL": "beta"}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	var err = Pipe{}.Run(ctx)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "docker registry.acme.com/beta/mytool (testdata/Dockerfile): no binaries found")
+	assert.Equal(t, "registry.acme.com/beta/mytool", ctx.Config.Dockers[0].Image)
+}
+
+func TestImageTemplateRenderedEmpty(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Dockers: []config.Docker{
+			{Image: "{{ .Env.IMAGE }}", Dockerfile: "testdata/Dockerfile"},
+		},
+	})
+	ctx.Env = map[string]string{"IMAGE": ""}
+	assert.EqualError(t, Pipe{}.Run(ctx), "docker #1: image template '{{ .Env.IMAGE }}' rendered empty")
+}
+
+func TestDefaultInvalidImageTemplate(t *testing.T) {
+	for tmpl, expected := range map[string]string{
+		"acme/{{ .ProjectName": `docker acme/{{ .ProjectName: invalid image template: template: image:1: unclosed action`,
+		"acme/{{ .Env.NOPE }}": `docker acme/{{ .Env.NOPE }}: invalid image template: template: image:1:12: executing "image" at <.Env.NOPE>: map has no entry for key "NOPE"`,
+	} {
+		var ctx = context.New(config.Project{
+			Dockers: []config.Docker{{Image: tmpl}},
+		})
+		ctx.Env = map[string]string{}
+		assert.EqualError(t, Pipe{}.Default(ctx), expected)
+	}
+}
+
 func TestDockerNotInPath(t *testing.T) {
 	var path = os.Getenv("PATH")
 	defer func() {
@@ -332,14 +979,42 @@ func TestDockerNotInPath(t *testing.T) {
 		Config: config.Project{
 			Dockers: []config.Docker{
 				{
-					Image: "a/b",
+					Image:      "a/b",
+					Dockerfile: "testdata/Dockerfile",
 				},
 			},
 		},
 	}
+	testlib.AssertSkipped(t, Pipe{}.Run(ctx))
+
+	ctx.Publish = true
+	assert.EqualError(t, Pipe{}.Run(ctx), ErrNoDocker.Error())
+
+	ctx.Publish = false
+	ctx.Config.Dockers[0].Required = true
 	assert.EqualError(t, Pipe{}.Run(ctx), ErrNoDocker.Error())
 }
 
+func TestDockerDaemonNotAvailable(t *testing.T) {
+	calls, back := testlib.FakeCommand(t, "docker", `echo "Cannot connect to the Docker daemon at unix:///var/run/docker.sock."; exit 1`)
+	defer back()
+	var ctx = context.New(config.Project{
+		Dockers: []config.Docker{
+			{Image: "a/b", Dockerfile: "testdata/Dockerfile"},
+		},
+	})
+	ctx.Snapshot = true
+	testlib.AssertSkipped(t, Pipe{}.Run(ctx))
+	assert.Equal(t, []string{"version"}, calls())
+
+	ctx.Snapshot = false
+	ctx.Publish = true
+	var err = Pipe{}.Run(ctx)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "docker is not available")
+	assert.Contains(t, err.Error(), "Cannot connect to the Docker daemon")
+}
+
 func TestDefault(t *testing.T) {
 	var ctx = &context.Context{
 		Config: config.Project{
@@ -350,7 +1025,7 @@ func TestDefault(t *testing.T) {
 			},
 			Dockers: []config.Docker{
 				{
-					Latest: true,
+					Latest: "true",
 				},
 			},
 		},
@@ -364,9 +1039,37 @@ func TestDefault(t *testing.T) {
 	assert.Equal(t, "Dockerfile", docker.Dockerfile)
 	assert.Empty(t, docker.OldTagTemplate)
 	assert.Equal(t, []string{"{{ .Version }}", "latest"}, docker.TagTemplates)
+	assert.Equal(t, "always", docker.Latest)
+	assert.Equal(t, "docker", docker.Use)
+	assert.Equal(t, config.DockerRetry{Attempts: 3, Delay: 10 * time.Second}, docker.Retry)
 
 }
 
+func TestDefaultMultipleDockers(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	for _, name := range []string{"Dockerfile", "Dockerfile.debug"} {
+		assert.NoError(t, ioutil.WriteFile(name, []byte("FROM scratch\n"), 0644))
+	}
+	var ctx = &context.Context{
+		Config: config.Project{
+			Builds: []config.Build{
+				{Binary: "foo"},
+			},
+			Dockers: []config.Docker{
+				{Image: "acme/mytool"},
+				{Image: "acme/mytool-debug", Dockerfile: "Dockerfile.debug"},
+			},
+		},
+	}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.Equal(t, "Dockerfile", ctx.Config.Dockers[0].Dockerfile)
+	assert.Equal(t, "Dockerfile.debug", ctx.Config.Dockers[1].Dockerfile)
+	for _, docker := range ctx.Config.Dockers {
+		assert.Equal(t, "foo", docker.Binary)
+	}
+}
+
 func TestDefaultNoDockers(t *testing.T) {
 	var ctx = &context.Context{
 		Config: config.Project{
@@ -399,6 +1102,18 @@ func TestDefaultSet(t *testing.T) {
 	assert.Empty(t, docker.OldTagTemplate)
 	assert.Equal(t, []string{"{{ .Version }}"}, docker.TagTemplates)
 	assert.Equal(t, "Dockerfile.foo", docker.Dockerfile)
+	assert.Equal(t, "auto", docker.Latest)
+}
+
+func TestDefaultInvalidLatest(t *testing.T) {
+	var ctx = &context.Context{
+		Config: config.Project{
+			Dockers: []config.Docker{
+				{Image: "acme/mytool", Latest: "sometimes"},
+			},
+		},
+	}
+	assert.EqualError(t, Pipe{}.Default(ctx), "docker acme/mytool: invalid latest 'sometimes', must be auto, always or never")
 }
 
 func TestDefaultWithOldTagTemplateSet(t *testing.T) {
@@ -408,7 +1123,7 @@ func TestDefaultWithOldTagTemplateSet(t *testing.T) {
 				{
 					Dockerfile:     "Dockerfile.foo",
 					OldTagTemplate: "{{.Tag}}",
-					Latest:         true,
+					Latest:         "true",
 					Binary:         "foo",
 				},
 			},
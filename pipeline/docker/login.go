@@ -0,0 +1,135 @@
+package docker
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+
+	"github.com/goreleaser/goreleaser/context"
+)
+
+type registryLogin struct {
+	command, registry, username, password string
+}
+
+// registryLogins returns the registries to log in, one per registry and
+// username, ignoring the dockers whose images won't be pushed.
+func registryLogins(ctx *context.Context) ([]registryLogin, error) {
+	var logins []registryLogin
+	var seen = map[string]bool{}
+	for _, docker := range ctx.Config.Dockers {
+		if docker.Username == "" || skipPush(ctx, docker) != "" {
+			continue
+		}
+		username, err := apply(ctx, "username", docker.Username)
+		if err != nil {
+			return nil, errors.Wrapf(err, "docker %s: failed to execute username template '%s'", docker.Image, docker.Username)
+		}
+		if docker.PasswordEnv == "" {
+			return nil, fmt.Errorf("docker %s: password_env is not set", docker.Image)
+		}
+		var password = ctx.Env[docker.PasswordEnv]
+		if password == "" {
+			return nil, fmt.Errorf("docker %s: %s is not set", docker.Image, docker.PasswordEnv)
+		}
+		var key = command(docker) + " " + docker.Registry + "/" + username
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		logins = append(logins, registryLogin{
+			command:  command(docker),
+			registry: docker.Registry,
+			username: username,
+			password: password,
+		})
+	}
+	return logins, nil
+}
+
+// login logs in the registries before the images are built, so a bad login
+// fails the release before any docker build, and the builds can pull private
+// base images. The logins are kept for the publish phase, which logs out.
+func login(ctx *context.Context) ([]registryLogin, error) {
+	logins, err := registryLogins(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, login := range logins {
+		if err := dockerLogin(ctx, login); err != nil {
+			logout(ctx, logins)
+			return nil, err
+		}
+	}
+	return logins, nil
+}
+
+// withLogins logs in the registries, runs fn and logs out of the registries
+// again, whether fn failed or not.
+func withLogins(ctx *context.Context, fn func() error) error {
+	logins, err := registryLogins(ctx)
+	if err != nil {
+		return err
+	}
+	defer logout(ctx, logins)
+	for _, login := range logins {
+		if err := dockerLogin(ctx, login); err != nil {
+			return err
+		}
+	}
+	return fn()
+}
+
+func logout(ctx *context.Context, logins []registryLogin) {
+	for _, login := range logins {
+		dockerLogout(ctx, login)
+	}
+}
+
+func dockerLogin(ctx *context.Context, login registryLogin) error {
+	log.WithField("registry", registryName(login.registry)).
+		WithField("username", login.username).
+		Info("logging in to docker registry")
+	var args = []string{"login", "--username", login.username, "--password-stdin"}
+	if login.registry != "" {
+		args = append(args, login.registry)
+	}
+	/* #nosec */
+	var cmd = exec.CommandContext(ctx, login.command, args...)
+	// the password is only given through stdin, so it never shows up in the
+	// process list or in the logs.
+	cmd.Stdin = strings.NewReader(login.password)
+	log.WithField("cmd", cmd.Args).Debug("running")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "failed to login to docker registry %s: \n%s", registryName(login.registry), string(out))
+	}
+	log.Debugf("docker login output: \n%s", string(out))
+	return nil
+}
+
+func dockerLogout(ctx *context.Context, login registryLogin) {
+	log.WithField("registry", registryName(login.registry)).Info("logging out of docker registry")
+	var args = []string{"logout"}
+	if login.registry != "" {
+		args = append(args, login.registry)
+	}
+	/* #nosec */
+	var cmd = exec.CommandContext(ctx, login.command, args...)
+	log.WithField("cmd", cmd.Args).Debug("running")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		log.WithField("registry", registryName(login.registry)).
+			WithField("output", strings.TrimSpace(string(out))).
+			Warn("failed to logout of docker registry")
+	}
+}
+
+func registryName(registry string) string {
+	if registry == "" {
+		return "docker hub"
+	}
+	return registry
+}
@@ -0,0 +1,179 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/testlib"
+	"github.com/stretchr/testify/assert"
+)
+
+func loginContext(dockers ...config.Docker) *context.Context {
+	var ctx = context.New(config.Project{Dockers: dockers})
+	ctx.Publish = true
+	ctx.Env = map[string]string{
+		"ACME_USER":     "robot",
+		"ACME_PASSWORD": "s3cr3t",
+	}
+	return ctx
+}
+
+func TestRegistryLogins(t *testing.T) {
+	var ctx = loginContext(
+		config.Docker{Image: "acme/mytool"},
+		config.Docker{
+			Image:       "registry.acme.com/mytool",
+			Registry:    "registry.acme.com",
+			Username:    "{{ .Env.ACME_USER }}",
+			PasswordEnv: "ACME_PASSWORD",
+		},
+		config.Docker{
+			Image:       "registry.acme.com/mytool-debug",
+			Registry:    "registry.acme.com",
+			Username:    "{{ .Env.ACME_USER }}",
+			PasswordEnv: "ACME_PASSWORD",
+		},
+		config.Docker{
+			Image:       "registry.acme.com/unpushed",
+			Registry:    "registry.acme.com",
+			Username:    "someone-else",
+			PasswordEnv: "ACME_PASSWORD",
+			SkipPush:    "true",
+		},
+	)
+	logins, err := registryLogins(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []registryLogin{
+		{command: "docker", registry: "registry.acme.com", username: "robot", password: "s3cr3t"},
+	}, logins)
+
+	ctx.Publish = false
+	logins, err = registryLogins(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, logins)
+}
+
+func TestRegistryLoginsErrors(t *testing.T) {
+	for expected, docker := range map[string]config.Docker{
+		"docker acme/mytool: password_env is not set": {
+			Image:    "acme/mytool",
+			Username: "robot",
+		},
+		"docker acme/mytool: NOPE is not set": {
+			Image:       "acme/mytool",
+			Username:    "robot",
+			PasswordEnv: "NOPE",
+		},
+		`docker acme/mytool: failed to execute username template '{{ .Env.NOPE }}': template: username:1:7: executing "username" at <.Env.NOPE>: map has no entry for key "NOPE"`: {
+			Image:       "acme/mytool",
+			Username:    "{{ .Env.NOPE }}",
+			PasswordEnv: "ACME_PASSWORD",
+		},
+	} {
+		_, err := registryLogins(loginContext(docker))
+		assert.EqualError(t, err, expected)
+	}
+}
+
+func TestWithLogins(t *testing.T) {
+	calls, back := testlib.FakeCommand(t, "docker", `[ "$1" = "login" ] && echo "stdin: $(cat)" >> "$(dirname "$0")/calls"; exit 0`)
+	defer back()
+	var ctx = loginContext(config.Docker{
+		Image:       "registry.acme.com/mytool",
+		Registry:    "registry.acme.com",
+		Username:    "robot",
+		PasswordEnv: "ACME_PASSWORD",
+	})
+	var ran bool
+	assert.NoError(t, withLogins(ctx, func() error {
+		ran = true
+		assert.Len(t, calls(), 2)
+		return nil
+	}))
+	assert.True(t, ran)
+	assert.Equal(t, []string{
+		"login --username robot --password-stdin registry.acme.com",
+		"stdin: s3cr3t",
+		"logout registry.acme.com",
+	}, calls())
+}
+
+func TestWithLoginsFails(t *testing.T) {
+	calls, back := testlib.FakeCommand(t, "docker", `echo "unauthorized: incorrect username or password"; exit 1`)
+	defer back()
+	var ctx = loginContext(config.Docker{
+		Image:       "acme/mytool",
+		Username:    "robot",
+		PasswordEnv: "ACME_PASSWORD",
+	})
+	var err = withLogins(ctx, func() error {
+		t.Error("should not run anything when the login fails")
+		return nil
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to login to docker registry docker hub")
+	assert.Contains(t, err.Error(), "incorrect username or password")
+	assert.Equal(t, []string{
+		"login --username robot --password-stdin",
+		"logout",
+	}, calls(), "should still try to logout")
+}
+
+// loginRunContext returns a context with a binary to build an image pushed to
+// a registry which needs a login
+func loginRunContext(t *testing.T) (*context.Context, func()) {
+	ctx, binary, back := processContext(t)
+	binary.Goos = "linux"
+	binary.Goarch = "amd64"
+	binary.Type = artifact.Binary
+	binary.Extra = map[string]interface{}{"Binary": "mybin"}
+	ctx.Artifacts.Add(binary)
+	ctx.Env = map[string]string{"ACME_PASSWORD": "s3cr3t"}
+	ctx.Config.Dockers = []config.Docker{
+		{
+			Image:        "registry.acme.com/mytool",
+			Dockerfile:   "Dockerfile",
+			Binary:       "mybin",
+			Goos:         "linux",
+			Goarch:       "amd64",
+			TagTemplates: []string{"{{ .Version }}"},
+			Registry:     "registry.acme.com",
+			Username:     "robot",
+			PasswordEnv:  "ACME_PASSWORD",
+		},
+	}
+	return ctx, back
+}
+
+func TestRunLoginFailsBeforeBuilding(t *testing.T) {
+	ctx, back := loginRunContext(t)
+	defer back()
+	calls, backDocker := testlib.FakeCommand(t, "docker", `[ "$1" = "login" ] && { echo "unauthorized: incorrect username or password"; exit 1; }; exit 0`)
+	defer backDocker()
+	var err = Pipe{}.Run(ctx)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to login to docker registry registry.acme.com")
+	assert.Equal(t, []string{
+		"version",
+		"login --username robot --password-stdin registry.acme.com",
+		"logout registry.acme.com",
+	}, calls())
+	assert.Empty(t, ctx.Artifacts.Filter(artifact.ByType(artifact.DockerImage)).List())
+}
+
+func TestRunKeepsTheLoginsForThePublishPhase(t *testing.T) {
+	ctx, back := loginRunContext(t)
+	defer back()
+	calls, backDocker := testlib.FakeCommand(t, "docker", "")
+	defer backDocker()
+	assert.NoError(t, Pipe{}.Run(ctx))
+	var commands []string
+	for _, call := range calls() {
+		commands = append(commands, strings.Fields(call)[0])
+	}
+	assert.Equal(t, []string{"version", "login", "build"}, commands)
+	assert.Len(t, ctx.Artifacts.Filter(artifact.ByType(artifact.DockerImage)).List(), 1)
+}
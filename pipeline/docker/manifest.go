@@ -0,0 +1,163 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/pipeline"
+)
+
+// digestRe matches the digest docker prints when pushing images and manifest
+// lists
+var digestRe = regexp.MustCompile(`sha256:[a-f0-9]{64}`)
+
+// ManifestPipe for docker manifest lists
+type ManifestPipe struct{}
+
+func (ManifestPipe) String() string {
+	return "creating Docker manifest lists"
+}
+
+// Run the pipe
+func (ManifestPipe) Run(ctx *context.Context) error {
+	if len(ctx.Config.DockerManifests) == 0 {
+		return pipeline.Skip("docker_manifests section is not configured")
+	}
+	if !ctx.Publish {
+		return pipeline.SkipPublish(ctx)
+	}
+	for i, manifest := range ctx.Config.DockerManifests {
+		if manifest.NameTemplate == "" {
+			return fmt.Errorf("docker manifest #%d: name_template is not set", i+1)
+		}
+		if len(manifest.ImageTemplates) == 0 {
+			return fmt.Errorf("docker manifest #%d: image_templates is not set", i+1)
+		}
+	}
+	if err := available(ctx, "docker"); err != nil {
+		return err
+	}
+	return withLogins(ctx, func() error {
+		for _, manifest := range ctx.Config.DockerManifests {
+			if err := createManifest(ctx, manifest); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func createManifest(ctx *context.Context, manifest config.DockerManifest) error {
+	name, err := apply(ctx, "manifest", manifest.NameTemplate)
+	if err != nil {
+		return errors.Wrapf(err, "failed to execute manifest name template '%s'", manifest.NameTemplate)
+	}
+	images, err := manifestImages(ctx, manifest)
+	if err != nil {
+		return errors.Wrapf(err, "docker manifest %s", name)
+	}
+	for _, image := range images {
+		if !image.ExtraBool("Pushed") {
+			log.WithField("manifest", name).
+				Warnf("skipping manifest because %s wasn't pushed", image.Name)
+			return nil
+		}
+	}
+	log.WithField("manifest", name).Info("creating docker manifest list")
+	var args = []string{"create", "--amend", name}
+	for _, image := range images {
+		args = append(args, image.Name)
+	}
+	if _, err := dockerManifest(ctx, args...); err != nil {
+		return errors.Wrapf(err, "failed to create docker manifest %s", name)
+	}
+	for _, image := range images {
+		if _, err := dockerManifest(ctx, annotateArgs(name, image)...); err != nil {
+			return errors.Wrapf(err, "failed to annotate %s in docker manifest %s", image.Name, name)
+		}
+	}
+	log.WithField("manifest", name).Info("pushing docker manifest list")
+	out, err := dockerManifest(ctx, "push", name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to push docker manifest %s", name)
+	}
+	ctx.Artifacts.Add(artifact.Artifact{
+		Type: artifact.DockerManifest,
+		Name: name,
+		Path: name,
+		Extra: map[string]interface{}{
+			"Digest": digestRe.FindString(out),
+		},
+	})
+	return nil
+}
+
+// manifestImages renders the image templates of the manifest and looks for
+// the docker images built with these names.
+func manifestImages(ctx *context.Context, manifest config.DockerManifest) ([]artifact.Artifact, error) {
+	var names []string
+	for _, tmpl := range manifest.ImageTemplates {
+		name, err := apply(ctx, "manifest image", tmpl)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to execute manifest image template '%s'", tmpl)
+		}
+		names = append(names, name)
+	}
+	var images []artifact.Artifact
+	var missing []string
+	for _, name := range names {
+		name := name
+		var found = ctx.Artifacts.Filter(
+			artifact.And(
+				artifact.ByType(artifact.DockerImage),
+				func(a artifact.Artifact) bool {
+					return a.Name == name
+				},
+			),
+		).List()
+		if len(found) == 0 {
+			missing = append(missing, name)
+			continue
+		}
+		images = append(images, found[0])
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf(
+			"images not found: %s, looked for: %s",
+			strings.Join(missing, ", "),
+			strings.Join(names, ", "),
+		)
+	}
+	return images, nil
+}
+
+func annotateArgs(name string, image artifact.Artifact) []string {
+	var args = []string{"annotate", name, image.Name, "--os", image.Goos, "--arch", image.Goarch}
+	if image.Goarm != "" {
+		args = append(args, "--variant", "v"+image.Goarm)
+	}
+	return args
+}
+
+func dockerManifest(ctx *context.Context, args ...string) (string, error) {
+	/* #nosec */
+	var cmd = exec.CommandContext(ctx, "docker", append([]string{"manifest"}, args...)...)
+	// docker manifest is still experimental on older docker clients
+	cmd.Env = append(os.Environ(), "DOCKER_CLI_EXPERIMENTAL=enabled")
+	log.WithField("cmd", cmd.Args).Debug("running")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", errors.Wrapf(err, "\n%s", string(out))
+	}
+	log.Debugf("docker manifest output: \n%s", string(out))
+	return string(out), nil
+}
@@ -0,0 +1,152 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/testlib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifestDescription(t *testing.T) {
+	assert.NotEmpty(t, ManifestPipe{}.String())
+}
+
+func TestManifestNotConfigured(t *testing.T) {
+	testlib.AssertSkipped(t, ManifestPipe{}.Run(context.New(config.Project{})))
+}
+
+func TestManifestSkipPublish(t *testing.T) {
+	var ctx = context.New(config.Project{
+		DockerManifests: []config.DockerManifest{
+			{NameTemplate: "acme/mytool:{{ .Version }}"},
+		},
+	})
+	testlib.AssertSkipped(t, ManifestPipe{}.Run(ctx))
+}
+
+func TestManifestInvalidConfig(t *testing.T) {
+	for expected, manifest := range map[string]config.DockerManifest{
+		"docker manifest #2: name_template is not set": {
+			ImageTemplates: []string{"acme/mytool:{{ .Version }}-amd64"},
+		},
+		"docker manifest #2: image_templates is not set": {
+			NameTemplate: "acme/mytool:{{ .Version }}",
+		},
+	} {
+		var ctx = context.New(config.Project{
+			DockerManifests: []config.DockerManifest{
+				{
+					NameTemplate:   "acme/mytool:latest",
+					ImageTemplates: []string{"acme/mytool:latest-amd64"},
+				},
+				manifest,
+			},
+		})
+		ctx.Publish = true
+		assert.EqualError(t, ManifestPipe{}.Run(ctx), expected)
+	}
+}
+
+func manifestContext() *context.Context {
+	var ctx = context.New(config.Project{})
+	ctx.Version = "1.2.3"
+	ctx.Git = context.GitInfo{CurrentTag: "v1.2.3"}
+	for _, goarch := range []string{"amd64", "arm64"} {
+		ctx.Artifacts.Add(artifact.Artifact{
+			Type:   artifact.DockerImage,
+			Name:   "acme/mytool:1.2.3-" + goarch,
+			Path:   "acme/mytool:1.2.3-" + goarch,
+			Goos:   "linux",
+			Goarch: goarch,
+			Extra:  map[string]interface{}{"Pushed": true},
+		})
+	}
+	return ctx
+}
+
+func TestManifestImages(t *testing.T) {
+	var ctx = manifestContext()
+	images, err := manifestImages(ctx, config.DockerManifest{
+		ImageTemplates: []string{
+			"acme/mytool:{{ .Version }}-amd64",
+			"acme/mytool:{{ .Version }}-arm64",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, images, 2)
+	assert.Equal(t, "amd64", images[0].Goarch)
+	assert.Equal(t, "arm64", images[1].Goarch)
+}
+
+func TestManifestImagesMissing(t *testing.T) {
+	var ctx = manifestContext()
+	_, err := manifestImages(ctx, config.DockerManifest{
+		ImageTemplates: []string{
+			"acme/mytool:{{ .Version }}-amd64",
+			"acme/mytool:{{ .Version }}-arm7",
+			"acme/mytool:{{ .Version }}-386",
+		},
+	})
+	assert.EqualError(t, err, "images not found: acme/mytool:1.2.3-arm7, acme/mytool:1.2.3-386, looked for: acme/mytool:1.2.3-amd64, acme/mytool:1.2.3-arm7, acme/mytool:1.2.3-386")
+}
+
+func TestManifestImagesInvalidTemplate(t *testing.T) {
+	var ctx = manifestContext()
+	_, err := manifestImages(ctx, config.DockerManifest{
+		ImageTemplates: []string{"acme/mytool:{{ .Env.NOPE }}"},
+	})
+	assert.EqualError(t, err, `failed to execute manifest image template 'acme/mytool:{{ .Env.NOPE }}': template: manifest image:1:19: executing "manifest image" at <.Env.NOPE>: map has no entry for key "NOPE"`)
+}
+
+func TestCreateManifestImagesNotPushed(t *testing.T) {
+	var ctx = manifestContext()
+	ctx.Artifacts.Add(artifact.Artifact{
+		Type:   artifact.DockerImage,
+		Name:   "acme/mytool:1.2.3-386",
+		Goos:   "linux",
+		Goarch: "386",
+		Extra:  map[string]interface{}{"Pushed": false},
+	})
+	assert.NoError(t, createManifest(ctx, config.DockerManifest{
+		NameTemplate: "acme/mytool:{{ .Version }}",
+		ImageTemplates: []string{
+			"acme/mytool:{{ .Version }}-amd64",
+			"acme/mytool:{{ .Version }}-386",
+		},
+	}))
+	assert.Empty(t, ctx.Artifacts.Filter(artifact.ByType(artifact.DockerManifest)).List())
+}
+
+func TestCreateManifestMissingImages(t *testing.T) {
+	var ctx = manifestContext()
+	assert.EqualError(t, createManifest(ctx, config.DockerManifest{
+		NameTemplate:   "acme/mytool:{{ .Version }}",
+		ImageTemplates: []string{"acme/mytool:{{ .Version }}-386"},
+	}), "docker manifest acme/mytool:1.2.3: images not found: acme/mytool:1.2.3-386, looked for: acme/mytool:1.2.3-386")
+}
+
+func TestAnnotateArgs(t *testing.T) {
+	assert.Equal(t, []string{
+		"annotate", "acme/mytool:1.2.3", "acme/mytool:1.2.3-arm64", "--os", "linux", "--arch", "arm64",
+	}, annotateArgs("acme/mytool:1.2.3", artifact.Artifact{
+		Name:   "acme/mytool:1.2.3-arm64",
+		Goos:   "linux",
+		Goarch: "arm64",
+	}))
+	assert.Equal(t, []string{
+		"annotate", "acme/mytool:1.2.3", "acme/mytool:1.2.3-armv7", "--os", "linux", "--arch", "arm", "--variant", "v7",
+	}, annotateArgs("acme/mytool:1.2.3", artifact.Artifact{
+		Name:   "acme/mytool:1.2.3-armv7",
+		Goos:   "linux",
+		Goarch: "arm",
+		Goarm:  "7",
+	}))
+}
+
+func TestDigestRe(t *testing.T) {
+	var digest = "sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	assert.Equal(t, digest, digestRe.FindString("Pushed ref acme/mytool@"+digest+"\n"+digest+"\n"))
+}
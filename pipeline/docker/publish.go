@@ -0,0 +1,225 @@
+package docker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/pipeline"
+)
+
+// dockerIndexExtra is the extra of the docker images with the index of the
+// docker config they were built with, which pushes them
+const dockerIndexExtra = "DockerIndex"
+
+// PublishPipe for pushing the docker images
+type PublishPipe struct{}
+
+func (PublishPipe) String() string {
+	return "pushing Docker images"
+}
+
+// Run the pipe
+func (PublishPipe) Run(ctx *context.Context) error {
+	var images = ctx.Artifacts.Filter(artifact.ByType(artifact.DockerImage)).List()
+	if len(images) == 0 {
+		return pipeline.Skip("no docker images were built")
+	}
+	if !ctx.Publish {
+		return pipeline.SkipPublish(ctx)
+	}
+	return withLogins(ctx, func() error {
+		if err := publish(ctx, images); err != nil {
+			return err
+		}
+		return writeDigests(ctx)
+	})
+}
+
+// dockerOf returns the docker config the image was built with
+func dockerOf(ctx *context.Context, image artifact.Artifact) config.Docker {
+	index, ok := image.Extra[dockerIndexExtra].(int)
+	if !ok || index >= len(ctx.Config.Dockers) {
+		return config.Docker{}
+	}
+	return ctx.Config.Dockers[index]
+}
+
+// skipPush returns why the images shouldn't be pushed, empty if they should.
+func skipPush(ctx *context.Context, docker config.Docker) string {
+	if !ctx.Publish && ctx.SkipPublish != "" {
+		return ctx.SkipPublish
+	}
+	if !ctx.Publish {
+		return "--skip-publish or --snapshot is set"
+	}
+	if docker.SkipPush == "true" {
+		return "docker.skip_push is set"
+	}
+	if docker.SkipPush == "auto" && ctx.IsPrerelease() {
+		return "this is a prerelease and docker.skip_push is auto"
+	}
+	return ""
+}
+
+// publish pushes the images which aren't skipped, recording their digests,
+// and removes the local tags of the pushed images with docker.cleanup.
+func publish(ctx *context.Context, images []artifact.Artifact) error {
+	var g errgroup.Group
+	var sem = make(chan bool, ctx.Parallelism)
+	var lock sync.Mutex
+	// the images to remove, by command
+	var cleanup = map[string][]string{}
+	var warned = map[string]bool{}
+	for _, image := range images {
+		image := image
+		var docker = dockerOf(ctx, image)
+		if reason := skipPush(ctx, docker); reason != "" {
+			if !warned[docker.Image] {
+				warned[docker.Image] = true
+				log.WithField("image", docker.Image).Warnf("skipping push because %s", reason)
+			}
+			continue
+		}
+		sem <- true
+		g.Go(func() error {
+			defer func() {
+				<-sem
+			}()
+			digest, err := dockerPush(ctx, docker, image.Name)
+			if err != nil {
+				return err
+			}
+			ctx.Artifacts.SetExtra(image, "Pushed", true)
+			ctx.Artifacts.SetExtra(image, "Digest", digest)
+			if docker.Cleanup {
+				lock.Lock()
+				defer lock.Unlock()
+				cleanup[command(docker)] = append(cleanup[command(docker)], image.Name)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	for command, names := range cleanup {
+		sort.Strings(names)
+		dockerRmi(ctx, command, names)
+	}
+	return nil
+}
+
+// dockerRmi removes the local tags of the pushed image, only warning when it
+// fails.
+func dockerRmi(ctx *context.Context, command string, images []string) {
+	log.WithField("images", images).Info("removing local docker images")
+	/* #nosec */
+	var cmd = exec.CommandContext(ctx, command, append([]string{"rmi"}, images...)...)
+	log.WithField("cmd", cmd.Args).Debug("running")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		log.WithField("images", images).
+			WithField("output", strings.TrimSpace(string(out))).
+			Warn("failed to remove local docker images")
+	}
+}
+
+// writeDigests writes the digests of the pushed images to the dist folder,
+// one `<digest>  <image>` per line, like the checksums file.
+func writeDigests(ctx *context.Context) error {
+	var lines []string
+	for _, image := range ctx.Artifacts.Filter(artifact.ByType(artifact.DockerImage)).List() {
+		if image.ExtraString("Digest") == "" {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s  %s", image.ExtraString("Digest"), image.Name))
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	sort.Strings(lines)
+	var path = filepath.Join(ctx.Config.Dist, "digests.txt")
+	log.WithField("file", path).Info("writing docker image digests")
+	if err := ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return errors.Wrap(err, "failed to write docker image digests")
+	}
+	ctx.Artifacts.Add(artifact.Artifact{
+		Type: artifact.DockerDigests,
+		Name: "digests.txt",
+		Path: path,
+	})
+	return nil
+}
+
+// retryableErrors are the parts of the docker push output that tell the push
+// failed because of the network or the registry, and not because of, e.g.,
+// missing permissions, so it's worth trying again.
+var retryableErrors = []string{
+	"received unexpected HTTP status: 5",
+	"500 Internal Server Error",
+	"502 Bad Gateway",
+	"503 Service Unavailable",
+	"504 Gateway Timeout",
+	"toomanyrequests",
+	"connection reset by peer",
+	"i/o timeout",
+	"TLS handshake timeout",
+	"net/http: request canceled",
+	"unexpected EOF",
+	": EOF",
+}
+
+func isRetryable(out string) bool {
+	for _, s := range retryableErrors {
+		if strings.Contains(out, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// dockerPush pushes the image, trying again with an exponential backoff when
+// the push fails with a retryable error, and returns the pushed digest.
+func dockerPush(ctx *context.Context, docker config.Docker, image string) (string, error) {
+	var retry = docker.Retry
+	var delay = retry.Delay
+	for attempt := 1; ; attempt++ {
+		log.WithField("image", image).Info("pushing docker image")
+		/* #nosec */
+		var cmd = exec.CommandContext(ctx, command(docker), "push", image)
+		log.WithField("cmd", cmd.Args).Debug("running")
+		out, err := cmd.CombinedOutput()
+		if err == nil {
+			log.Debugf("docker push output: \n%s", string(out))
+			var digest = digestRe.FindString(string(out))
+			if digest == "" {
+				log.WithField("image", image).Warn("no digest found in the docker push output")
+			}
+			return digest, nil
+		}
+		if attempt >= retry.Attempts || !isRetryable(string(out)) {
+			return "", errors.Wrapf(err, "failed to push docker image: \n%s", string(out))
+		}
+		log.WithField("image", image).
+			WithField("output", strings.TrimSpace(string(out))).
+			Warnf("push failed, trying again in %s (attempt %d of %d)", delay, attempt+1, retry.Attempts)
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
@@ -28,11 +28,19 @@ func (Pipe) Default(ctx *context.Context) error {
 	if env.GitHubToken == "" {
 		env.GitHubToken = "~/.config/goreleaser/github_token"
 	}
+	if env.GitLabToken == "" {
+		env.GitLabToken = "~/.config/goreleaser/gitlab_token"
+	}
 	return nil
 }
 
 // Run the pipe
 func (Pipe) Run(ctx *context.Context) error {
+	gitlabToken, err := loadEnv("GITLAB_TOKEN", ctx.Config.EnvFiles.GitLabToken)
+	if err != nil {
+		return errors.Wrap(err, "failed to load gitlab token")
+	}
+	ctx.GitLabToken = gitlabToken
 	token, err := loadEnv("GITHUB_TOKEN", ctx.Config.EnvFiles.GitHubToken)
 	ctx.Token = token
 	if !ctx.Publish {
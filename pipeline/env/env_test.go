@@ -21,6 +21,7 @@ func TestDefault(t *testing.T) {
 		ctx := context.New(config.Project{})
 		assert.NoError(t, Pipe{}.Default(ctx))
 		assert.Equal(t, "~/.config/goreleaser/github_token", ctx.Config.EnvFiles.GitHubToken)
+		assert.Equal(t, "~/.config/goreleaser/gitlab_token", ctx.Config.EnvFiles.GitLabToken)
 	})
 	t.Run("custom config config", func(tt *testing.T) {
 		cfg := "what"
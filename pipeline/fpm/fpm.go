@@ -137,6 +137,7 @@ func create(ctx *context.Context, format, arch string, binaries []artifact.Artif
 	}
 	ctx.Artifacts.Add(artifact.Artifact{
 		Type:   artifact.LinuxPackage,
+		ID:     ctx.Config.FPM.ID,
 		Name:   name + "." + format,
 		Path:   file,
 		Goos:   binaries[0].Goos,
@@ -156,6 +157,10 @@ func cmd(ctx *context.Context, options []string) *exec.Cmd {
 		}
 		cmd.Env = append(cmd.Env, env)
 	}
+	if ctx.Config.Git.Reproducible {
+		// fpm and rpmbuild take the build date of the packages from it
+		cmd.Env = append(cmd.Env, fmt.Sprintf("SOURCE_DATE_EPOCH=%d", ctx.Date.Unix()))
+	}
 	return cmd
 }
 
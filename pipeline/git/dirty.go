@@ -0,0 +1,76 @@
+package git
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// dirtyLines returns the lines of the git status output whose paths don't
+// all match one of the ignored globs. Renames are only ignored when both
+// paths are.
+func dirtyLines(status string, ignored []string) []string {
+	var globs []*regexp.Regexp
+	for _, glob := range ignored {
+		globs = append(globs, globRegexp(glob))
+	}
+	var lines []string
+	for _, line := range strings.Split(status, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if len(line) > 3 && ignoredPaths(strings.Split(line[3:], " -> "), globs) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func ignoredPaths(paths []string, globs []*regexp.Regexp) bool {
+	for _, path := range paths {
+		// paths with special characters are quoted
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			path = unquoted
+		}
+		if !matchesAny(path, globs) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesAny(path string, globs []*regexp.Regexp) bool {
+	for _, glob := range globs {
+		if glob.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// globRegexp converts a glob to a regexp: `*` and `?` match within a path
+// segment, `**` matches any number of segments.
+func globRegexp(glob string) *regexp.Regexp {
+	var re bytes.Buffer
+	re.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			re.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			re.WriteString("[^/]*")
+		case glob[i] == '?':
+			re.WriteString("[^/]")
+		default:
+			re.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	re.WriteString("$")
+	return regexp.MustCompile(re.String())
+}
@@ -0,0 +1,60 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobRegexp(t *testing.T) {
+	for glob, paths := range map[string]map[string]bool{
+		"VERSION": {
+			"VERSION":     true,
+			"VERSION.txt": false,
+			"a/VERSION":   false,
+		},
+		"build/**": {
+			"build/info.json":   true,
+			"build/a/info.json": true,
+			"build":             false,
+			"builds/info.json":  false,
+			"a/build/info.json": false,
+			"build.go":          false,
+		},
+		"*.json": {
+			"info.json":   true,
+			"a/info.json": false,
+		},
+		"**/*.json": {
+			"info.json":     true,
+			"a/b/info.json": true,
+			"info.yaml":     false,
+		},
+		"file?.txt": {
+			"file1.txt":  true,
+			"file12.txt": false,
+		},
+		"a+b(c).txt": {
+			"a+b(c).txt": true,
+			"aab(c).txt": false,
+		},
+	} {
+		var re = globRegexp(glob)
+		for path, match := range paths {
+			assert.Equal(t, match, re.MatchString(path), "%s %s", glob, path)
+		}
+	}
+}
+
+func TestDirtyLines(t *testing.T) {
+	var status = " M VERSION\n" +
+		"?? build/info.json\n" +
+		"?? build/a/b.json\n" +
+		" M main.go\n" +
+		"R  build/old.json -> build/new.json\n" +
+		"R  build/old.go -> main_test.go\n" +
+		`?? "build/with \"quotes\".json"` + "\n"
+	assert.Equal(t, []string{" M main.go", "R  build/old.go -> main_test.go"}, dirtyLines(status, []string{"VERSION", "build/**"}))
+	assert.Len(t, dirtyLines(status, nil), 7)
+	assert.Empty(t, dirtyLines("", nil))
+}
@@ -1,6 +1,9 @@
 package git
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // ErrInvalidVersionFormat is return when the version isnt in a valid format
 type ErrInvalidVersionFormat struct {
@@ -11,6 +14,25 @@ func (e ErrInvalidVersionFormat) Error() string {
 	return fmt.Sprintf("%v is not in a valid version format", e.version)
 }
 
+// ErrInvalidSnapshotName happens when the snapshot name template renders a
+// name which can't be used as a version
+type ErrInvalidSnapshotName struct {
+	name string
+}
+
+func (e ErrInvalidSnapshotName) Error() string {
+	return fmt.Sprintf("snapshot name '%s' is not a valid version: it must start with a letter or a digit and have only letters, digits and . + ~ -", e.name)
+}
+
+// ErrTagRegex happens when the tag doesn't match the git.tag_regex
+type ErrTagRegex struct {
+	tag, regex string
+}
+
+func (e ErrTagRegex) Error() string {
+	return fmt.Sprintf("git tag %v doesn't match the git.tag_regex %v", e.tag, e.regex)
+}
+
 // ErrDirty happens when the repo has uncommitted/unstashed changes
 type ErrDirty struct {
 	status string
@@ -29,6 +51,32 @@ func (e ErrWrongRef) Error() string {
 	return fmt.Sprintf("git tag %v was not made against commit %v", e.tag, e.commit)
 }
 
+// ErrShallow happens when the repository is a shallow clone, whose missing
+// history and tags would make the changelog wrong, and git.unshallow is not
+// set.
+var ErrShallow = fmt.Errorf("git repository is a shallow clone, so the previous tag and the changelog would be wrong. Either fetch the whole history with `git fetch --tags --unshallow` or set git.unshallow")
+
 // ErrNoTag happens if the underlying git repository doesn't contain any tags
 // but no snapshot-release was requested.
 var ErrNoTag = fmt.Errorf("git doesn't contain any tags. Either add a tag or use --snapshot")
+
+// ErrNoTagWithPrefix happens if none of the tags of the git repository start
+// with the git.tag_prefix, but no snapshot-release was requested.
+type ErrNoTagWithPrefix struct {
+	prefix string
+}
+
+func (e ErrNoTagWithPrefix) Error() string {
+	return fmt.Sprintf("git doesn't contain any tags starting with %v. Either add a tag or use --snapshot", e.prefix)
+}
+
+// ErrWrongPrefix happens when HEAD is tagged, but not with a tag starting
+// with the git.tag_prefix
+type ErrWrongPrefix struct {
+	tags   []string
+	prefix string
+}
+
+func (e ErrWrongPrefix) Error() string {
+	return fmt.Sprintf("HEAD is tagged %v, none of which start with the git.tag_prefix %v", strings.Join(e.tags, ", "), e.prefix)
+}
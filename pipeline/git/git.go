@@ -2,15 +2,20 @@ package git
 
 import (
 	"bytes"
+	"fmt"
+	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 	"time"
 
 	"github.com/apex/log"
+	"github.com/goreleaser/goreleaser/config"
 	"github.com/goreleaser/goreleaser/context"
 	"github.com/goreleaser/goreleaser/internal/git"
 	"github.com/goreleaser/goreleaser/pipeline"
+	"github.com/masterminds/semver"
 	"github.com/pkg/errors"
 )
 
@@ -21,23 +26,50 @@ func (Pipe) String() string {
 	return "getting and validating git state"
 }
 
+// Default sets the pipe defaults
+func (Pipe) Default(ctx *context.Context) error {
+	if ctx.Config.Git.Remote == "" {
+		ctx.Config.Git.Remote = "origin"
+	}
+	return nil
+}
+
 // Run the pipe
 func (Pipe) Run(ctx *context.Context) (err error) {
-	tag, commit, err := getInfo()
+	if err = unshallow(ctx); err != nil {
+		return
+	}
+	var prefix = ctx.Config.Git.TagPrefix
+	tag, commit, err := getInfo(prefix)
 	if err != nil {
 		return
 	}
 	if tag == "" && !ctx.Snapshot {
+		if prefix != "" {
+			return ErrNoTagWithPrefix{prefix}
+		}
 		return ErrNoTag
 	}
+	date, err := commitDate()
+	if err != nil {
+		return errors.Wrap(err, "failed to get the commit date")
+	}
 	ctx.Git = context.GitInfo{
 		CurrentTag: tag,
 		Commit:     commit,
+		CommitDate: date,
+		URL:        getURL(ctx.Config.Git.Remote),
 	}
 	log.Infof("releasing %s, commit %s", tag, commit)
+	if ctx.Config.Git.Reproducible {
+		if ctx.Date, err = reproducibleDate(ctx); err != nil {
+			return
+		}
+	}
 	if err = setVersion(ctx, tag, commit); err != nil {
 		return
 	}
+	setSemver(ctx)
 	if !ctx.Validate {
 		return pipeline.Skip("--skip-validate is set")
 	}
@@ -53,52 +85,160 @@ func setVersion(ctx *context.Context, tag, commit string) (err error) {
 		ctx.Version = snapshotName
 		return nil
 	}
-	// removes usual `v` prefix
-	ctx.Version = strings.TrimPrefix(tag, "v")
+	ctx.Version, err = versionFor(ctx.Config.Git, tag)
 	return
 }
 
+// setSemver parses the version as semver, leaving the components empty if
+// it isn't
+func setSemver(ctx *context.Context) {
+	sv, err := semver.NewVersion(ctx.Version)
+	if err != nil {
+		log.WithField("version", ctx.Version).Debug("version is not semver, its components are empty")
+		ctx.Semver = context.Semver{}
+		return
+	}
+	ctx.Semver = context.Semver{
+		Major:      strconv.FormatInt(sv.Major(), 10),
+		Minor:      strconv.FormatInt(sv.Minor(), 10),
+		Patch:      strconv.FormatInt(sv.Patch(), 10),
+		Prerelease: sv.Prerelease(),
+	}
+}
+
+// versionFor extracts the version from the tag, with the capture group of
+// the tag regex when there is one, or removing the tag prefix and the usual
+// `v` prefix otherwise.
+func versionFor(cfg config.Git, tag string) (string, error) {
+	if cfg.TagRegex == "" {
+		return strings.TrimPrefix(strings.TrimPrefix(tag, cfg.TagPrefix), "v"), nil
+	}
+	re, err := regexp.Compile(cfg.TagRegex)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid git.tag_regex '%s'", cfg.TagRegex)
+	}
+	if re.NumSubexp() != 1 {
+		return "", fmt.Errorf("git.tag_regex '%s' must have one capture group, the version", cfg.TagRegex)
+	}
+	var match = re.FindStringSubmatch(tag)
+	if match == nil {
+		return "", ErrTagRegex{tag, cfg.TagRegex}
+	}
+	return match[1], nil
+}
+
 type snapshotNameData struct {
-	Commit    string
-	Tag       string
-	Timestamp int64
+	Commit          string
+	ShortCommit     string
+	Tag             string
+	Env             map[string]string
+	Timestamp       int64
+	CommitDate      string
+	CommitTimestamp int64
 }
 
 func getSnapshotName(ctx *context.Context, tag, commit string) (string, error) {
-	tmpl, err := template.New("snapshot").Parse(ctx.Config.Snapshot.NameTemplate)
+	tmpl, err := template.New("snapshot").
+		Option("missingkey=error").
+		Parse(ctx.Config.Snapshot.NameTemplate)
 	var out bytes.Buffer
 	if err != nil {
 		return "", err
 	}
 	var data = snapshotNameData{
-		Commit:    commit,
-		Tag:       tag,
-		Timestamp: time.Now().Unix(),
+		Commit:          commit,
+		ShortCommit:     shortCommit(commit),
+		Tag:             tag,
+		Env:             ctx.Env,
+		Timestamp:       time.Now().Unix(),
+		CommitDate:      ctx.Git.CommitDate.UTC().Format(time.RFC3339),
+		CommitTimestamp: ctx.Git.CommitDate.Unix(),
+	}
+	if ctx.Config.Git.Reproducible {
+		// the build date, so snapshots of the same commit have the same name
+		data.Timestamp = ctx.Date.Unix()
+	}
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	var name = out.String()
+	if !snapshotNameRe.MatchString(name) {
+		return "", ErrInvalidSnapshotName{name}
+	}
+	return name, nil
+}
+
+// snapshotNameRe matches the names usable as the version of the archives
+// and the deb and rpm packages
+var snapshotNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.+~-]*$`)
+
+// shortCommit returns the abbreviated commit hash
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
 	}
-	err = tmpl.Execute(&out, data)
-	return out.String(), err
+	return commit
 }
 
 func validate(ctx *context.Context, commit, tag string) error {
-	out, err := git.Run("status", "--porcelain")
-	if strings.TrimSpace(out) != "" || err != nil {
+	var args = []string{"status", "--porcelain"}
+	if len(ctx.Config.Git.IgnoreDirtyPaths) > 0 {
+		// lists the files of the untracked directories, so they can be
+		// ignored one by one
+		args = append(args, "--untracked-files=all")
+	}
+	out, err := git.Run(args...)
+	if err != nil {
 		return ErrDirty{out}
 	}
+	if lines := dirtyLines(out, ctx.Config.Git.IgnoreDirtyPaths); len(lines) > 0 {
+		return ErrDirty{strings.Join(lines, "\n")}
+	}
 	if ctx.Snapshot {
 		return nil
 	}
-	if !regexp.MustCompile("^[0-9.]+").MatchString(ctx.Version) {
+	if _, err := semver.NewVersion(ctx.Version); err != nil && !ctx.Config.Git.SkipVersionCheck {
 		return ErrInvalidVersionFormat{ctx.Version}
 	}
 	_, err = git.Clean(git.Run("describe", "--exact-match", "--tags", "--match", tag))
 	if err != nil {
+		if tags := headTags(); ctx.Config.Git.TagPrefix != "" && len(tags) > 0 {
+			return ErrWrongPrefix{tags, ctx.Config.Git.TagPrefix}
+		}
 		return ErrWrongRef{commit, tag}
 	}
 	return nil
 }
 
-func getInfo() (tag, commit string, err error) {
-	tag, err = git.Clean(git.Run("describe", "--tags", "--abbrev=0"))
+// getURL returns the URL of the given remote, without its credentials, or
+// empty if there is no such remote
+func getURL(remote string) string {
+	rawurl, err := git.Clean(git.Run("config", "--get", "remote."+remote+".url"))
+	if err != nil {
+		log.WithError(err).Infof("failed to retrieve the %s url", remote)
+		return ""
+	}
+	// the url ends up in the docker image labels
+	if u, err := url.Parse(rawurl); err == nil && u.User != nil {
+		u.User = nil
+		return u.String()
+	}
+	return rawurl
+}
+
+// headTags returns the tags of HEAD
+func headTags() []string {
+	out, err := git.Run("tag", "--points-at", "HEAD")
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(out)
+}
+
+// getInfo returns the latest tag, only considering the tags starting with
+// the given prefix, and the HEAD commit
+func getInfo(prefix string) (tag, commit string, err error) {
+	tag, err = git.Clean(git.Run(git.DescribeArgs(prefix, "--tags", "--abbrev=0")...))
 	if err != nil {
 		log.WithError(err).Info("failed to retrieve current tag")
 	}
@@ -0,0 +1,40 @@
+package git
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/git"
+	"github.com/pkg/errors"
+)
+
+// commitDate returns the committer date of HEAD
+func commitDate() (time.Time, error) {
+	out, err := git.Clean(git.Run("log", "-1", "--format='%ct'", "HEAD"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	secs, err := strconv.ParseInt(out, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid commit date '%s'", out)
+	}
+	return time.Unix(secs, 0).UTC(), nil
+}
+
+// reproducibleDate returns the date to build with in reproducible mode:
+// the one of SOURCE_DATE_EPOCH, when set, or the commit date otherwise.
+// See https://reproducible-builds.org/specs/source-date-epoch/
+func reproducibleDate(ctx *context.Context) (time.Time, error) {
+	var epoch = ctx.Env["SOURCE_DATE_EPOCH"]
+	if epoch == "" {
+		return ctx.Git.CommitDate, nil
+	}
+	secs, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid SOURCE_DATE_EPOCH '%s'", epoch)
+	}
+	log.WithField("SOURCE_DATE_EPOCH", epoch).Info("using the source date")
+	return time.Unix(secs, 0).UTC(), nil
+}
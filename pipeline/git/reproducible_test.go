@@ -0,0 +1,71 @@
+package git
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/testlib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitDate(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "commit1")
+	testlib.GitTag(t, "v0.0.1")
+	var now = time.Now()
+	var ctx = context.New(config.Project{})
+	testlib.AssertSkipped(t, Pipe{}.Run(ctx))
+	assert.WithinDuration(t, now, ctx.Git.CommitDate, time.Minute)
+	assert.Equal(t, time.UTC, ctx.Git.CommitDate.Location())
+	// the build date is still the current one
+	assert.False(t, ctx.Date.Before(now))
+}
+
+func TestReproducible(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "commit1")
+	testlib.GitTag(t, "v0.0.1")
+	var ctx = context.New(config.Project{
+		Git: config.Git{Reproducible: true},
+	})
+	ctx.Env = map[string]string{}
+	testlib.AssertSkipped(t, Pipe{}.Run(ctx))
+	assert.Equal(t, ctx.Git.CommitDate, ctx.Date)
+
+	ctx.Env["SOURCE_DATE_EPOCH"] = "1500000000"
+	testlib.AssertSkipped(t, Pipe{}.Run(ctx))
+	assert.Equal(t, time.Unix(1500000000, 0).UTC(), ctx.Date)
+	assert.NotEqual(t, ctx.Git.CommitDate, ctx.Date)
+
+	ctx.Env["SOURCE_DATE_EPOCH"] = "yesterday"
+	assert.EqualError(
+		t,
+		Pipe{}.Run(ctx),
+		`invalid SOURCE_DATE_EPOCH 'yesterday': strconv.ParseInt: parsing "yesterday": invalid syntax`,
+	)
+}
+
+func TestReproducibleSnapshot(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "first")
+	var ctx = context.New(config.Project{
+		Git: config.Git{Reproducible: true},
+		Snapshot: config.Snapshot{
+			NameTemplate: "{{ .Timestamp }}-{{ .CommitTimestamp }}",
+		},
+	})
+	ctx.Env = map[string]string{}
+	ctx.Snapshot = true
+	testlib.AssertSkipped(t, Pipe{}.Run(ctx))
+	var ts = strconv.FormatInt(ctx.Git.CommitDate.Unix(), 10)
+	assert.Equal(t, ts+"-"+ts, ctx.Version)
+}
@@ -0,0 +1,52 @@
+package git
+
+import (
+	"os"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/git"
+)
+
+// unshallow fetches the whole history and the tags of a shallow clone when
+// git.unshallow is set. Otherwise a shallow clone fails the release when the
+// changelog, which needs the history up to the previous tag, is built.
+func unshallow(ctx *context.Context) error {
+	if !isShallow() {
+		return nil
+	}
+	if !ctx.Config.Git.Unshallow {
+		if ctx.Snapshot || ctx.Config.Changelog.Skip || ctx.ReleaseNotes != "" {
+			log.Warn("git repository is a shallow clone, its history and tags may be incomplete")
+			return nil
+		}
+		return ErrShallow
+	}
+	var remote = ctx.Config.Git.Remote
+	log.WithField("remote", remote).Info("fetching the whole history of the shallow clone")
+	if _, err := git.Run("fetch", "--tags", "--unshallow", remote); err != nil {
+		return errors.Wrapf(err, "failed to fetch the whole history from %s", remote)
+	}
+	if isShallow() {
+		return errors.Errorf("git repository is still a shallow clone after fetching the whole history from %s", remote)
+	}
+	return nil
+}
+
+// isShallow tells if the repository is a shallow clone
+func isShallow() bool {
+	out, err := git.Clean(git.Run("rev-parse", "--is-shallow-repository"))
+	if err == nil && (out == "true" || out == "false") {
+		return out == "true"
+	}
+	// gits older than 2.15 print the unknown flag back, but they have a
+	// shallow file in shallow clones
+	path, err := git.Clean(git.Run("rev-parse", "--git-path", "shallow"))
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,84 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/git"
+	"github.com/goreleaser/goreleaser/internal/testlib"
+	"github.com/stretchr/testify/assert"
+)
+
+// shallowClone creates a repository with two tags and moves to a depth 1
+// clone of it, returning a func moving back.
+func shallowClone(t *testing.T) func() {
+	folder, back := testlib.Mktmp(t)
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "commit1")
+	testlib.GitTag(t, "v0.0.1")
+	testlib.GitCommit(t, "commit2")
+	testlib.GitCommit(t, "commit3")
+	testlib.GitTag(t, "v0.0.2")
+	var clone = filepath.Join(folder, "clone")
+	_, err := git.Run("clone", "--quiet", "--depth", "1", "file://"+folder, clone)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(clone))
+	return back
+}
+
+func TestShallowClone(t *testing.T) {
+	defer shallowClone(t)()
+	assert.True(t, isShallow())
+	var ctx = context.New(config.Project{})
+	ctx.Validate = true
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.EqualError(t, Pipe{}.Run(ctx), ErrShallow.Error())
+
+	// the history isn't needed without changelog
+	ctx.Config.Changelog.Skip = true
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.Equal(t, "v0.0.2", ctx.Git.CurrentTag)
+	assert.True(t, isShallow())
+}
+
+func TestShallowCloneUnshallow(t *testing.T) {
+	defer shallowClone(t)()
+	var ctx = context.New(config.Project{
+		Git: config.Git{Unshallow: true},
+	})
+	ctx.Validate = true
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.False(t, isShallow())
+	assert.Equal(t, "v0.0.2", ctx.Git.CurrentTag)
+	// the previous tag and its history are there for the changelog
+	prev, err := git.Clean(git.Run("describe", "--tags", "--abbrev=0", "v0.0.2^"))
+	assert.NoError(t, err)
+	assert.Equal(t, "v0.0.1", prev)
+	out, err := git.Run("log", "--format=%s", "v0.0.1..v0.0.2")
+	assert.NoError(t, err)
+	assert.Equal(t, "commit3\ncommit2\n", out)
+}
+
+func TestShallowCloneUnshallowFails(t *testing.T) {
+	defer shallowClone(t)()
+	var ctx = context.New(config.Project{
+		Git: config.Git{Unshallow: true, Remote: "nope"},
+	})
+	err := Pipe{}.Run(ctx)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "failed to fetch the whole history from nope")
+	}
+}
+
+func TestNotShallow(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitCommit(t, "commit1")
+	assert.False(t, isShallow())
+	assert.NoError(t, unshallow(context.New(config.Project{})))
+}
@@ -0,0 +1,163 @@
+// Package hooks implements the Pipe interface running the global hooks,
+// the commands of `before.hooks` before the builds and the ones of
+// `after.hooks` at the end of the release, even when it failed.
+package hooks
+
+import (
+	"bytes"
+	stdctx "context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/pipeline"
+)
+
+// BeforePipe runs the before hooks
+type BeforePipe struct{}
+
+func (BeforePipe) String() string {
+	return "running before hooks"
+}
+
+// Run the pipe
+func (BeforePipe) Run(ctx *context.Context) error {
+	if len(ctx.Config.Before.Hooks) == 0 {
+		return pipeline.Skip("before.hooks is not set")
+	}
+	for _, hook := range ctx.Config.Before.Hooks {
+		if err := run(ctx, hook, newData(ctx), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AfterPipe runs the after hooks, with the error of the release, if any
+type AfterPipe struct {
+	Err error
+}
+
+func (AfterPipe) String() string {
+	return "running after hooks"
+}
+
+// Run the pipe
+func (p AfterPipe) Run(ctx *context.Context) error {
+	if len(ctx.Config.After.Hooks) == 0 {
+		return pipeline.Skip("after.hooks is not set")
+	}
+	if ctx.Err() != nil {
+		// the release timed out or was interrupted, the hooks still run
+		var fresh = *ctx
+		fresh.Context = stdctx.Background()
+		ctx = &fresh
+	}
+	var data = afterData{data: newData(ctx), Success: p.Err == nil}
+	if p.Err != nil {
+		data.Error = p.Err.Error()
+	}
+	var env = []string{
+		"GORELEASER_SUCCESS=" + strconv.FormatBool(data.Success),
+		"GORELEASER_ERROR=" + data.Error,
+	}
+	for _, hook := range ctx.Config.After.Hooks {
+		if err := run(ctx, hook, data, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// data of the hook templates
+type data struct {
+	ProjectName, Tag, Version, Commit string
+	Env                               map[string]string
+}
+
+// afterData of the after hook templates, telling how the release went
+type afterData struct {
+	data
+	Success bool
+	Error   string
+}
+
+func newData(ctx *context.Context) data {
+	return data{
+		ProjectName: ctx.Config.ProjectName,
+		Tag:         ctx.Git.CurrentTag,
+		Version:     ctx.Version,
+		Commit:      ctx.Git.Commit,
+		Env:         ctx.Env,
+	}
+}
+
+// run renders the hook with the data and runs it with the given environment
+// variables added to the ones of goreleaser, logging its output as it goes
+func run(ctx *context.Context, hook string, data interface{}, env []string) error {
+	command, err := render(hook, data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to render hook '%s'", hook)
+	}
+	var args = strings.Fields(command)
+	if len(args) == 0 {
+		return nil
+	}
+	var log = log.WithField("hook", command)
+	log.Info("running hook")
+	/* #nosec */
+	var cmd = exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(cmd.Env, os.Environ()...)
+	cmd.Env = append(cmd.Env, env...)
+	var out = &logWriter{log: log}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err = cmd.Run()
+	out.flush()
+	if err != nil {
+		return errors.Wrapf(err, "hook '%s' failed", command)
+	}
+	return nil
+}
+
+func render(hook string, data interface{}) (string, error) {
+	t, err := template.New("hook").Option("missingkey=error").Parse(hook)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	err = t.Execute(&out, data)
+	return out.String(), err
+}
+
+// logWriter logs the lines written to it
+type logWriter struct {
+	log *log.Entry
+	buf bytes.Buffer
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		var line, err = w.buf.ReadString('\n')
+		if err != nil {
+			// keep the incomplete line for the next write
+			w.buf.WriteString(line)
+			return len(p), nil
+		}
+		w.log.Info(strings.TrimSuffix(line, "\n"))
+	}
+}
+
+func (w *logWriter) flush() {
+	if w.buf.Len() > 0 {
+		w.log.Info(w.buf.String())
+		w.buf.Reset()
+	}
+}
@@ -0,0 +1,145 @@
+package hooks
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/testlib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescription(t *testing.T) {
+	assert.NotEmpty(t, BeforePipe{}.String())
+	assert.NotEmpty(t, AfterPipe{}.String())
+}
+
+func TestBefore(t *testing.T) {
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	var ctx = context.New(config.Project{
+		ProjectName: "mytool",
+		Before: config.Before{
+			Hooks: []string{
+				"touch first",
+				"touch {{ .ProjectName }}-{{ .Env.HOOK_SUFFIX }}",
+				"mv first second",
+			},
+		},
+	})
+	ctx.Env = map[string]string{"HOOK_SUFFIX": "generated"}
+	assert.NoError(t, BeforePipe{}.Run(ctx))
+	assert.FileExists(t, filepath.Join(folder, "second"))
+	assert.FileExists(t, filepath.Join(folder, "mytool-generated"))
+}
+
+func TestBeforeFails(t *testing.T) {
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	var ctx = context.New(config.Project{
+		Before: config.Before{
+			Hooks: []string{"touch first", "false", "touch second"},
+		},
+	})
+	assert.EqualError(t, BeforePipe{}.Run(ctx), "hook 'false' failed: exit status 1")
+	assert.FileExists(t, filepath.Join(folder, "first"))
+	files, err := ioutil.ReadDir(folder)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1, "the hooks after the failed one don't run")
+}
+
+func TestBeforeInvalid(t *testing.T) {
+	for hook, msg := range map[string]string{
+		"nope-not-a-command":   `hook 'nope-not-a-command' failed: exec: "nope-not-a-command": executable file not found in $PATH`,
+		"echo {{ .Env.NOPE }}": "failed to render hook 'echo {{ .Env.NOPE }}': ",
+		"echo {{":              "failed to render hook 'echo {{': ",
+	} {
+		var ctx = context.New(config.Project{
+			Before: config.Before{Hooks: []string{hook}},
+		})
+		ctx.Env = map[string]string{}
+		var err = BeforePipe{}.Run(ctx)
+		if assert.Error(t, err, hook) {
+			assert.Contains(t, err.Error(), msg, hook)
+		}
+	}
+}
+
+func TestBeforeSkip(t *testing.T) {
+	testlib.AssertSkipped(t, BeforePipe{}.Run(context.New(config.Project{})))
+}
+
+func TestLogWriter(t *testing.T) {
+	var w = &logWriter{log: log.WithField("hook", "test")}
+	n, err := w.Write([]byte("first\nsec"))
+	assert.NoError(t, err)
+	assert.Equal(t, 9, n)
+	assert.Equal(t, "sec", w.buf.String())
+	_, err = w.Write([]byte("ond\n"))
+	assert.NoError(t, err)
+	assert.Empty(t, w.buf.String())
+	_, err = w.Write([]byte("last"))
+	assert.NoError(t, err)
+	w.flush()
+	assert.Empty(t, w.buf.String())
+}
+
+func TestAfter(t *testing.T) {
+	for name, tt := range map[string]struct {
+		err            error
+		file, expected string
+	}{
+		"success": {nil, "ok", "true \n"},
+		"failure": {errors.New("failed to build"), "failed", "false failed to build\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			folder, back := testlib.Mktmp(t)
+			defer back()
+			assert.NoError(t, ioutil.WriteFile(
+				filepath.Join(folder, "hook.sh"),
+				[]byte(`echo "$GORELEASER_SUCCESS $GORELEASER_ERROR" > result`),
+				0644,
+			))
+			var ctx = context.New(config.Project{
+				After: config.After{
+					Hooks: []string{
+						"sh hook.sh",
+						"touch {{ if .Success }}ok{{ else }}failed{{ end }}",
+					},
+				},
+			})
+			assert.NoError(t, AfterPipe{Err: tt.err}.Run(ctx))
+			bts, err := ioutil.ReadFile(filepath.Join(folder, "result"))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, string(bts))
+			assert.FileExists(t, filepath.Join(folder, tt.file))
+		})
+	}
+}
+
+func TestAfterCanceled(t *testing.T) {
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	ctx, cancel := context.NewWithTimeout(config.Project{
+		After: config.After{Hooks: []string{"touch done"}},
+	}, time.Minute)
+	cancel()
+	assert.NoError(t, AfterPipe{Err: ctx.Err()}.Run(ctx))
+	assert.FileExists(t, filepath.Join(folder, "done"))
+}
+
+func TestAfterFails(t *testing.T) {
+	var ctx = context.New(config.Project{
+		After: config.After{Hooks: []string{"false"}},
+	})
+	assert.EqualError(t, AfterPipe{}.Run(ctx), "hook 'false' failed: exit status 1")
+}
+
+func TestAfterSkip(t *testing.T) {
+	testlib.AssertSkipped(t, AfterPipe{}.Run(context.New(config.Project{})))
+}
@@ -0,0 +1,91 @@
+// Package metadata implements the Pipe interface writing the artifacts and
+// the release metadata to the dist folder, as json.
+package metadata
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/pipeline"
+)
+
+// Pipe for the artifacts and metadata files
+type Pipe struct{}
+
+func (Pipe) String() string {
+	return "writing artifacts metadata"
+}
+
+// artifactJSON is an artifact in artifacts.json, its fields are part of the
+// format and must not be renamed
+type artifactJSON struct {
+	Name      string                 `json:"name"`
+	Path      string                 `json:"path"`
+	Type      string                 `json:"type"`
+	ID        string                 `json:"id,omitempty"`
+	Goos      string                 `json:"goos,omitempty"`
+	Goarch    string                 `json:"goarch,omitempty"`
+	Goarm     string                 `json:"goarm,omitempty"`
+	Checksums map[string]string      `json:"checksums,omitempty"`
+	Extra     map[string]interface{} `json:"extra,omitempty"`
+}
+
+// metadataJSON is the content of metadata.json, its fields are part of the
+// format and must not be renamed
+type metadataJSON struct {
+	ProjectName string `json:"project_name"`
+	Tag         string `json:"tag"`
+	PreviousTag string `json:"previous_tag,omitempty"`
+	Version     string `json:"version"`
+	Commit      string `json:"commit"`
+	Date        string `json:"date"`
+	Snapshot    bool   `json:"snapshot"`
+}
+
+// Run the pipe
+func (Pipe) Run(ctx *context.Context) error {
+	if ctx.Config.Metadata.Skip {
+		return pipeline.Skip("metadata.skip is set")
+	}
+	// the artifacts are listed in the order they were added
+	var artifacts = []artifactJSON{}
+	for _, a := range ctx.Artifacts.List() {
+		artifacts = append(artifacts, artifactJSON{
+			Name:      a.Name,
+			Path:      a.Path,
+			Type:      a.Type.String(),
+			ID:        a.ID,
+			Goos:      a.Goos,
+			Goarch:    a.Goarch,
+			Goarm:     a.Goarm,
+			Checksums: a.Checksums,
+			Extra:     a.Extra,
+		})
+	}
+	if err := write(ctx, "artifacts.json", artifacts); err != nil {
+		return err
+	}
+	return write(ctx, "metadata.json", metadataJSON{
+		ProjectName: ctx.Config.ProjectName,
+		Tag:         ctx.Git.CurrentTag,
+		PreviousTag: ctx.Git.PreviousTag,
+		Version:     ctx.Version,
+		Commit:      ctx.Git.Commit,
+		Date:        ctx.Date.UTC().Format(time.RFC3339),
+		Snapshot:    ctx.Snapshot,
+	})
+}
+
+func write(ctx *context.Context, name string, data interface{}) error {
+	bts, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	var path = filepath.Join(ctx.Config.Dist, name)
+	log.WithField("file", path).Info("writing")
+	return ioutil.WriteFile(path, append(bts, '\n'), 0644)
+}
@@ -0,0 +1,149 @@
+package metadata
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/testlib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescription(t *testing.T) {
+	assert.NotEmpty(t, Pipe{}.String())
+}
+
+func TestRun(t *testing.T) {
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	var ctx = context.New(config.Project{
+		ProjectName: "mytool",
+		Dist:        folder,
+	})
+	ctx.Version = "1.2.3"
+	ctx.Date = time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)
+	ctx.Git = context.GitInfo{
+		CurrentTag:  "v1.2.3",
+		PreviousTag: "v1.2.2",
+		Commit:      "a1b2c3d4",
+	}
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:   "mytool",
+		Path:   "dist/linux_amd64/mytool",
+		Goos:   "linux",
+		Goarch: "amd64",
+		Type:   artifact.Binary,
+		ID:     "cli",
+		Extra:  map[string]interface{}{"Binary": "mytool", "Ext": ""},
+	})
+	var archive = artifact.Artifact{
+		Name:   "mytool_1.2.3_linux_armv6.tar.gz",
+		Path:   "dist/mytool_1.2.3_linux_armv6.tar.gz",
+		Goos:   "linux",
+		Goarch: "arm",
+		Goarm:  "6",
+		Type:   artifact.UploadableArchive,
+		Extra:  map[string]interface{}{"Files": []string{"README.md", "mytool"}},
+	}
+	ctx.Artifacts.Add(archive)
+	ctx.Artifacts.SetChecksums(archive, map[string]string{"sha256": "abc"})
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name: "org/mytool:v1.2.3",
+		Path: "org/mytool:v1.2.3",
+		Type: artifact.DockerImage,
+		Extra: map[string]interface{}{
+			"Pushed": true,
+			"Digest": "sha256:def",
+		},
+	})
+	assert.NoError(t, Pipe{}.Run(ctx))
+
+	bts, err := ioutil.ReadFile(filepath.Join(folder, "artifacts.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, `[
+  {
+    "name": "mytool",
+    "path": "dist/linux_amd64/mytool",
+    "type": "Binary",
+    "id": "cli",
+    "goos": "linux",
+    "goarch": "amd64",
+    "extra": {
+      "Binary": "mytool",
+      "Ext": ""
+    }
+  },
+  {
+    "name": "mytool_1.2.3_linux_armv6.tar.gz",
+    "path": "dist/mytool_1.2.3_linux_armv6.tar.gz",
+    "type": "UploadableArchive",
+    "goos": "linux",
+    "goarch": "arm",
+    "goarm": "6",
+    "checksums": {
+      "sha256": "abc"
+    },
+    "extra": {
+      "Files": [
+        "README.md",
+        "mytool"
+      ]
+    }
+  },
+  {
+    "name": "org/mytool:v1.2.3",
+    "path": "org/mytool:v1.2.3",
+    "type": "DockerImage",
+    "extra": {
+      "Digest": "sha256:def",
+      "Pushed": true
+    }
+  }
+]
+`, string(bts))
+
+	bts, err = ioutil.ReadFile(filepath.Join(folder, "metadata.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{
+  "project_name": "mytool",
+  "tag": "v1.2.3",
+  "previous_tag": "v1.2.2",
+  "version": "1.2.3",
+  "commit": "a1b2c3d4",
+  "date": "2018-06-01T10:00:00Z",
+  "snapshot": false
+}
+`, string(bts))
+}
+
+func TestRunNoArtifacts(t *testing.T) {
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	var ctx = context.New(config.Project{Dist: folder})
+	assert.NoError(t, Pipe{}.Run(ctx))
+	bts, err := ioutil.ReadFile(filepath.Join(folder, "artifacts.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, "[]\n", string(bts))
+}
+
+func TestRunSkip(t *testing.T) {
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	var ctx = context.New(config.Project{
+		Dist:     folder,
+		Metadata: config.Metadata{Skip: true},
+	})
+	testlib.AssertSkipped(t, Pipe{}.Run(ctx))
+	files, err := ioutil.ReadDir(folder)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestRunInvalidDist(t *testing.T) {
+	var ctx = context.New(config.Project{Dist: "/nope/nope"})
+	assert.Error(t, Pipe{}.Run(ctx))
+}
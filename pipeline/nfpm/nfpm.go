@@ -4,6 +4,7 @@ package nfpm
 import (
 	"os"
 	"path/filepath"
+	"strings"
 
 	"golang.org/x/sync/errgroup"
 
@@ -108,7 +109,7 @@ func create(ctx *context.Context, format, arch string, binaries []artifact.Artif
 		Recommends:  ctx.Config.NFPM.Recommends,
 		Suggests:    ctx.Config.NFPM.Suggests,
 		Name:        ctx.Config.ProjectName,
-		Version:     ctx.Version,
+		Version:     packageVersion(format, ctx.Version),
 		Section:     "",
 		Priority:    "",
 		Maintainer:  ctx.Config.NFPM.Maintainer,
@@ -141,6 +142,7 @@ func create(ctx *context.Context, format, arch string, binaries []artifact.Artif
 	}
 	ctx.Artifacts.Add(artifact.Artifact{
 		Type:   artifact.LinuxPackage,
+		ID:     ctx.Config.NFPM.ID,
 		Name:   name + "." + format,
 		Path:   path,
 		Goos:   binaries[0].Goos,
@@ -149,3 +151,13 @@ func create(ctx *context.Context, format, arch string, binaries []artifact.Artif
 	})
 	return nil
 }
+
+// packageVersion returns the version of the package of the given format.
+// rpm versions can't have a `-`, which separates the version from the
+// release, so it is replaced with a `_`, as fpm does.
+func packageVersion(format, version string) string {
+	if format == "rpm" {
+		return strings.Replace(version, "-", "_", -1)
+	}
+	return version
+}
@@ -63,6 +63,7 @@ func TestRunPipe(t *testing.T) {
 		ProjectName: "mybin",
 		Dist:        dist,
 		NFPM: config.FPM{
+			ID:           "packages",
 			Bindir:       "/usr/bin",
 			NameTemplate: defaultNameTemplate,
 			Formats:      []string{"deb", "rpm"},
@@ -97,6 +98,9 @@ func TestRunPipe(t *testing.T) {
 	}
 	assert.NoError(t, Pipe{}.Run(ctx))
 	assert.Len(t, ctx.Config.NFPM.Files, 1, "should not modify the config file list")
+	for _, pkg := range ctx.Artifacts.Filter(artifact.ByType(artifact.LinuxPackage)).List() {
+		assert.Equal(t, "packages", pkg.ID)
+	}
 }
 
 func TestInvalidNameTemplate(t *testing.T) {
@@ -145,6 +149,12 @@ func TestCreateFileDoesntExist(t *testing.T) {
 	assert.Contains(t, Pipe{}.Run(ctx).Error(), `dist/mybin/mybin: no such file or directory`)
 }
 
+func TestPackageVersion(t *testing.T) {
+	assert.Equal(t, "1.2.3-next+abc", packageVersion("deb", "1.2.3-next+abc"))
+	assert.Equal(t, "1.2.3_next+abc", packageVersion("rpm", "1.2.3-next+abc"))
+	assert.Equal(t, "1.2.3", packageVersion("rpm", "1.2.3"))
+}
+
 func TestDefault(t *testing.T) {
 	var ctx = &context.Context{
 		Config: config.Project{
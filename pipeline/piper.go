@@ -18,6 +18,15 @@ type Piper interface {
 // to proceed because of that.
 var ErrSkipPublish = Skip("--skip-publish is set")
 
+// SkipPublish returns the skip of a publishing pipe, with the reason the
+// publishing is disabled
+func SkipPublish(ctx *context.Context) ErrSkip {
+	if ctx.SkipPublish != "" {
+		return Skip(ctx.SkipPublish)
+	}
+	return ErrSkipPublish
+}
+
 // IsSkip returns true if the error is an ErrSkip
 func IsSkip(err error) bool {
 	_, ok := err.(ErrSkip)
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -18,3 +20,10 @@ func TestIsSkip(t *testing.T) {
 	assert.True(t, IsSkip(Skip("whatever")))
 	assert.False(t, IsSkip(errors.New("nope")))
 }
+
+func TestSkipPublish(t *testing.T) {
+	var ctx = context.New(config.Project{})
+	assert.Equal(t, ErrSkipPublish, SkipPublish(ctx))
+	ctx.SkipPublish = "publish_if is false"
+	assert.EqualError(t, SkipPublish(ctx), "publish_if is false")
+}
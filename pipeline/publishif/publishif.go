@@ -0,0 +1,94 @@
+// Package publishif implements the Pipe interface disabling the publishing
+// pipes when the publish_if condition is false.
+package publishif
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/pipeline"
+)
+
+// Pipe for the publish_if condition
+type Pipe struct{}
+
+func (Pipe) String() string {
+	return "checking whether to publish"
+}
+
+// Run the pipe
+func (Pipe) Run(ctx *context.Context) error {
+	var condition = ctx.Config.PublishIf
+	if condition == "" {
+		return pipeline.Skip("publish_if is not set")
+	}
+	if !ctx.Publish {
+		return pipeline.Skip("publishing is already disabled")
+	}
+	publish, err := evaluate(ctx, condition)
+	if err != nil {
+		return err
+	}
+	if !publish {
+		ctx.Publish = false
+		ctx.SkipPublish = fmt.Sprintf("publish_if '%s' is false for the tag %s", condition, ctx.Git.CurrentTag)
+		log.Warn(ctx.SkipPublish + ", nothing will be published")
+	}
+	return nil
+}
+
+// funcs usable in the condition
+var funcs = template.FuncMap{
+	"contains":  strings.Contains,
+	"hasPrefix": strings.HasPrefix,
+	"hasSuffix": strings.HasSuffix,
+	"match": func(pattern, s string) (bool, error) {
+		return regexp.MatchString(pattern, s)
+	},
+}
+
+// evaluate renders the condition, which must render to true or false
+func evaluate(ctx *context.Context, condition string) (bool, error) {
+	t, err := template.New("publish_if").
+		Option("missingkey=error").
+		Funcs(funcs).
+		Parse(condition)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid publish_if '%s'", condition)
+	}
+	var data = struct {
+		ProjectName, Tag, Version, Commit string
+		Major, Minor, Patch, Prerelease   string
+		IsSnapshot, IsPrerelease          bool
+		Env                               map[string]string
+	}{
+		ProjectName:  ctx.Config.ProjectName,
+		Tag:          ctx.Git.CurrentTag,
+		Version:      ctx.Version,
+		Commit:       ctx.Git.Commit,
+		Major:        ctx.Semver.Major,
+		Minor:        ctx.Semver.Minor,
+		Patch:        ctx.Semver.Patch,
+		Prerelease:   ctx.Semver.Prerelease,
+		IsSnapshot:   ctx.Snapshot,
+		IsPrerelease: ctx.Semver.Prerelease != "",
+		Env:          ctx.Env,
+	}
+	var out bytes.Buffer
+	if err := t.Execute(&out, data); err != nil {
+		return false, errors.Wrapf(err, "failed to evaluate publish_if '%s'", condition)
+	}
+	result, err := strconv.ParseBool(strings.TrimSpace(out.String()))
+	if err != nil {
+		return false, fmt.Errorf("publish_if '%s' must render true or false, got '%s'", condition, out.String())
+	}
+	return result, nil
+}
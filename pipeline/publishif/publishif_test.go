@@ -0,0 +1,93 @@
+package publishif
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/testlib"
+	"github.com/goreleaser/goreleaser/pipeline"
+	"github.com/masterminds/semver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescription(t *testing.T) {
+	assert.NotEmpty(t, Pipe{}.String())
+}
+
+func newContext(condition, tag string) *context.Context {
+	var ctx = context.New(config.Project{
+		ProjectName: "mytool",
+		PublishIf:   condition,
+	})
+	ctx.Env = map[string]string{"CHANNEL": "stable"}
+	ctx.Git.CurrentTag = tag
+	ctx.Version = tag[1:]
+	if sv, err := semver.NewVersion(ctx.Version); err == nil {
+		ctx.Semver = context.Semver{
+			Major:      strconv.FormatInt(sv.Major(), 10),
+			Minor:      strconv.FormatInt(sv.Minor(), 10),
+			Patch:      strconv.FormatInt(sv.Patch(), 10),
+			Prerelease: sv.Prerelease(),
+		}
+	}
+	ctx.Publish = true
+	return ctx
+}
+
+func TestRun(t *testing.T) {
+	for _, tt := range []struct {
+		condition, tag string
+		publish        bool
+	}{
+		{`{{ not (hasPrefix .Prerelease "internal") }}`, "v1.2.3-internal.1", false},
+		{`{{ not (hasPrefix .Prerelease "internal") }}`, "v1.2.3-rc.1", true},
+		{`{{ not (hasPrefix .Prerelease "internal") }}`, "v1.2.3", true},
+		{`{{ eq .Prerelease "" }}`, "v1.2.3-rc.1", false},
+		{`{{ match "^v1\\." .Tag }}`, "v1.2.3", true},
+		{`{{ match "^v1\\." .Tag }}`, "v2.0.0", false},
+		{`{{ contains .Tag "internal" | not }}`, "v2.0.0", true},
+		{`{{ hasSuffix .Version ".0" }}`, "v2.0.0", true},
+		{`{{ eq .Env.CHANNEL "stable" }}`, "v2.0.0", true},
+		{`{{ if eq .Major "0" }}false{{ else }}true{{ end }}`, "v0.1.0", false},
+		{" true\n", "v0.1.0", true},
+		{"{{ not .IsPrerelease }}", "v0.1.0-rc.1", false},
+	} {
+		var ctx = newContext(tt.condition, tt.tag)
+		assert.NoError(t, Pipe{}.Run(ctx), tt.condition)
+		assert.Equal(t, tt.publish, ctx.Publish, tt.condition+" "+tt.tag)
+		if !tt.publish {
+			assert.Equal(
+				t,
+				"publish_if '"+tt.condition+"' is false for the tag "+tt.tag,
+				pipeline.SkipPublish(ctx).Error(),
+			)
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	for condition, msg := range map[string]string{
+		"{{ .Tag }}":           "publish_if '{{ .Tag }}' must render true or false, got 'v1.2.3'",
+		"{{ .Nope }}":          "failed to evaluate publish_if '{{ .Nope }}': ",
+		`{{ match "(" .Tag }}`: `failed to evaluate publish_if '{{ match "(" .Tag }}': `,
+		"{{":                   "invalid publish_if '{{': ",
+	} {
+		var ctx = newContext(condition, "v1.2.3")
+		var err = Pipe{}.Run(ctx)
+		if assert.Error(t, err, condition) {
+			assert.Contains(t, err.Error(), msg, condition)
+		}
+		assert.True(t, ctx.Publish)
+	}
+}
+
+func TestRunSkip(t *testing.T) {
+	var ctx = newContext("", "v1.2.3")
+	testlib.AssertSkipped(t, Pipe{}.Run(ctx))
+	ctx = newContext("false", "v1.2.3")
+	ctx.Publish = false
+	testlib.AssertSkipped(t, Pipe{}.Run(ctx))
+	assert.Equal(t, pipeline.ErrSkipPublish, pipeline.SkipPublish(ctx))
+}
@@ -3,28 +3,49 @@ package release
 import (
 	"bytes"
 	"os/exec"
+	"sort"
 	"text/template"
 
+	"github.com/goreleaser/goreleaser/checksum"
 	"github.com/goreleaser/goreleaser/context"
 	"github.com/goreleaser/goreleaser/internal/artifact"
 )
 
-const bodyTemplateText = `{{ .ReleaseNotes }}
-
-{{- if .DockerImages }}
+const bodyTemplateText = `{{ with .ReleaseNotes }}{{ . }}
 
+{{ end }}
+{{- with .DockerImages -}}
 ## Docker images
-{{ range $element := .DockerImages }}
-- ` + "`docker pull {{ . -}}`" + `
-{{- end -}}
+{{ range . }}
+- ` + "`docker pull {{ .Name -}}`" + `{{ with .Digest }} (` + "`{{ . }}`" + `){{ end }}
+{{- end }}
+
+{{ end -}}
+{{- with .Signatures -}}
+## Verify this release
+{{ range . }}
+- ` + "`{{ .Name }}`" + `{{ with .Fingerprint }} signed by ` + "`{{ . }}`" + `{{ end }}
 {{- end }}
 
+{{ end -}}
 ---
 Automated with [GoReleaser](https://github.com/goreleaser)
 Built with {{ .GoVersion }}`
 
 var bodyTemplate *template.Template
 
+type dockerImage struct {
+	Name, Digest string
+}
+
+type signature struct {
+	Name, Fingerprint string
+}
+
+type checksumLine struct {
+	Name, Algorithm, Sum, Line string
+}
+
 func init() {
 	bodyTemplate = template.Must(template.New("release").Parse(bodyTemplateText))
 }
@@ -40,17 +61,56 @@ func describeBody(ctx *context.Context) (bytes.Buffer, error) {
 
 func describeBodyVersion(ctx *context.Context, version string) (bytes.Buffer, error) {
 	var out bytes.Buffer
-	var dockers []string
+	var dockers []dockerImage
 	for _, a := range ctx.Artifacts.Filter(artifact.ByType(artifact.DockerImage)).List() {
-		dockers = append(dockers, a.Name)
+		// images built but not pushed can't be pulled
+		if !a.ExtraBool("Pushed") {
+			continue
+		}
+		dockers = append(dockers, dockerImage{Name: a.Name, Digest: a.ExtraString("Digest")})
+	}
+	var signatures []signature
+	for _, a := range ctx.Artifacts.Filter(artifact.ByType(artifact.Signature)).List() {
+		signatures = append(signatures, signature{Name: a.Name, Fingerprint: a.ExtraString("Fingerprint")})
 	}
 	err := bodyTemplate.Execute(&out, struct {
 		ReleaseNotes, GoVersion string
-		DockerImages            []string
+		DockerImages            []dockerImage
+		Checksums               []checksumLine
+		Signatures              []signature
 	}{
 		ReleaseNotes: ctx.ReleaseNotes,
 		GoVersion:    version,
 		DockerImages: dockers,
+		Checksums:    checksumLines(ctx),
+		Signatures:   signatures,
 	})
 	return out, err
 }
+
+// checksumLines returns the checksums recorded by the checksums pipe, sorted
+// by artifact name, in the format and algorithms order of its config.
+func checksumLines(ctx *context.Context) []checksumLine {
+	var artifacts = ctx.Artifacts.Filter(func(a artifact.Artifact) bool {
+		return len(a.Checksums) > 0
+	}).List()
+	sort.SliceStable(artifacts, func(i, j int) bool {
+		return artifacts[i].Name < artifacts[j].Name
+	})
+	var lines []checksumLine
+	for _, a := range artifacts {
+		for _, algorithm := range ctx.Config.Checksum.Algorithms {
+			sum, ok := a.Checksums[algorithm]
+			if !ok {
+				continue
+			}
+			lines = append(lines, checksumLine{
+				Name:      a.Name,
+				Algorithm: algorithm,
+				Sum:       sum,
+				Line:      checksum.Line(ctx.Config.Checksum.Format, algorithm, sum, a.Name),
+			})
+		}
+	}
+	return lines
+}
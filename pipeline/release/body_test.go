@@ -4,6 +4,7 @@ import (
 	"flag"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/goreleaser/goreleaser/config"
@@ -24,8 +25,9 @@ func TestDescribeBody(t *testing.T) {
 		"goreleaser/godownloader:v0.1.0",
 	} {
 		ctx.Artifacts.Add(artifact.Artifact{
-			Name: d,
-			Type: artifact.DockerImage,
+			Name:  d,
+			Type:  artifact.DockerImage,
+			Extra: map[string]interface{}{"Pushed": true},
 		})
 	}
 	out, err := describeBodyVersion(ctx, "go version go1.9 darwin/amd64")
@@ -40,6 +42,96 @@ func TestDescribeBody(t *testing.T) {
 	assert.Equal(t, string(bts), out.String())
 }
 
+func TestDescribeBodyDockerImagesDigests(t *testing.T) {
+	var digest = "sha256:" + strings.Repeat("a1", 32)
+	var ctx = context.New(config.Project{})
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:  "goreleaser/goreleaser:0.40.0",
+		Type:  artifact.DockerImage,
+		Extra: map[string]interface{}{"Pushed": true, "Digest": digest},
+	})
+	out, err := describeBodyVersion(ctx, "go version go1.9 darwin/amd64")
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "- `docker pull goreleaser/goreleaser:0.40.0` (`"+digest+"`)\n")
+}
+
+func TestDescribeBodyDockerImagesNotPushed(t *testing.T) {
+	var ctx = context.New(config.Project{})
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:  "goreleaser/goreleaser:0.40.0",
+		Type:  artifact.DockerImage,
+		Extra: map[string]interface{}{"Pushed": true},
+	})
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:  "goreleaser/goreleaser-debug:0.40.0",
+		Type:  artifact.DockerImage,
+		Extra: map[string]interface{}{"Pushed": false},
+	})
+	out, err := describeBodyVersion(ctx, "go version go1.9 darwin/amd64")
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "goreleaser/goreleaser:0.40.0")
+	assert.NotContains(t, out.String(), "goreleaser/goreleaser-debug")
+}
+
+func TestDescribeBodySignatures(t *testing.T) {
+	var fingerprint = strings.Repeat("A1", 20)
+	var ctx = context.New(config.Project{})
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:  "checksums.txt.sig",
+		Type:  artifact.Signature,
+		Extra: map[string]interface{}{"Fingerprint": fingerprint},
+	})
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name: "checksums.txt.minisig",
+		Type: artifact.Signature,
+	})
+	out, err := describeBodyVersion(ctx, "go version go1.9 darwin/amd64")
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"## Verify this release\n\n- `checksums.txt.sig` signed by `"+fingerprint+"`\n- `checksums.txt.minisig`\n\n---\nAutomated with [GoReleaser](https://github.com/goreleaser)\nBuilt with go version go1.9 darwin/amd64",
+		out.String(),
+	)
+}
+
+func TestChecksumLines(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Checksum: config.Checksum{
+			Algorithms: []string{"sha256", "md5"},
+			Format:     "bsd",
+		},
+	})
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:      "foo_linux_amd64.tar.gz",
+		Type:      artifact.UploadableArchive,
+		Checksums: map[string]string{"sha256": "aaa", "md5": "bbb"},
+	})
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:      "foo_darwin_amd64.tar.gz",
+		Type:      artifact.UploadableArchive,
+		Checksums: map[string]string{"sha256": "ccc"},
+	})
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name: "foo_windows_amd64.zip",
+		Type: artifact.UploadableArchive,
+	})
+	assert.Equal(t, []checksumLine{
+		{Name: "foo_darwin_amd64.tar.gz", Algorithm: "sha256", Sum: "ccc", Line: "SHA256 (foo_darwin_amd64.tar.gz) = ccc\n"},
+		{Name: "foo_linux_amd64.tar.gz", Algorithm: "sha256", Sum: "aaa", Line: "SHA256 (foo_linux_amd64.tar.gz) = aaa\n"},
+		{Name: "foo_linux_amd64.tar.gz", Algorithm: "md5", Sum: "bbb", Line: "MD5 (foo_linux_amd64.tar.gz) = bbb\n"},
+	}, checksumLines(ctx))
+
+	// the checksums are only given to the template, the default body doesn't
+	// list them
+	out, err := describeBodyVersion(ctx, "go version go1.9 darwin/amd64")
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"---\nAutomated with [GoReleaser](https://github.com/goreleaser)\nBuilt with go version go1.9 darwin/amd64",
+		out.String(),
+	)
+}
+
 func TestDescribeBodyNoDockerImagesNoBrews(t *testing.T) {
 	var changelog = "\nfeature1: description\nfeature2: other description"
 	var ctx = &context.Context{
@@ -58,6 +150,17 @@ func TestDescribeBodyNoDockerImagesNoBrews(t *testing.T) {
 	assert.Equal(t, string(bts), out.String())
 }
 
+func TestDescribeBodyNoReleaseNotes(t *testing.T) {
+	var ctx = context.New(config.Project{})
+	out, err := describeBodyVersion(ctx, "go version go1.9 darwin/amd64")
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"---\nAutomated with [GoReleaser](https://github.com/goreleaser)\nBuilt with go version go1.9 darwin/amd64",
+		out.String(),
+	)
+}
+
 func TestDontEscapeHTML(t *testing.T) {
 	var changelog = "<h1>test</h1>"
 	var ctx = context.New(config.Project{})
@@ -24,14 +24,21 @@ func (Pipe) Default(ctx *context.Context) error {
 	if ctx.Config.Release.NameTemplate == "" {
 		ctx.Config.Release.NameTemplate = "{{.Tag}}"
 	}
-	if ctx.Config.Release.GitHub.Name != "" {
+	// the configured owner and name win over the ones of the remote
+	var github = &ctx.Config.Release.GitHub
+	if github.Owner != "" && github.Name != "" {
 		return nil
 	}
-	repo, err := remoteRepo()
+	repo, err := remoteRepo(ctx.Config.Git.Remote)
 	if err != nil {
 		return err
 	}
-	ctx.Config.Release.GitHub = repo
+	if github.Owner == "" {
+		github.Owner = repo.Owner
+	}
+	if github.Name == "" {
+		github.Name = repo.Name
+	}
 	return nil
 }
 
@@ -46,7 +53,7 @@ func (Pipe) Run(ctx *context.Context) error {
 
 func doRun(ctx *context.Context, c client.Client) error {
 	if !ctx.Publish {
-		return pipeline.ErrSkipPublish
+		return pipeline.SkipPublish(ctx)
 	}
 	log.WithField("tag", ctx.Git.CurrentTag).
 		WithField("repo", ctx.Config.Release.GitHub.String()).
@@ -59,17 +66,20 @@ func doRun(ctx *context.Context, c client.Client) error {
 	if err != nil {
 		return err
 	}
+	var filters = []artifact.Filter{
+		artifact.ByType(artifact.UploadableArchive),
+		artifact.ByType(artifact.UploadableBinary),
+		artifact.ByType(artifact.Checksum),
+		artifact.ByType(artifact.DockerDigests),
+		artifact.ByType(artifact.Signature),
+		artifact.ByType(artifact.LinuxPackage),
+	}
+	if ctx.Config.Changelog.Upload {
+		filters = append(filters, artifact.ByType(artifact.Changelog))
+	}
 	var g errgroup.Group
 	sem := make(chan bool, ctx.Parallelism)
-	for _, artifact := range ctx.Artifacts.Filter(
-		artifact.Or(
-			artifact.ByType(artifact.UploadableArchive),
-			artifact.ByType(artifact.UploadableBinary),
-			artifact.ByType(artifact.Checksum),
-			artifact.ByType(artifact.Signature),
-			artifact.ByType(artifact.LinuxPackage),
-		),
-	).List() {
+	for _, artifact := range ctx.Artifacts.Filter(artifact.Or(filters...)).List() {
 		sem <- true
 		artifact := artifact
 		g.Go(func() error {
@@ -7,10 +7,13 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/goreleaser/goreleaser/config"
 	"github.com/goreleaser/goreleaser/context"
 	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/client"
+	"github.com/goreleaser/goreleaser/internal/git"
 	"github.com/goreleaser/goreleaser/internal/testlib"
 	"github.com/stretchr/testify/assert"
 )
@@ -56,6 +59,38 @@ func TestRunPipe(t *testing.T) {
 	assert.Contains(t, client.UploadedFileNames, "bin.tar.gz")
 }
 
+func TestRunPipeUploadChangelog(t *testing.T) {
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	changelog, err := os.Create(filepath.Join(folder, "CHANGELOG.md"))
+	assert.NoError(t, err)
+	for _, upload := range []bool{true, false} {
+		var ctx = context.New(config.Project{
+			Dist: folder,
+			Release: config.Release{
+				GitHub: config.Repo{
+					Owner: "test",
+					Name:  "test",
+				},
+			},
+			Changelog: config.Changelog{
+				Upload: upload,
+			},
+		})
+		ctx.Git = context.GitInfo{CurrentTag: "v1.0.0"}
+		ctx.Publish = true
+		ctx.Artifacts.Add(artifact.Artifact{
+			Type: artifact.Changelog,
+			Name: "CHANGELOG.md",
+			Path: changelog.Name(),
+		})
+		client := &DummyClient{}
+		assert.NoError(t, doRun(ctx, client))
+		assert.True(t, client.CreatedRelease)
+		assert.Equal(t, upload, client.UploadedFile)
+	}
+}
+
 func TestRunPipeReleaseCreationFailed(t *testing.T) {
 	var config = config.Project{
 		Release: config.Release{
@@ -146,13 +181,52 @@ func TestDefault(t *testing.T) {
 	testlib.GitRemoteAdd(t, "git@github.com:goreleaser/goreleaser.git")
 
 	var ctx = &context.Context{
-		Config: config.Project{},
+		Config: config.Project{
+			Git: config.Git{Remote: "origin"},
+		},
 	}
 	assert.NoError(t, Pipe{}.Default(ctx))
 	assert.Equal(t, "goreleaser", ctx.Config.Release.GitHub.Name)
 	assert.Equal(t, "goreleaser", ctx.Config.Release.GitHub.Owner)
 }
 
+func TestDefaultRemote(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitRemoteAdd(t, "git@github.com:someone/goreleaser.git")
+	_, err := git.Run("remote", "add", "upstream", "ssh://git@github.com/goreleaser/goreleaser.git")
+	assert.NoError(t, err)
+
+	var ctx = &context.Context{
+		Config: config.Project{
+			Git: config.Git{Remote: "upstream"},
+		},
+	}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.Equal(t, "goreleaser/goreleaser", ctx.Config.Release.GitHub.String())
+}
+
+func TestDefaultPartiallyFilled(t *testing.T) {
+	_, back := testlib.Mktmp(t)
+	defer back()
+	testlib.GitInit(t)
+	testlib.GitRemoteAdd(t, "git@github.com:goreleaser/goreleaser.git")
+
+	var ctx = &context.Context{
+		Config: config.Project{
+			Git: config.Git{Remote: "origin"},
+			Release: config.Release{
+				GitHub: config.Repo{
+					Owner: "acme",
+				},
+			},
+		},
+	}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.Equal(t, "acme/goreleaser", ctx.Config.Release.GitHub.String())
+}
+
 func TestDefaultFilled(t *testing.T) {
 	_, back := testlib.Mktmp(t)
 	defer back()
@@ -211,7 +285,7 @@ func (client *DummyClient) CreateRelease(ctx *context.Context, body string) (rel
 	return
 }
 
-func (client *DummyClient) CreateFile(ctx *context.Context, commitAuthor config.CommitAuthor, repo config.Repo, content bytes.Buffer, path string) (err error) {
+func (client *DummyClient) CreateFile(ctx *context.Context, commitAuthor config.CommitAuthor, repo config.Repo, content bytes.Buffer, path, message string) (err error) {
 	return
 }
 
@@ -223,3 +297,11 @@ func (client *DummyClient) Upload(ctx *context.Context, releaseID int64, name st
 	client.UploadedFileNames = append(client.UploadedFileNames, name)
 	return
 }
+
+func (client *DummyClient) MergedPullRequests(ctx *context.Context, repo config.Repo, since time.Time) (prs []client.PullRequest, err error) {
+	return
+}
+
+func (client *DummyClient) GetFile(ctx *context.Context, repo config.Repo, path string) (content []byte, err error) {
+	return
+}
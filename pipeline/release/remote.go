@@ -1,6 +1,8 @@
 package release
 
 import (
+	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/goreleaser/goreleaser/config"
@@ -8,34 +10,51 @@ import (
 	"github.com/pkg/errors"
 )
 
-// remoteRepo gets the repo name from the Git config.
-func remoteRepo() (result config.Repo, err error) {
+// remoteRepo gets the repo name from the URL of the given remote in the Git
+// config.
+func remoteRepo(remote string) (result config.Repo, err error) {
 	if !git.IsRepo() {
 		return result, errors.New("current folder is not a git repository")
 	}
-	out, err := git.Run("config", "--get", "remote.origin.url")
+	out, err := git.Clean(git.Run("config", "--get", "remote."+remote+".url"))
 	if err != nil {
-		return result, errors.Wrap(err, "repository doesn't have an `origin` remote")
+		return result, errors.Wrapf(err, "repository doesn't have an `%s` remote", remote)
 	}
-	return extractRepoFromURL(out), nil
+	return extractRepoFromURL(out)
 }
 
-func extractRepoFromURL(s string) config.Repo {
-	for _, r := range []string{
-		"git@github.com:",
-		".git",
-		"https://github.com/",
-		"\n",
-	} {
-		s = strings.Replace(s, r, "", -1)
+// extractRepoFromURL gets the owner and name of the repository from a remote
+// URL, either a URL with a scheme, like https:// or ssh://, which may have
+// credentials and a port, or an scp-like `[user@]host:owner/name` one. The
+// owner is everything before the name, to support GitLab subgroups.
+func extractRepoFromURL(s string) (config.Repo, error) {
+	s = strings.TrimSpace(s)
+	var path string
+	if u, err := url.Parse(s); err == nil && u.Scheme != "" && u.Host != "" {
+		path = u.Path
+	} else if i := strings.Index(s, ":"); i > 0 && !strings.Contains(s, "://") {
+		path = s[i+1:]
+	} else {
+		return config.Repo{}, fmt.Errorf("unsupported git remote url: %s", withoutCredentials(s))
 	}
-	return toRepo(s)
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	var i = strings.LastIndex(path, "/")
+	if i <= 0 || i == len(path)-1 {
+		return config.Repo{}, fmt.Errorf("git remote url %s doesn't have an owner and a name", withoutCredentials(s))
+	}
+	return config.Repo{
+		Owner: path[:i],
+		Name:  path[i+1:],
+	}, nil
 }
 
-func toRepo(s string) config.Repo {
-	var ss = strings.Split(s, "/")
-	return config.Repo{
-		Owner: ss[0],
-		Name:  ss[1],
+// withoutCredentials removes the user and password of a remote URL, so they
+// never show up in the errors.
+func withoutCredentials(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
 	}
+	u.User = nil
+	return u.String()
 }
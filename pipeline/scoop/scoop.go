@@ -6,15 +6,31 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
 
+	"github.com/apex/log"
+	"github.com/goreleaser/goreleaser/checksum"
+	"github.com/goreleaser/goreleaser/config"
 	"github.com/goreleaser/goreleaser/context"
 	"github.com/goreleaser/goreleaser/internal/artifact"
 	"github.com/goreleaser/goreleaser/internal/client"
+	"github.com/goreleaser/goreleaser/internal/filenametemplate"
 	"github.com/goreleaser/goreleaser/pipeline"
 )
 
-// ErrNoWindows when there is no build for windows (goos doesn't contain windows)
-var ErrNoWindows = errors.New("scoop requires a windows build")
+// ErrNoArchitectures happens when none of the windows archives has an
+// architecture scoop knows about
+var ErrNoArchitectures = errors.New("scoop requires a windows 386, amd64 or arm64 archive")
+
+// architectures maps goarchs to the scoop architecture keys
+var architectures = map[string]string{
+	"386":   "32bit",
+	"amd64": "64bit",
+	"arm64": "arm64",
+}
 
 // Pipe for build
 type Pipe struct{}
@@ -25,11 +41,14 @@ func (Pipe) String() string {
 
 // Run the pipe
 func (Pipe) Run(ctx *context.Context) error {
-	client, err := client.NewGitHub(ctx)
+	c, err := client.NewGitHub(ctx)
 	if err != nil {
 		return err
 	}
-	return doRun(ctx, client)
+	if ctx.Config.Scoop.Git.URL != "" {
+		return doRun(ctx, client.NewGit(ctx, c, ctx.Config.Scoop.Git, filepath.Join(ctx.Config.Dist, "scoop-bucket")))
+	}
+	return doRun(ctx, c)
 }
 
 // Default sets the pipe defaults
@@ -40,11 +59,20 @@ func (Pipe) Default(ctx *context.Context) error {
 	if ctx.Config.Scoop.CommitAuthor.Email == "" {
 		ctx.Config.Scoop.CommitAuthor.Email = "goreleaser@carlosbecker.com"
 	}
+	if ctx.Config.Scoop.Homepage == "" {
+		ctx.Config.Scoop.Homepage = ctx.Config.Brew.Homepage
+	}
+	if ctx.Config.Scoop.Description == "" {
+		ctx.Config.Scoop.Description = ctx.Config.Brew.Description
+	}
+	if ctx.Config.Scoop.CommitMessageTemplate == "" {
+		ctx.Config.Scoop.CommitMessageTemplate = "{{ .ProjectName }} version {{ .Tag }}"
+	}
 	return nil
 }
 
 func doRun(ctx *context.Context, client client.Client) error {
-	if ctx.Config.Scoop.Bucket.Name == "" {
+	if ctx.Config.Scoop.Bucket.Name == "" && ctx.Config.Scoop.Git.URL == "" {
 		return pipeline.Skip("scoop section is not configured")
 	}
 	if ctx.Config.Archive.Format == "binary" {
@@ -58,65 +86,205 @@ func doRun(ctx *context.Context, client client.Client) error {
 		),
 	).List()
 	if len(archives) == 0 {
-		return ErrNoWindows
+		return pipeline.Skip("no windows archives found")
+	}
+	if ctx.Snapshot {
+		return pipeline.Skip("not available for snapshots")
 	}
-
-	path := ctx.Config.ProjectName + ".json"
 
 	content, err := buildManifest(ctx, client, archives)
 	if err != nil {
 		return err
 	}
 
+	var filename = ctx.Config.ProjectName + ".json"
+	var path = filepath.Join(ctx.Config.Dist, filename)
+	log.WithField("manifest", path).Info("writing")
+	if err := ioutil.WriteFile(path, content.Bytes(), 0644); err != nil {
+		return err
+	}
+	ctx.Artifacts.Add(artifact.Artifact{
+		Type: artifact.ScoopManifest,
+		Name: filename,
+		Path: path,
+	})
+
+	if ctx.Config.Scoop.SkipUpload == "true" {
+		return pipeline.Skip("scoop.skip_upload is set")
+	}
+	if ctx.Config.Scoop.SkipUpload == "auto" && ctx.IsPrerelease() {
+		return pipeline.Skip("prerelease detected with 'auto' upload, skipping scoop publish")
+	}
 	if !ctx.Publish {
-		return pipeline.ErrSkipPublish
+		return pipeline.SkipPublish(ctx)
 	}
 	if ctx.Config.Release.Draft {
 		return pipeline.Skip("release is marked as draft")
 	}
 
+	msg, err := filenametemplate.Apply(
+		ctx.Config.Scoop.CommitMessageTemplate,
+		filenametemplate.NewFields(ctx, nil, archives...),
+	)
+	if err != nil {
+		return err
+	}
+
+	path = bucketPath(ctx)
+	log.WithField("manifest", path).
+		WithField("bucket", bucketName(ctx)).
+		WithField("message", msg).
+		Info("pushing")
 	return client.CreateFile(
 		ctx,
 		ctx.Config.Scoop.CommitAuthor,
 		ctx.Config.Scoop.Bucket,
 		content,
 		path,
+		msg,
 	)
 }
 
+// bucketName is the bucket repository, for logging
+func bucketName(ctx *context.Context) string {
+	if ctx.Config.Scoop.Git.URL != "" {
+		return ctx.Config.Scoop.Git.URL
+	}
+	return ctx.Config.Scoop.Bucket.String()
+}
+
+// bucketPath is the path of the manifest inside the bucket repository,
+// always with forward slashes.
+func bucketPath(ctx *context.Context) string {
+	return filepath.ToSlash(filepath.Join(ctx.Config.Scoop.Folder, ctx.Config.ProjectName+".json"))
+}
+
 // Manifest represents a scoop.sh App Manifest, more info:
 // https://github.com/lukesampson/scoop/wiki/App-Manifests
 type Manifest struct {
-	Version      string              `json:"version"`               // The version of the app that this manifest installs.
-	Architecture map[string]Resource `json:"architecture"`          // `architecture`: If the app has 32- and 64-bit versions, architecture can be used to wrap the differences.
-	Homepage     string              `json:"homepage,omitempty"`    // `homepage`: The home page for the program.
-	License      string              `json:"license,omitempty"`     // `license`: The software license for the program. For well-known licenses, this will be a string like "MIT" or "GPL2". For custom licenses, this should be the URL of the license.
-	Description  string              `json:"description,omitempty"` // Description of the app
+	Version      string              `json:"version"`                // The version of the app that this manifest installs.
+	URL          string              `json:"url,omitempty"`          // URL to the archive, when there is only one architecture
+	Hash         string              `json:"hash,omitempty"`         // sha256 of the archive, when there is only one architecture
+	Bin          []string            `json:"bin,omitempty"`          // names of the binaries inside the archive, when there is only one architecture
+	Architecture map[string]Resource `json:"architecture,omitempty"` // `architecture`: If the app has 32- and 64-bit versions, architecture can be used to wrap the differences.
+	Homepage     string              `json:"homepage,omitempty"`     // `homepage`: The home page for the program.
+	License      interface{}         `json:"license,omitempty"`      // `license`: The software license for the program. For well-known licenses, this will be a string like "MIT" or "GPL2". For custom licenses, this is a License.
+	Description  string              `json:"description,omitempty"`  // Description of the app
+	PreInstall   []string            `json:"pre_install,omitempty"`  // `pre_install`: PowerShell lines run before the app is installed.
+	PostInstall  []string            `json:"post_install,omitempty"` // `post_install`: PowerShell lines run after the app is installed.
+	Persist      []interface{}       `json:"persist,omitempty"`      // `persist`: Paths kept across versions, either a path or a [source, target] pair.
+	Checkver     *Checkver           `json:"checkver,omitempty"`     // `checkver`: How scoop finds newer versions of the app.
+	Autoupdate   *Autoupdate         `json:"autoupdate,omitempty"`   // `autoupdate`: How scoop updates the manifest to a newer version.
 }
 
-// Resource represents a combination of a url and a binary name for an architecture
-type Resource struct {
+// License represents a custom license of the app
+type License struct {
+	Identifier string `json:"identifier"`    // identifier of the license, e.g. "Freeware"
+	URL        string `json:"url,omitempty"` // URL of the license text
+}
+
+// Checkver represents how scoop looks for newer versions of the app
+type Checkver struct {
+	GitHub   string `json:"github,omitempty"`   // URL of the GitHub repository whose releases are checked
+	URL      string `json:"url,omitempty"`      // URL of the page the version is found in
+	Regex    string `json:"regex,omitempty"`    // regex matching the version
+	JSONPath string `json:"jsonpath,omitempty"` // JSONPath to the version, when url is a json document
+}
+
+// Autoupdate represents the urls of a newer version of the app, with the
+// version replaced by the $version placeholder
+type Autoupdate struct {
+	URL          string                        `json:"url,omitempty"`          // URL to the archive, when there is only one architecture
+	Architecture map[string]AutoupdateResource `json:"architecture,omitempty"` // URLs to the archives of each architecture
+}
+
+// AutoupdateResource represents the url of a newer version of the archive of
+// an architecture
+type AutoupdateResource struct {
 	URL string `json:"url"` // URL to the archive
-	Bin string `json:"bin"` // name of binary inside the archive
+}
+
+// Resource represents a combination of a url, its hash and the binary names for an architecture
+type Resource struct {
+	URL  string   `json:"url"`  // URL to the archive
+	Hash string   `json:"hash"` // sha256 of the archive
+	Bin  []string `json:"bin"`  // names of the binaries inside the archive
 }
 
 func buildManifest(ctx *context.Context, client client.Client, artifacts []artifact.Artifact) (result bytes.Buffer, err error) {
 	manifest := Manifest{
 		Version:      ctx.Version,
 		Architecture: make(map[string]Resource),
-		Homepage:     ctx.Config.Scoop.Homepage,
-		License:      ctx.Config.Scoop.License,
-		Description:  ctx.Config.Scoop.Description,
+		Checkver:     checkver(ctx),
+	}
+	var fields = filenametemplate.NewFields(ctx, nil, artifacts...)
+	if manifest.Homepage, err = filenametemplate.Apply(ctx.Config.Scoop.Homepage, fields); err != nil {
+		return
+	}
+	if manifest.Description, err = filenametemplate.Apply(ctx.Config.Scoop.Description, fields); err != nil {
+		return
 	}
+	if manifest.License, err = license(ctx, fields); err != nil {
+		return
+	}
+	if manifest.PreInstall, err = script(ctx.Config.Scoop.PreInstall, fields); err != nil {
+		return
+	}
+	if manifest.PostInstall, err = script(ctx.Config.Scoop.PostInstall, fields); err != nil {
+		return
+	}
+	if manifest.Persist, err = persist(ctx); err != nil {
+		return
+	}
+	var updates = map[string]AutoupdateResource{}
 
 	for _, artifact := range artifacts {
-		var arch = "64bit"
-		if artifact.Goarch == "386" {
-			arch = "32bit"
+		arch, ok := architectures[artifact.Goarch]
+		if !ok {
+			log.WithField("archive", artifact.Name).
+				Warnf("scoop doesn't support windows/%s%s, skipping", artifact.Goarch, artifact.Goarm)
+			continue
+		}
+		if _, exists := manifest.Architecture[arch]; exists {
+			log.WithField("archive", artifact.Name).
+				Warnf("there is already a %s archive, skipping", arch)
+			continue
+		}
+		sum, err := checksum.Artifact("sha256", artifact)
+		if err != nil {
+			return result, err
 		}
 		manifest.Architecture[arch] = Resource{
-			URL: getDownloadURL(ctx, ctx.Config.GitHubURLs.Download, artifact.Name),
-			Bin: ctx.Config.Builds[0].Binary + ".exe",
+			URL:  getDownloadURL(ctx, ctx.Config.GitHubURLs.Download, artifact.Name),
+			Hash: sum,
+			Bin:  binaries(ctx, artifact),
+		}
+		if manifest.Checkver != nil {
+			url, err := autoupdateURL(ctx, artifact)
+			if err != nil {
+				return result, err
+			}
+			updates[arch] = AutoupdateResource{URL: url}
+		}
+	}
+	if len(manifest.Architecture) == 0 {
+		return result, ErrNoArchitectures
+	}
+	if len(manifest.Architecture) == 1 {
+		for _, resource := range manifest.Architecture {
+			manifest.URL = resource.URL
+			manifest.Hash = resource.Hash
+			manifest.Bin = resource.Bin
+		}
+		manifest.Architecture = nil
+	}
+	if manifest.Checkver != nil {
+		manifest.Autoupdate = &Autoupdate{Architecture: updates}
+		if len(updates) == 1 {
+			for _, update := range updates {
+				manifest.Autoupdate.URL = update.URL
+			}
+			manifest.Autoupdate.Architecture = nil
 		}
 	}
 
@@ -134,7 +302,114 @@ func getDownloadURL(ctx *context.Context, githubURL, file string) string {
 		githubURL,
 		ctx.Config.Release.GitHub.Owner,
 		ctx.Config.Release.GitHub.Name,
-		ctx.Version,
+		ctx.Git.CurrentTag,
 		file,
 	)
 }
+
+// binaries returns the paths, inside the archive, of the binaries built for
+// the archive platform.
+func binaries(ctx *context.Context, archive artifact.Artifact) []string {
+	var bins []string
+	for _, binary := range ctx.Artifacts.Filter(
+		artifact.And(
+			artifact.ByType(artifact.Binary),
+			artifact.ByGoos(archive.Goos),
+			artifact.ByGoarch(archive.Goarch),
+			artifact.ByGoarm(archive.Goarm),
+		),
+	).List() {
+		bins = append(bins, path.Join(archive.ExtraString("WrappedIn"), binary.Name))
+	}
+	return bins
+}
+
+// checkver returns the checkver section of the manifest, nil if it is not
+// configured.
+func checkver(ctx *context.Context) *Checkver {
+	var cfg = ctx.Config.Scoop.Checkver
+	if cfg == (config.ScoopCheckver{}) {
+		return nil
+	}
+	var result = &Checkver{
+		URL:      cfg.URL,
+		Regex:    cfg.Regex,
+		JSONPath: cfg.JSONPath,
+	}
+	if cfg.GitHub {
+		result.GitHub = fmt.Sprintf(
+			"%s/%s/%s",
+			ctx.Config.GitHubURLs.Download,
+			ctx.Config.Release.GitHub.Owner,
+			ctx.Config.Release.GitHub.Name,
+		)
+	}
+	return result
+}
+
+// autoupdateURL returns the url the archive of a newer version is downloaded
+// from, which defaults to the release asset of that version.
+func autoupdateURL(ctx *context.Context, artifact artifact.Artifact) (string, error) {
+	var url = getDownloadURL(ctx, ctx.Config.GitHubURLs.Download, artifact.Name)
+	if ctx.Config.Scoop.Autoupdate.URLTemplate != "" {
+		var err error
+		url, err = filenametemplate.Apply(
+			ctx.Config.Scoop.Autoupdate.URLTemplate,
+			filenametemplate.NewFields(ctx, nil, artifact),
+		)
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.Replace(url, ctx.Version, "$version", -1), nil
+}
+
+// persist returns the persist entries of the manifest, plain paths being
+// rendered as strings and renamed ones as [source, target] pairs.
+func persist(ctx *context.Context) ([]interface{}, error) {
+	var result []interface{}
+	for _, entry := range ctx.Config.Scoop.Persist {
+		switch len(entry) {
+		case 1:
+			result = append(result, entry[0])
+		case 2:
+			result = append(result, []string(entry))
+		default:
+			return nil, fmt.Errorf("invalid scoop persist entry %v: must be a path or a [source, target] pair", []string(entry))
+		}
+	}
+	return result, nil
+}
+
+// script applies the template to each line of an install script.
+func script(lines []string, fields filenametemplate.Fields) ([]string, error) {
+	var result []string
+	for _, line := range lines {
+		line, err := filenametemplate.Apply(line, fields)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, line)
+	}
+	return result, nil
+}
+
+// license returns the license of the manifest: its identifier for
+// well-known licenses, a License when it has an url, nil if not set.
+func license(ctx *context.Context, fields filenametemplate.Fields) (interface{}, error) {
+	identifier, err := filenametemplate.Apply(ctx.Config.Scoop.License.Identifier, fields)
+	if err != nil {
+		return nil, err
+	}
+	url, err := filenametemplate.Apply(ctx.Config.Scoop.License.URL, fields)
+	if err != nil {
+		return nil, err
+	}
+	if url != "" {
+		return License{Identifier: identifier, URL: url}, nil
+	}
+	if identifier != "" {
+		return identifier, nil
+	}
+	return nil, nil
+}
@@ -5,12 +5,15 @@ import (
 	"flag"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/goreleaser/goreleaser/config"
 	"github.com/goreleaser/goreleaser/context"
 	"github.com/goreleaser/goreleaser/internal/artifact"
 	"github.com/goreleaser/goreleaser/internal/client"
+	"github.com/goreleaser/goreleaser/internal/git"
 	"github.com/goreleaser/goreleaser/internal/testlib"
 	"github.com/stretchr/testify/assert"
 )
@@ -52,6 +55,22 @@ func TestDefault(t *testing.T) {
 	assert.NoError(t, Pipe{}.Default(ctx))
 	assert.NotEmpty(t, ctx.Config.Scoop.CommitAuthor.Name)
 	assert.NotEmpty(t, ctx.Config.Scoop.CommitAuthor.Email)
+	assert.Equal(t, "{{ .ProjectName }} version {{ .Tag }}", ctx.Config.Scoop.CommitMessageTemplate)
+}
+
+func TestDefaultFromBrew(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Brew: config.Homebrew{
+			Description: "brew description",
+			Homepage:    "https://brew.sh",
+		},
+		Scoop: config.Scoop{
+			Description: "scoop description",
+		},
+	})
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.Equal(t, "scoop description", ctx.Config.Scoop.Description)
+	assert.Equal(t, "https://brew.sh", ctx.Config.Scoop.Homepage)
 }
 
 func Test_doRun(t *testing.T) {
@@ -203,7 +222,7 @@ func Test_doRun(t *testing.T) {
 				{Name: "foo_1.0.1_linux_amd64.tar.gz", Goos: "linux", Goarch: "amd64"},
 				{Name: "foo_1.0.1_linux_386.tar.gz", Goos: "linux", Goarch: "386"},
 			},
-			shouldErr("scoop requires a windows build"),
+			shouldErr("no windows archives found"),
 		},
 		{
 			"no scoop",
@@ -240,6 +259,50 @@ func Test_doRun(t *testing.T) {
 			},
 			shouldErr("scoop section is not configured"),
 		},
+		{
+			"snapshot",
+			args{
+				&context.Context{
+					Git: context.GitInfo{
+						CurrentTag: "v1.0.1",
+					},
+					Version:   "1.0.1",
+					Artifacts: artifact.New(),
+					Snapshot:  true,
+					Config: config.Project{
+						Builds: []config.Build{
+							{Binary: "test", Goarch: []string{"amd64"}, Goos: []string{"windows"}},
+						},
+						Dist:        ".",
+						ProjectName: "run-pipe",
+						Archive: config.Archive{
+							Format: "tar.gz",
+						},
+						Release: config.Release{
+							GitHub: config.Repo{
+								Owner: "test",
+								Name:  "test",
+							},
+						},
+						Scoop: config.Scoop{
+							Bucket: config.Repo{
+								Owner: "test",
+								Name:  "test",
+							},
+							Description: "A run pipe test formula",
+							Homepage:    "https://github.com/goreleaser",
+						},
+					},
+					Publish: false,
+				},
+				&DummyClient{},
+			},
+			[]artifact.Artifact{
+				{Name: "foo_1.0.1_windows_amd64.tar.gz", Goos: "windows", Goarch: "amd64"},
+				{Name: "foo_1.0.1_windows_386.tar.gz", Goos: "windows", Goarch: "386"},
+			},
+			shouldErr("not available for snapshots"),
+		},
 		{
 			"no publish",
 			args{
@@ -366,7 +429,12 @@ func Test_doRun(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			folder, back := testlib.Mktmp(t)
+			defer back()
 			for _, a := range tt.artifacts {
+				a.Path = filepath.Join(folder, a.Name)
+				_, err := os.Create(a.Path)
+				assert.NoError(t, err)
 				tt.args.ctx.Artifacts.Add(a)
 			}
 			tt.assertError(t, doRun(tt.args.ctx, tt.args.client))
@@ -374,7 +442,322 @@ func Test_doRun(t *testing.T) {
 	}
 }
 
+func Test_doRunSkipUpload(t *testing.T) {
+	for name, tt := range map[string]struct {
+		skipUpload string
+		prerelease string
+		skipped    string
+	}{
+		"true":                {"true", "", "scoop.skip_upload is set"},
+		"auto prerelease":     {"auto", "rc1", "prerelease detected with 'auto' upload, skipping scoop publish"},
+		"auto not prerelease": {"auto", "", ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			folder, back := testlib.Mktmp(t)
+			defer back()
+			var ctx = context.New(config.Project{
+				Dist:        folder,
+				ProjectName: "run-pipe",
+				Release: config.Release{
+					GitHub: config.Repo{
+						Owner: "test",
+						Name:  "test",
+					},
+				},
+				Scoop: config.Scoop{
+					Bucket: config.Repo{
+						Owner: "test",
+						Name:  "test",
+					},
+					SkipUpload: tt.skipUpload,
+				},
+			})
+			ctx.Git = context.GitInfo{CurrentTag: "v1.0.1"}
+			ctx.Version = "1.0.1"
+			ctx.Semver = context.Semver{Major: "1", Minor: "0", Patch: "1", Prerelease: tt.prerelease}
+			ctx.Publish = true
+			var path = filepath.Join(folder, "foo_1.0.1_windows_amd64.zip")
+			assert.NoError(t, ioutil.WriteFile(path, []byte("amd64"), 0644))
+			ctx.Artifacts.Add(artifact.Artifact{
+				Name:   "foo_1.0.1_windows_amd64.zip",
+				Path:   path,
+				Goos:   "windows",
+				Goarch: "amd64",
+				Type:   artifact.UploadableArchive,
+			})
+			var client = &DummyClient{}
+			var err = doRun(ctx, client)
+			if tt.skipped == "" {
+				assert.NoError(t, err)
+			} else {
+				testlib.AssertSkipped(t, err)
+				assert.EqualError(t, err, tt.skipped)
+			}
+			assert.Equal(t, tt.skipped == "", client.CreatedFile)
+
+			var manifests = ctx.Artifacts.Filter(artifact.ByType(artifact.ScoopManifest)).List()
+			assert.Len(t, manifests, 1)
+			assert.Equal(t, "run-pipe.json", manifests[0].Name)
+			bts, err := ioutil.ReadFile(filepath.Join(folder, "run-pipe.json"))
+			assert.NoError(t, err)
+			assert.Contains(t, string(bts), `"hash": "`)
+		})
+	}
+}
+
+func Test_doRunCommit(t *testing.T) {
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	var ctx = context.New(config.Project{
+		Dist:        folder,
+		ProjectName: "mytool",
+		Release: config.Release{
+			GitHub: config.Repo{
+				Owner: "test",
+				Name:  "test",
+			},
+		},
+		Scoop: config.Scoop{
+			Bucket: config.Repo{
+				Owner:  "test",
+				Name:   "bucket",
+				Branch: "staging",
+			},
+			Folder:                "bucket",
+			CommitMessageTemplate: "{{ .ProjectName }}: update to {{ .Version }}",
+		},
+	})
+	ctx.Git = context.GitInfo{CurrentTag: "v1.2.3"}
+	ctx.Version = "1.2.3"
+	ctx.Publish = true
+	var path = filepath.Join(folder, "mytool_1.2.3_windows_amd64.zip")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("amd64"), 0644))
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:   "mytool_1.2.3_windows_amd64.zip",
+		Path:   path,
+		Goos:   "windows",
+		Goarch: "amd64",
+		Type:   artifact.UploadableArchive,
+	})
+	var client = &DummyClient{}
+	assert.NoError(t, doRun(ctx, client))
+	assert.Equal(t, "bucket/mytool.json", client.Path)
+	assert.Equal(t, "mytool: update to 1.2.3", client.Message)
+	assert.Equal(t, "staging", client.Repo.Branch)
+
+	ctx.Config.Scoop.CommitMessageTemplate = "{{ .Nope }}"
+	assert.Error(t, doRun(ctx, client))
+}
+
+func Test_doRunGit(t *testing.T) {
+	folder, back := testlib.Mktmp(t)
+	defer back()
+	var bare = filepath.Join(folder, "bucket.git")
+	_, err := git.Run("init", "--bare", bare)
+	assert.NoError(t, err)
+	var dist = filepath.Join(folder, "dist")
+	assert.NoError(t, os.Mkdir(dist, 0755))
+	var ctx = context.New(config.Project{
+		Dist:        dist,
+		ProjectName: "mytool",
+		GitHubURLs: config.GitHubURLs{
+			Download: "https://github.mycompany.com",
+		},
+		Release: config.Release{
+			GitHub: config.Repo{
+				Owner: "test",
+				Name:  "test",
+			},
+		},
+		Scoop: config.Scoop{
+			Git: config.GitRepo{
+				URL: "file://" + bare,
+			},
+			CommitAuthor: config.CommitAuthor{
+				Name:  "bot",
+				Email: "bot@example.com",
+			},
+			CommitMessageTemplate: "{{ .ProjectName }} {{ .Version }}",
+		},
+	})
+	ctx.Git = context.GitInfo{CurrentTag: "v1.2.3"}
+	ctx.Version = "1.2.3"
+	ctx.Publish = true
+	var path = filepath.Join(dist, "mytool_1.2.3_windows_amd64.zip")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("amd64"), 0644))
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:   "mytool_1.2.3_windows_amd64.zip",
+		Path:   path,
+		Goos:   "windows",
+		Goarch: "amd64",
+		Type:   artifact.UploadableArchive,
+	})
+	var c = client.NewGit(ctx, &DummyClient{}, ctx.Config.Scoop.Git, filepath.Join(dist, "scoop-bucket"))
+	assert.NoError(t, doRun(ctx, c))
+
+	out, err := git.Run("--git-dir", bare, "show", "HEAD:mytool.json")
+	assert.NoError(t, err)
+	assert.Contains(t, out, `"url": "https://github.mycompany.com/test/test/releases/download/v1.2.3/mytool_1.2.3_windows_amd64.zip"`)
+	out, err = git.Run("--git-dir", bare, "log", "-1", "--format=%s")
+	assert.NoError(t, err)
+	assert.Equal(t, "mytool 1.2.3\n", out)
+}
+
 func Test_buildManifest(t *testing.T) {
+	var autoupdate = func(ctx *context.Context) {
+		ctx.Config.Scoop.Checkver = config.ScoopCheckver{GitHub: true}
+	}
+	var autoupdateTemplate = func(ctx *context.Context) {
+		ctx.Config.Scoop.Checkver = config.ScoopCheckver{
+			URL:   "https://example.com/releases.json",
+			Regex: `"latest":\s*"v([\d.]+)"`,
+		}
+		ctx.Config.Scoop.Autoupdate = config.ScoopAutoupdate{
+			URLTemplate: "https://dl.example.com/{{ .Tag }}/{{ .ArtifactName }}",
+		}
+	}
+	var install = func(ctx *context.Context) {
+		ctx.Config.Scoop.PreInstall = config.StringArray{`Write-Host "installing {{ .Version }}"`}
+		ctx.Config.Scoop.PostInstall = config.StringArray{
+			`Write-Host "installed {{ .ProjectName }} {{ .Tag }}"`,
+			`Register-ScheduledTask -TaskName "{{ .ProjectName }}" -Action (New-ScheduledTaskAction -Execute "$dir\test.exe")`,
+		}
+	}
+	var persist = func(ctx *context.Context) {
+		ctx.Config.Scoop.Persist = []config.StringArray{
+			{"data"},
+			{"config.json", "conf/config.json"},
+		}
+	}
+	var licensed = func(ctx *context.Context) {
+		ctx.Config.Scoop.Homepage = "https://example.com/{{ .ProjectName }}"
+		ctx.Config.Scoop.Description = "{{ .ProjectName }} {{ .Version }}"
+		ctx.Config.Scoop.License = config.ScoopLicense{
+			Identifier: "Freeware",
+			URL:        "https://example.com/{{ .Tag }}/LICENSE",
+		}
+	}
+	var mit = func(ctx *context.Context) {
+		ctx.Config.Scoop.License = config.ScoopLicense{Identifier: "MIT"}
+	}
+	for name, tt := range map[string]struct {
+		opts    func(ctx *context.Context)
+		goarchs []string
+	}{
+		"test_buildmanifest":                     {nil, []string{"amd64", "386"}},
+		"test_buildmanifest_single":              {nil, []string{"amd64"}},
+		"test_buildmanifest_arm64":               {nil, []string{"amd64", "386", "arm64", "arm"}},
+		"test_buildmanifest_autoupdate":          {autoupdate, []string{"amd64", "386"}},
+		"test_buildmanifest_autoupdate_template": {autoupdateTemplate, []string{"amd64"}},
+		"test_buildmanifest_install":             {install, []string{"amd64", "386"}},
+		"test_buildmanifest_persist":             {persist, []string{"amd64"}},
+		"test_buildmanifest_license":             {licensed, []string{"amd64"}},
+		"test_buildmanifest_license_identifier":  {mit, []string{"amd64"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			out, err := manifestFor(t, tt.opts, tt.goarchs...)
+			assert.NoError(t, err)
+			var golden = "testdata/" + name + ".json.golden"
+			if *update {
+				ioutil.WriteFile(golden, out.Bytes(), 0655)
+			}
+			bts, err := ioutil.ReadFile(golden)
+			assert.NoError(t, err)
+			assert.Equal(t, string(bts), out.String())
+		})
+	}
+}
+
+func Test_buildManifestNoArchitectures(t *testing.T) {
+	_, err := manifestFor(t, nil, "arm")
+	assert.EqualError(t, err, ErrNoArchitectures.Error())
+}
+
+func Test_buildManifestInvalidAutoupdateTemplate(t *testing.T) {
+	_, err := manifestFor(t, func(ctx *context.Context) {
+		ctx.Config.Scoop.Checkver = config.ScoopCheckver{GitHub: true}
+		ctx.Config.Scoop.Autoupdate = config.ScoopAutoupdate{
+			URLTemplate: "{{ .Nope }}",
+		}
+	}, "amd64")
+	assert.Error(t, err)
+}
+
+func Test_buildManifestInvalidInstallTemplate(t *testing.T) {
+	_, err := manifestFor(t, func(ctx *context.Context) {
+		ctx.Config.Scoop.PostInstall = config.StringArray{"{{ .Nope }}"}
+	}, "amd64")
+	assert.Error(t, err)
+}
+
+func Test_buildManifestInvalidTemplates(t *testing.T) {
+	for name, opts := range map[string]func(ctx *context.Context){
+		"homepage":    func(ctx *context.Context) { ctx.Config.Scoop.Homepage = "{{ .Nope }}" },
+		"description": func(ctx *context.Context) { ctx.Config.Scoop.Description = "{{ .Nope }}" },
+		"license":     func(ctx *context.Context) { ctx.Config.Scoop.License.Identifier = "{{ .Nope }}" },
+		"license url": func(ctx *context.Context) { ctx.Config.Scoop.License.URL = "{{ .Nope }}" },
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := manifestFor(t, opts, "amd64")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_buildManifestInvalidPersist(t *testing.T) {
+	_, err := manifestFor(t, func(ctx *context.Context) {
+		ctx.Config.Scoop.Persist = []config.StringArray{{"a", "b", "c"}}
+	}, "amd64")
+	assert.EqualError(t, err, "invalid scoop persist entry [a b c]: must be a path or a [source, target] pair")
+}
+
+func Test_buildManifestStoredChecksum(t *testing.T) {
+	var ctx = manifestContext(nil)
+	// the archive isn't read when the checksums pipe recorded its sha256
+	var archive = artifact.Artifact{
+		Name:      "foo_1.0.1_windows_amd64.tar.gz",
+		Path:      "/does/not/exist/foo_1.0.1_windows_amd64.tar.gz",
+		Goos:      "windows",
+		Goarch:    "amd64",
+		Type:      artifact.UploadableArchive,
+		Checksums: map[string]string{"sha256": "0123456789abcdef"},
+	}
+	ctx.Artifacts.Add(archive)
+	out, err := buildManifest(ctx, &DummyClient{}, []artifact.Artifact{archive})
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), `"hash": "0123456789abcdef"`)
+}
+
+// manifestFor builds the manifest of a windows archive, and binary, for each
+// of the given goarchs, after applying opts to the context.
+func manifestFor(t *testing.T, opts func(ctx *context.Context), goarchs ...string) (bytes.Buffer, error) {
+	var ctx = manifestContext(opts)
+	folder, err := ioutil.TempDir("", "goreleasertest")
+	assert.NoError(t, err)
+	var archives []artifact.Artifact
+	for _, goarch := range goarchs {
+		var archive = artifact.Artifact{
+			Name:   "foo_1.0.1_windows_" + goarch + ".tar.gz",
+			Path:   filepath.Join(folder, "foo_1.0.1_windows_"+goarch+".tar.gz"),
+			Goos:   "windows",
+			Goarch: goarch,
+			Type:   artifact.UploadableArchive,
+		}
+		assert.NoError(t, ioutil.WriteFile(archive.Path, []byte(goarch), 0644))
+		archives = append(archives, archive)
+		ctx.Artifacts.Add(archive)
+		ctx.Artifacts.Add(artifact.Artifact{
+			Name:   "test.exe",
+			Goos:   "windows",
+			Goarch: goarch,
+			Type:   artifact.Binary,
+		})
+	}
+	return buildManifest(ctx, &DummyClient{}, archives)
+}
+
+// manifestContext returns the context of the manifest tests, changed by opts
+func manifestContext(opts func(ctx *context.Context)) *context.Context {
 	var ctx = &context.Context{
 		Git: context.GitInfo{
 			CurrentTag: "v1.0.1",
@@ -410,20 +793,11 @@ func Test_buildManifest(t *testing.T) {
 		},
 		Publish: true,
 	}
-	out, err := buildManifest(ctx, &DummyClient{}, []artifact.Artifact{
-		{Name: "foo_1.0.1_windows_amd64.tar.gz", Goos: "windows", Goarch: "amd64"},
-		{Name: "foo_1.0.1_windows_386.tar.gz", Goos: "windows", Goarch: "386"},
-	})
-	assert.NoError(t, err)
-	var golden = "testdata/test_buildmanifest.json.golden"
-	if *update {
-		ioutil.WriteFile(golden, out.Bytes(), 0655)
+	if opts != nil {
+		opts(ctx)
 	}
-	bts, err := ioutil.ReadFile(golden)
-	assert.NoError(t, err)
-	assert.Equal(t, string(bts), out.String())
+	return ctx
 }
-
 func Test_getDownloadURL(t *testing.T) {
 	type args struct {
 		ctx       *context.Context
@@ -437,13 +811,13 @@ func Test_getDownloadURL(t *testing.T) {
 	}{
 		{
 			"simple",
-			args{&context.Context{Version: "1.0.0", Config: config.Project{Release: config.Release{GitHub: config.Repo{Owner: "user", Name: "repo"}}}}, "https://github.com", "file.tar.gz"},
-			"https://github.com/user/repo/releases/download/1.0.0/file.tar.gz",
+			args{&context.Context{Git: context.GitInfo{CurrentTag: "v1.0.0"}, Config: config.Project{Release: config.Release{GitHub: config.Repo{Owner: "user", Name: "repo"}}}}, "https://github.com", "file.tar.gz"},
+			"https://github.com/user/repo/releases/download/v1.0.0/file.tar.gz",
 		},
 		{
 			"custom",
-			args{&context.Context{Version: "1.0.0", Config: config.Project{Release: config.Release{GitHub: config.Repo{Owner: "user", Name: "repo"}}}}, "https://git.my.company.com", "file.tar.gz"},
-			"https://git.my.company.com/user/repo/releases/download/1.0.0/file.tar.gz",
+			args{&context.Context{Git: context.GitInfo{CurrentTag: "v1.0.0"}, Config: config.Project{Release: config.Release{GitHub: config.Repo{Owner: "user", Name: "repo"}}}}, "https://git.my.company.com", "file.tar.gz"},
+			"https://git.my.company.com/user/repo/releases/download/v1.0.0/file.tar.gz",
 		},
 	}
 	for _, tt := range tests {
@@ -454,22 +828,59 @@ func Test_getDownloadURL(t *testing.T) {
 	}
 }
 
+func Test_binaries(t *testing.T) {
+	var ctx = context.New(config.Project{})
+	for _, a := range []artifact.Artifact{
+		{Name: "foo.exe", Goos: "windows", Goarch: "amd64", Type: artifact.Binary},
+		{Name: "bar.exe", Goos: "windows", Goarch: "amd64", Type: artifact.Binary},
+		{Name: "foo.exe", Goos: "windows", Goarch: "386", Type: artifact.Binary},
+		{Name: "foo", Goos: "linux", Goarch: "amd64", Type: artifact.Binary},
+	} {
+		ctx.Artifacts.Add(a)
+	}
+	assert.Equal(t, []string{"foo.exe", "bar.exe"}, binaries(ctx, artifact.Artifact{
+		Goos:   "windows",
+		Goarch: "amd64",
+	}))
+	assert.Equal(t, []string{"foo_windows_386/foo.exe"}, binaries(ctx, artifact.Artifact{
+		Goos:   "windows",
+		Goarch: "386",
+		Extra: map[string]interface{}{
+			"WrappedIn": "foo_windows_386",
+		},
+	}))
+}
+
 type DummyClient struct {
 	CreatedFile bool
 	Content     string
+	Path        string
+	Message     string
+	Repo        config.Repo
 }
 
 func (client *DummyClient) CreateRelease(ctx *context.Context, body string) (releaseID int64, err error) {
 	return
 }
 
-func (client *DummyClient) CreateFile(ctx *context.Context, commitAuthor config.CommitAuthor, repo config.Repo, content bytes.Buffer, path string) (err error) {
+func (client *DummyClient) CreateFile(ctx *context.Context, commitAuthor config.CommitAuthor, repo config.Repo, content bytes.Buffer, path, message string) (err error) {
 	client.CreatedFile = true
 	bts, _ := ioutil.ReadAll(&content)
 	client.Content = string(bts)
+	client.Path = path
+	client.Message = message
+	client.Repo = repo
 	return
 }
 
 func (client *DummyClient) Upload(ctx *context.Context, releaseID int64, name string, file *os.File) (err error) {
 	return
 }
+
+func (client *DummyClient) MergedPullRequests(ctx *context.Context, repo config.Repo, since time.Time) (prs []client.PullRequest, err error) {
+	return
+}
+
+func (client *DummyClient) GetFile(ctx *context.Context, repo config.Repo, path string) (content []byte, err error) {
+	return
+}
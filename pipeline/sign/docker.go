@@ -0,0 +1,156 @@
+package sign
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/filenametemplate"
+	"github.com/goreleaser/goreleaser/pipeline"
+)
+
+// DockerPipe for docker image signing. It runs after the images and
+// manifest lists are pushed, as the signatures are pushed to the registry.
+type DockerPipe struct{}
+
+func (DockerPipe) String() string {
+	return "signing docker images"
+}
+
+// Default sets the DockerPipe defaults.
+func (DockerPipe) Default(ctx *context.Context) error {
+	for i := range ctx.Config.DockerSigns {
+		cfg := &ctx.Config.DockerSigns[i]
+		if cfg.Cmd == "" {
+			cfg.Cmd = "docker"
+		}
+		if len(cfg.Args) == 0 {
+			cfg.Args = []string{"trust", "sign", "${image}"}
+		}
+		if cfg.Artifacts == "" {
+			cfg.Artifacts = "images"
+		}
+		if cfg.Timeout == 0 {
+			cfg.Timeout = 5 * time.Minute
+		}
+	}
+	return nil
+}
+
+// Run the pipe
+func (DockerPipe) Run(ctx *context.Context) error {
+	if len(ctx.Config.DockerSigns) == 0 {
+		return pipeline.Skip("docker_signs section is not configured")
+	}
+	if !ctx.Publish {
+		return pipeline.SkipPublish(ctx)
+	}
+	var signings []signing
+	for _, cfg := range ctx.Config.DockerSigns {
+		filter, err := dockerFilterFor(cfg)
+		if err != nil {
+			return err
+		}
+		if filter == nil {
+			continue
+		}
+		if _, err := exec.LookPath(cfg.Cmd); err != nil {
+			return fmt.Errorf("sign: %s not present in $PATH", cfg.Cmd)
+		}
+		for _, image := range ctx.Artifacts.Filter(filter).List() {
+			if image.ExtraString("Digest") == "" && usesDigest(cfg) {
+				return fmt.Errorf("sign: no digest was recorded for %s", image.Name)
+			}
+			signings = append(signings, signing{cfg: cfg, artifact: image})
+		}
+	}
+	if len(signings) == 0 {
+		return pipeline.Skip("no docker images to sign")
+	}
+	for _, s := range signings {
+		if err := signImage(ctx, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dockerFilterFor returns the filter of the images the config signs, or nil
+// if it signs none. Images which weren't pushed can't be signed.
+func dockerFilterFor(cfg config.Sign) (artifact.Filter, error) {
+	var pushed = func(a artifact.Artifact) bool {
+		return a.ExtraBool("Pushed")
+	}
+	switch cfg.Artifacts {
+	case "images":
+		return artifact.And(artifact.ByType(artifact.DockerImage), pushed), nil
+	case "manifests":
+		return artifact.ByType(artifact.DockerManifest), nil
+	case "all":
+		return artifact.Or(
+			artifact.And(artifact.ByType(artifact.DockerImage), pushed),
+			artifact.ByType(artifact.DockerManifest),
+		), nil
+	case "none":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("invalid list of docker artifacts to sign: %s", cfg.Artifacts)
+	}
+}
+
+// usesDigest tells whether the command of the config needs the digest of
+// the images.
+func usesDigest(cfg config.Sign) bool {
+	for _, arg := range append(cfg.Args, cfg.Env...) {
+		if strings.Contains(arg, "digest") {
+			return true
+		}
+	}
+	return false
+}
+
+func signImage(ctx *context.Context, s signing) error {
+	cfg := s.cfg
+
+	var fields = filenametemplate.NewFields(ctx, nil, s.artifact)
+	key, cleanup, err := keyFor(ctx, cfg, fields)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	env := map[string]string{
+		"image":    s.artifact.Name,
+		"artifact": s.artifact.Name,
+		"digest":   s.artifact.ExtraString("Digest"),
+		"key":      key,
+	}
+
+	args, err := render("args", cfg.Args, fields, env)
+	if err != nil {
+		return err
+	}
+	cmdEnv, err := render("env", cfg.Env, fields, env)
+	if err != nil {
+		return err
+	}
+	cmdEnv = append(os.Environ(), cmdEnv...)
+
+	log.WithField("image", s.artifact.Name).Info("signing docker image")
+	output, err := run(ctx, cfg, cfg.Cmd, args, cmdEnv, ctx.Env[cfg.PassphraseEnv])
+	if err == errTimeout {
+		return fmt.Errorf("sign: %s timed out after %s signing %s", cfg.Cmd, cfg.Timeout, s.artifact.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("sign: %s failed with %q", cfg.Cmd, output)
+	}
+	log.Debugf("%s output: \n%s", cfg.Cmd, output)
+	return nil
+}
@@ -0,0 +1,145 @@
+package sign
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/testlib"
+)
+
+const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestDockerDescription(t *testing.T) {
+	assert.NotEmpty(t, DockerPipe{}.String())
+}
+
+func TestDockerDefault(t *testing.T) {
+	var ctx = context.New(config.Project{
+		DockerSigns: []config.Sign{{}},
+	})
+	assert.NoError(t, DockerPipe{}.Default(ctx))
+	assert.Equal(t, "docker", ctx.Config.DockerSigns[0].Cmd)
+	assert.Equal(t, []string{"trust", "sign", "${image}"}, ctx.Config.DockerSigns[0].Args)
+	assert.Equal(t, "images", ctx.Config.DockerSigns[0].Artifacts)
+}
+
+func TestDockerSkip(t *testing.T) {
+	testlib.AssertSkipped(t, DockerPipe{}.Run(context.New(config.Project{})))
+}
+
+func TestDockerSkipPublish(t *testing.T) {
+	ctx, _, done := dockerSignContext(t, config.Sign{})
+	defer done()
+	ctx.Publish = false
+	testlib.AssertSkipped(t, DockerPipe{}.Run(ctx))
+}
+
+// dockerSignContext returns a context with pushed docker images and a
+// manifest list, and a fake sign command appending its arguments to the
+// returned file.
+func dockerSignContext(t *testing.T, signs ...config.Sign) (*context.Context, string, func()) {
+	tmpdir, err := ioutil.TempDir("", "goreleaser")
+	assert.NoError(t, err)
+	var calls = filepath.Join(tmpdir, "calls")
+	var script = filepath.Join(tmpdir, "docker")
+	assert.NoError(t, ioutil.WriteFile(script, []byte("#!/bin/sh\necho \"$@\" >> "+calls+"\n"), 0755))
+	for i := range signs {
+		if signs[i].Cmd == "" {
+			signs[i].Cmd = script
+		}
+	}
+	var ctx = context.New(config.Project{
+		Dist:        tmpdir,
+		DockerSigns: signs,
+	})
+	ctx.Publish = true
+	for _, a := range []artifact.Artifact{
+		{Name: "org/mybin:v1.2.3", Type: artifact.DockerImage, Extra: map[string]interface{}{"Pushed": true, "Digest": digest}},
+		{Name: "org/mybin:local", Type: artifact.DockerImage, Extra: map[string]interface{}{"Pushed": false}},
+		{Name: "org/mybin:latest", Type: artifact.DockerManifest, Extra: map[string]interface{}{"Digest": digest}},
+	} {
+		a.Path = a.Name
+		ctx.Artifacts.Add(a)
+	}
+	assert.NoError(t, DockerPipe{}.Default(ctx))
+	return ctx, calls, func() {
+		assert.NoError(t, os.RemoveAll(tmpdir))
+	}
+}
+
+func TestDockerSignImages(t *testing.T) {
+	ctx, calls, done := dockerSignContext(t, config.Sign{})
+	defer done()
+	assert.NoError(t, DockerPipe{}.Run(ctx))
+	bts, err := ioutil.ReadFile(calls)
+	assert.NoError(t, err)
+	assert.Equal(t, "trust sign org/mybin:v1.2.3\n", string(bts))
+}
+
+func TestDockerSignAll(t *testing.T) {
+	ctx, calls, done := dockerSignContext(t, config.Sign{
+		Artifacts: "all",
+		Key:       "{{ .ProjectName }}.key",
+		Args:      []string{"sign", "--key", "${key}", "${image}@${digest}"},
+	})
+	defer done()
+	ctx.Config.ProjectName = "mybin"
+	assert.NoError(t, DockerPipe{}.Run(ctx))
+	bts, err := ioutil.ReadFile(calls)
+	assert.NoError(t, err)
+	assert.Equal(t, "sign --key mybin.key org/mybin:v1.2.3@"+digest+"\nsign --key mybin.key org/mybin:latest@"+digest+"\n", string(bts))
+}
+
+func TestDockerSignManifests(t *testing.T) {
+	ctx, calls, done := dockerSignContext(t, config.Sign{Artifacts: "manifests"})
+	defer done()
+	assert.NoError(t, DockerPipe{}.Run(ctx))
+	bts, err := ioutil.ReadFile(calls)
+	assert.NoError(t, err)
+	assert.Equal(t, "trust sign org/mybin:latest\n", string(bts))
+}
+
+func TestDockerSignNone(t *testing.T) {
+	ctx, _, done := dockerSignContext(t, config.Sign{Artifacts: "none"})
+	defer done()
+	testlib.AssertSkipped(t, DockerPipe{}.Run(ctx))
+}
+
+func TestDockerSignInvalidArtifacts(t *testing.T) {
+	ctx, _, done := dockerSignContext(t, config.Sign{Artifacts: "checksum"})
+	defer done()
+	assert.EqualError(t, DockerPipe{}.Run(ctx), "invalid list of docker artifacts to sign: checksum")
+}
+
+func TestDockerSignNoDigest(t *testing.T) {
+	ctx, _, done := dockerSignContext(t, config.Sign{Args: []string{"sign", "${image}@${digest}"}})
+	defer done()
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name:  "org/mybin:v1",
+		Type:  artifact.DockerImage,
+		Extra: map[string]interface{}{"Pushed": true},
+	})
+	assert.EqualError(t, DockerPipe{}.Run(ctx), "sign: no digest was recorded for org/mybin:v1")
+}
+
+func TestDockerSignNotInPath(t *testing.T) {
+	ctx, _, done := dockerSignContext(t, config.Sign{Cmd: "nope-cosign"})
+	defer done()
+	assert.EqualError(t, DockerPipe{}.Run(ctx), "sign: nope-cosign not present in $PATH")
+}
+
+func TestDockerSignFails(t *testing.T) {
+	ctx, _, done := dockerSignContext(t, config.Sign{})
+	defer done()
+	var script = filepath.Join(ctx.Config.Dist, "fail")
+	assert.NoError(t, ioutil.WriteFile(script, []byte("#!/bin/sh\necho no trust data\nexit 1\n"), 0755))
+	ctx.Config.DockerSigns[0].Cmd = script
+	assert.EqualError(t, DockerPipe{}.Run(ctx), `sign: `+script+` failed with "no trust data\n"`)
+}
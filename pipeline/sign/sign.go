@@ -1,13 +1,23 @@
 package sign
 
 import (
+	stdctx "context"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/goreleaser/goreleaser/config"
 	"github.com/goreleaser/goreleaser/context"
 	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/filenametemplate"
 	"github.com/goreleaser/goreleaser/pipeline"
 )
 
@@ -18,86 +28,479 @@ func (Pipe) String() string {
 	return "signing artifacts"
 }
 
+// preset holds the defaults of a signing tool
+type preset struct {
+	modes          map[string]mode
+	keyArgs        []string
+	passphraseEnv  string
+	trustedComment string
+	needsKey       bool
+}
+
+// mode holds the defaults of a signing tool for a kind of signature
+type mode struct {
+	signature  string
+	args       []string
+	verifyArgs []string
+}
+
+// presets of the signing tools known, by command name. Other commands use
+// the gpg one.
+var presets = map[string]preset{
+	"gpg": {
+		modes: map[string]mode{
+			"detached": {
+				signature:  "${artifact}.sig",
+				args:       []string{"--output", "$signature", "--detach-sig", "$artifact"},
+				verifyArgs: []string{"--verify", "$signature", "$artifact"},
+			},
+			"armored": {
+				signature:  "${artifact}.asc",
+				args:       []string{"--armor", "--output", "$signature", "--detach-sig", "$artifact"},
+				verifyArgs: []string{"--verify", "$signature", "$artifact"},
+			},
+			"clearsign": {
+				signature:  "${artifact}.asc",
+				args:       []string{"--output", "$signature", "--clearsign", "$artifact"},
+				verifyArgs: []string{"--verify", "$signature"},
+			},
+		},
+		keyArgs:       []string{"--local-user", "${key}"},
+		passphraseEnv: "GPG_PASSPHRASE",
+	},
+	"minisign": {
+		modes: map[string]mode{
+			"detached": {
+				signature:  "${artifact}.minisig",
+				args:       []string{"-S", "-s", "${key}", "-t", "${trusted_comment}", "-m", "${artifact}", "-x", "${signature}"},
+				verifyArgs: []string{"-V", "-p", "${public_key}", "-m", "${artifact}", "-x", "${signature}"},
+			},
+		},
+		passphraseEnv:  "MINISIGN_PASSWORD",
+		trustedComment: "{{ .ProjectName }} {{ .Version }}",
+		needsKey:       true,
+	},
+	"signify": {
+		modes: map[string]mode{
+			"detached": {
+				signature:  "${artifact}.sig",
+				args:       []string{"-S", "-s", "${key}", "-m", "${artifact}", "-x", "${signature}"},
+				verifyArgs: []string{"-V", "-p", "${public_key}", "-m", "${artifact}", "-x", "${signature}"},
+			},
+		},
+		needsKey: true,
+	},
+}
+
+func presetFor(cfg config.Sign) preset {
+	if p, ok := presets[filepath.Base(cfg.Cmd)]; ok {
+		return p
+	}
+	return presets["gpg"]
+}
+
 // Default sets the Pipes defaults.
 func (Pipe) Default(ctx *context.Context) error {
-	cfg := &ctx.Config.Sign
-	if cfg.Cmd == "" {
-		cfg.Cmd = "gpg"
+	if len(ctx.Config.Signs) == 0 {
+		ctx.Config.Signs = []config.Sign{ctx.Config.Sign}
 	}
-	if cfg.Signature == "" {
-		cfg.Signature = "${artifact}.sig"
+	for i := range ctx.Config.Signs {
+		cfg := &ctx.Config.Signs[i]
+		if cfg.Cmd == "" {
+			cfg.Cmd = "gpg"
+		}
+		if cfg.Mode == "" {
+			cfg.Mode = "detached"
+		}
+		var preset = presetFor(*cfg)
+		mode, ok := preset.modes[cfg.Mode]
+		if !ok {
+			if _, known := presets["gpg"].modes[cfg.Mode]; known {
+				return fmt.Errorf("sign: %s doesn't support the %s mode", cfg.Cmd, cfg.Mode)
+			}
+			return fmt.Errorf("invalid sign mode '%s', must be detached, armored or clearsign", cfg.Mode)
+		}
+		if cfg.Signature == "" {
+			cfg.Signature = mode.signature
+		}
+		if len(cfg.Args) == 0 {
+			cfg.Args = mode.args
+			// the key is only added to the default args, custom ones
+			// can use ${key} where the command needs it.
+			if cfg.Key != "" && len(preset.keyArgs) > 0 {
+				cfg.Args = append(append([]string{}, preset.keyArgs...), mode.args...)
+			}
+		}
+		if cfg.VerifyCmd == "" {
+			cfg.VerifyCmd = cfg.Cmd
+		}
+		if len(cfg.VerifyArgs) == 0 {
+			cfg.VerifyArgs = mode.verifyArgs
+		}
+		if cfg.Artifacts == "" {
+			cfg.Artifacts = "none"
+		}
+		if cfg.PassphraseEnv == "" {
+			cfg.PassphraseEnv = preset.passphraseEnv
+		}
+		if cfg.TrustedComment == "" {
+			cfg.TrustedComment = preset.trustedComment
+		}
+		if cfg.Timeout == 0 {
+			cfg.Timeout = 5 * time.Minute
+		}
 	}
-	if len(cfg.Args) == 0 {
-		cfg.Args = []string{"--output", "$signature", "--detach-sig", "$artifact"}
+	return nil
+}
+
+// signing is an artifact to sign with a sign config
+type signing struct {
+	cfg       config.Sign
+	artifact  artifact.Artifact
+	signature string
+}
+
+// Run executes the Pipe. It runs before the checksums, so their files
+// list the signatures, which ChecksumPipe then signs.
+func (Pipe) Run(ctx *context.Context) error {
+	return signMatching(ctx, func(artifact.Artifact) bool {
+		return true
+	})
+}
+
+// ChecksumPipe for checksums signing.
+type ChecksumPipe struct{}
+
+func (ChecksumPipe) String() string {
+	return "signing checksums"
+}
+
+// Run executes the ChecksumPipe.
+func (ChecksumPipe) Run(ctx *context.Context) error {
+	return signMatching(ctx, artifact.ByType(artifact.Checksum))
+}
+
+// signMatching signs the artifacts matching the given filter which weren't
+// signed yet.
+func signMatching(ctx *context.Context, only artifact.Filter) error {
+	var signings []signing
+	var signatures = map[string]int{}
+	var signed = map[string]bool{}
+	for _, sig := range ctx.Artifacts.Filter(artifact.ByType(artifact.Signature)).List() {
+		signed[sig.Path] = true
 	}
-	if cfg.Artifacts == "" {
-		cfg.Artifacts = "none"
+	for i, cfg := range ctx.Config.Signs {
+		filter, err := filterFor(cfg)
+		if err != nil {
+			return err
+		}
+		if filter == nil {
+			continue
+		}
+		if _, err := exec.LookPath(cfg.Cmd); err != nil {
+			return fmt.Errorf("sign: %s not present in $PATH", cfg.Cmd)
+		}
+		if presetFor(cfg).needsKey && cfg.Key == "" && cfg.KeyEnv == "" {
+			return fmt.Errorf("sign: %s needs a key or a key_env", cfg.Cmd)
+		}
+		if err := checkVerify(cfg); err != nil {
+			return err
+		}
+		for _, a := range ctx.Artifacts.Filter(artifact.And(filter, only)).List() {
+			signature, err := signatureFor(ctx, cfg, a)
+			if err != nil {
+				return err
+			}
+			if signed[signature] {
+				continue
+			}
+			// the same artifact can be signed by several configs, as long as
+			// they don't write the same signature.
+			if j, ok := signatures[signature]; ok {
+				if i == j {
+					return fmt.Errorf("sign: signs #%d would write the signature %s for several artifacts", i+1, signature)
+				}
+				return fmt.Errorf("sign: signs #%d and #%d would both write the signature %s", j+1, i+1, signature)
+			}
+			signatures[signature] = i
+			signings = append(signings, signing{cfg: cfg, artifact: a, signature: signature})
+		}
+	}
+	if len(signings) == 0 && disabled(ctx) {
+		return pipeline.Skip("artifact signing disabled")
+	}
+	return sign(ctx, signings)
+}
+
+// checkVerify checks the verification of the signatures can run
+func checkVerify(cfg config.Sign) error {
+	if !cfg.Verify {
+		return nil
+	}
+	if _, err := exec.LookPath(cfg.VerifyCmd); err != nil {
+		return fmt.Errorf("sign: %s not present in $PATH", cfg.VerifyCmd)
+	}
+	if cfg.PublicKey != "" {
+		return nil
+	}
+	for _, arg := range cfg.VerifyArgs {
+		if strings.Contains(arg, "public_key") {
+			return fmt.Errorf("sign: verifying with %s needs a public_key", cfg.VerifyCmd)
+		}
 	}
 	return nil
 }
 
-// Run executes the Pipe.
-func (Pipe) Run(ctx *context.Context) error {
-	switch ctx.Config.Sign.Artifacts {
+// disabled tells whether all the sign configs are disabled
+func disabled(ctx *context.Context) bool {
+	for _, cfg := range ctx.Config.Signs {
+		if cfg.Artifacts != "none" {
+			return false
+		}
+	}
+	return true
+}
+
+// filterFor returns the filter of the artifacts the config signs, or nil if
+// it signs none.
+func filterFor(cfg config.Sign) (artifact.Filter, error) {
+	var filter artifact.Filter
+	switch cfg.Artifacts {
 	case "checksum":
-		return sign(ctx, ctx.Artifacts.Filter(artifact.ByType(artifact.Checksum)).List())
+		filter = artifact.ByType(artifact.Checksum)
+	case "archive":
+		filter = artifact.ByType(artifact.UploadableArchive)
+	case "binary":
+		filter = artifact.ByType(artifact.UploadableBinary)
+	case "package":
+		filter = artifact.ByType(artifact.LinuxPackage)
 	case "all":
-		return sign(ctx, ctx.Artifacts.Filter(
-			artifact.Or(
-				artifact.ByType(artifact.UploadableArchive),
-				artifact.ByType(artifact.UploadableBinary),
-				artifact.ByType(artifact.Checksum),
-				artifact.ByType(artifact.LinuxPackage),
-			)).List())
+		filter = artifact.Or(
+			artifact.ByType(artifact.UploadableArchive),
+			artifact.ByType(artifact.UploadableBinary),
+			artifact.ByType(artifact.Checksum),
+			artifact.ByType(artifact.LinuxPackage),
+		)
 	case "none":
-		return pipeline.Skip("artifact signing disabled")
+		return nil, nil
 	default:
-		return fmt.Errorf("invalid list of artifacts to sign: %s", ctx.Config.Sign.Artifacts)
+		return nil, fmt.Errorf("invalid list of artifacts to sign: %s", cfg.Artifacts)
 	}
+	if len(cfg.IDs) > 0 {
+		filter = artifact.And(filter, artifact.ByIDs(cfg.IDs...))
+	}
+	return filter, nil
 }
 
-func sign(ctx *context.Context, artifacts []artifact.Artifact) error {
-	var sigs []string
-	for _, a := range artifacts {
-		sig, err := signone(ctx, a)
-		if err != nil {
+func sign(ctx *context.Context, signings []signing) error {
+	var g errgroup.Group
+	var sem = make(chan bool, ctx.Parallelism)
+	var fingerprints = make([]string, len(signings))
+	for i, s := range signings {
+		i, s := i, s
+		sem <- true
+		g.Go(func() error {
+			defer func() {
+				<-sem
+			}()
+			fingerprint, err := signone(ctx, s)
+			fingerprints[i] = fingerprint
 			return err
-		}
-		sigs = append(sigs, sig)
+		})
 	}
-	for _, sig := range sigs {
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	// clearsigned documents are added as signatures too, the signed
+	// artifacts are never replaced.
+	for i, s := range signings {
 		ctx.Artifacts.Add(artifact.Artifact{
 			Type: artifact.Signature,
-			Name: sig,
-			Path: filepath.Join(ctx.Config.Dist, sig),
+			Name: filepath.Base(s.signature),
+			Path: s.signature,
+			Extra: map[string]interface{}{
+				"Mode":        s.cfg.Mode,
+				"Fingerprint": fingerprints[i],
+			},
 		})
 	}
 	return nil
 }
 
-func signone(ctx *context.Context, artifact artifact.Artifact) (string, error) {
-	cfg := ctx.Config.Sign
+// signatureFor renders the signature path of the artifact. Signatures
+// named without a folder are written in the dist folder.
+func signatureFor(ctx *context.Context, cfg config.Sign, artifact artifact.Artifact) (string, error) {
+	name, err := filenametemplate.Apply(cfg.Signature, filenametemplate.NewFields(ctx, nil, artifact))
+	if err != nil {
+		return "", errors.Wrapf(err, "sign: failed to execute signature template '%s'", cfg.Signature)
+	}
+	var signature = filepath.Clean(expand(name, map[string]string{
+		"artifact": artifact.Path,
+	}))
+	if filepath.Dir(signature) == "." {
+		signature = filepath.Join(ctx.Config.Dist, signature)
+	}
+	return signature, nil
+}
+
+// signone signs the artifact, returning the fingerprint of the key used
+// when the command tells it, which gpg does.
+func signone(ctx *context.Context, s signing) (string, error) {
+	cfg := s.cfg
+
+	var fields = filenametemplate.NewFields(ctx, nil, s.artifact)
+	key, cleanup, err := keyFor(ctx, cfg, fields)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	comment, err := filenametemplate.Apply(cfg.TrustedComment, fields)
+	if err != nil {
+		return "", errors.Wrapf(err, "sign: failed to execute trusted comment template '%s'", cfg.TrustedComment)
+	}
+	publicKey, err := filenametemplate.Apply(cfg.PublicKey, fields)
+	if err != nil {
+		return "", errors.Wrapf(err, "sign: failed to execute public key template '%s'", cfg.PublicKey)
+	}
 
 	env := map[string]string{
-		"artifact": artifact.Path,
+		"artifact":        s.artifact.Path,
+		"signature":       s.signature,
+		"key":             key,
+		"trusted_comment": comment,
+		"public_key":      publicKey,
+	}
+
+	args, err := render("args", cfg.Args, fields, env)
+	if err != nil {
+		return "", err
+	}
+	cmdEnv, err := render("env", cfg.Env, fields, env)
+	if err != nil {
+		return "", err
+	}
+	cmdEnv = append(os.Environ(), cmdEnv...)
+
+	// the passphrase is only given through stdin, so it never shows up in
+	// the process list or in the logs. gpg needs to be told to read it
+	// there instead of asking for it with pinentry.
+	var passphrase = ctx.Env[cfg.PassphraseEnv]
+	if passphrase != "" && filepath.Base(cfg.Cmd) == "gpg" {
+		args = append([]string{"--batch", "--pinentry-mode", "loopback", "--passphrase-fd", "0"}, args...)
+	}
+	// gpg tells the fingerprint of the key it signed with in its status
+	// lines.
+	if filepath.Base(cfg.Cmd) == "gpg" {
+		args = append([]string{"--status-fd", "2"}, args...)
+	}
+
+	output, err := run(ctx, cfg, cfg.Cmd, args, cmdEnv, passphrase)
+	if err == errTimeout {
+		return "", fmt.Errorf("sign: %s timed out after %s signing %s", cfg.Cmd, cfg.Timeout, s.artifact.Name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("sign: %s failed with %q", cfg.Cmd, output)
+	}
+	var fingerprint = fingerprintFor(output)
+	if !cfg.Verify {
+		return fingerprint, nil
 	}
-	env["signature"] = expand(cfg.Signature, env)
 
-	var args []string
-	for _, a := range cfg.Args {
-		args = append(args, expand(a, env))
+	args, err = render("verify args", cfg.VerifyArgs, fields, env)
+	if err != nil {
+		return "", err
 	}
+	output, err = run(ctx, cfg, cfg.VerifyCmd, args, cmdEnv, "")
+	if err == errTimeout {
+		return "", fmt.Errorf("sign: %s timed out after %s verifying %s", cfg.VerifyCmd, cfg.Timeout, s.signature)
+	}
+	if err != nil {
+		return "", fmt.Errorf("sign: signature %s doesn't verify with %s: %q", s.signature, cfg.VerifyCmd, output)
+	}
+	return fingerprint, nil
+}
 
+// sigCreatedRe matches the gpg status line telling the signature was
+// created, which ends with the fingerprint of the key.
+var sigCreatedRe = regexp.MustCompile(`(?m)^\[GNUPG:\] SIG_CREATED .* ([0-9A-F]{40})$`)
+
+func fingerprintFor(output string) string {
+	if match := sigCreatedRe.FindStringSubmatch(output); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// render applies the templates, then replaces the variables, of each
+// argument.
+func render(what string, tmpls []string, fields filenametemplate.Fields, env map[string]string) ([]string, error) {
+	var result []string
+	for _, tmpl := range tmpls {
+		value, err := filenametemplate.Apply(tmpl, fields)
+		if err != nil {
+			return nil, errors.Wrapf(err, "sign: failed to execute %s template '%s'", what, tmpl)
+		}
+		result = append(result, expand(value, env))
+	}
+	return result, nil
+}
+
+var errTimeout = errors.New("timed out")
+
+// run runs the command, killing it after the timeout of the config.
+func run(ctx *context.Context, cfg config.Sign, name string, args, env []string, stdin string) (string, error) {
+	timeout, cancel := stdctx.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
 	// The GoASTScanner flags this as a security risk.
 	// However, this works as intended. The nosec annotation
 	// tells the scanner to ignore this.
+	// Each argument is given as is to the command, no shell is involved.
 	// #nosec
-	cmd := exec.CommandContext(ctx, cfg.Cmd, args...)
+	cmd := exec.CommandContext(timeout, name, args...)
+	cmd.Env = env
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
 	output, err := cmd.CombinedOutput()
+	if timeout.Err() == stdctx.DeadlineExceeded {
+		return string(output), errTimeout
+	}
+	return string(output), err
+}
+
+// keyFor returns the key to sign with. A key given through key_env is
+// written to a temporary file, which the returned func removes, as the
+// signing tools only read their secret keys from files.
+func keyFor(ctx *context.Context, cfg config.Sign, fields filenametemplate.Fields) (string, func(), error) {
+	var noop = func() {}
+	if cfg.KeyEnv == "" {
+		key, err := filenametemplate.Apply(cfg.Key, fields)
+		if err != nil {
+			return "", noop, errors.Wrapf(err, "sign: failed to execute key template '%s'", cfg.Key)
+		}
+		return key, noop, nil
+	}
+	var content = ctx.Env[cfg.KeyEnv]
+	if content == "" {
+		return "", noop, fmt.Errorf("sign: %s is not set", cfg.KeyEnv)
+	}
+	file, err := ioutil.TempFile("", "goreleaser-sign")
 	if err != nil {
-		return "", fmt.Errorf("sign: %s failed with %q", cfg.Cmd, string(output))
+		return "", noop, err
+	}
+	var cleanup = func() {
+		os.Remove(file.Name()) // nolint: errcheck
+	}
+	if _, err := file.WriteString(content + "\n"); err != nil {
+		file.Close() // nolint: errcheck
+		cleanup()
+		return "", noop, err
+	}
+	if err := file.Close(); err != nil {
+		cleanup()
+		return "", noop, err
 	}
-	return filepath.Base(env["signature"]), nil
+	return file.Name(), cleanup, nil
 }
 
 func expand(s string, env map[string]string) string {
@@ -7,11 +7,16 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/goreleaser/goreleaser/config"
 	"github.com/goreleaser/goreleaser/context"
 	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/testlib"
+	"github.com/goreleaser/goreleaser/pipeline"
+	"github.com/goreleaser/goreleaser/pipeline/checksums"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,10 +27,11 @@ func TestDescription(t *testing.T) {
 func TestSignDefault(t *testing.T) {
 	ctx := &context.Context{}
 	Pipe{}.Default(ctx)
-	assert.Equal(t, ctx.Config.Sign.Cmd, "gpg")
-	assert.Equal(t, ctx.Config.Sign.Signature, "${artifact}.sig")
-	assert.Equal(t, ctx.Config.Sign.Args, []string{"--output", "$signature", "--detach-sig", "$artifact"})
-	assert.Equal(t, ctx.Config.Sign.Artifacts, "none")
+	assert.Len(t, ctx.Config.Signs, 1)
+	assert.Equal(t, ctx.Config.Signs[0].Cmd, "gpg")
+	assert.Equal(t, ctx.Config.Signs[0].Signature, "${artifact}.sig")
+	assert.Equal(t, ctx.Config.Signs[0].Args, []string{"--output", "$signature", "--detach-sig", "$artifact"})
+	assert.Equal(t, ctx.Config.Signs[0].Artifacts, "none")
 }
 
 func TestSignDisabled(t *testing.T) {
@@ -37,7 +43,7 @@ func TestSignDisabled(t *testing.T) {
 
 func TestSignInvalidArtifacts(t *testing.T) {
 	ctx := &context.Context{}
-	ctx.Config.Sign.Artifacts = "foo"
+	ctx.Config.Signs = []config.Sign{{Artifacts: "foo"}}
 	err := Pipe{}.Run(ctx)
 	assert.EqualError(t, err, "invalid list of artifacts to sign: foo")
 }
@@ -69,6 +75,42 @@ func TestSignArtifacts(t *testing.T) {
 			),
 			signatures: []string{"checksum.sig"},
 		},
+		{
+			desc: "sign armored",
+			ctx: context.New(
+				config.Project{
+					Sign: config.Sign{Artifacts: "checksum", Mode: "armored"},
+				},
+			),
+			signatures: []string{"checksum.asc"},
+		},
+		{
+			desc: "clearsign checksums",
+			ctx: context.New(
+				config.Project{
+					Sign: config.Sign{Artifacts: "checksum", Mode: "clearsign"},
+				},
+			),
+			signatures: []string{"checksum.asc"},
+		},
+		{
+			desc: "sign with a key and verify",
+			ctx: context.New(
+				config.Project{
+					Sign: config.Sign{Artifacts: "all", Key: user, Verify: true},
+				},
+			),
+			signatures: []string{"artifact1.sig", "artifact2.sig", "checksum.sig"},
+		},
+		{
+			desc: "clearsign and verify",
+			ctx: context.New(
+				config.Project{
+					Sign: config.Sign{Artifacts: "checksum", Mode: "clearsign", Verify: true},
+				},
+			),
+			signatures: []string{"checksum.asc"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -78,6 +120,682 @@ func TestSignArtifacts(t *testing.T) {
 	}
 }
 
+func TestSignKeyUnavailable(t *testing.T) {
+	assert.NoError(t, os.Chmod(keyring, 0700))
+	tmpdir, err := ioutil.TempDir("", "goreleaser")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	var file = filepath.Join(tmpdir, "checksum")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("foo"), 0644))
+	var ctx = context.New(config.Project{
+		Dist: tmpdir,
+		Sign: config.Sign{Artifacts: "checksum"},
+	})
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name: "checksum",
+		Path: file,
+		Type: artifact.Checksum,
+	})
+	assert.NoError(t, Pipe{}.Default(ctx))
+	ctx.Config.Signs[0].Args = append([]string{"--homedir", keyring, "--batch", "-u", "nope@example.com"}, ctx.Config.Signs[0].Args...)
+
+	err = Pipe{}.Run(ctx)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "sign: gpg failed with")
+	assert.Contains(t, err.Error(), "nope@example.com")
+	assert.Empty(t, ctx.Artifacts.Filter(artifact.ByType(artifact.Signature)).List())
+}
+
+func TestSignCustomCommand(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "goreleaser")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	var script = filepath.Join(tmpdir, "hsm-sign")
+	assert.NoError(t, ioutil.WriteFile(script, []byte("#!/bin/sh\nfor arg in \"$@\"; do echo \"$arg\"; done > \"$2\"\necho \"$SIGN_KEY\" >> \"$2\"\n"), 0755))
+	var file = filepath.Join(tmpdir, "checksum")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("foo"), 0644))
+	var ctx = context.New(config.Project{
+		Dist: tmpdir,
+		Sign: config.Sign{
+			Artifacts: "checksum",
+			Cmd:       script,
+			Args:      []string{"{{ .Version }}", "${signature}", "with spaces 'and quotes'", "${artifact}"},
+			Env:       []string{"SIGN_KEY=key-{{ .Env.KEY_ID }}"},
+		},
+	})
+	ctx.Version = "1.2.3"
+	ctx.Env = map[string]string{"KEY_ID": "42"}
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name: "checksum",
+		Path: file,
+		Type: artifact.Checksum,
+	})
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+
+	bts, err := ioutil.ReadFile(file + ".sig")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3\n"+file+".sig\nwith spaces 'and quotes'\n"+file+"\nkey-42\n", string(bts))
+}
+
+func TestSignCustomCommandFails(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Sign: config.Sign{
+			Artifacts: "checksum",
+			Cmd:       "sh",
+			Args:      []string{"-c", "echo no hsm available >&2; exit 1"},
+		},
+	})
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name: "checksum",
+		Path: "checksum",
+		Type: artifact.Checksum,
+	})
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.EqualError(t, Pipe{}.Run(ctx), `sign: sh failed with "no hsm available\n"`)
+}
+
+func TestSignInvalidArgsTemplate(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Sign: config.Sign{
+			Artifacts: "checksum",
+			Args:      []string{"{{ .Nope }}"},
+		},
+	})
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name: "checksum",
+		Path: "checksum",
+		Type: artifact.Checksum,
+	})
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.Error(t, Pipe{}.Run(ctx))
+}
+
+// multiSignContext returns a context with several artifacts and a sign
+// script writing the key given as first argument to the signature given as
+// second argument.
+func multiSignContext(t *testing.T, signs ...config.Sign) (*context.Context, func()) {
+	tmpdir, err := ioutil.TempDir("", "goreleaser")
+	assert.NoError(t, err)
+	var script = filepath.Join(tmpdir, "fake-sign")
+	assert.NoError(t, ioutil.WriteFile(script, []byte("#!/bin/sh\necho \"$1\" > \"$2\"\n"), 0755))
+	for i := range signs {
+		signs[i].Cmd = script
+	}
+	var ctx = context.New(config.Project{
+		Dist:  tmpdir,
+		Signs: signs,
+	})
+	for _, a := range []artifact.Artifact{
+		{Name: "checksums.txt", Type: artifact.Checksum},
+		{Name: "mybin.tar.gz", Type: artifact.UploadableArchive},
+		{Name: "mybin.deb", Type: artifact.LinuxPackage},
+		{Name: "mybin", Type: artifact.UploadableBinary, ID: "cli"},
+		{Name: "mybind", Type: artifact.UploadableBinary, ID: "daemon"},
+		{Name: "mybin-bin", Type: artifact.Binary, ID: "cli"},
+	} {
+		a.Path = filepath.Join(tmpdir, a.Name)
+		assert.NoError(t, ioutil.WriteFile(a.Path, []byte("foo"), 0644))
+		ctx.Artifacts.Add(a)
+	}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	return ctx, func() {
+		assert.NoError(t, os.RemoveAll(tmpdir))
+	}
+}
+
+func signedBy(t *testing.T, ctx *context.Context) map[string]string {
+	var result = map[string]string{}
+	for _, sig := range ctx.Artifacts.Filter(artifact.ByType(artifact.Signature)).List() {
+		bts, err := ioutil.ReadFile(sig.Path)
+		assert.NoError(t, err)
+		result[sig.Name] = string(bytes.TrimSpace(bts))
+	}
+	return result
+}
+
+func TestSignMultiple(t *testing.T) {
+	ctx, done := multiSignContext(t,
+		config.Sign{Artifacts: "checksum", Args: []string{"release", "${signature}"}},
+		config.Sign{Artifacts: "package", Args: []string{"packages", "${signature}"}},
+		config.Sign{Artifacts: "binary", IDs: []string{"cli"}, Args: []string{"cli", "${signature}"}},
+		config.Sign{Artifacts: "archive", Args: []string{"archives", "${signature}"}},
+	)
+	defer done()
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.Equal(t, map[string]string{
+		"checksums.txt.sig": "release",
+		"mybin.deb.sig":     "packages",
+		"mybin.sig":         "cli",
+		"mybin.tar.gz.sig":  "archives",
+	}, signedBy(t, ctx))
+}
+
+func TestSignOverlapping(t *testing.T) {
+	ctx, done := multiSignContext(t,
+		config.Sign{Artifacts: "checksum", Args: []string{"release", "${signature}"}},
+		config.Sign{Artifacts: "all", Signature: "${artifact}.asc", Args: []string{"other", "${signature}"}},
+	)
+	defer done()
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.Equal(t, map[string]string{
+		"checksums.txt.sig": "release",
+		"checksums.txt.asc": "other",
+		"mybin.tar.gz.asc":  "other",
+		"mybin.deb.asc":     "other",
+		"mybin.asc":         "other",
+		"mybind.asc":        "other",
+	}, signedBy(t, ctx))
+}
+
+func TestSignOverlappingSameSignature(t *testing.T) {
+	ctx, done := multiSignContext(t,
+		config.Sign{Artifacts: "checksum", Args: []string{"release", "${signature}"}},
+		config.Sign{Artifacts: "all", Args: []string{"other", "${signature}"}},
+	)
+	defer done()
+	assert.EqualError(t, Pipe{}.Run(ctx), "sign: signs #1 and #2 would both write the signature "+filepath.Join(ctx.Config.Dist, "checksums.txt.sig"))
+	assert.Empty(t, signedBy(t, ctx))
+	_, err := os.Stat(filepath.Join(ctx.Config.Dist, "mybin.tar.gz.sig"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSignSameSignatureForSeveralArtifacts(t *testing.T) {
+	ctx, done := multiSignContext(t,
+		config.Sign{Artifacts: "all", Signature: "all.sig", Args: []string{"other", "${signature}"}},
+	)
+	defer done()
+	assert.EqualError(t, Pipe{}.Run(ctx), "sign: signs #1 would write the signature "+filepath.Join(ctx.Config.Dist, "all.sig")+" for several artifacts")
+}
+
+func TestSignSignatureTemplate(t *testing.T) {
+	ctx, done := multiSignContext(t,
+		config.Sign{Artifacts: "checksum", Signature: "sha256sums.asc", Args: []string{"release", "${signature}"}},
+		config.Sign{Artifacts: "archive", Signature: "{{ .ArtifactName }}-{{ .Version }}.asc", Args: []string{"archives", "${signature}"}},
+	)
+	defer done()
+	ctx.Version = "1.2.3"
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.Equal(t, map[string]string{
+		"sha256sums.asc":         "release",
+		"mybin.tar.gz-1.2.3.asc": "archives",
+	}, signedBy(t, ctx))
+	for _, sig := range ctx.Artifacts.Filter(artifact.ByType(artifact.Signature)).List() {
+		assert.Equal(t, filepath.Join(ctx.Config.Dist, sig.Name), sig.Path)
+	}
+}
+
+func TestSignSignatureTemplateCollision(t *testing.T) {
+	ctx, done := multiSignContext(t,
+		config.Sign{Artifacts: "checksum", Signature: "{{ .ProjectName }}.asc", Args: []string{"release", "${signature}"}},
+		config.Sign{Artifacts: "package", Signature: "{{ .ProjectName }}.asc", Args: []string{"packages", "${signature}"}},
+	)
+	defer done()
+	ctx.Config.ProjectName = "mybin"
+	assert.EqualError(t, Pipe{}.Run(ctx), "sign: signs #1 and #2 would both write the signature "+filepath.Join(ctx.Config.Dist, "mybin.asc"))
+	assert.Empty(t, signedBy(t, ctx))
+}
+
+func TestSignInvalidSignatureTemplate(t *testing.T) {
+	ctx, done := multiSignContext(t,
+		config.Sign{Artifacts: "checksum", Signature: "{{ .Nope }}"},
+	)
+	defer done()
+	assert.Error(t, Pipe{}.Run(ctx))
+}
+
+func TestSignAllDisabled(t *testing.T) {
+	ctx, done := multiSignContext(t,
+		config.Sign{Artifacts: "none"},
+		config.Sign{Artifacts: "none"},
+	)
+	defer done()
+	assert.EqualError(t, Pipe{}.Run(ctx), "artifact signing disabled")
+}
+
+func TestSignDefaultPassphraseAndTimeout(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Signs: []config.Sign{{}, {PassphraseEnv: "RELEASE_KEY_PASSPHRASE", Timeout: time.Minute}},
+	})
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.Equal(t, "GPG_PASSPHRASE", ctx.Config.Signs[0].PassphraseEnv)
+	assert.Equal(t, 5*time.Minute, ctx.Config.Signs[0].Timeout)
+	assert.Equal(t, "RELEASE_KEY_PASSPHRASE", ctx.Config.Signs[1].PassphraseEnv)
+	assert.Equal(t, time.Minute, ctx.Config.Signs[1].Timeout)
+}
+
+// fakeSigner puts a script with the given name first in the $PATH. It
+// writes its arguments, the content of the temporary key files given and its
+// stdin to the signature given in its arguments, i.e. the one in dist.
+func fakeSigner(t *testing.T, name, dist string) func() {
+	_, back := testlib.FakeCommand(t, name, `
+for arg in "$@"; do case "$arg" in `+dist+`*sig|`+dist+`*.asc) sig="$arg";; esac; done
+echo "$@" > "$sig"
+for arg in "$@"; do case "$arg" in */goreleaser-sign*) cat "$arg" >> "$sig";; esac; done
+cat >> "$sig"`)
+	return back
+}
+
+func TestSignPassphrase(t *testing.T) {
+	ctx, done := multiSignContext(t, config.Sign{Artifacts: "checksum"})
+	defer done()
+	defer fakeSigner(t, "gpg", ctx.Config.Dist)()
+	ctx.Config.Signs[0].Cmd = "gpg"
+	ctx.Env = map[string]string{"GPG_PASSPHRASE": "secret"}
+	assert.NoError(t, Pipe{}.Run(ctx))
+	var sig = filepath.Join(ctx.Config.Dist, "checksums.txt")
+	bts, err := ioutil.ReadFile(sig + ".sig")
+	assert.NoError(t, err)
+	assert.Equal(t, "--status-fd 2 --batch --pinentry-mode loopback --passphrase-fd 0 --output "+sig+".sig --detach-sig "+sig+"\nsecret", string(bts))
+}
+
+func TestSignNoPassphrase(t *testing.T) {
+	ctx, done := multiSignContext(t, config.Sign{Artifacts: "checksum"})
+	defer done()
+	defer fakeSigner(t, "gpg", ctx.Config.Dist)()
+	ctx.Config.Signs[0].Cmd = "gpg"
+	ctx.Env = map[string]string{}
+	assert.NoError(t, Pipe{}.Run(ctx))
+	var sig = filepath.Join(ctx.Config.Dist, "checksums.txt")
+	bts, err := ioutil.ReadFile(sig + ".sig")
+	assert.NoError(t, err)
+	assert.Equal(t, "--status-fd 2 --output "+sig+".sig --detach-sig "+sig+"\n", string(bts))
+}
+
+func TestSignPassphraseCustomCommand(t *testing.T) {
+	ctx, done := multiSignContext(t, config.Sign{
+		Artifacts:     "checksum",
+		PassphraseEnv: "HSM_PIN",
+	})
+	defer done()
+	ctx.Config.Signs[0].Cmd = "sh"
+	ctx.Config.Signs[0].Args = []string{"-c", "cat > ${signature}"}
+	ctx.Env = map[string]string{"HSM_PIN": "1234"}
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.Equal(t, map[string]string{"checksums.txt.sig": "1234"}, signedBy(t, ctx))
+}
+
+func TestSignTimeout(t *testing.T) {
+	ctx, done := multiSignContext(t, config.Sign{Artifacts: "checksum"})
+	defer done()
+	ctx.Config.Signs[0].Cmd = "sleep"
+	ctx.Config.Signs[0].Args = []string{"5"}
+	ctx.Config.Signs[0].Timeout = 50 * time.Millisecond
+	assert.EqualError(t, Pipe{}.Run(ctx), "sign: sleep timed out after 50ms signing checksums.txt")
+}
+
+func TestSignDefaultPresets(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Signs: []config.Sign{{Cmd: "minisign"}, {Cmd: "/usr/bin/signify"}, {Cmd: "hsm-sign"}},
+	})
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.Equal(t, "${artifact}.minisig", ctx.Config.Signs[0].Signature)
+	assert.Equal(t, []string{"-S", "-s", "${key}", "-t", "${trusted_comment}", "-m", "${artifact}", "-x", "${signature}"}, ctx.Config.Signs[0].Args)
+	assert.Equal(t, "MINISIGN_PASSWORD", ctx.Config.Signs[0].PassphraseEnv)
+	assert.Equal(t, "{{ .ProjectName }} {{ .Version }}", ctx.Config.Signs[0].TrustedComment)
+	assert.Equal(t, "${artifact}.sig", ctx.Config.Signs[1].Signature)
+	assert.Equal(t, []string{"-S", "-s", "${key}", "-m", "${artifact}", "-x", "${signature}"}, ctx.Config.Signs[1].Args)
+	assert.Empty(t, ctx.Config.Signs[1].PassphraseEnv)
+	assert.Empty(t, ctx.Config.Signs[1].TrustedComment)
+	assert.Equal(t, "${artifact}.sig", ctx.Config.Signs[2].Signature)
+	assert.Equal(t, []string{"--output", "$signature", "--detach-sig", "$artifact"}, ctx.Config.Signs[2].Args)
+	assert.Equal(t, "GPG_PASSPHRASE", ctx.Config.Signs[2].PassphraseEnv)
+}
+
+func TestSignModes(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Signs: []config.Sign{{}, {Mode: "armored"}, {Mode: "clearsign"}, {Mode: "clearsign", Signature: "${artifact}.txt"}},
+	})
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.Equal(t, "detached", ctx.Config.Signs[0].Mode)
+	assert.Equal(t, "${artifact}.sig", ctx.Config.Signs[0].Signature)
+	assert.Equal(t, "${artifact}.asc", ctx.Config.Signs[1].Signature)
+	assert.Equal(t, []string{"--armor", "--output", "$signature", "--detach-sig", "$artifact"}, ctx.Config.Signs[1].Args)
+	assert.Equal(t, "${artifact}.asc", ctx.Config.Signs[2].Signature)
+	assert.Equal(t, []string{"--output", "$signature", "--clearsign", "$artifact"}, ctx.Config.Signs[2].Args)
+	assert.Equal(t, "${artifact}.txt", ctx.Config.Signs[3].Signature)
+}
+
+func TestSignInvalidMode(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Signs: []config.Sign{{Mode: "inline"}},
+	})
+	assert.EqualError(t, Pipe{}.Default(ctx), "invalid sign mode 'inline', must be detached, armored or clearsign")
+}
+
+func TestSignModeNotSupported(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Signs: []config.Sign{{Cmd: "minisign", Mode: "clearsign"}},
+	})
+	assert.EqualError(t, Pipe{}.Default(ctx), "sign: minisign doesn't support the clearsign mode")
+}
+
+func TestSignClearsignKeepsChecksums(t *testing.T) {
+	ctx, done := multiSignContext(t, config.Sign{Artifacts: "checksum", Mode: "clearsign"})
+	defer done()
+	defer fakeSigner(t, "gpg", ctx.Config.Dist)()
+	ctx.Config.Signs[0].Cmd = "gpg"
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	var checksums = ctx.Artifacts.Filter(artifact.ByType(artifact.Checksum)).List()
+	assert.Len(t, checksums, 1)
+	assert.Equal(t, filepath.Join(ctx.Config.Dist, "checksums.txt"), checksums[0].Path)
+	var sigs = ctx.Artifacts.Filter(artifact.ByType(artifact.Signature)).List()
+	assert.Len(t, sigs, 1)
+	assert.Equal(t, "checksums.txt.asc", sigs[0].Name)
+	assert.Equal(t, "clearsign", sigs[0].Extra["Mode"])
+}
+
+func TestSignSkipsSigned(t *testing.T) {
+	ctx, done := multiSignContext(t, config.Sign{Artifacts: "all"})
+	defer done()
+	assert.NoError(t, Pipe{}.Run(ctx))
+	assert.NoError(t, os.Remove(filepath.Join(ctx.Config.Dist, "mybin.deb.sig")))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	_, err := os.Stat(filepath.Join(ctx.Config.Dist, "mybin.deb.sig"))
+	assert.True(t, os.IsNotExist(err), "the signed artifacts should not be signed again")
+	assert.Len(t, ctx.Artifacts.Filter(artifact.ByType(artifact.Signature)).List(), 5)
+}
+
+func TestSignChecksumPipe(t *testing.T) {
+	ctx, done := multiSignContext(t, config.Sign{Artifacts: "all"})
+	defer done()
+	assert.NotEmpty(t, ChecksumPipe{}.String())
+	assert.NoError(t, ChecksumPipe{}.Run(ctx))
+	assert.Equal(t, []string{"checksums.txt.sig"}, signatureNames(ctx))
+}
+
+func TestSignChecksumPipeDisabled(t *testing.T) {
+	ctx := &context.Context{}
+	ctx.Config.Signs = []config.Sign{{Artifacts: "none"}}
+	testlib.AssertSkipped(t, ChecksumPipe{}.Run(ctx))
+}
+
+// TestSignedDistChecksums signs a dist the way a release does: the artifacts
+// first, then the checksums, which list the signatures, and the checksums
+// last.
+func TestSignedDistChecksums(t *testing.T) {
+	if _, err := exec.LookPath("sha256sum"); err != nil {
+		t.Skip("sha256sum not present in $PATH")
+	}
+	assert.NoError(t, os.Chmod(keyring, 0700))
+	tmpdir, err := ioutil.TempDir("", "goreleaser")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+	var ctx = context.New(config.Project{
+		Dist:        tmpdir,
+		ProjectName: "mybin",
+		Sign:        config.Sign{Artifacts: "all"},
+		Checksum:    config.Checksum{NameTemplate: "mybin_checksums.txt"},
+	})
+	for _, a := range []artifact.Artifact{
+		{Name: "mybin.tar.gz", Type: artifact.UploadableArchive},
+		{Name: "mybin.deb", Type: artifact.LinuxPackage},
+	} {
+		a.Path = filepath.Join(tmpdir, a.Name)
+		assert.NoError(t, ioutil.WriteFile(a.Path, []byte(a.Name), 0644))
+		ctx.Artifacts.Add(a)
+	}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, checksums.Pipe{}.Default(ctx))
+	ctx.Config.Signs[0].Args = append([]string{"--homedir", keyring}, ctx.Config.Signs[0].Args...)
+	for _, pipe := range []pipeline.Piper{Pipe{}, checksums.Pipe{}, ChecksumPipe{}} {
+		assert.NoError(t, pipe.Run(ctx))
+	}
+	assert.Equal(t, []string{"mybin.deb.sig", "mybin.tar.gz.sig", "mybin_checksums.txt.sig"}, sortedSignatureNames(ctx))
+	bts, err := ioutil.ReadFile(filepath.Join(tmpdir, "mybin_checksums.txt"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(bts), "  mybin.deb.sig\n")
+	assert.Contains(t, string(bts), "  mybin.tar.gz.sig\n")
+	var cmd = exec.Command("sha256sum", "-c", "mybin_checksums.txt")
+	cmd.Dir = tmpdir
+	out, err := cmd.CombinedOutput()
+	assert.NoError(t, err, string(out))
+	verifySignature(t, ctx, "mybin_checksums.txt.sig")
+}
+
+func TestSignedDistSplitChecksums(t *testing.T) {
+	if _, err := exec.LookPath("sha256sum"); err != nil {
+		t.Skip("sha256sum not present in $PATH")
+	}
+	ctx, done := multiSignContext(t, config.Sign{Artifacts: "all"})
+	defer done()
+	ctx.Config.ProjectName = "mybin"
+	ctx.Config.Checksum = config.Checksum{NameTemplate: "mybin_checksums.txt", Split: true}
+	ctx.Artifacts = artifact.New()
+	for _, a := range []artifact.Artifact{
+		{Name: "mybin.tar.gz", Type: artifact.UploadableArchive},
+		{Name: "mybin.deb", Type: artifact.LinuxPackage},
+	} {
+		a.Path = filepath.Join(ctx.Config.Dist, a.Name)
+		ctx.Artifacts.Add(a)
+	}
+	assert.NoError(t, checksums.Pipe{}.Default(ctx))
+	for _, pipe := range []pipeline.Piper{Pipe{}, checksums.Pipe{}, ChecksumPipe{}} {
+		assert.NoError(t, pipe.Run(ctx))
+	}
+	// the checksum files of each artifact are signed with the combined one
+	assert.Equal(t, []string{
+		"mybin.deb.sha256.sig",
+		"mybin.deb.sig",
+		"mybin.tar.gz.sha256.sig",
+		"mybin.tar.gz.sig",
+		"mybin_checksums.txt.sig",
+	}, sortedSignatureNames(ctx))
+	for _, name := range []string{"mybin_checksums.txt", "mybin.deb.sha256", "mybin.tar.gz.sha256"} {
+		var cmd = exec.Command("sha256sum", "-c", name)
+		cmd.Dir = ctx.Config.Dist
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+}
+
+func sortedSignatureNames(ctx *context.Context) []string {
+	var names = signatureNames(ctx)
+	sort.Strings(names)
+	return names
+}
+
+func TestSignKeyDefaultArgs(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Signs: []config.Sign{
+			{Key: "{{ .Env.GPG_KEY_ID }}"},
+			{Key: "{{ .Env.GPG_KEY_ID }}", Args: []string{"-u", "${key}", "--detach-sig", "$artifact"}},
+			{Cmd: "signify", Key: "signify.sec"},
+		},
+	})
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.Equal(t, []string{"--local-user", "${key}", "--output", "$signature", "--detach-sig", "$artifact"}, ctx.Config.Signs[0].Args)
+	assert.Equal(t, []string{"-u", "${key}", "--detach-sig", "$artifact"}, ctx.Config.Signs[1].Args)
+	assert.Equal(t, []string{"-S", "-s", "${key}", "-m", "${artifact}", "-x", "${signature}"}, ctx.Config.Signs[2].Args)
+	assert.Equal(t, "gpg", ctx.Config.Signs[0].VerifyCmd)
+	assert.Equal(t, []string{"--verify", "$signature", "$artifact"}, ctx.Config.Signs[0].VerifyArgs)
+	assert.Equal(t, "signify", ctx.Config.Signs[2].VerifyCmd)
+	assert.Equal(t, []string{"-V", "-p", "${public_key}", "-m", "${artifact}", "-x", "${signature}"}, ctx.Config.Signs[2].VerifyArgs)
+}
+
+func TestSignKeyTemplate(t *testing.T) {
+	ctx, done := multiSignContext(t, config.Sign{Artifacts: "checksum"})
+	defer done()
+	defer fakeSigner(t, "gpg", ctx.Config.Dist)()
+	ctx.Env = map[string]string{"GPG_KEY_ID": "ABCD1234"}
+	ctx.Config.Signs = []config.Sign{{Artifacts: "checksum", Key: "{{ .Env.GPG_KEY_ID }}"}}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	var file = filepath.Join(ctx.Config.Dist, "checksums.txt")
+	bts, err := ioutil.ReadFile(file + ".sig")
+	assert.NoError(t, err)
+	assert.Equal(t, "--status-fd 2 --local-user ABCD1234 --output "+file+".sig --detach-sig "+file+"\n", string(bts))
+}
+
+func TestSignVerifyCustomCommand(t *testing.T) {
+	ctx, done := multiSignContext(t, config.Sign{Artifacts: "checksum"})
+	defer done()
+	defer fakeSigner(t, "signify", ctx.Config.Dist)()
+	var verify = filepath.Join(ctx.Config.Dist, "verify")
+	var log = filepath.Join(ctx.Config.Dist, "verify.log")
+	assert.NoError(t, ioutil.WriteFile(verify, []byte("#!/bin/sh\necho \"$@\" > "+log+"\n"), 0755))
+	ctx.Config.Signs = []config.Sign{{
+		Cmd:       "signify",
+		Artifacts: "checksum",
+		Key:       "signify.sec",
+		Verify:    true,
+		VerifyCmd: verify,
+		PublicKey: "{{ .ProjectName }}.pub",
+	}}
+	ctx.Config.ProjectName = "mybin"
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	var file = filepath.Join(ctx.Config.Dist, "checksums.txt")
+	bts, err := ioutil.ReadFile(log)
+	assert.NoError(t, err)
+	assert.Equal(t, "-V -p mybin.pub -m "+file+" -x "+file+".sig\n", string(bts))
+}
+
+func TestSignVerifyFails(t *testing.T) {
+	ctx, done := multiSignContext(t, config.Sign{Artifacts: "checksum"})
+	defer done()
+	defer fakeSigner(t, "gpg", ctx.Config.Dist)()
+	var verify = filepath.Join(ctx.Config.Dist, "verify")
+	assert.NoError(t, ioutil.WriteFile(verify, []byte("#!/bin/sh\necho BAD signature\nexit 1\n"), 0755))
+	ctx.Config.Signs = []config.Sign{{Artifacts: "checksum", Verify: true, VerifyCmd: verify}}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	var sig = filepath.Join(ctx.Config.Dist, "checksums.txt.sig")
+	assert.EqualError(t, Pipe{}.Run(ctx), `sign: signature `+sig+` doesn't verify with `+verify+`: "BAD signature\n"`)
+	assert.Empty(t, ctx.Artifacts.Filter(artifact.ByType(artifact.Signature)).List())
+}
+
+func TestSignVerifyNeedsPublicKey(t *testing.T) {
+	ctx, done := multiSignContext(t, config.Sign{Artifacts: "checksum"})
+	defer done()
+	defer fakeSigner(t, "minisign", ctx.Config.Dist)()
+	ctx.Config.Signs = []config.Sign{{Cmd: "minisign", Artifacts: "checksum", Key: "minisign.key", Verify: true}}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.EqualError(t, Pipe{}.Run(ctx), "sign: verifying with minisign needs a public_key")
+}
+
+func TestSignVerifyCmdNotInPath(t *testing.T) {
+	ctx, done := multiSignContext(t, config.Sign{Artifacts: "checksum", Verify: true, VerifyCmd: "nope-verify"})
+	defer done()
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.EqualError(t, Pipe{}.Run(ctx), "sign: nope-verify not present in $PATH")
+}
+
+func TestSignMinisignPreset(t *testing.T) {
+	ctx, done := multiSignContext(t, config.Sign{Artifacts: "checksum"})
+	defer done()
+	defer fakeSigner(t, "minisign", ctx.Config.Dist)()
+	ctx.Config.ProjectName = "mybin"
+	ctx.Version = "1.2.3"
+	ctx.Env = map[string]string{"MINISIGN_PASSWORD": "secret", "KEYS": "/keys"}
+	ctx.Config.Signs = []config.Sign{{Cmd: "minisign", Artifacts: "checksum", Key: "{{ .Env.KEYS }}/minisign.key"}}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	var file = filepath.Join(ctx.Config.Dist, "checksums.txt")
+	bts, err := ioutil.ReadFile(file + ".minisig")
+	assert.NoError(t, err)
+	assert.Equal(t, "-S -s /keys/minisign.key -t mybin 1.2.3 -m "+file+" -x "+file+".minisig\nsecret", string(bts))
+	assert.Equal(t, []string{"checksums.txt.minisig"}, signatureNames(ctx))
+}
+
+func TestSignKeyEnv(t *testing.T) {
+	ctx, done := multiSignContext(t, config.Sign{Artifacts: "checksum"})
+	defer done()
+	defer fakeSigner(t, "signify", ctx.Config.Dist)()
+	ctx.Env = map[string]string{"SIGNIFY_KEY": "untrusted comment: signify secret key\nRWRCSwAAAA"}
+	ctx.Config.Signs = []config.Sign{{Cmd: "signify", Artifacts: "checksum", KeyEnv: "SIGNIFY_KEY"}}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	bts, err := ioutil.ReadFile(filepath.Join(ctx.Config.Dist, "checksums.txt.sig"))
+	assert.NoError(t, err)
+	var lines = strings.Split(string(bts), "\n")
+	assert.Equal(t, []string{"untrusted comment: signify secret key", "RWRCSwAAAA", ""}, lines[1:])
+	var args = strings.Fields(lines[0])
+	assert.Equal(t, "-s", args[1])
+	_, err = os.Stat(args[2])
+	assert.True(t, os.IsNotExist(err), "the key file should have been removed")
+}
+
+func TestSignKeyEnvNotSet(t *testing.T) {
+	ctx, done := multiSignContext(t, config.Sign{Artifacts: "checksum"})
+	defer done()
+	defer fakeSigner(t, "signify", ctx.Config.Dist)()
+	ctx.Env = map[string]string{}
+	ctx.Config.Signs = []config.Sign{{Cmd: "signify", Artifacts: "checksum", KeyEnv: "SIGNIFY_KEY"}}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.EqualError(t, Pipe{}.Run(ctx), "sign: SIGNIFY_KEY is not set")
+}
+
+func TestSignNeedsKey(t *testing.T) {
+	ctx, done := multiSignContext(t, config.Sign{Artifacts: "checksum"})
+	defer done()
+	defer fakeSigner(t, "minisign", ctx.Config.Dist)()
+	ctx.Config.Signs = []config.Sign{{Cmd: "minisign", Artifacts: "checksum"}}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.EqualError(t, Pipe{}.Run(ctx), "sign: minisign needs a key or a key_env")
+}
+
+func TestSignToolNotInPath(t *testing.T) {
+	var path = os.Getenv("PATH")
+	defer func() {
+		assert.NoError(t, os.Setenv("PATH", path))
+	}()
+	assert.NoError(t, os.Setenv("PATH", ""))
+	var ctx = context.New(config.Project{
+		Signs: []config.Sign{{Cmd: "minisign", Artifacts: "checksum", Key: "minisign.key"}},
+	})
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.EqualError(t, Pipe{}.Run(ctx), "sign: minisign not present in $PATH")
+}
+
+func TestSignMinisignVerifies(t *testing.T) {
+	if _, err := exec.LookPath("minisign"); err != nil {
+		t.Skip("minisign not present in $PATH")
+	}
+	ctx, done := multiSignContext(t, config.Sign{Artifacts: "checksum"})
+	defer done()
+	var pub = filepath.Join(ctx.Config.Dist, "minisign.pub")
+	var key = filepath.Join(ctx.Config.Dist, "minisign.key")
+	out, err := exec.Command("minisign", "-G", "-W", "-p", pub, "-s", key).CombinedOutput()
+	assert.NoError(t, err, string(out))
+	ctx.Config.ProjectName = "mybin"
+	ctx.Version = "1.2.3"
+	ctx.Config.Signs = []config.Sign{{Cmd: "minisign", Artifacts: "checksum", Key: key, Verify: true, PublicKey: pub}}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	out, err = exec.Command("minisign", "-V", "-p", pub, "-m", filepath.Join(ctx.Config.Dist, "checksums.txt")).CombinedOutput()
+	assert.NoError(t, err, string(out))
+	assert.Contains(t, string(out), "mybin 1.2.3")
+}
+
+func TestSignSignifyVerifies(t *testing.T) {
+	if _, err := exec.LookPath("signify"); err != nil {
+		t.Skip("signify not present in $PATH")
+	}
+	ctx, done := multiSignContext(t, config.Sign{Artifacts: "checksum"})
+	defer done()
+	var pub = filepath.Join(ctx.Config.Dist, "signify.pub")
+	var key = filepath.Join(ctx.Config.Dist, "signify.sec")
+	out, err := exec.Command("signify", "-G", "-n", "-p", pub, "-s", key).CombinedOutput()
+	assert.NoError(t, err, string(out))
+	ctx.Config.Signs = []config.Sign{{Cmd: "signify", Artifacts: "checksum", Key: key, Verify: true, PublicKey: pub}}
+	assert.NoError(t, Pipe{}.Default(ctx))
+	assert.NoError(t, Pipe{}.Run(ctx))
+	var file = filepath.Join(ctx.Config.Dist, "checksums.txt")
+	out, err = exec.Command("signify", "-V", "-p", pub, "-m", file, "-x", file+".sig").CombinedOutput()
+	assert.NoError(t, err, string(out))
+}
+
+func signatureNames(ctx *context.Context) []string {
+	var names []string
+	for _, sig := range ctx.Artifacts.Filter(artifact.ByType(artifact.Signature)).List() {
+		names = append(names, sig.Name)
+	}
+	return names
+}
+
 const keyring = "testdata/gnupg"
 const user = "nopass"
 
@@ -114,7 +832,10 @@ func testSign(t *testing.T, ctx *context.Context, signatures []string) {
 	// configure the pipeline
 	// make sure we are using the test keyring
 	assert.NoError(t, Pipe{}.Default(ctx))
-	ctx.Config.Sign.Args = append([]string{"--homedir", keyring}, ctx.Config.Sign.Args...)
+	for i := range ctx.Config.Signs {
+		ctx.Config.Signs[i].Args = append([]string{"--homedir", keyring}, ctx.Config.Signs[i].Args...)
+		ctx.Config.Signs[i].VerifyArgs = append([]string{"--homedir", keyring}, ctx.Config.Signs[i].VerifyArgs...)
+	}
 
 	// run the pipeline
 	assert.NoError(t, Pipe{}.Run(ctx))
@@ -135,19 +856,44 @@ func testSign(t *testing.T, ctx *context.Context, signatures []string) {
 		verifySignature(t, ctx, sig)
 	}
 
+	var fingerprints = keyFingerprints(t)
 	var signArtifacts []string
 	for _, sig := range ctx.Artifacts.Filter(artifact.ByType(artifact.Signature)).List() {
 		signArtifacts = append(signArtifacts, sig.Name)
+		assert.Contains(t, fingerprints, sig.Extra["Fingerprint"])
 	}
 	// check signature is an artifact
 	assert.Equal(t, signArtifacts, signatures)
 }
 
+// keyFingerprints returns the fingerprints of the key of the user and of
+// its subkeys.
+func keyFingerprints(t *testing.T) []string {
+	out, err := exec.Command("gpg", "--homedir", keyring, "--with-colons", "--fingerprint", user).Output()
+	assert.NoError(t, err)
+	var fingerprints []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "fpr:") {
+			fingerprints = append(fingerprints, strings.Trim(line[len("fpr:"):], ":"))
+		}
+	}
+	return fingerprints
+}
+
 func verifySignature(t *testing.T, ctx *context.Context, sig string) {
 	artifact := sig[:len(sig)-len(".sig")]
 
+	var args = []string{"--homedir", keyring, "--verify", filepath.Join(ctx.Config.Dist, sig), filepath.Join(ctx.Config.Dist, artifact)}
+	bts, err := ioutil.ReadFile(filepath.Join(ctx.Config.Dist, sig))
+	assert.NoError(t, err)
+	if bytes.HasPrefix(bts, []byte("-----BEGIN PGP SIGNED MESSAGE-----")) {
+		// clearsigned documents hold the artifact, which is left untouched
+		assert.Contains(t, string(bts), "\nfoo\n")
+		args = args[:len(args)-1]
+	}
+
 	// verify signature was made with key for usesr 'nopass'
-	cmd := exec.Command("gpg", "--homedir", keyring, "--verify", filepath.Join(ctx.Config.Dist, sig), filepath.Join(ctx.Config.Dist, artifact))
+	cmd := exec.Command("gpg", args...)
 	out, err := cmd.CombinedOutput()
 	assert.NoError(t, err)
 
@@ -159,3 +905,11 @@ func verifySignature(t *testing.T, ctx *context.Context, sig string) {
 		t.Fatalf("signature is not from %s", user)
 	}
 }
+
+func TestVerifyFileNeedsPublicKey(t *testing.T) {
+	assert.EqualError(
+		t,
+		VerifyFile(context.New(config.Project{}), "minisign", "", "checksums.txt.minisig", "checksums.txt"),
+		"sign: verifying with minisign needs a public key",
+	)
+}
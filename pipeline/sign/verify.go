@@ -0,0 +1,67 @@
+package sign
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+)
+
+// VerifyFile verifies the detached signature of a file with the given
+// signing command, the way the signing pipes verify the signatures they
+// create. gpg uses the public key when given, and its keyring otherwise.
+func VerifyFile(ctx *context.Context, cmd, publicKey, signature, file string) error {
+	var cfg = config.Sign{Cmd: cmd, Timeout: 5 * time.Minute}
+	var preset = presetFor(cfg)
+	if preset.needsKey && publicKey == "" {
+		return fmt.Errorf("sign: verifying with %s needs a public key", cmd)
+	}
+	var env = map[string]string{
+		"artifact":   file,
+		"signature":  signature,
+		"public_key": publicKey,
+	}
+	var args []string
+	for _, arg := range preset.modes["detached"].verifyArgs {
+		args = append(args, expand(arg, env))
+	}
+	if publicKey != "" && !preset.needsKey {
+		home, cleanup, err := gpgHome(ctx, cfg, publicKey)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		args = append([]string{"--homedir", home, "--batch"}, args...)
+	}
+	output, err := run(ctx, cfg, cmd, args, os.Environ(), "")
+	if err == errTimeout {
+		return fmt.Errorf("sign: %s timed out after %s verifying %s", cmd, cfg.Timeout, signature)
+	}
+	if err != nil {
+		return fmt.Errorf("sign: signature %s doesn't verify with %s: %q", signature, cmd, output)
+	}
+	return nil
+}
+
+// gpgHome returns a temporary gpg home directory, which the returned func
+// removes, with only the given public key imported, so the signature can't
+// verify with any other key of the keyring.
+func gpgHome(ctx *context.Context, cfg config.Sign, publicKey string) (string, func(), error) {
+	home, err := ioutil.TempDir("", "goreleaser-gpg")
+	if err != nil {
+		return "", func() {}, err
+	}
+	var cleanup = func() {
+		os.RemoveAll(home) // nolint: errcheck
+	}
+	var args = []string{"--homedir", home, "--batch", "--import", filepath.Clean(publicKey)}
+	if output, err := run(ctx, cfg, cfg.Cmd, args, os.Environ(), ""); err != nil {
+		cleanup()
+		return "", func() {}, fmt.Errorf("sign: failed to import the public key %s: %q", publicKey, output)
+	}
+	return home, cleanup, nil
+}
@@ -0,0 +1,101 @@
+package snapcraft
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/filenametemplate"
+)
+
+// copyFiles copies the extra files into the prime directory. Both the
+// sources and destinations are templates, and sources may be globs, whose
+// matches are copied into the destination directory.
+func copyFiles(ctx *context.Context, primeDir string, fields filenametemplate.Fields, binaries []artifact.Artifact) error {
+	var taken = map[string]string{
+		filepath.Join("meta", "snap.yaml"): "the snap metadata",
+	}
+	for _, binary := range binaries {
+		taken[filepath.Base(binary.Path)] = "the binary " + binary.Name
+	}
+	var srcs []string
+	for src := range ctx.Config.Snapcraft.Files {
+		srcs = append(srcs, src)
+	}
+	sort.Strings(srcs)
+	for _, tmpl := range srcs {
+		src, err := filenametemplate.Apply(tmpl, fields)
+		if err != nil {
+			return errors.Wrapf(err, "failed to execute snapcraft file template '%s'", tmpl)
+		}
+		dest, err := filenametemplate.Apply(ctx.Config.Snapcraft.Files[tmpl], fields)
+		if err != nil {
+			return errors.Wrapf(err, "failed to execute snapcraft file template '%s'", ctx.Config.Snapcraft.Files[tmpl])
+		}
+		dest = filepath.Clean(dest)
+		if filepath.IsAbs(dest) || dest == ".." || strings.HasPrefix(dest, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("snapcraft file %s: destination '%s' must be inside the snap root", src, dest)
+		}
+		matches, err := filepath.Glob(src)
+		if err != nil {
+			return errors.Wrapf(err, "snapcraft file %s: invalid glob", src)
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("snapcraft file %s: no files found", src)
+		}
+		var glob = strings.ContainsAny(src, "*?[")
+		for _, match := range matches {
+			var target = dest
+			if glob {
+				target = filepath.Join(dest, filepath.Base(match))
+			}
+			if what, ok := taken[target]; ok {
+				return fmt.Errorf("snapcraft file %s would overwrite %s at %s", match, what, target)
+			}
+			taken[target] = "the file " + match
+			log.WithField("src", match).
+				WithField("dest", target).
+				Debug("adding file to snap")
+			if err := copyFile(match, filepath.Join(primeDir, target)); err != nil {
+				return errors.Wrapf(err, "failed to copy %s to the snap", match)
+			}
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dest string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", src)
+	}
+	// #nosec
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close() // nolint: errcheck
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close() // nolint: errcheck
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,119 @@
+package snapcraft
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/filenametemplate"
+	"github.com/stretchr/testify/assert"
+)
+
+func filesContext(t *testing.T, files map[string]string) (*context.Context, string, string) {
+	folder, err := ioutil.TempDir("", "snapcrafttest")
+	assert.NoError(t, err)
+	var src = filepath.Join(folder, "src")
+	assert.NoError(t, os.MkdirAll(filepath.Join(src, "certs"), 0755))
+	for name, content := range map[string]string{
+		"config.yml":     "debug: false",
+		"certs/a.pem":    "a",
+		"certs/b.pem":    "b",
+		"certs/only.txt": "nope",
+	} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(src, name), []byte(content), 0644))
+	}
+	var ctx = context.New(config.Project{
+		ProjectName: "mytool",
+		Snapcraft: config.Snapcraft{
+			Files: files,
+		},
+	})
+	ctx.Version = "1.2.3"
+	ctx.Env = map[string]string{"SRC": src}
+	var primeDir = filepath.Join(folder, "prime")
+	assert.NoError(t, os.Mkdir(primeDir, 0755))
+	return ctx, src, primeDir
+}
+
+func copyTestFiles(ctx *context.Context, primeDir string) error {
+	var binaries = []artifact.Artifact{
+		{Name: "mytool", Path: "dist/linux_amd64/mytool", Goos: "linux", Goarch: "amd64"},
+	}
+	var fields = filenametemplate.NewFields(ctx, nil, binaries...)
+	return copyFiles(ctx, primeDir, fields, binaries)
+}
+
+func TestCopyFiles(t *testing.T) {
+	ctx, _, primeDir := filesContext(t, map[string]string{
+		"{{ .Env.SRC }}/config.yml":  "etc/{{ .ProjectName }}/config.yml",
+		"{{ .Env.SRC }}/certs/*.pem": "etc/ssl",
+	})
+	assert.NoError(t, copyTestFiles(ctx, primeDir))
+	for name, content := range map[string]string{
+		"etc/mytool/config.yml": "debug: false",
+		"etc/ssl/a.pem":         "a",
+		"etc/ssl/b.pem":         "b",
+	} {
+		bts, err := ioutil.ReadFile(filepath.Join(primeDir, name))
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(bts))
+	}
+	_, err := os.Stat(filepath.Join(primeDir, "etc", "ssl", "only.txt"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCopyFilesOverwritesBinary(t *testing.T) {
+	ctx, src, primeDir := filesContext(t, map[string]string{
+		"{{ .Env.SRC }}/config.yml": "mytool",
+	})
+	assert.EqualError(t, copyTestFiles(ctx, primeDir), "snapcraft file "+src+"/config.yml would overwrite the binary mytool at mytool")
+}
+
+func TestCopyFilesOverwritesMetadata(t *testing.T) {
+	ctx, src, primeDir := filesContext(t, map[string]string{
+		"{{ .Env.SRC }}/config.yml": "./meta/snap.yaml",
+	})
+	assert.EqualError(t, copyTestFiles(ctx, primeDir), "snapcraft file "+src+"/config.yml would overwrite the snap metadata at meta/snap.yaml")
+}
+
+func TestCopyFilesOverwritesFile(t *testing.T) {
+	ctx, src, primeDir := filesContext(t, map[string]string{
+		"{{ .Env.SRC }}/certs/a.pem": "etc/ssl/a.pem",
+		"{{ .Env.SRC }}/certs/*":     "etc/ssl",
+	})
+	assert.EqualError(t, copyTestFiles(ctx, primeDir), "snapcraft file "+src+"/certs/a.pem would overwrite the file "+src+"/certs/a.pem at etc/ssl/a.pem")
+}
+
+func TestCopyFilesNotFound(t *testing.T) {
+	ctx, src, primeDir := filesContext(t, map[string]string{
+		"{{ .Env.SRC }}/nope/*.pem": "etc/ssl",
+	})
+	assert.EqualError(t, copyTestFiles(ctx, primeDir), "snapcraft file "+src+"/nope/*.pem: no files found")
+}
+
+func TestCopyFilesOutsideRoot(t *testing.T) {
+	for _, dest := range []string{"/etc/mytool/config.yml", "../config.yml"} {
+		ctx, src, primeDir := filesContext(t, map[string]string{
+			"{{ .Env.SRC }}/config.yml": dest,
+		})
+		assert.EqualError(t, copyTestFiles(ctx, primeDir), "snapcraft file "+src+"/config.yml: destination '"+dest+"' must be inside the snap root")
+	}
+}
+
+func TestCopyFilesDirectory(t *testing.T) {
+	ctx, src, primeDir := filesContext(t, map[string]string{
+		"{{ .Env.SRC }}/certs": "etc/ssl",
+	})
+	assert.EqualError(t, copyTestFiles(ctx, primeDir), "failed to copy "+src+"/certs to the snap: "+src+"/certs is a directory")
+}
+
+func TestCopyFilesInvalidTemplate(t *testing.T) {
+	ctx, _, primeDir := filesContext(t, map[string]string{
+		"{{ .Env.NOPE }}/config.yml": "etc/config.yml",
+	})
+	assert.Error(t, copyTestFiles(ctx, primeDir))
+}
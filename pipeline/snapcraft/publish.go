@@ -0,0 +1,92 @@
+package snapcraft
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/filenametemplate"
+	"github.com/goreleaser/goreleaser/pipeline"
+)
+
+// PublishPipe for snap store publishing
+type PublishPipe struct{}
+
+func (PublishPipe) String() string {
+	return "publishing snap packages to the snap store"
+}
+
+// Run the pipe
+func (PublishPipe) Run(ctx *context.Context) error {
+	if !ctx.Config.Snapcraft.Publish {
+		return pipeline.Skip("snapcraft.publish is not enabled")
+	}
+	if !ctx.Publish {
+		return pipeline.SkipPublish(ctx)
+	}
+	var snaps = ctx.Artifacts.Filter(
+		artifact.And(
+			artifact.ByType(artifact.LinuxPackage),
+			func(a artifact.Artifact) bool {
+				return strings.HasSuffix(a.Name, ".snap")
+			},
+		),
+	).List()
+	if len(snaps) == 0 {
+		return pipeline.Skip("no snaps were built")
+	}
+	if _, err := exec.LookPath("snapcraft"); err != nil {
+		return ErrNoSnapcraft
+	}
+	for _, snap := range snaps {
+		if err := push(ctx, snap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// channels renders the channel templates for the given snap.
+func channels(ctx *context.Context, snap artifact.Artifact) ([]string, error) {
+	var fields = filenametemplate.NewFields(ctx, ctx.Config.Snapcraft.Replacements, snap)
+	var result []string
+	for _, tmpl := range ctx.Config.Snapcraft.ChannelTemplates {
+		channel, err := filenametemplate.Apply(tmpl, fields)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to execute channel template '%s'", tmpl)
+		}
+		if channel = strings.TrimSpace(channel); channel != "" {
+			result = append(result, channel)
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no channels to release %s to", snap.Name)
+	}
+	return result, nil
+}
+
+// push uploads the snap to the store and releases it to its channels, using
+// the credentials of the snapcraft login or SNAPCRAFT_STORE_CREDENTIALS.
+func push(ctx *context.Context, snap artifact.Artifact) error {
+	channels, err := channels(ctx, snap)
+	if err != nil {
+		return err
+	}
+	log.WithField("snap", snap.Name).
+		WithField("channels", channels).
+		Info("pushing snap")
+	/* #nosec */
+	var cmd = exec.CommandContext(ctx, "snapcraft", "push", "--release="+strings.Join(channels, ","), snap.Path)
+	log.WithField("cmd", cmd.Args).Debug("running")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to push %s to the snap store: \n%s", snap.Name, string(out))
+	}
+	log.Debugf("snapcraft push output: \n%s", string(out))
+	return nil
+}
@@ -0,0 +1,126 @@
+package snapcraft
+
+import (
+	"testing"
+
+	"github.com/goreleaser/goreleaser/config"
+	"github.com/goreleaser/goreleaser/context"
+	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/testlib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishDescription(t *testing.T) {
+	assert.NotEmpty(t, PublishPipe{}.String())
+}
+
+func TestPublishNotEnabled(t *testing.T) {
+	testlib.AssertSkipped(t, PublishPipe{}.Run(context.New(config.Project{})))
+}
+
+func TestPublishSkipPublish(t *testing.T) {
+	var ctx = publishContext()
+	ctx.Publish = false
+	testlib.AssertSkipped(t, PublishPipe{}.Run(ctx))
+}
+
+func TestPublishNoSnaps(t *testing.T) {
+	var ctx = publishContext()
+	ctx.Artifacts = artifact.New()
+	testlib.AssertSkipped(t, PublishPipe{}.Run(ctx))
+}
+
+func TestPublish(t *testing.T) {
+	calls, back := testlib.FakeCommand(t, "snapcraft", "")
+	defer back()
+	var ctx = publishContext()
+	assert.NoError(t, PublishPipe{}.Run(ctx))
+	assert.Equal(t, []string{
+		"push --release=candidate,stable dist/mybin_1.2.3_linux_amd64.snap",
+	}, calls())
+}
+
+func TestPublishPrerelease(t *testing.T) {
+	calls, back := testlib.FakeCommand(t, "snapcraft", "")
+	defer back()
+	var ctx = publishContext()
+	ctx.Version = "1.2.3-rc1"
+	ctx.Git.CurrentTag = "v1.2.3-rc1"
+	ctx.Semver.Prerelease = "rc1"
+	ctx.Config.Snapcraft.ChannelTemplates = []string{
+		"{{ if .Prerelease }}edge{{ else }}stable{{ end }}",
+		"{{ if not .Prerelease }}candidate{{ end }}",
+	}
+	assert.NoError(t, PublishPipe{}.Run(ctx))
+	assert.Equal(t, []string{
+		"push --release=edge dist/mybin_1.2.3_linux_amd64.snap",
+	}, calls())
+}
+
+func TestPublishRejected(t *testing.T) {
+	_, back := testlib.FakeCommand(t, "snapcraft", `echo "The store was unable to accept this snap."; exit 2`)
+	defer back()
+	var ctx = publishContext()
+	assert.EqualError(t, PublishPipe{}.Run(ctx), "failed to push mybin_1.2.3_linux_amd64.snap to the snap store: \nThe store was unable to accept this snap.\n")
+}
+
+func TestPublishInvalidChannelTemplate(t *testing.T) {
+	_, back := testlib.FakeCommand(t, "snapcraft", "")
+	defer back()
+	var ctx = publishContext()
+	ctx.Config.Snapcraft.ChannelTemplates = []string{"{{ .Nope }}"}
+	assert.Error(t, PublishPipe{}.Run(ctx))
+}
+
+func TestPublishNoChannels(t *testing.T) {
+	_, back := testlib.FakeCommand(t, "snapcraft", "")
+	defer back()
+	var ctx = publishContext()
+	ctx.Config.Snapcraft.ChannelTemplates = []string{"{{ if .Prerelease }}edge{{ end }}"}
+	assert.EqualError(t, PublishPipe{}.Run(ctx), "no channels to release mybin_1.2.3_linux_amd64.snap to")
+}
+
+func TestDefaultChannels(t *testing.T) {
+	for grade, expected := range map[string][]string{
+		"":       {"edge", "beta", "candidate", "stable"},
+		"stable": {"edge", "beta", "candidate", "stable"},
+		"devel":  {"edge", "beta"},
+	} {
+		var ctx = context.New(config.Project{
+			Snapcraft: config.Snapcraft{
+				Grade:   grade,
+				Publish: true,
+			},
+		})
+		assert.NoError(t, Pipe{}.Default(ctx))
+		assert.Equal(t, expected, ctx.Config.Snapcraft.ChannelTemplates, grade)
+	}
+}
+
+func publishContext() *context.Context {
+	var ctx = context.New(config.Project{
+		Snapcraft: config.Snapcraft{
+			Publish:          true,
+			ChannelTemplates: []string{"candidate", "stable"},
+		},
+	})
+	ctx.Publish = true
+	ctx.Version = "1.2.3"
+	ctx.Semver = context.Semver{Major: "1", Minor: "2", Patch: "3"}
+	ctx.Git.CurrentTag = "v1.2.3"
+	ctx.Artifacts.Add(artifact.Artifact{
+		Type:   artifact.LinuxPackage,
+		Name:   "mybin_1.2.3_linux_amd64.snap",
+		Path:   "dist/mybin_1.2.3_linux_amd64.snap",
+		Goos:   "linux",
+		Goarch: "amd64",
+	})
+	ctx.Artifacts.Add(artifact.Artifact{
+		Type:   artifact.LinuxPackage,
+		Name:   "mybin_1.2.3_linux_amd64.deb",
+		Path:   "dist/mybin_1.2.3_linux_amd64.deb",
+		Goos:   "linux",
+		Goarch: "amd64",
+	})
+	return ctx
+}
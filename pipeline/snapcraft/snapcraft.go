@@ -2,17 +2,23 @@
 package snapcraft
 
 import (
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/apex/log"
+	"github.com/masterminds/semver"
+	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
 	yaml "gopkg.in/yaml.v2"
 
+	"github.com/goreleaser/goreleaser/config"
 	"github.com/goreleaser/goreleaser/context"
 	"github.com/goreleaser/goreleaser/internal/artifact"
 	"github.com/goreleaser/goreleaser/internal/filenametemplate"
@@ -37,17 +43,33 @@ type Metadata struct {
 	Description   string
 	Grade         string `yaml:",omitempty"`
 	Confinement   string `yaml:",omitempty"`
+	Base          string `yaml:",omitempty"`
+	License       string `yaml:",omitempty"`
 	Architectures []string
+	Layout        map[string]map[string]string `yaml:",omitempty"`
 	Apps          map[string]AppMetadata
 }
 
 // AppMetadata for the binaries that will be in the snap package
 type AppMetadata struct {
-	Command string
-	Plugs   []string `yaml:",omitempty"`
-	Daemon  string   `yaml:",omitempty"`
+	Command   string
+	Plugs     []string `yaml:",omitempty"`
+	Slots     []string `yaml:",omitempty"`
+	Daemon    string   `yaml:",omitempty"`
+	Completer string   `yaml:",omitempty"`
 }
 
+// licenseRe matches SPDX license expressions, e.g. `MIT` or
+// `(Apache-2.0 OR MIT) AND BSD-3-Clause`
+var licenseRe = regexp.MustCompile(`^\(*[A-Za-z0-9.+-]+\)*( (AND|OR|WITH) \(*[A-Za-z0-9.+-]+\)*)*$`)
+
+// versionRe matches the version in the `snapcraft version` output, e.g.
+// `snapcraft, version 2.43.1` or `snapcraft 4.4`
+var versionRe = regexp.MustCompile(`\d+\.\d+(\.\d+)?`)
+
+// maxSummaryLength is the longest summary the snap store accepts
+const maxSummaryLength = 79
+
 const defaultNameTemplate = "{{ .ProjectName }}_{{ .Version }}_{{ .Os }}_{{ .Arch }}{{ if .Arm }}v{{ .Arm }}{{ end }}"
 
 // Pipe for snapcraft packaging
@@ -63,6 +85,34 @@ func (Pipe) Default(ctx *context.Context) error {
 	if snap.NameTemplate == "" {
 		snap.NameTemplate = defaultNameTemplate
 	}
+	switch snap.Confinement {
+	case "", "strict", "classic", "devmode":
+	default:
+		return fmt.Errorf("invalid snapcraft confinement '%s', must be strict, classic or devmode", snap.Confinement)
+	}
+	switch snap.Grade {
+	case "", "stable", "devel":
+	default:
+		return fmt.Errorf("invalid snapcraft grade '%s', must be stable or devel", snap.Grade)
+	}
+	if snap.License != "" && !licenseRe.MatchString(snap.License) {
+		return fmt.Errorf("invalid snapcraft license '%s', must be a SPDX license expression", snap.License)
+	}
+	// templated summaries are only checked once rendered
+	if !strings.Contains(snap.Summary, "{{") {
+		if err := checkSummary(snap.Summary); err != nil {
+			return err
+		}
+	}
+	if snap.Publish && len(snap.ChannelTemplates) == 0 {
+		// devel snaps can only be released to the edge and beta channels
+		switch snap.Grade {
+		case "devel":
+			snap.ChannelTemplates = []string{"edge", "beta"}
+		default:
+			snap.ChannelTemplates = []string{"edge", "beta", "candidate", "stable"}
+		}
+	}
 	return nil
 }
 
@@ -77,103 +127,337 @@ func (Pipe) Run(ctx *context.Context) error {
 	if ctx.Config.Snapcraft.Description == "" {
 		return ErrNoDescription
 	}
-	_, err := exec.LookPath("snapcraft")
+	if err := checkApps(ctx); err != nil {
+		return err
+	}
+	version, err := available(ctx)
 	if err != nil {
-		return ErrNoSnapcraft
+		if !ctx.Publish && !ctx.Config.Snapcraft.Required {
+			return pipeline.Skip(err.Error())
+		}
+		return err
+	}
+	if err := checkVersion(ctx, version); err != nil {
+		return err
 	}
 
 	var g errgroup.Group
+	for arch, binaries := range snapBinaries(ctx) {
+		arch, binaries := arch, binaries
+		g.Go(func() error {
+			return create(ctx, arch, binaries)
+		})
+	}
+	return g.Wait()
+}
+
+// snapBinaries returns the linux binaries to snap, by snap architecture.
+// The ARMv6 and ARMv7 binaries are both armhf snaps, so only the ones with
+// the highest goarm are snapped.
+func snapBinaries(ctx *context.Context) map[string][]artifact.Artifact {
+	var result = map[string][]artifact.Artifact{}
 	for platform, binaries := range ctx.Artifacts.Filter(
 		artifact.And(
 			artifact.ByGoos("linux"),
 			artifact.ByType(artifact.Binary),
 		),
 	).GroupByPlatform() {
-		arch := linux.Arch(platform)
-		binaries := binaries
-		g.Go(func() error {
-			return create(ctx, arch, binaries)
-		})
+		arch, ok := linux.SnapArch(binaries[0].Goarch, binaries[0].Goarm)
+		if !ok {
+			log.WithField("platform", platform).
+				Warnf("skipping unsupported architecture, snaps are only built for %s", strings.Join(linux.SnapArchs(), ", "))
+			continue
+		}
+		if other, ok := result[arch]; ok {
+			var skipped = other
+			if other[0].Goarm > binaries[0].Goarm {
+				skipped, binaries = binaries, other
+			}
+			log.WithField("arch", arch).
+				Warnf("skipping the goarm %s binaries, the snap is built with the goarm %s ones", skipped[0].Goarm, binaries[0].Goarm)
+		}
+		result[arch] = binaries
 	}
-	return g.Wait()
+	return result
+}
+
+// available checks that snapcraft is installed and works, returning its
+// version.
+func available(ctx *context.Context) (string, error) {
+	if _, err := exec.LookPath("snapcraft"); err != nil {
+		return "", ErrNoSnapcraft
+	}
+	/* #nosec */
+	var cmd = exec.CommandContext(ctx, "snapcraft", "version")
+	log.WithField("cmd", cmd.Args).Debug("running")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("snapcraft is not available: \n%s", string(out))
+	}
+	return versionRe.FindString(string(out)), nil
+}
+
+// features are the snapcraft options only supported by recent snapcraft
+// versions
+var features = []struct {
+	name  string
+	since *semver.Version
+	used  func(snap config.Snapcraft) bool
+}{
+	{"base", semver.MustParse("3.0.0"), func(snap config.Snapcraft) bool {
+		return snap.Base != ""
+	}},
+	{"layout", semver.MustParse("2.42.0"), func(snap config.Snapcraft) bool {
+		return len(snap.Layout) > 0
+	}},
+}
+
+// checkVersion verifies that the snapcraft version supports the features
+// used, before any snap is built.
+func checkVersion(ctx *context.Context, version string) error {
+	sv, err := semver.NewVersion(version)
+	if err != nil {
+		log.Warn("unknown snapcraft version, not checking it supports the features used")
+		return nil
+	}
+	for _, feature := range features {
+		if feature.used(ctx.Config.Snapcraft) && sv.LessThan(feature.since) {
+			return fmt.Errorf("snapcraft %s is too old, %s needs snapcraft %s or newer", version, feature.name, feature.since)
+		}
+	}
+	return nil
+}
+
+// checkSummary verifies the summary fits in the store's limit.
+func checkSummary(summary string) error {
+	if n := utf8.RuneCountInString(summary); n > maxSummaryLength {
+		return fmt.Errorf("snapcraft summary is too long, the store allows %d characters at most, got %d", maxSummaryLength, n)
+	}
+	return nil
+}
+
+// checkApps verifies that every app configured runs one of the linux
+// binaries and that its command is relative to the snap root.
+func checkApps(ctx *context.Context) error {
+	var binaries = map[string]bool{}
+	for _, binary := range ctx.Artifacts.Filter(
+		artifact.And(
+			artifact.ByGoos("linux"),
+			artifact.ByType(artifact.Binary),
+		),
+	).List() {
+		binaries[binary.Name] = true
+	}
+	var names []string
+	for name := range binaries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var apps []string
+	for app := range ctx.Config.Snapcraft.Apps {
+		apps = append(apps, app)
+	}
+	sort.Strings(apps)
+	for _, app := range apps {
+		var settings = ctx.Config.Snapcraft.Apps[app]
+		if !binaries[appBinary(app, settings)] {
+			return fmt.Errorf(
+				"snapcraft app '%s' doesn't match any binary, available binaries are: %s",
+				appBinary(app, settings), strings.Join(names, ", "),
+			)
+		}
+		if strings.HasPrefix(settings.Command, "/") {
+			return fmt.Errorf(
+				"snapcraft app '%s': command '%s' must be relative to the snap root",
+				app, settings.Command,
+			)
+		}
+	}
+	return nil
+}
+
+// appBinary is the name of the binary the app runs, which is the app name
+// unless set otherwise.
+func appBinary(app string, settings config.SnapcraftAppMetadata) string {
+	if settings.Binary != "" {
+		return settings.Binary
+	}
+	return app
+}
+
+// appsFor returns the apps running the given binary, defaulting to a single
+// app named after the binary.
+func appsFor(ctx *context.Context, binary string) map[string]config.SnapcraftAppMetadata {
+	var apps = map[string]config.SnapcraftAppMetadata{}
+	for app, settings := range ctx.Config.Snapcraft.Apps {
+		if appBinary(app, settings) == binary {
+			apps[app] = settings
+		}
+	}
+	if len(apps) == 0 {
+		apps[binary] = config.SnapcraftAppMetadata{}
+	}
+	return apps
 }
 
 func create(ctx *context.Context, arch string, binaries []artifact.Artifact) error {
-	var log = log.WithField("arch", arch)
-	folder, err := filenametemplate.Apply(
-		ctx.Config.Snapcraft.NameTemplate,
-		filenametemplate.NewFields(ctx, ctx.Config.Snapcraft.Replacements, binaries...),
-	)
+	var fields = filenametemplate.NewFields(ctx, ctx.Config.Snapcraft.Replacements, binaries...)
+	// the snap arch already tells the arm version apart, and is used unless
+	// the goarch is replaced.
+	if _, ok := ctx.Config.Snapcraft.Replacements[binaries[0].Goarch]; !ok {
+		fields.Arch = arch
+		fields.Arm = ""
+	}
+	folder, err := filenametemplate.Apply(ctx.Config.Snapcraft.NameTemplate, fields)
 	if err != nil {
 		return err
 	}
 	// prime is the directory that then will be compressed to make the .snap package.
 	var folderDir = filepath.Join(ctx.Config.Dist, folder)
 	var primeDir = filepath.Join(folderDir, "prime")
+	if err = prime(ctx, primeDir, arch, binaries); err != nil {
+		return err
+	}
+
+	var snap = filepath.Join(ctx.Config.Dist, folder+".snap")
+	/* #nosec */
+	var cmd = exec.CommandContext(ctx, "snapcraft", "pack", primeDir, "--output", snap)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to generate snap package: %s", string(out))
+	}
+	ctx.Artifacts.Add(artifact.Artifact{
+		Type:   artifact.LinuxPackage,
+		ID:     ctx.Config.Snapcraft.ID,
+		Name:   folder + ".snap",
+		Path:   snap,
+		Goos:   binaries[0].Goos,
+		Goarch: binaries[0].Goarch,
+		Goarm:  binaries[0].Goarm,
+	})
+	return nil
+}
+
+// prime fills the prime directory, that is packed as is by snapcraft, with
+// the binaries and the meta/snap.yaml describing them.
+func prime(ctx *context.Context, primeDir, arch string, binaries []artifact.Artifact) error {
+	var log = log.WithField("arch", arch)
 	var metaDir = filepath.Join(primeDir, "meta")
 	// #nosec
-	if err = os.MkdirAll(metaDir, 0755); err != nil {
+	if err := os.MkdirAll(metaDir, 0755); err != nil {
 		return err
 	}
 
 	var file = filepath.Join(primeDir, "meta", "snap.yaml")
 	log.WithField("file", file).Debug("creating snap metadata")
 
+	var snapName = ctx.Config.ProjectName
+	if ctx.Config.Snapcraft.Name != "" {
+		snapName = ctx.Config.Snapcraft.Name
+	}
+	var fields = filenametemplate.NewFields(ctx, ctx.Config.Snapcraft.Replacements, binaries...)
+	snapName, err := filenametemplate.Apply(snapName, fields)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute snapcraft name template")
+	}
+	summary, err := filenametemplate.Apply(ctx.Config.Snapcraft.Summary, fields)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute snapcraft summary template")
+	}
+	if err := checkSummary(summary); err != nil {
+		return err
+	}
+	description, err := filenametemplate.Apply(ctx.Config.Snapcraft.Description, fields)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute snapcraft description template")
+	}
+
 	var metadata = &Metadata{
+		Name:          snapName,
 		Version:       ctx.Version,
-		Summary:       ctx.Config.Snapcraft.Summary,
-		Description:   ctx.Config.Snapcraft.Description,
+		Summary:       summary,
+		Description:   description,
 		Grade:         ctx.Config.Snapcraft.Grade,
 		Confinement:   ctx.Config.Snapcraft.Confinement,
+		Base:          ctx.Config.Snapcraft.Base,
+		License:       ctx.Config.Snapcraft.License,
 		Architectures: []string{arch},
+		Layout:        ctx.Config.Snapcraft.Layout,
 		Apps:          make(map[string]AppMetadata),
 	}
 
-	metadata.Name = ctx.Config.ProjectName
-	if ctx.Config.Snapcraft.Name != "" {
-		metadata.Name = ctx.Config.Snapcraft.Name
-	}
-
 	for _, binary := range binaries {
 		log.WithField("path", binary.Path).
 			WithField("name", binary.Name).
 			Debug("passed binary to snapcraft")
-		appMetadata := AppMetadata{
-			Command: binary.Name,
-		}
-		if configAppMetadata, ok := ctx.Config.Snapcraft.Apps[binary.Name]; ok {
-			appMetadata.Plugs = configAppMetadata.Plugs
-			appMetadata.Daemon = configAppMetadata.Daemon
+		// the binary is at the root of prime, so the command, relative to
+		// prime, is just its file name. Classic snaps run it from the host
+		// system instead of a core base, so it must be statically linked or
+		// only need the host libraries.
+		var name = filepath.Base(binary.Path)
+		for app, settings := range appsFor(ctx, binary.Name) {
+			var appMetadata = AppMetadata{
+				Command:   name,
+				Plugs:     settings.Plugs,
+				Slots:     settings.Slots,
+				Daemon:    settings.Daemon,
+				Completer: settings.Completer,
+			}
+			if settings.Command != "" {
+				appMetadata.Command = settings.Command
+			}
+			if settings.Args != "" {
+				appMetadata.Command += " " + settings.Args
+			}
+			// classic snaps aren't confined, so interfaces don't apply to them.
+			if metadata.Confinement == "classic" && len(appMetadata.Plugs)+len(appMetadata.Slots) > 0 {
+				log.WithField("app", app).
+					Warn("plugs and slots are ignored with classic confinement")
+				appMetadata.Plugs = nil
+				appMetadata.Slots = nil
+			}
+			metadata.Apps[app] = appMetadata
 		}
-		metadata.Apps[binary.Name] = appMetadata
 
-		destBinaryPath := filepath.Join(primeDir, filepath.Base(binary.Path))
-		if err = os.Link(binary.Path, destBinaryPath); err != nil {
+		destBinaryPath := filepath.Join(primeDir, name)
+		if err := os.Link(binary.Path, destBinaryPath); err != nil {
 			return err
 		}
 	}
-	out, err := yaml.Marshal(metadata)
-	if err != nil {
+	if err := copyFiles(ctx, primeDir, fields, binaries); err != nil {
 		return err
 	}
-
-	if err = ioutil.WriteFile(file, out, 0644); err != nil {
+	out, err := marshal(metadata, ctx.Config.Snapcraft.Extra)
+	if err != nil {
 		return err
 	}
+	return ioutil.WriteFile(file, out, 0644)
+}
 
-	var snap = filepath.Join(ctx.Config.Dist, folder+".snap")
-	/* #nosec */
-	var cmd = exec.CommandContext(ctx, "snapcraft", "pack", primeDir, "--output", snap)
-	if out, err = cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to generate snap package: %s", string(out))
+// marshal renders the metadata with the extra keys appended, sorted, so
+// keys snapcraft supports but goreleaser doesn't model yet can be set.
+func marshal(metadata *Metadata, extra map[string]interface{}) ([]byte, error) {
+	out, err := yaml.Marshal(metadata)
+	if err != nil || len(extra) == 0 {
+		return out, err
 	}
-	ctx.Artifacts.Add(artifact.Artifact{
-		Type:   artifact.LinuxPackage,
-		Name:   folder + ".snap",
-		Path:   snap,
-		Goos:   binaries[0].Goos,
-		Goarch: binaries[0].Goarch,
-		Goarm:  binaries[0].Goarm,
-	})
-	return nil
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(out, &doc); err != nil {
+		return nil, err
+	}
+	var generated = map[string]bool{}
+	for _, item := range doc {
+		generated[fmt.Sprint(item.Key)] = true
+	}
+	var keys []string
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if generated[key] {
+			return nil, fmt.Errorf("snapcraft extra key '%s' is already generated by goreleaser", key)
+		}
+		doc = append(doc, yaml.MapItem{Key: key, Value: extra[key]})
+	}
+	return yaml.Marshal(doc)
 }
@@ -1,20 +1,25 @@
 package snapcraft
 
 import (
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/goreleaser/goreleaser/config"
 	"github.com/goreleaser/goreleaser/context"
 	"github.com/goreleaser/goreleaser/internal/artifact"
+	"github.com/goreleaser/goreleaser/internal/testlib"
 	"github.com/goreleaser/goreleaser/pipeline"
 	"github.com/stretchr/testify/assert"
 	yaml "gopkg.in/yaml.v2"
 )
 
+var update = flag.Bool("update", false, "update .golden files")
+
 func TestDescription(t *testing.T) {
 	assert.NotEmpty(t, Pipe{}.String())
 }
@@ -140,6 +145,79 @@ func TestRunPipeWithPlugsAndDaemon(t *testing.T) {
 	assert.Equal(t, metadata.Apps["mybin"].Daemon, "simple")
 }
 
+func TestRunPipeAppWithoutBinary(t *testing.T) {
+	folder, err := ioutil.TempDir("", "snapcrafttest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		ProjectName: "mybin",
+		Dist:        folder,
+		Snapcraft: config.Snapcraft{
+			Summary:     "test summary",
+			Description: "test description",
+			Apps: map[string]config.SnapcraftAppMetadata{
+				"mybin":  {Daemon: "simple"},
+				"mybind": {Daemon: "simple"},
+			},
+		},
+	})
+	addBinaries(t, ctx, "mybin", folder)
+	ctx.Artifacts.Add(artifact.Artifact{
+		Name: "otherbin",
+		Path: filepath.Join(folder, "otherbin"),
+		Goos: "linux",
+		Type: artifact.Binary,
+	})
+	assert.EqualError(t, Pipe{}.Run(ctx), "snapcraft app 'mybind' doesn't match any binary, available binaries are: mybin, otherbin")
+}
+
+func TestRunPipeArchs(t *testing.T) {
+	_, back := testlib.FakeCommand(t, "snapcraft", "")
+	defer back()
+	folder, err := ioutil.TempDir("", "snapcrafttest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		ProjectName: "mybin",
+		Dist:        folder,
+		Snapcraft: config.Snapcraft{
+			NameTemplate: "mybin_{{ .Arch }}{{ if .Arm }}v{{ .Arm }}{{ end }}",
+			Summary:      "test summary",
+			Description:  "test description",
+			Replacements: map[string]string{"s390x": "ibmz"},
+		},
+	})
+	for _, platform := range [][2]string{
+		{"arm", "6"}, {"arm", "7"}, {"arm64", ""}, {"ppc64le", ""}, {"s390x", ""}, {"mips", ""},
+	} {
+		var dir = filepath.Join(folder, platform[0]+platform[1])
+		assert.NoError(t, os.Mkdir(dir, 0755))
+		var path = filepath.Join(dir, "mybin")
+		_, err := os.Create(path)
+		assert.NoError(t, err)
+		ctx.Artifacts.Add(artifact.Artifact{
+			Name:   "mybin",
+			Path:   path,
+			Goos:   "linux",
+			Goarch: platform[0],
+			Goarm:  platform[1],
+			Type:   artifact.Binary,
+		})
+	}
+	assert.NoError(t, Pipe{}.Run(ctx))
+	var snaps = map[string]string{}
+	for _, snap := range ctx.Artifacts.Filter(artifact.ByType(artifact.LinuxPackage)).List() {
+		snaps[snap.Name] = readMetadata(t, filepath.Join(folder, strings.TrimSuffix(snap.Name, ".snap"), "prime")).Architectures[0]
+		if snap.Goarch == "arm" {
+			assert.Equal(t, "7", snap.Goarm, "the armhf snap should have the ARMv7 binaries")
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"mybin_armhf.snap":   "armhf",
+		"mybin_arm64.snap":   "arm64",
+		"mybin_ppc64el.snap": "ppc64el",
+		"mybin_ibmz.snap":    "s390x",
+	}, snaps)
+}
+
 func TestNoSnapcraftInPath(t *testing.T) {
 	var path = os.Getenv("PATH")
 	defer func() {
@@ -152,9 +230,71 @@ func TestNoSnapcraftInPath(t *testing.T) {
 			Description: "dummy",
 		},
 	})
+	testlib.AssertSkipped(t, Pipe{}.Run(ctx))
+	ctx.Publish = true
+	assert.EqualError(t, Pipe{}.Run(ctx), ErrNoSnapcraft.Error())
+	ctx.Publish = false
+	ctx.Config.Snapcraft.Required = true
 	assert.EqualError(t, Pipe{}.Run(ctx), ErrNoSnapcraft.Error())
 }
 
+func TestSnapcraftNotAvailable(t *testing.T) {
+	_, back := testlib.FakeCommand(t, "snapcraft", `echo "cannot connect to the snapd socket"; exit 1`)
+	defer back()
+	var ctx = context.New(config.Project{
+		Snapcraft: config.Snapcraft{
+			Summary:     "dummy",
+			Description: "dummy",
+		},
+	})
+	testlib.AssertSkipped(t, Pipe{}.Run(ctx))
+	ctx.Publish = true
+	assert.EqualError(t, Pipe{}.Run(ctx), "snapcraft is not available: \ncannot connect to the snapd socket\n")
+}
+
+func TestCheckVersion(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Snapcraft: config.Snapcraft{
+			Base: "core18",
+			Layout: map[string]map[string]string{
+				"/etc/mybin": {"bind": "$SNAP_DATA/etc"},
+			},
+		},
+	})
+	assert.NoError(t, checkVersion(ctx, "3.0.1"))
+	assert.NoError(t, checkVersion(ctx, ""))
+	assert.EqualError(t, checkVersion(ctx, "2.43"), "snapcraft 2.43 is too old, base needs snapcraft 3.0.0 or newer")
+	ctx.Config.Snapcraft.Base = ""
+	assert.NoError(t, checkVersion(ctx, "2.43"))
+	assert.EqualError(t, checkVersion(ctx, "2.35.1"), "snapcraft 2.35.1 is too old, layout needs snapcraft 2.42.0 or newer")
+	ctx.Config.Snapcraft.Layout = nil
+	assert.NoError(t, checkVersion(ctx, "2.35.1"))
+}
+
+func TestRunPipeSnapcraftTooOld(t *testing.T) {
+	calls, back := testlib.FakeCommand(t, "snapcraft", `echo "snapcraft, version 2.35"`)
+	defer back()
+	folder, err := ioutil.TempDir("", "snapcrafttest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		ProjectName: "mybin",
+		Dist:        folder,
+		Snapcraft: config.Snapcraft{
+			Summary:     "test summary",
+			Description: "test description",
+			Base:        "core18",
+		},
+	})
+	addBinaries(t, ctx, "mybin", folder)
+	assert.EqualError(t, Pipe{}.Run(ctx), "snapcraft 2.35 is too old, base needs snapcraft 3.0.0 or newer")
+	assert.Equal(t, []string{"version"}, calls())
+}
+
+func TestVersionRe(t *testing.T) {
+	assert.Equal(t, "2.43.1", versionRe.FindString("snapcraft, version 2.43.1\n"))
+	assert.Equal(t, "4.4", versionRe.FindString("snapcraft 4.4\n"))
+}
+
 func TestDefault(t *testing.T) {
 	var ctx = context.New(config.Project{})
 	assert.NoError(t, Pipe{}.Default(ctx))
@@ -171,6 +311,326 @@ func TestDefaultSet(t *testing.T) {
 	assert.Equal(t, "foo", ctx.Config.Snapcraft.NameTemplate)
 }
 
+func TestDefaultInvalidConfinement(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Snapcraft: config.Snapcraft{
+			Confinement: "confined",
+		},
+	})
+	assert.EqualError(t, Pipe{}.Default(ctx), "invalid snapcraft confinement 'confined', must be strict, classic or devmode")
+}
+
+func TestDefaultInvalidGrade(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Snapcraft: config.Snapcraft{
+			Grade: "beta",
+		},
+	})
+	assert.EqualError(t, Pipe{}.Default(ctx), "invalid snapcraft grade 'beta', must be stable or devel")
+}
+
+func TestDefaultSummaryTooLong(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Snapcraft: config.Snapcraft{
+			Summary: strings.Repeat("a", 80),
+		},
+	})
+	assert.EqualError(t, Pipe{}.Default(ctx), "snapcraft summary is too long, the store allows 79 characters at most, got 80")
+}
+
+func TestDefaultSummaryTemplateNotChecked(t *testing.T) {
+	var ctx = context.New(config.Project{
+		Snapcraft: config.Snapcraft{
+			Summary: strings.Repeat("a", 80) + "{{ .Version }}",
+		},
+	})
+	assert.NoError(t, Pipe{}.Default(ctx))
+}
+
+func TestPrimeTemplates(t *testing.T) {
+	folder, err := ioutil.TempDir("", "snapcrafttest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		ProjectName: "mytool",
+		Dist:        folder,
+		Snapcraft: config.Snapcraft{
+			Name:        "acme-{{ .ProjectName }}",
+			Summary:     "mytool {{ .Version }} for {{ .Arch }}",
+			Description: "Built from {{ .Tag }} by {{ .Env.BUILDER }}.",
+		},
+	})
+	ctx.Version = "1.2.3"
+	ctx.Git.CurrentTag = "v1.2.3"
+	ctx.Env = map[string]string{"BUILDER": "ci"}
+	addBinaries(t, ctx, "mytool", folder)
+	var primeDir = filepath.Join(folder, "prime")
+	assert.NoError(t, prime(ctx, primeDir, "amd64", ctx.Artifacts.List()[:1]))
+	metadata := readMetadata(t, primeDir)
+	assert.Equal(t, "acme-mytool", metadata.Name)
+	assert.Equal(t, "mytool 1.2.3 for amd64", metadata.Summary)
+	assert.Equal(t, "Built from v1.2.3 by ci.", metadata.Description)
+}
+
+func TestPrimeSummaryTooLong(t *testing.T) {
+	folder, err := ioutil.TempDir("", "snapcrafttest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		ProjectName: "mytool",
+		Dist:        folder,
+		Snapcraft: config.Snapcraft{
+			Summary:     strings.Repeat("a", 75) + "{{ .Version }}",
+			Description: "test description",
+		},
+	})
+	ctx.Version = "1.2.3"
+	addBinaries(t, ctx, "mytool", folder)
+	var primeDir = filepath.Join(folder, "prime")
+	assert.EqualError(t, prime(ctx, primeDir, "amd64", ctx.Artifacts.List()[:1]), "snapcraft summary is too long, the store allows 79 characters at most, got 80")
+}
+
+func TestPrimeInvalidSummaryTemplate(t *testing.T) {
+	folder, err := ioutil.TempDir("", "snapcrafttest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		ProjectName: "mytool",
+		Dist:        folder,
+		Snapcraft: config.Snapcraft{
+			Summary:     "{{ .Nope }}",
+			Description: "test description",
+		},
+	})
+	addBinaries(t, ctx, "mytool", folder)
+	var primeDir = filepath.Join(folder, "prime")
+	assert.Error(t, prime(ctx, primeDir, "amd64", ctx.Artifacts.List()[:1]))
+}
+
+func TestDefaultLicense(t *testing.T) {
+	for _, license := range []string{"MIT", "GPL-3.0+", "Apache-2.0 OR MIT", "(Apache-2.0 OR MIT) AND BSD-3-Clause", "GPL-2.0 WITH Classpath-exception-2.0"} {
+		var ctx = context.New(config.Project{
+			Snapcraft: config.Snapcraft{License: license},
+		})
+		assert.NoError(t, Pipe{}.Default(ctx), license)
+	}
+}
+
+func TestDefaultInvalidLicense(t *testing.T) {
+	for _, license := range []string{"MIT, BSD", "Apache 2", "MIT or BSD"} {
+		var ctx = context.New(config.Project{
+			Snapcraft: config.Snapcraft{License: license},
+		})
+		assert.EqualError(t, Pipe{}.Default(ctx), "invalid snapcraft license '"+license+"', must be a SPDX license expression")
+	}
+}
+
+func TestPrimeExtra(t *testing.T) {
+	folder, err := ioutil.TempDir("", "snapcrafttest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		ProjectName: "mytool",
+		Dist:        folder,
+		Snapcraft: config.Snapcraft{
+			Summary:     "test summary",
+			Description: "test description",
+			License:     "MIT",
+			Extra: map[string]interface{}{
+				"title":   "My Tool",
+				"website": "https://example.com",
+				"environment": map[string]string{
+					"LANG": "C.UTF-8",
+				},
+			},
+		},
+	})
+	ctx.Version = "1.2.3"
+	addBinaries(t, ctx, "mytool", folder)
+	var primeDir = filepath.Join(folder, "prime")
+	assert.NoError(t, prime(ctx, primeDir, "amd64", ctx.Artifacts.List()[:1]))
+	out, err := ioutil.ReadFile(filepath.Join(primeDir, "meta", "snap.yaml"))
+	assert.NoError(t, err)
+	var golden = "testdata/prime_extra.yaml.golden"
+	if *update {
+		ioutil.WriteFile(golden, out, 0655)
+	}
+	bts, err := ioutil.ReadFile(golden)
+	assert.NoError(t, err)
+	assert.Equal(t, string(bts), string(out))
+}
+
+func TestPrimeExtraCollision(t *testing.T) {
+	folder, err := ioutil.TempDir("", "snapcrafttest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		ProjectName: "mytool",
+		Dist:        folder,
+		Snapcraft: config.Snapcraft{
+			Summary:     "test summary",
+			Description: "test description",
+			Extra: map[string]interface{}{
+				"version": "2.0.0",
+			},
+		},
+	})
+	addBinaries(t, ctx, "mytool", folder)
+	var primeDir = filepath.Join(folder, "prime")
+	assert.EqualError(t, prime(ctx, primeDir, "amd64", ctx.Artifacts.List()[:1]), "snapcraft extra key 'version' is already generated by goreleaser")
+}
+
+func TestPrime(t *testing.T) {
+	folder, err := ioutil.TempDir("", "snapcrafttest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		ProjectName: "mybin",
+		Dist:        folder,
+		Snapcraft: config.Snapcraft{
+			Summary:     "test summary",
+			Description: "test description",
+			Grade:       "devel",
+			Confinement: "devmode",
+			Base:        "core18",
+			Layout: map[string]map[string]string{
+				"/etc/mybin": {"bind": "$SNAP_DATA/etc"},
+			},
+			Apps: map[string]config.SnapcraftAppMetadata{
+				"mybin": {
+					Plugs:     []string{"home", "network"},
+					Slots:     []string{"dbus-mybin"},
+					Args:      "--foreground",
+					Completer: "mybin-completion.bash",
+				},
+			},
+		},
+	})
+	ctx.Version = "testversion"
+	addBinaries(t, ctx, "mybin", folder)
+	var primeDir = filepath.Join(folder, "prime")
+	assert.NoError(t, prime(ctx, primeDir, "amd64", ctx.Artifacts.List()[:1]))
+	metadata := readMetadata(t, primeDir)
+	assert.Equal(t, "devel", metadata.Grade)
+	assert.Equal(t, "devmode", metadata.Confinement)
+	assert.Equal(t, "core18", metadata.Base)
+	assert.Equal(t, map[string]map[string]string{
+		"/etc/mybin": {"bind": "$SNAP_DATA/etc"},
+	}, metadata.Layout)
+	assert.Equal(t, []string{"amd64"}, metadata.Architectures)
+	assert.Equal(t, []string{"home", "network"}, metadata.Apps["mybin"].Plugs)
+	assert.Equal(t, []string{"dbus-mybin"}, metadata.Apps["mybin"].Slots)
+	assert.Equal(t, "mybin --foreground", metadata.Apps["mybin"].Command)
+	assert.Equal(t, "mybin-completion.bash", metadata.Apps["mybin"].Completer)
+	assert.FileExists(t, filepath.Join(primeDir, "mybin"))
+}
+
+func TestPrimeClassic(t *testing.T) {
+	folder, err := ioutil.TempDir("", "snapcrafttest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		ProjectName: "mybin",
+		Dist:        folder,
+		Snapcraft: config.Snapcraft{
+			Summary:     "test summary",
+			Description: "test description",
+			Confinement: "classic",
+			Apps: map[string]config.SnapcraftAppMetadata{
+				"mybin": {
+					Plugs:  []string{"home", "network"},
+					Daemon: "simple",
+				},
+			},
+		},
+	})
+	ctx.Version = "testversion"
+	addBinaries(t, ctx, "mybin", folder)
+	var primeDir = filepath.Join(folder, "prime")
+	assert.NoError(t, prime(ctx, primeDir, "amd64", ctx.Artifacts.List()[:1]))
+	metadata := readMetadata(t, primeDir)
+	assert.Equal(t, "classic", metadata.Confinement)
+	assert.Empty(t, metadata.Base)
+	assert.Empty(t, metadata.Apps["mybin"].Plugs)
+	assert.Equal(t, "simple", metadata.Apps["mybin"].Daemon)
+	assert.Equal(t, "mybin", metadata.Apps["mybin"].Command)
+}
+
+func TestPrimeApps(t *testing.T) {
+	folder, err := ioutil.TempDir("", "snapcrafttest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		ProjectName: "mytool",
+		Dist:        folder,
+		Snapcraft: config.Snapcraft{
+			Summary:     "test summary",
+			Description: "test description",
+			Grade:       "stable",
+			Confinement: "strict",
+			Base:        "core18",
+			Apps: map[string]config.SnapcraftAppMetadata{
+				"server": {
+					Binary:  "mytool-server",
+					Command: "mytool-server --config $SNAP_DATA/config.yml",
+					Plugs:   []string{"network", "network-bind"},
+					Daemon:  "simple",
+				},
+				"mytool": {
+					Args:  "--verbose",
+					Plugs: []string{"home"},
+				},
+			},
+		},
+	})
+	ctx.Version = "1.2.3"
+	var binaries []artifact.Artifact
+	for _, name := range []string{"mytool", "mytool-server", "mytool-admin"} {
+		var path = filepath.Join(folder, name)
+		_, err := os.Create(path)
+		assert.NoError(t, err)
+		binaries = append(binaries, artifact.Artifact{
+			Name:   name,
+			Path:   path,
+			Goos:   "linux",
+			Goarch: "amd64",
+			Type:   artifact.Binary,
+		})
+	}
+	var primeDir = filepath.Join(folder, "prime")
+	assert.NoError(t, prime(ctx, primeDir, "amd64", binaries))
+	out, err := ioutil.ReadFile(filepath.Join(primeDir, "meta", "snap.yaml"))
+	assert.NoError(t, err)
+	var golden = "testdata/prime_apps.yaml.golden"
+	if *update {
+		ioutil.WriteFile(golden, out, 0655)
+	}
+	bts, err := ioutil.ReadFile(golden)
+	assert.NoError(t, err)
+	assert.Equal(t, string(bts), string(out))
+	for _, name := range []string{"mytool", "mytool-server", "mytool-admin"} {
+		assert.FileExists(t, filepath.Join(primeDir, name))
+	}
+}
+
+func TestRunPipeAbsoluteCommand(t *testing.T) {
+	folder, err := ioutil.TempDir("", "snapcrafttest")
+	assert.NoError(t, err)
+	var ctx = context.New(config.Project{
+		ProjectName: "mybin",
+		Dist:        folder,
+		Snapcraft: config.Snapcraft{
+			Summary:     "test summary",
+			Description: "test description",
+			Apps: map[string]config.SnapcraftAppMetadata{
+				"mybin": {Command: "/usr/bin/mybin"},
+			},
+		},
+	})
+	addBinaries(t, ctx, "mybin", folder)
+	assert.EqualError(t, Pipe{}.Run(ctx), "snapcraft app 'mybin': command '/usr/bin/mybin' must be relative to the snap root")
+}
+
+func readMetadata(t *testing.T, primeDir string) Metadata {
+	yamlFile, err := ioutil.ReadFile(filepath.Join(primeDir, "meta", "snap.yaml"))
+	assert.NoError(t, err)
+	var metadata Metadata
+	assert.NoError(t, yaml.Unmarshal(yamlFile, &metadata))
+	return metadata
+}
+
 func addBinaries(t *testing.T, ctx *context.Context, name, dist string) {
 	for _, goos := range []string{"linux", "darwin"} {
 		for _, goarch := range []string{"amd64", "386"} {